@@ -30,6 +30,13 @@ func exit(cancel context.CancelFunc, app *serverapp.App, exited chan any) {
 func main() {
 	buildinfo.Print()
 
+	if len(os.Args) > 1 && os.Args[1] == serverapp.MigrateCommand {
+		if err := serverapp.Migrate(context.Background(), os.Args[2:]); err != nil {
+			logger.Log.Fatal("Error while migrating database", zap.Error(err))
+		}
+		return
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	var wg sync.WaitGroup
 	app, err := serverapp.New(ctx, &wg)
@@ -0,0 +1,68 @@
+package monitoring
+
+import (
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// cpuMetricPrefix — префикс метрик загрузки ядер: CPUutilization1, CPUutilization2...
+const cpuMetricPrefix = "CPUutilization"
+
+// precision возвращает указатель на точность вывода.
+func precision(p int) *int {
+	return &p
+}
+
+// metadata — описания метрик, которые отправляет агент.
+var metadata = map[string]storage.Metadata{
+	"Alloc":         {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Размер выделенных объектов в куче"},
+	"BuckHashSys":   {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Память хеш-таблицы профилировщика"},
+	"Frees":         {Type: "gauge", Precision: precision(0), Help: "Количество освобожденных объектов"},
+	"GCCPUFraction": {Type: "gauge", Precision: precision(6), Help: "Доля процессорного времени, занятого сборщиком мусора"},
+	"GCSys":         {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Память метаданных сборщика мусора"},
+	"HeapAlloc":     {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Размер выделенных объектов в куче"},
+	"HeapIdle":      {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Неиспользуемые спаны кучи"},
+	"HeapInuse":     {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Используемые спаны кучи"},
+	"HeapObjects":   {Type: "gauge", Precision: precision(0), Help: "Количество объектов в куче"},
+	"HeapReleased":  {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Память кучи, возвращенная системе"},
+	"HeapSys":       {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Память кучи, полученная от системы"},
+	"LastGC":        {Type: "gauge", Unit: "ns", Precision: precision(0), Help: "Время последней сборки мусора, наносекунды unix"},
+	"Lookups":       {Type: "gauge", Precision: precision(0), Help: "Количество разыменований указателей рантаймом"},
+	"MCacheInuse":   {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Используемые структуры mcache"},
+	"MCacheSys":     {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Память структур mcache, полученная от системы"},
+	"MSpanInuse":    {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Используемые структуры mspan"},
+	"MSpanSys":      {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Память структур mspan, полученная от системы"},
+	"Mallocs":       {Type: "gauge", Precision: precision(0), Help: "Количество выделенных объектов"},
+	"NextGC":        {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Размер кучи, при котором запустится сборка мусора"},
+	"NumForcedGC":   {Type: "gauge", Precision: precision(0), Help: "Количество принудительных сборок мусора"},
+	"NumGC":         {Type: "gauge", Precision: precision(0), Help: "Количество сборок мусора"},
+	"OtherSys":      {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Прочая память рантайма"},
+	"PauseTotalNs":  {Type: "gauge", Unit: "ns", Precision: precision(0), Help: "Суммарное время пауз сборщика мусора"},
+	"StackInuse":    {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Используемые спаны стеков"},
+	"StackSys":      {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Память стеков, полученная от системы"},
+	"Sys":           {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Вся память, полученная от системы"},
+	"TotalAlloc":    {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Суммарный размер выделенных объектов"},
+	"TotalMemory":   {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Объем оперативной памяти"},
+	"FreeMemory":    {Type: "gauge", Unit: "bytes", Precision: precision(0), Help: "Свободная оперативная память"},
+	"RandomValue":   {Type: "gauge", Precision: precision(3), Help: "Случайное значение"},
+	"PollCount":     {Type: "counter", Help: "Количество опросов метрик"},
+	"RequestLatency": {
+		Type: "summary", Unit: "seconds", Precision: precision(3), Help: "Длительность отправки метрик на сервер",
+	},
+}
+
+// Metadata возвращает описание метрики name или nil, если метрика неизвестна.
+func Metadata(name string) *storage.Metadata {
+	if strings.HasPrefix(name, cpuMetricPrefix) {
+		return &storage.Metadata{Type: "gauge", Unit: "percent", Precision: precision(2), Help: "Загрузка ядра процессора"}
+	}
+
+	meta, ok := metadata[name]
+	if !ok {
+		return nil
+	}
+	meta = meta.Copy()
+
+	return &meta
+}
@@ -46,9 +46,8 @@ func (m *MonitoringStats) saveGopsStats() {
 	if err != nil {
 		logger.Log.Error(err.Error())
 	} else {
-		cpuName := "CPUutilization"
 		for i, val := range cpuPercent {
-			key := fmt.Sprintf("%s%d", cpuName, i+1)
+			key := fmt.Sprintf("%s%d", cpuMetricPrefix, i+1)
 			stats[key] = val
 		}
 	}
@@ -31,3 +31,25 @@ func TestSaveGopsStats(t *testing.T) {
 	_, ok = m.gopsStats["TotalMemory"]
 	assert.True(t, ok)
 }
+
+func TestMetadata(t *testing.T) {
+	m := New(5)
+	m.PollSignal = make(chan any, 1)
+	m.saveRuntimeStats()
+
+	for name := range m.GetRuntimeStats() {
+		meta := Metadata(name)
+		require.NotNil(t, meta, name)
+		assert.NoError(t, meta.Validate(), name)
+	}
+
+	assert.Equal(t, "bytes", Metadata("HeapAlloc").Unit)
+	assert.Equal(t, "ns", Metadata("LastGC").Unit)
+	assert.Equal(t, "percent", Metadata("CPUutilization3").Unit)
+	assert.Equal(t, "counter", Metadata("PollCount").Type)
+	assert.Nil(t, Metadata("Unknown"))
+
+	// изменение результата не меняет описание
+	*Metadata("HeapAlloc").Precision = 5
+	assert.Equal(t, 0, *Metadata("HeapAlloc").Precision)
+}
@@ -3,23 +3,27 @@ package req
 import (
 	"context"
 	"errors"
+	"sync"
 
 	"github.com/LekcRg/metrics/internal/config"
 	"github.com/LekcRg/metrics/internal/crypto"
 	"github.com/LekcRg/metrics/internal/logger"
 	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
 	pb "github.com/LekcRg/metrics/proto"
+	"github.com/google/uuid"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/metadata"
-	"google.golang.org/protobuf/proto"
 )
 
 type GRPCClient struct {
-	conn   *grpc.ClientConn
-	client pb.MetricsClient
-	config config.AgentConfig
+	conn     *grpc.ClientConn
+	client   pb.MetricsClient
+	stream   *frameStream // поток StreamRequest, nil до первого кадра
+	config   config.AgentConfig
+	streamMu sync.Mutex
 }
 
 func NewGRPCClient(cfg config.AgentConfig) *GRPCClient {
@@ -44,22 +48,60 @@ func NewGRPCClientWithConn(conn *grpc.ClientConn, cfg config.AgentConfig) *GRPCC
 	}
 }
 
-func (g *GRPCClient) GRPCRequest(ctx context.Context, metrics []models.Metrics) error {
+// typeToProto переводит тип метрики в protobuf.
+func typeToProto(mType string) pb.Metric_Type {
+	switch mType {
+	case "counter":
+		return pb.Metric_COUNTER
+	case "histogram":
+		return pb.Metric_HISTOGRAM
+	case "summary":
+		return pb.Metric_SUMMARY
+	case "set":
+		return pb.Metric_SET
+	}
+
+	return pb.Metric_GAUGE
+}
+
+// metadataToProto переводит описание метрики в protobuf, nil остается nil.
+func metadataToProto(meta *storage.Metadata) *pb.Metadata {
+	if meta == nil {
+		return nil
+	}
+
+	res := &pb.Metadata{
+		Unit: meta.Unit,
+		Help: meta.Help,
+	}
+	if meta.Type != "" {
+		t := typeToProto(meta.Type)
+		res.Type = &t
+	}
+	if meta.Precision != nil {
+		p := int32(*meta.Precision)
+		res.Precision = &p
+	}
+
+	return res
+}
+
+// newRequest собирает пачку метрик с новым ключом пачки,
+// зашифрованную, если задан публичный ключ.
+func (g *GRPCClient) newRequest(metrics []models.Metrics) (*pb.UpdateMetricsRequest, error) {
 	if len(metrics) == 0 || metrics == nil {
-		return errors.New("empty metrics list")
+		return nil, errors.New("empty metrics list")
 	}
 
 	list := make([]*pb.Metric, 0, len(metrics))
 	for _, m := range metrics {
-		mtype := pb.Metric_GAUGE
-		if m.MType == "counter" {
-			mtype = pb.Metric_COUNTER
-		}
 		list = append(list, &pb.Metric{
-			Id:    m.ID,
-			MType: mtype,
-			Delta: (*int64)(m.Delta),
-			Value: (*float64)(m.Value),
+			Id:     m.ID,
+			MType:  typeToProto(m.MType),
+			Delta:  (*int64)(m.Delta),
+			Value:  (*float64)(m.Value),
+			Labels: m.Labels,
+			Meta:   metadataToProto(m.Meta),
 		})
 	}
 
@@ -69,36 +111,56 @@ func (g *GRPCClient) GRPCRequest(ctx context.Context, metrics []models.Metrics)
 
 	var enctypted []byte
 	if g.config.PublicKey != nil {
-		encryptedBytes, err := proto.Marshal(req)
+		encryptedBytes, err := crypto.MarshalProto(req)
 		if err != nil {
-			return err
+			return nil, err
 		}
 
 		enctypted, err = crypto.EncryptRSA(encryptedBytes, g.config.PublicKey)
 		if err != nil {
-			return err
+			return nil, err
 		}
 		req = &pb.UpdateMetricsRequest{
 			Encrypted: enctypted,
 		}
 	}
 
-	if g.config.Key != "" {
-		b, err := proto.Marshal(req)
-		if err != nil {
-			logger.Log.Error("Error while marshal UpdateMetricsRequest pb", zap.Error(err))
-		}
-		sha := crypto.GenerateHMAC(b, g.config.Key)
+	req.BatchId = uuid.NewString()
+
+	return req, nil
+}
+
+// hash возвращает HMAC-SHA256 пачки или пустую строку без ключа.
+func (g *GRPCClient) hash(req *pb.UpdateMetricsRequest) string {
+	if g.config.Key == "" {
+		return ""
+	}
+
+	b, err := crypto.MarshalProto(req)
+	if err != nil {
+		logger.Log.Error("Error while marshal UpdateMetricsRequest pb", zap.Error(err))
+	}
+
+	return crypto.GenerateHMAC(b, g.config.Key)
+}
+
+func (g *GRPCClient) GRPCRequest(ctx context.Context, metrics []models.Metrics) error {
+	req, err := g.newRequest(metrics)
+	if err != nil {
+		return err
+	}
 
+	if sha := g.hash(req); sha != "" {
 		md := metadata.New(map[string]string{"HashSHA256": sha})
 		ctx = metadata.NewOutgoingContext(ctx, md)
 	}
 
-	_, err := g.client.UpdateMetrics(ctx, req)
+	_, err = g.client.UpdateMetrics(ctx, req)
 	return err
 }
 
 func (g *GRPCClient) Shutdown() {
+	g.closeStream()
 	if err := g.conn.Close(); err != nil {
 		logger.Log.Error("GRPC conn close error", zap.Error(err))
 	}
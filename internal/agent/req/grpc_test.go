@@ -37,10 +37,14 @@ func (s *mockServer) UpdateMetrics(
 }
 
 func getConn(t *testing.T) (*grpc.ClientConn, *mockServer) {
+	srv := &mockServer{}
+	return dial(t, srv), srv
+}
+
+func dial(t *testing.T, srv pb.MetricsServer) *grpc.ClientConn {
 	lis := bufconn.Listen(1024 * 1024)
 
 	s := grpc.NewServer()
-	srv := &mockServer{}
 	pb.RegisterMetricsServer(s, srv)
 
 	go func() {
@@ -58,7 +62,7 @@ func getConn(t *testing.T) (*grpc.ClientConn, *mockServer) {
 	)
 
 	require.NoError(t, err)
-	return conn, srv
+	return conn
 }
 
 func checkList(t *testing.T, want, list []*pb.Metric) {
@@ -91,13 +95,25 @@ var (
 		MType: pb.Metric_GAUGE,
 		Value: floatPtr(1),
 	}
+	summaryMetric = models.Metrics{
+		ID:    "latency",
+		MType: "summary",
+		Value: gaugePtr(0.5),
+	}
+	summaryMetricPb = pb.Metric{
+		Id:    "latency",
+		MType: pb.Metric_SUMMARY,
+		Value: floatPtr(0.5),
+	}
 	list = []models.Metrics{
 		counterMetric,
 		gaugeMetric,
+		summaryMetric,
 	}
 	wantList = []*pb.Metric{
 		&counterMetricPb,
 		&gaugeMetricPb,
+		&summaryMetricPb,
 	}
 )
 
@@ -149,6 +165,7 @@ func TestGRPCRequest(t *testing.T) {
 			if !tt.notCheckList {
 				// assert.Equal(t, tt.wantList, srv.recieved.Metrics)
 				checkList(t, tt.wantList, srv.recieved.Metrics)
+				assert.NotEmpty(t, srv.recieved.BatchId)
 			}
 		}()
 	}
@@ -212,22 +229,19 @@ func TestGRPCRequestHMAC(t *testing.T) {
 		},
 	})
 
-	ctx := context.Background()
-	err := cl.GRPCRequest(ctx, list)
+	err := cl.GRPCRequest(context.Background(), list)
 	require.NoError(t, err)
 
+	checkList(t, wantList, srv.recieved.Metrics)
+
 	wantReq := &pb.UpdateMetricsRequest{
 		Metrics: wantList,
+		BatchId: srv.recieved.BatchId,
 	}
-	b, err := proto.Marshal(wantReq)
+	b, err := crypto.MarshalProto(wantReq)
 	require.NoError(t, err)
 	wantHmac := crypto.GenerateHMAC(b, key)
 
-	err = cl.GRPCRequest(context.Background(), list)
-	require.NoError(t, err)
-
-	checkList(t, wantList, srv.recieved.Metrics)
-
 	md, ok := metadata.FromIncomingContext(srv.recievedCtx)
 	headerHash := ""
 	if ok {
@@ -251,3 +265,34 @@ func counterPtr(v storage.Counter) *storage.Counter {
 func floatPtr(v float64) *float64 {
 	return &v
 }
+
+func TestMetadataToProto(t *testing.T) {
+	precision := 2
+	gauge := pb.Metric_GAUGE
+	pbPrecision := int32(2)
+
+	tests := []struct {
+		meta *storage.Metadata
+		want *pb.Metadata
+		name string
+	}{
+		{
+			name: "Nil",
+		},
+		{
+			name: "Unit and help",
+			meta: &storage.Metadata{Unit: "bytes", Help: "heap"},
+			want: &pb.Metadata{Unit: "bytes", Help: "heap"},
+		},
+		{
+			name: "Type and precision",
+			meta: &storage.Metadata{Type: "gauge", Precision: &precision},
+			want: &pb.Metadata{Type: &gauge, Precision: &pbPrecision},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.True(t, proto.Equal(tt.want, metadataToProto(tt.meta)))
+		})
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/LekcRg/metrics/internal/logger"
 	"github.com/LekcRg/metrics/internal/models"
 	"github.com/LekcRg/metrics/internal/retry"
+	"github.com/google/uuid"
 )
 
 type RequestArgs struct {
@@ -48,6 +49,8 @@ func HTTPRequest(args RequestArgs) error {
 	}
 
 	req.Header.Set("Content-Type", "application/json")
+	// повтор после таймаута придет с тем же ключом, и сервер не применит пачку дважды
+	req.Header.Set("Idempotency-Key", uuid.NewString())
 	if args.Config.IP != "" {
 		req.Header.Set("X-Real-IP", args.Config.IP)
 	}
@@ -55,6 +58,14 @@ func HTTPRequest(args RequestArgs) error {
 	var resp *http.Response
 
 	err = retry.Retry(args.Ctx, func() error {
+		// тело прочитано предыдущей попыткой
+		if req.GetBody != nil {
+			req.Body, err = req.GetBody()
+			if err != nil {
+				return err
+			}
+		}
+
 		resp, err = client.Do(req)
 		if err != nil {
 			return err
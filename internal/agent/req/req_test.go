@@ -55,6 +55,7 @@ func TestHTTPRequest(t *testing.T) {
 			body, err := json.Marshal(metrics)
 			require.NoError(t, err)
 			svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.NotEmpty(t, r.Header.Get("Idempotency-Key"))
 				if tt.key != "" {
 					sha := crypto.GenerateHMAC(body, tt.key)
 
@@ -85,3 +86,25 @@ func TestHTTPRequest(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPRequestBatchID(t *testing.T) {
+	keys := make([]string, 0, 2)
+	svr := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer svr.Close()
+
+	val := storage.Gauge(1)
+	args := RequestArgs{
+		Ctx:     context.Background(),
+		URL:     svr.URL,
+		Metrics: []models.Metrics{{ID: "test", MType: "gauge", Value: &val}},
+	}
+	require.NoError(t, HTTPRequest(args))
+	require.NoError(t, HTTPRequest(args))
+
+	require.Len(t, keys, 2)
+	assert.NotEmpty(t, keys[0])
+	assert.NotEqual(t, keys[0], keys[1])
+}
@@ -0,0 +1,211 @@
+package req
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/LekcRg/metrics/internal/models"
+	pb "github.com/LekcRg/metrics/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var errStreamClosed = errors.New("metrics stream closed")
+
+// frameStream — открытый поток StreamMetrics. Кадры отправляются
+// с возрастающими номерами, подтверждения читает recvLoop.
+// Окно window ограничивает число кадров без подтверждения.
+type frameStream struct {
+	err     error // причина закрытия потока
+	stream  pb.Metrics_StreamMetricsClient
+	pending map[uint64]chan *pb.FrameAck
+	window  chan struct{}
+	done    chan struct{}
+	cancel  context.CancelFunc
+	seq     uint64
+	mu      sync.Mutex
+	sendMu  sync.Mutex // Send потока нельзя вызывать конкурентно
+}
+
+func newFrameStream(client pb.MetricsClient, window int) (*frameStream, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := client.StreamMetrics(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	if window < 1 {
+		window = 1
+	}
+
+	fs := &frameStream{
+		stream:  stream,
+		pending: make(map[uint64]chan *pb.FrameAck),
+		window:  make(chan struct{}, window),
+		done:    make(chan struct{}),
+		cancel:  cancel,
+	}
+	go fs.recvLoop()
+
+	return fs, nil
+}
+
+// recvLoop передает подтверждения ожидающим кадрам до закрытия потока.
+func (fs *frameStream) recvLoop() {
+	for {
+		ack, err := fs.stream.Recv()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				err = errStreamClosed
+			}
+			fs.close(err)
+			return
+		}
+
+		fs.mu.Lock()
+		ch, ok := fs.pending[ack.GetSeq()]
+		delete(fs.pending, ack.GetSeq())
+		fs.mu.Unlock()
+
+		if ok {
+			ch <- ack
+		}
+	}
+}
+
+// close закрывает поток с причиной err. Ожидающие кадры получают err.
+func (fs *frameStream) close(err error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	if fs.err != nil {
+		return
+	}
+
+	fs.err = err
+	close(fs.done)
+	fs.cancel()
+}
+
+// closeErr возвращает причину закрытия потока, nil для открытого.
+func (fs *frameStream) closeErr() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	return fs.err
+}
+
+// shutdown сообщает серверу, что кадров больше не будет, и закрывает поток.
+func (fs *frameStream) shutdown() {
+	fs.sendMu.Lock()
+	fs.stream.CloseSend()
+	fs.sendMu.Unlock()
+
+	fs.close(errStreamClosed)
+}
+
+// send отправляет кадр с пачкой req и хешем hash и ждет его подтверждения.
+// Если окно заполнено, send ждет подтверждения предыдущих кадров.
+func (fs *frameStream) send(ctx context.Context, req *pb.UpdateMetricsRequest, hash string) (*pb.FrameAck, error) {
+	select {
+	case fs.window <- struct{}{}:
+	case <-fs.done:
+		return nil, fs.closeErr()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	defer func() { <-fs.window }()
+
+	ch := make(chan *pb.FrameAck, 1)
+
+	fs.mu.Lock()
+	if fs.err != nil {
+		fs.mu.Unlock()
+		return nil, fs.err
+	}
+	fs.seq++
+	seq := fs.seq
+	fs.pending[seq] = ch
+	fs.mu.Unlock()
+
+	fs.sendMu.Lock()
+	err := fs.stream.Send(&pb.MetricsFrame{Seq: seq, Request: req, Hash: hash})
+	fs.sendMu.Unlock()
+
+	if err != nil {
+		fs.close(err)
+		return nil, err
+	}
+
+	select {
+	case ack := <-ch:
+		return ack, nil
+	case <-fs.done:
+		return nil, fs.closeErr()
+	case <-ctx.Done():
+		fs.mu.Lock()
+		delete(fs.pending, seq)
+		fs.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// getStream возвращает открытый поток, открывая новый при первом кадре
+// и после обрыва.
+func (g *GRPCClient) getStream() (*frameStream, error) {
+	g.streamMu.Lock()
+	defer g.streamMu.Unlock()
+
+	if g.stream != nil && g.stream.closeErr() == nil {
+		return g.stream, nil
+	}
+
+	fs, err := newFrameStream(g.client, g.config.RateLimit)
+	if err != nil {
+		return nil, err
+	}
+	g.stream = fs
+
+	return fs, nil
+}
+
+// closeStream закрывает поток StreamRequest, если он открыт.
+func (g *GRPCClient) closeStream() {
+	g.streamMu.Lock()
+	defer g.streamMu.Unlock()
+
+	if g.stream != nil {
+		g.stream.shutdown()
+		g.stream = nil
+	}
+}
+
+// StreamRequest отправляет метрики кадром долгоживущего потока StreamMetrics
+// и ждет подтверждения кадра. Поток открывается при первом кадре
+// и переоткрывается после обрыва. Без подтверждения в потоке может быть
+// не больше RateLimit кадров, остальные ждут.
+func (g *GRPCClient) StreamRequest(ctx context.Context, metrics []models.Metrics) error {
+	req, err := g.newRequest(metrics)
+	if err != nil {
+		return err
+	}
+
+	fs, err := g.getStream()
+	if err != nil {
+		return err
+	}
+
+	ack, err := fs.send(ctx, req, g.hash(req))
+	if err != nil {
+		return err
+	}
+
+	if code := codes.Code(ack.GetCode()); code != codes.OK {
+		return status.Error(code, ack.GetMessage())
+	}
+
+	return nil
+}
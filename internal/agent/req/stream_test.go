@@ -0,0 +1,149 @@
+package req
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/models"
+	pb "github.com/LekcRg/metrics/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// streamServer подтверждает кадры потока с кодом code.
+type streamServer struct {
+	pb.UnimplementedMetricsServer
+	frames     []*pb.MetricsFrame
+	streams    int
+	closeAfter int // поток закрывается после стольких кадров, 0 — не закрывается
+	code       codes.Code
+	mu         sync.Mutex
+}
+
+func (s *streamServer) StreamMetrics(stream pb.Metrics_StreamMetricsServer) error {
+	s.mu.Lock()
+	s.streams++
+	s.mu.Unlock()
+
+	received := 0
+	for {
+		frame, err := stream.Recv()
+		if err != nil {
+			return nil
+		}
+
+		s.mu.Lock()
+		s.frames = append(s.frames, frame)
+		s.mu.Unlock()
+
+		err = stream.Send(&pb.FrameAck{Seq: frame.GetSeq(), Code: int32(s.code), Message: "frame error"})
+		if err != nil {
+			return err
+		}
+
+		received++
+		if s.closeAfter > 0 && received == s.closeAfter {
+			return status.Error(codes.Unavailable, "server is going away")
+		}
+	}
+}
+
+func TestStreamRequest(t *testing.T) {
+	const key = "secret-key"
+
+	srv := &streamServer{}
+	cl := NewGRPCClientWithConn(dial(t, srv), config.AgentConfig{
+		CommonConfig: config.CommonConfig{Key: key},
+		RateLimit:    2,
+	})
+	defer cl.Shutdown()
+
+	const workers = 10
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, cl.StreamRequest(context.Background(), []models.Metrics{counterMetric}))
+		}()
+	}
+	wg.Wait()
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+
+	assert.Equal(t, 1, srv.streams)
+	require.Len(t, srv.frames, workers)
+
+	seqs := map[uint64]struct{}{}
+	for _, frame := range srv.frames {
+		seqs[frame.GetSeq()] = struct{}{}
+
+		checkList(t, []*pb.Metric{&counterMetricPb}, frame.GetRequest().GetMetrics())
+		assert.NotEmpty(t, frame.GetRequest().GetBatchId())
+
+		b, err := crypto.MarshalProto(frame.GetRequest())
+		require.NoError(t, err)
+		assert.Equal(t, crypto.GenerateHMAC(b, key), frame.GetHash())
+	}
+	assert.Len(t, seqs, workers)
+}
+
+func TestStreamRequestErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		metrics  []models.Metrics
+		code     codes.Code
+		wantCode codes.Code
+	}{
+		{
+			name:     "Rejected frame",
+			metrics:  list,
+			code:     codes.InvalidArgument,
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Empty list",
+			metrics:  []models.Metrics{},
+			wantCode: codes.Unknown,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cl := NewGRPCClientWithConn(dial(t, &streamServer{code: tt.code}), config.AgentConfig{})
+			defer cl.Shutdown()
+
+			err := cl.StreamRequest(context.Background(), tt.metrics)
+			require.Error(t, err)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
+
+func TestStreamRequestReopen(t *testing.T) {
+	srv := &streamServer{closeAfter: 1}
+	cl := NewGRPCClientWithConn(dial(t, srv), config.AgentConfig{})
+	defer cl.Shutdown()
+
+	require.NoError(t, cl.StreamRequest(context.Background(), list))
+
+	// сервер закрыл поток после первого кадра
+	assert.Eventually(t, func() bool {
+		cl.streamMu.Lock()
+		defer cl.streamMu.Unlock()
+		return cl.stream.closeErr() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	require.NoError(t, cl.StreamRequest(context.Background(), list))
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	assert.Equal(t, 2, srv.streams)
+	assert.Len(t, srv.frames, 2)
+}
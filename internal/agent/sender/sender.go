@@ -17,12 +17,16 @@ import (
 	"go.uber.org/zap"
 )
 
+// latencyMetric — имя summary с длительностью отправки метрик в секундах.
+const latencyMetric = "RequestLatency"
+
 type Sender struct {
 	monitor   *monitoring.MonitoringStats
 	grpc      *req.GRPCClient
 	jobs      chan []models.Metrics
 	shutdown  chan bool
 	url       string
+	latencies []float64
 	config    config.AgentConfig
 	wg        sync.WaitGroup
 	mu        sync.Mutex
@@ -71,9 +75,13 @@ func (s *Sender) postRequestWorker(ctx context.Context) {
 			reqCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 			defer cancel()
 			var err error
-			if s.config.IsGRPC {
+			start := time.Now()
+			switch {
+			case s.config.IsGRPC && s.config.GRPCStream:
+				err = s.grpc.StreamRequest(reqCtx, data)
+			case s.config.IsGRPC:
 				err = s.grpc.GRPCRequest(ctx, data)
-			} else {
+			default:
 				reqArgs := req.RequestArgs{
 					Metrics: data,
 					Ctx:     reqCtx,
@@ -89,6 +97,7 @@ func (s *Sender) postRequestWorker(ctx context.Context) {
 			}
 
 			s.mu.Lock()
+			s.latencies = append(s.latencies, time.Since(start).Seconds())
 			s.countSent++
 			logger.Log.Info("Request sent. Total: " + strconv.Itoa(s.countSent) + " requests")
 			s.mu.Unlock()
@@ -115,6 +124,7 @@ func (s *Sender) genMetricStruct(
 		ID:    name,
 		Value: value,
 		Delta: delta,
+		Meta:  monitoring.Metadata(name),
 	}
 }
 
@@ -147,11 +157,32 @@ func (s *Sender) sendRandom(ctx context.Context) {
 	s.jobs <- data
 }
 
+// sendLatency отправляет накопленные длительности запросов наблюдениями summary.
+func (s *Sender) sendLatency(ctx context.Context) {
+	s.mu.Lock()
+	latencies := s.latencies
+	s.latencies = nil
+	s.mu.Unlock()
+
+	if len(latencies) == 0 {
+		return
+	}
+
+	data := make([]models.Metrics, 0, len(latencies))
+	for _, l := range latencies {
+		val := storage.Gauge(l)
+		data = append(data, s.genMetricStruct("summary", latencyMetric, &val, nil))
+	}
+
+	s.jobs <- data
+}
+
 func (s *Sender) sendAllMetrics(ctx context.Context) {
 	// стоит объеденить в один запрос?
 	s.sendGaugeMetrics(ctx, s.monitor.GetRuntimeStats())
 	s.sendGaugeMetrics(ctx, s.monitor.GetGopsStats())
 	s.sendRandom(ctx)
+	s.sendLatency(ctx)
 }
 
 func (s *Sender) startWorkerPool(ctx context.Context) {
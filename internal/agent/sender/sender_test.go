@@ -79,4 +79,15 @@ func TestSender(t *testing.T) {
 		}
 	}
 	assert.Greater(t, countFound, len(needMetric)-1)
+
+	latency := 0
+	for _, m := range received {
+		if m.ID == latencyMetric {
+			assert.Equal(t, "summary", m.MType)
+			require.NotNil(t, m.Value)
+			assert.GreaterOrEqual(t, float64(*m.Value), 0.0)
+			latency++
+		}
+	}
+	assert.Positive(t, latency, "request latency is not reported")
 }
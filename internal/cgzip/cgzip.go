@@ -29,7 +29,8 @@ type (
 	}
 
 	headerData struct {
-		statusCode int
+		statusCode  int
+		wroteHeader bool
 	}
 )
 
@@ -39,11 +40,20 @@ var toGzip = []string{
 }
 
 func (w *gzipWriter) Write(b []byte) (int, error) {
+	// заголовки уже отправлены первой записью, она же выбрала сжатие
+	if w.headerData.wroteHeader {
+		if w.gzipped {
+			return w.Writer.Write(b)
+		}
+		return w.ResponseWriter.Write(b)
+	}
+
 	if w.headerData.statusCode == 0 {
 		w.headerData.statusCode = http.StatusOK
 	}
 
 	contentType := w.Header().Get("Content-Type")
+	w.headerData.wroteHeader = true
 	if !slices.Contains(toGzip, contentType) ||
 		w.headerData.statusCode > 299 {
 		w.ResponseWriter.WriteHeader(w.headerData.statusCode)
@@ -61,6 +71,11 @@ func (w *gzipWriter) Write(b []byte) (int, error) {
 	return w.Writer.Write(b)
 }
 
+// Unwrap возвращает исходный http.ResponseWriter для http.ResponseController.
+func (w *gzipWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
 func (w gzipWriter) WriteHeader(statusCode int) {
 	w.headerData.statusCode = statusCode
 }
@@ -92,6 +107,11 @@ func GzipHandle(next http.Handler) http.Handler {
 		}()
 
 		next.ServeHTTP(gzwr, r)
+
+		// ответ без тела, например 204
+		if headerData.statusCode != 0 && !headerData.wroteHeader {
+			w.WriteHeader(headerData.statusCode)
+		}
 	})
 }
 
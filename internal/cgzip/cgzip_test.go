@@ -79,6 +79,45 @@ func TestGzipHandle(t *testing.T) {
 	}
 }
 
+func TestGzipHandleFlush(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		isGzip      bool
+	}{
+		{
+			name:        "gzipped",
+			contentType: "text/html",
+			isGzip:      true,
+		},
+		{
+			name:        "not gzipped event stream",
+			contentType: "text/event-stream",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Add("Accept-Encoding", "gzip")
+			h := GzipHandle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusOK)
+				w.Write([]byte(content[:3]))
+				require.NoError(t, http.NewResponseController(w).Flush())
+				w.Write([]byte(content[3:]))
+			}))
+			h.ServeHTTP(w, r)
+			res := w.Result()
+			defer res.Body.Close()
+
+			assert.True(t, w.Flushed)
+			body := ungzip(t, res, tt.isGzip)
+			assert.Equal(t, content, string(body))
+		})
+	}
+}
+
 func TestGzipBody(t *testing.T) {
 	tests := []struct {
 		name            string
@@ -25,18 +25,37 @@ type CommonConfig struct {
 	IsDev         bool   `env:"IS_DEV" json:"dev"`
 }
 
+// ServerConfig — настройки сервера. Buckets и TenantTokens заполняет serverapp
+// из HistogramBuckets и Tenants, чтобы конфиг не зависел от пакетов сервера.
 type ServerConfig struct {
-	PrivateKey      *rsa.PrivateKey
-	TrustedNetwork  *netip.Prefix
-	Addr            string `env:"ADDRESS" json:"address"`
-	GRPCAddr        string `env:"GRPC_ADDR" json:"grpc_addr"`
-	FileStoragePath string `env:"FILE_STORAGE_PATH" json:"store_file"`
-	DatabaseDSN     string `env:"DATABASE_DSN" json:"database_dsn"`
-	TrustedSubnet   string `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	PrivateKey             *rsa.PrivateKey
+	TrustedNetwork         *netip.Prefix
+	Buckets                []float64
+	TenantTokens           map[string]string
+	Addr                   string `env:"ADDRESS" json:"address"`
+	GRPCAddr               string `env:"GRPC_ADDR" json:"grpc_addr"`
+	FileStoragePath        string `env:"FILE_STORAGE_PATH" json:"store_file"`
+	DatabaseDSN            string `env:"DATABASE_DSN" json:"database_dsn"`
+	WALDir                 string `env:"WAL_DIR" json:"wal_dir"`
+	TrustedSubnet          string `env:"TRUSTED_SUBNET" json:"trusted_subnet"`
+	StatsDAddr             string `env:"STATSD_ADDR" json:"statsd_addr"`
+	GraphiteAddr           string `env:"GRAPHITE_ADDR" json:"graphite_addr"`
+	GraphiteCounterPattern string `env:"GRAPHITE_COUNTER_PATTERN" json:"graphite_counter_pattern"`
+	InfluxCumulative       string `env:"INFLUX_CUMULATIVE" json:"influx_cumulative"`
+	HistogramBuckets       string `env:"HISTOGRAM_BUCKETS" json:"histogram_buckets"`
+	Retention              string `env:"RETENTION" json:"retention"`
+	Tenants                string `env:"TENANTS" json:"tenants"`
+	TenantHeader           string `env:"TENANT_HEADER" json:"tenant_header"`
 	CommonConfig
-	StoreInterval int  `env:"STORE_INTERVAL" envDefault:"-1" json:"store_interval"`
-	Restore       bool `env:"RESTORE" json:"restore"`
-	SyncSave      bool
+	StoreInterval       int  `env:"STORE_INTERVAL" envDefault:"-1" json:"store_interval"`
+	StatsDFlushInterval int  `env:"STATSD_FLUSH_INTERVAL" json:"statsd_flush_interval"`
+	SummaryWindow       int  `env:"SUMMARY_WINDOW" json:"summary_window"`
+	WALSnapshotInterval int  `env:"WAL_SNAPSHOT_INTERVAL" json:"wal_snapshot_interval"`
+	RetentionInterval   int  `env:"RETENTION_INTERVAL" json:"retention_interval"`
+	MetricTTL           int  `env:"METRIC_TTL" json:"metric_ttl"`
+	TenantMaxSeries     int  `env:"TENANT_MAX_SERIES" json:"tenant_max_series"`
+	Restore             bool `env:"RESTORE" json:"restore"`
+	SyncSave            bool
 }
 
 type AgentConfig struct {
@@ -49,6 +68,7 @@ type AgentConfig struct {
 	RateLimit      int  `env:"RATE_LIMIT" json:"rate_limit"`
 	IsHTTPS        bool `env:"IS_HTTPS" json:"https"`
 	IsGRPC         bool `env:"IS_GRPC" json:"is_grpc"`
+	GRPCStream     bool `env:"GRPC_STREAM" json:"grpc_stream"` // с IsGRPC метрики отправляются одним потоком
 }
 
 var defaultCommon = CommonConfig{
@@ -60,14 +80,18 @@ var defaultCommon = CommonConfig{
 }
 
 var defaultServer = ServerConfig{
-	CommonConfig:    defaultCommon,
-	FileStoragePath: "store.json",
-	Addr:            "localhost:8080",
-	GRPCAddr:        ":3200",
-	DatabaseDSN:     "",
-	StoreInterval:   -1,
-	Restore:         false,
-	SyncSave:        false,
+	CommonConfig:        defaultCommon,
+	FileStoragePath:     "store.json",
+	Addr:                "localhost:8080",
+	GRPCAddr:            ":3200",
+	DatabaseDSN:         "",
+	StoreInterval:       -1,
+	Restore:             false,
+	SyncSave:            false,
+	StatsDFlushInterval: 10,
+	SummaryWindow:       600,
+	WALSnapshotInterval: 300,
+	RetentionInterval:   60,
 }
 
 var defaultAgent = AgentConfig{
@@ -78,6 +102,7 @@ var defaultAgent = AgentConfig{
 	RateLimit:      5,
 	IsHTTPS:        false,
 	IsGRPC:         false,
+	GRPCStream:     false,
 }
 
 func loadCommonFlags(flSet *flag.FlagSet, cfg *CommonConfig) {
@@ -129,9 +154,30 @@ func loadServerFlags(flSet *flag.FlagSet, fl *ServerConfig) {
 	flSet.IntVar(&fl.StoreInterval, "i", 0, "time is seconds to save db to store(file)")
 	flSet.StringVar(&fl.FileStoragePath, "f", "", "path to save store")
 	flSet.BoolVar(&fl.Restore, "r", false, "restore db from file")
-	flSet.StringVar(&fl.DatabaseDSN, "d", "", "Postgres database DSN")
+	flSet.StringVar(&fl.DatabaseDSN, "d", "", "database DSN: postgres or sqlite://path")
+	flSet.StringVar(&fl.WALDir, "wal", "", "directory for on-disk storage with write-ahead log, empty to disable")
+	flSet.IntVar(&fl.WALSnapshotInterval, "wal-snapshot", 0, "time in seconds between write-ahead log compactions")
 	flSet.StringVar(&fl.TrustedSubnet, "t", "", "Trusted subnet in CIDR notation (e.g., 192.168.1.0/24)")
 	flSet.StringVar(&fl.GRPCAddr, "g", "", "GRPC address")
+	flSet.StringVar(&fl.StatsDAddr, "statsd", "", "StatsD UDP/TCP address, empty to disable")
+	flSet.IntVar(&fl.StatsDFlushInterval, "statsd-flush", 0, "time in seconds to aggregate StatsD packets")
+	flSet.StringVar(&fl.GraphiteAddr, "graphite", "", "Graphite plaintext TCP address, empty to disable")
+	flSet.StringVar(&fl.GraphiteCounterPattern, "graphite-counter", "",
+		"regexp for Graphite paths that are counters, other paths are gauges")
+	flSet.StringVar(&fl.InfluxCumulative, "influx-cumulative", "",
+		"comma-separated Influx measurements whose integer fields are cumulative counters")
+	flSet.StringVar(&fl.HistogramBuckets, "histogram-buckets", "",
+		"comma-separated upper bounds of histogram buckets, empty for defaults")
+	flSet.IntVar(&fl.SummaryWindow, "summary-window", 0, "time in seconds for summary quantiles window, summary count and sum are all-time")
+	flSet.StringVar(&fl.Retention, "retention", "",
+		"history retention policy like raw:24h,1m:30d,1h:365d, empty or off for no time limit (memory storage keeps the latest 10000 samples per series)")
+	flSet.IntVar(&fl.RetentionInterval, "retention-interval", 0, "time in seconds between retention runs")
+	flSet.IntVar(&fl.MetricTTL, "metric-ttl", 0,
+		"time in seconds after the last update when a metric is deleted, 0 to keep forever")
+	flSet.StringVar(&fl.Tenants, "tenants", "", "comma-separated token:tenant pairs for bearer tokens")
+	flSet.StringVar(&fl.TenantHeader, "tenant-header", "",
+		"trusted request header with tenant ID set by a proxy, empty to disable, ignored with -tenants")
+	flSet.IntVar(&fl.TenantMaxSeries, "tenant-max-series", 0, "max series per tenant, 0 for unlimited")
 	loadCommonFlags(flSet, &fl.CommonConfig)
 }
 
@@ -184,9 +230,14 @@ func LoadServerCfg(args ...string) ServerConfig {
 		panic("merge err\n" + err.Error())
 	}
 
-	if cfg.DatabaseDSN != "" {
+	switch {
+	case cfg.DatabaseDSN != "":
 		cfg.Restore = false
-	} else {
+	case cfg.WALDir != "":
+		// журнал сам сохраняет каждое изменение, файл store не нужен
+		cfg.Restore = false
+		cfg.SyncSave = false
+	default:
 		cfg.SyncSave = cfg.StoreInterval == 0
 	}
 
@@ -209,6 +260,8 @@ func loadAgentFlags(flSet *flag.FlagSet, fl *AgentConfig) {
 	flSet.IntVar(&fl.RateLimit, "l", 0, "rate limit requests")
 	flSet.BoolVar(&fl.IsHTTPS, "s", false, "https true/false, default false")
 	flSet.BoolVar(&fl.IsGRPC, "g", false, "metrics will be sent via GRPC")
+	flSet.BoolVar(&fl.GRPCStream, "grpc-stream", false,
+		"with -g metrics will be sent as frames of one long-lived GRPC stream")
 	flSet.StringVar(&fl.Addr, "a", "", "server address (http/grpc)")
 	loadCommonFlags(flSet, &fl.CommonConfig)
 }
@@ -210,6 +210,51 @@ func TestLoadServerCfg(t *testing.T) {
 				Restore:     false,
 			},
 		},
+		{
+			name: "Histogram buckets",
+			env:  []string{"HISTOGRAM_BUCKETS", "0.1, 1,10"},
+			want: ServerConfig{
+				HistogramBuckets: "0.1, 1,10",
+			},
+		},
+		{
+			name: "WAL disables store file",
+			env:  []string{"WAL_DIR", "/tmp/metrics", "RESTORE", "true"},
+			want: ServerConfig{
+				WALDir:  "/tmp/metrics",
+				Restore: false,
+			},
+		},
+		{
+			name: "Summary window",
+			env:  []string{"SUMMARY_WINDOW", "60"},
+			want: ServerConfig{
+				SummaryWindow: 60,
+			},
+		},
+		{
+			name: "Retention policy",
+			env:  []string{"RETENTION", "raw:24h,1m:30d", "RETENTION_INTERVAL", "30"},
+			want: ServerConfig{
+				Retention:         "raw:24h,1m:30d",
+				RetentionInterval: 30,
+			},
+		},
+		{
+			name: "Retention off",
+			env:  []string{"RETENTION", "off"},
+			want: ServerConfig{
+				Retention: "off",
+			},
+		},
+		{
+			name: "Tenants",
+			env:  []string{"TENANTS", "tok1:team-a, tok2:team-b", "TENANT_MAX_SERIES", "100"},
+			want: ServerConfig{
+				Tenants:         "tok1:team-a, tok2:team-b",
+				TenantMaxSeries: 100,
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
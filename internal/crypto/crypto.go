@@ -24,6 +24,14 @@ func GenerateHMAC(content []byte, key string) string {
 	return hex.EncodeToString(dst)
 }
 
+// MarshalProto сериализует сообщение для подписи и шифрования.
+// Элементы map (метки, квантили) сериализуются в случайном порядке,
+// поэтому байты одного сообщения совпадают только при детерминированной
+// сериализации.
+func MarshalProto(in proto.Message) ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(in)
+}
+
 func GetAndValidHMACProto(ctx context.Context, key string, in proto.Message) error {
 	if key == "" {
 		return nil
@@ -31,21 +39,30 @@ func GetAndValidHMACProto(ctx context.Context, key string, in proto.Message) err
 
 	md, ok := metadata.FromIncomingContext(ctx)
 	headerHash := ""
-	if ok {
-		headerHash = md.Get("HashSHA256")[0]
+	if vals := md.Get("HashSHA256"); ok && len(vals) > 0 {
+		headerHash = vals[0]
+	}
+
+	return ValidHMACProto(key, headerHash, in)
+}
+
+// ValidHMACProto проверяет HMAC-SHA256 hash сообщения in, например кадра потока,
+// у которого хеш передается в самом кадре, а не в метаданных.
+func ValidHMACProto(key string, hash string, in proto.Message) error {
+	if key == "" {
+		return nil
 	}
 
-	if headerHash == "" {
+	if hash == "" {
 		return status.Error(codes.PermissionDenied, "Empty HashSHA256")
 	}
 
-	inBytes, err := proto.Marshal(in)
+	inBytes, err := MarshalProto(in)
 	if err != nil {
 		return status.Error(codes.Internal, "Internal server error")
 	}
 
-	hash := GenerateHMAC(inBytes, key)
-	if hash != headerHash {
+	if GenerateHMAC(inBytes, key) != hash {
 		return status.Error(codes.PermissionDenied, "Hash is not correct")
 	}
 
@@ -165,3 +165,25 @@ func TestGetAndValidHMACProto(t *testing.T) {
 		})
 	}
 }
+
+func TestValidHMACProtoMaps(t *testing.T) {
+	msg := &pb.UpdateMetricsRequest{
+		Metrics: []*pb.Metric{
+			{
+				Id:        "latency",
+				Labels:    map[string]string{"host": "web1", "region": "eu", "service": "api", "zone": "b"},
+				Quantiles: map[string]float64{"0.5": 1, "0.9": 2, "0.99": 3},
+			},
+		},
+	}
+	b, err := MarshalProto(msg)
+	require.NoError(t, err)
+	hash := GenerateHMAC(b, key)
+
+	for range 100 {
+		got, err := MarshalProto(msg)
+		require.NoError(t, err)
+		require.Equal(t, b, got)
+		require.NoError(t, ValidHMACProto(key, hash, msg))
+	}
+}
@@ -94,6 +94,8 @@ func RsaMiddleware(priv *rsa.PrivateKey) func(next http.Handler) http.Handler {
 			if err != nil {
 				logger.Log.Error("Error while read body with rsa")
 				http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+				return
 			}
 			defer r.Body.Close()
 
@@ -101,6 +103,8 @@ func RsaMiddleware(priv *rsa.PrivateKey) func(next http.Handler) http.Handler {
 			if err != nil {
 				logger.Log.Error("Error while decrypt body")
 				http.Error(w, "Internal server error", http.StatusInternalServerError)
+
+				return
 			}
 
 			r.Body = io.NopCloser(bytes.NewReader(newBody))
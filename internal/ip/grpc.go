@@ -0,0 +1,38 @@
+package ip
+
+import (
+	"context"
+	"net/netip"
+	"slices"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// FilterInterceptor — аналог FilterMiddleware для gRPC: методы methods
+// доступны только клиентам из network. Адрес клиента передается
+// в метаданных x-real-ip, как заголовок X-Real-IP в HTTP.
+func FilterInterceptor(network *netip.Prefix, methods ...string) grpc.UnaryServerInterceptor {
+	return func(
+		ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+	) (any, error) {
+		if network == nil || !slices.Contains(methods, info.FullMethod) {
+			return handler(ctx, req)
+		}
+
+		var ipStr string
+		md, _ := metadata.FromIncomingContext(ctx)
+		if vals := md.Get("x-real-ip"); len(vals) > 0 {
+			ipStr = vals[0]
+		}
+
+		ip, err := netip.ParseAddr(ipStr)
+		if err != nil || !network.Contains(ip) {
+			return nil, status.Error(codes.PermissionDenied, "Permission denied")
+		}
+
+		return handler(ctx, req)
+	}
+}
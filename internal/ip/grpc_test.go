@@ -0,0 +1,75 @@
+package ip
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestFilterInterceptor(t *testing.T) {
+	network := netip.MustParsePrefix("192.168.1.0/24")
+	const filtered = "/metric.Metrics/DeleteMetric"
+
+	tests := []struct {
+		network  *netip.Prefix
+		name     string
+		method   string
+		realIP   string
+		wantCode codes.Code
+	}{
+		{
+			name:     "nil network - should allow all",
+			method:   filtered,
+			realIP:   "10.0.0.1",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "allowed IP",
+			network:  &network,
+			method:   filtered,
+			realIP:   "192.168.1.100",
+			wantCode: codes.OK,
+		},
+		{
+			name:     "blocked IP",
+			network:  &network,
+			method:   filtered,
+			realIP:   "10.0.0.1",
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "no IP",
+			network:  &network,
+			method:   filtered,
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "other method is not filtered",
+			network:  &network,
+			method:   "/metric.Metrics/UpdateMetrics",
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.realIP != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-real-ip", tt.realIP))
+			}
+
+			interceptor := FilterInterceptor(tt.network, filtered)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method},
+				func(context.Context, any) (any, error) {
+					return nil, nil
+				})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+		})
+	}
+}
@@ -39,6 +39,11 @@ func (r *loggingResponseWriter) WriteHeader(statusCode int) {
 	r.responseData.status = statusCode // захватываем код статуса
 }
 
+// Unwrap возвращает исходный http.ResponseWriter для http.ResponseController.
+func (r *loggingResponseWriter) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
 func InterceptorLogger(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
 	startTime := time.Now()
 	md, ok := metadata.FromIncomingContext(ctx)
@@ -55,6 +60,26 @@ func InterceptorLogger(ctx context.Context, req any, info *grpc.UnaryServerInfo,
 	return h, err
 }
 
+// StreamInterceptorLogger — InterceptorLogger для потоковых методов,
+// записывает поток после его закрытия.
+func StreamInterceptorLogger(
+	srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	startTime := time.Now()
+	md, ok := metadata.FromIncomingContext(ss.Context())
+
+	err := handler(srv, ss)
+	Log.Info("got incoming GRPC stream",
+		zap.Bool("ok", ok),
+		zap.String("Method", info.FullMethod),
+		zap.Duration("time", time.Since(startTime)),
+		zap.Any("md", md),
+		zap.Error(err),
+	)
+
+	return err
+}
+
 // Initialize конфигурирует Log по уровню логирования и режиму.
 func Initialize(level string, isDev bool) error {
 	lvl, err := zap.ParseAtomicLevel(level)
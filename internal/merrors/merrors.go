@@ -3,15 +3,42 @@ package merrors
 import "errors"
 
 var (
-	ErrIncorrectCounterValue = errors.New("counter value must be int64")
-	ErrIncorrectGaugeValue   = errors.New("counter value must be float64")
+	ErrIncorrectCounterValue   = errors.New("counter value must be int64")
+	ErrIncorrectGaugeValue     = errors.New("counter value must be float64")
+	ErrIncorrectHistogramValue = errors.New("histogram value must be float64")
+	ErrIncorrectSummaryValue   = errors.New("summary value must be float64")
 )
 
 var (
-	ErrIncorrectMetricType     = errors.New("incorrect type. type must be a counter or a gauge")
-	ErrMissingMetricValue      = errors.New("missing metric value")
-	ErrCannotGetNewMetricValue = errors.New("can'not get new value")
-	ErrNotFoundMetric          = errors.New("not found metric")
+	ErrIncorrectMetricType       = errors.New("incorrect type. type must be a counter, a gauge, a histogram, a summary or a set")
+	ErrMissingMetricValue        = errors.New("missing metric value")
+	ErrCannotGetNewMetricValue   = errors.New("can'not get new value")
+	ErrNotFoundMetric            = errors.New("not found metric")
+	ErrIncorrectTimeRange        = errors.New("incorrect time range. from must be before to")
+	ErrIncorrectStep             = errors.New("incorrect step. step must be positive")
+	ErrTooManyPoints             = errors.New("too many points. increase step or decrease time range")
+	ErrIncorrectAggregation      = errors.New("incorrect aggregation. must be last, avg, min, max, sum or rate")
+	ErrIncorrectSeriesKey        = errors.New("incorrect series key")
+	ErrIncorrectMatchers         = errors.New("incorrect label matchers")
+	ErrIncorrectHistogram        = errors.New("incorrect histogram. buckets must increase, counts must match buckets")
+	ErrIncorrectHistogramBuckets = errors.New("histogram buckets differ from stored buckets")
+	ErrIncorrectQuantile         = errors.New("incorrect quantile. must be between 0 and 1")
+	ErrIncorrectSet              = errors.New("incorrect set sketch")
+	ErrIncorrectRetention        = errors.New("incorrect retention policy. must be raw:24h,1m:30d,1h:365d like")
+	ErrIncorrectMetadata         = errors.New("incorrect metadata. type must be a metric type, precision between 0 and 17")
+	ErrIncorrectTenant           = errors.New("incorrect tenant. must be 1-64 letters, digits, _ or -")
+	ErrIncorrectTenants          = errors.New("incorrect tenants. must be token1:tenant1,token2:tenant2 like")
+	ErrIncorrectTenantKey        = errors.New("metric name of the default tenant must not contain /")
+	ErrUnknownTenantToken        = errors.New("unknown tenant token")
+	ErrTenantTokenRequired       = errors.New("tenant token required. tenant header is ignored when tokens are set")
+	ErrSeriesQuotaExceeded       = errors.New("tenant series quota exceeded")
+	ErrTenantNotAllowed          = errors.New("operation works with all tenants and is not allowed for a tenant")
+	ErrIncorrectBatchID          = errors.New("incorrect batch id. must be at most 128 characters")
+	ErrDuplicateBatch            = errors.New("batch already applied")
+	ErrIncorrectMetricName       = errors.New("incorrect metric name. must not be empty")
+	ErrBatchRejected             = errors.New("batch rejected. strict batch has invalid metrics")
+	ErrIncorrectPageToken        = errors.New("incorrect page token")
+	ErrSlowConsumer              = errors.New("subscriber is too slow. too many unread changes")
 )
 
 var (
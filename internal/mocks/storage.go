@@ -6,6 +6,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/server/storage"
 	mock "github.com/stretchr/testify/mock"
@@ -38,6 +39,53 @@ func (_m *MockStorage) EXPECT() *MockStorage_Expecter {
 	return &MockStorage_Expecter{mock: &_m.Mock}
 }
 
+// ApplyRetention provides a mock function for the type MockStorage
+func (_mock *MockStorage) ApplyRetention(ctx context.Context, policy storage.RetentionPolicy, now time.Time) error {
+	ret := _mock.Called(ctx, policy, now)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ApplyRetention")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, storage.RetentionPolicy, time.Time) error); ok {
+		r0 = returnFunc(ctx, policy, now)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockStorage_ApplyRetention_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ApplyRetention'
+type MockStorage_ApplyRetention_Call struct {
+	*mock.Call
+}
+
+// ApplyRetention is a helper method to define mock.On call
+//   - ctx
+//   - policy
+//   - now
+func (_e *MockStorage_Expecter) ApplyRetention(ctx interface{}, policy interface{}, now interface{}) *MockStorage_ApplyRetention_Call {
+	return &MockStorage_ApplyRetention_Call{Call: _e.mock.On("ApplyRetention", ctx, policy, now)}
+}
+
+func (_c *MockStorage_ApplyRetention_Call) Run(run func(ctx context.Context, policy storage.RetentionPolicy, now time.Time)) *MockStorage_ApplyRetention_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(storage.RetentionPolicy), args[2].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockStorage_ApplyRetention_Call) Return(err error) *MockStorage_ApplyRetention_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockStorage_ApplyRetention_Call) RunAndReturn(run func(ctx context.Context, policy storage.RetentionPolicy, now time.Time) error) *MockStorage_ApplyRetention_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Close provides a mock function for the type MockStorage
 func (_mock *MockStorage) Close() {
 	_mock.Called()
@@ -71,6 +119,169 @@ func (_c *MockStorage_Close_Call) RunAndReturn(run func()) *MockStorage_Close_Ca
 	return _c
 }
 
+// DeleteMetric provides a mock function for the type MockStorage
+func (_mock *MockStorage) DeleteMetric(ctx context.Context, mType string, name string) error {
+	ret := _mock.Called(ctx, mType, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMetric")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, mType, name)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockStorage_DeleteMetric_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteMetric'
+type MockStorage_DeleteMetric_Call struct {
+	*mock.Call
+}
+
+// DeleteMetric is a helper method to define mock.On call
+//   - ctx
+//   - mType
+//   - name
+func (_e *MockStorage_Expecter) DeleteMetric(ctx interface{}, mType interface{}, name interface{}) *MockStorage_DeleteMetric_Call {
+	return &MockStorage_DeleteMetric_Call{Call: _e.mock.On("DeleteMetric", ctx, mType, name)}
+}
+
+func (_c *MockStorage_DeleteMetric_Call) Run(run func(ctx context.Context, mType string, name string)) *MockStorage_DeleteMetric_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockStorage_DeleteMetric_Call) Return(err error) *MockStorage_DeleteMetric_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockStorage_DeleteMetric_Call) RunAndReturn(run func(ctx context.Context, mType string, name string) error) *MockStorage_DeleteMetric_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// DeleteStale provides a mock function for the type MockStorage
+func (_mock *MockStorage) DeleteStale(ctx context.Context, before time.Time) ([]storage.MetricRef, error) {
+	ret := _mock.Called(ctx, before)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteStale")
+	}
+
+	var r0 []storage.MetricRef
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time) ([]storage.MetricRef, error)); ok {
+		return returnFunc(ctx, before)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, time.Time) []storage.MetricRef); ok {
+		r0 = returnFunc(ctx, before)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.MetricRef)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, time.Time) error); ok {
+		r1 = returnFunc(ctx, before)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_DeleteStale_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteStale'
+type MockStorage_DeleteStale_Call struct {
+	*mock.Call
+}
+
+// DeleteStale is a helper method to define mock.On call
+//   - ctx
+//   - before
+func (_e *MockStorage_Expecter) DeleteStale(ctx interface{}, before interface{}) *MockStorage_DeleteStale_Call {
+	return &MockStorage_DeleteStale_Call{Call: _e.mock.On("DeleteStale", ctx, before)}
+}
+
+func (_c *MockStorage_DeleteStale_Call) Run(run func(ctx context.Context, before time.Time)) *MockStorage_DeleteStale_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockStorage_DeleteStale_Call) Return(metricRefs []storage.MetricRef, err error) *MockStorage_DeleteStale_Call {
+	_c.Call.Return(metricRefs, err)
+	return _c
+}
+
+func (_c *MockStorage_DeleteStale_Call) RunAndReturn(run func(ctx context.Context, before time.Time) ([]storage.MetricRef, error)) *MockStorage_DeleteStale_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// FindSeries provides a mock function for the type MockStorage
+func (_mock *MockStorage) FindSeries(ctx context.Context, mType string, name string, matchers []storage.Matcher) ([]storage.Series, error) {
+	ret := _mock.Called(ctx, mType, name, matchers)
+
+	if len(ret) == 0 {
+		panic("no return value specified for FindSeries")
+	}
+
+	var r0 []storage.Series
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []storage.Matcher) ([]storage.Series, error)); ok {
+		return returnFunc(ctx, mType, name, matchers)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string, []storage.Matcher) []storage.Series); ok {
+		r0 = returnFunc(ctx, mType, name, matchers)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Series)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, string, []storage.Matcher) error); ok {
+		r1 = returnFunc(ctx, mType, name, matchers)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_FindSeries_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'FindSeries'
+type MockStorage_FindSeries_Call struct {
+	*mock.Call
+}
+
+// FindSeries is a helper method to define mock.On call
+//   - ctx
+//   - mType
+//   - name
+//   - matchers
+func (_e *MockStorage_Expecter) FindSeries(ctx interface{}, mType interface{}, name interface{}, matchers interface{}) *MockStorage_FindSeries_Call {
+	return &MockStorage_FindSeries_Call{Call: _e.mock.On("FindSeries", ctx, mType, name, matchers)}
+}
+
+func (_c *MockStorage_FindSeries_Call) Run(run func(ctx context.Context, mType string, name string, matchers []storage.Matcher)) *MockStorage_FindSeries_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].([]storage.Matcher))
+	})
+	return _c
+}
+
+func (_c *MockStorage_FindSeries_Call) Return(seriess []storage.Series, err error) *MockStorage_FindSeries_Call {
+	_c.Call.Return(seriess, err)
+	return _c
+}
+
+func (_c *MockStorage_FindSeries_Call) RunAndReturn(run func(ctx context.Context, mType string, name string, matchers []storage.Matcher) ([]storage.Series, error)) *MockStorage_FindSeries_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetAll provides a mock function for the type MockStorage
 func (_mock *MockStorage) GetAll(ctx context.Context) (storage.Database, error) {
 	ret := _mock.Called(ctx)
@@ -180,6 +391,125 @@ func (_c *MockStorage_GetCounterByName_Call) RunAndReturn(run func(ctx context.C
 	return _c
 }
 
+// GetCounterHistory provides a mock function for the type MockStorage
+func (_mock *MockStorage) GetCounterHistory(ctx context.Context, name string, from time.Time, to time.Time) ([]storage.Sample, error) {
+	ret := _mock.Called(ctx, name, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCounterHistory")
+	}
+
+	var r0 []storage.Sample
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) ([]storage.Sample, error)); ok {
+		return returnFunc(ctx, name, from, to)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) []storage.Sample); ok {
+		r0 = returnFunc(ctx, name, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Sample)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r1 = returnFunc(ctx, name, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_GetCounterHistory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCounterHistory'
+type MockStorage_GetCounterHistory_Call struct {
+	*mock.Call
+}
+
+// GetCounterHistory is a helper method to define mock.On call
+//   - ctx
+//   - name
+//   - from
+//   - to
+func (_e *MockStorage_Expecter) GetCounterHistory(ctx interface{}, name interface{}, from interface{}, to interface{}) *MockStorage_GetCounterHistory_Call {
+	return &MockStorage_GetCounterHistory_Call{Call: _e.mock.On("GetCounterHistory", ctx, name, from, to)}
+}
+
+func (_c *MockStorage_GetCounterHistory_Call) Run(run func(ctx context.Context, name string, from time.Time, to time.Time)) *MockStorage_GetCounterHistory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetCounterHistory_Call) Return(samples []storage.Sample, err error) *MockStorage_GetCounterHistory_Call {
+	_c.Call.Return(samples, err)
+	return _c
+}
+
+func (_c *MockStorage_GetCounterHistory_Call) RunAndReturn(run func(ctx context.Context, name string, from time.Time, to time.Time) ([]storage.Sample, error)) *MockStorage_GetCounterHistory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetCounterRollups provides a mock function for the type MockStorage
+func (_mock *MockStorage) GetCounterRollups(ctx context.Context, name string, resolution time.Duration, from time.Time, to time.Time) ([]storage.Rollup, error) {
+	ret := _mock.Called(ctx, name, resolution, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCounterRollups")
+	}
+
+	var r0 []storage.Rollup
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Duration, time.Time, time.Time) ([]storage.Rollup, error)); ok {
+		return returnFunc(ctx, name, resolution, from, to)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Duration, time.Time, time.Time) []storage.Rollup); ok {
+		r0 = returnFunc(ctx, name, resolution, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Rollup)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Duration, time.Time, time.Time) error); ok {
+		r1 = returnFunc(ctx, name, resolution, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_GetCounterRollups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCounterRollups'
+type MockStorage_GetCounterRollups_Call struct {
+	*mock.Call
+}
+
+// GetCounterRollups is a helper method to define mock.On call
+//   - ctx
+//   - name
+//   - resolution
+//   - from
+//   - to
+func (_e *MockStorage_Expecter) GetCounterRollups(ctx interface{}, name interface{}, resolution interface{}, from interface{}, to interface{}) *MockStorage_GetCounterRollups_Call {
+	return &MockStorage_GetCounterRollups_Call{Call: _e.mock.On("GetCounterRollups", ctx, name, resolution, from, to)}
+}
+
+func (_c *MockStorage_GetCounterRollups_Call) Run(run func(ctx context.Context, name string, resolution time.Duration, from time.Time, to time.Time)) *MockStorage_GetCounterRollups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Duration), args[3].(time.Time), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetCounterRollups_Call) Return(rollups []storage.Rollup, err error) *MockStorage_GetCounterRollups_Call {
+	_c.Call.Return(rollups, err)
+	return _c
+}
+
+func (_c *MockStorage_GetCounterRollups_Call) RunAndReturn(run func(ctx context.Context, name string, resolution time.Duration, from time.Time, to time.Time) ([]storage.Rollup, error)) *MockStorage_GetCounterRollups_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetGaugeByName provides a mock function for the type MockStorage
 func (_mock *MockStorage) GetGaugeByName(ctx context.Context, name string) (storage.Gauge, error) {
 	ret := _mock.Called(ctx, name)
@@ -235,6 +565,290 @@ func (_c *MockStorage_GetGaugeByName_Call) RunAndReturn(run func(ctx context.Con
 	return _c
 }
 
+// GetGaugeHistory provides a mock function for the type MockStorage
+func (_mock *MockStorage) GetGaugeHistory(ctx context.Context, name string, from time.Time, to time.Time) ([]storage.Sample, error) {
+	ret := _mock.Called(ctx, name, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGaugeHistory")
+	}
+
+	var r0 []storage.Sample
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) ([]storage.Sample, error)); ok {
+		return returnFunc(ctx, name, from, to)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Time, time.Time) []storage.Sample); ok {
+		r0 = returnFunc(ctx, name, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Sample)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Time, time.Time) error); ok {
+		r1 = returnFunc(ctx, name, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_GetGaugeHistory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGaugeHistory'
+type MockStorage_GetGaugeHistory_Call struct {
+	*mock.Call
+}
+
+// GetGaugeHistory is a helper method to define mock.On call
+//   - ctx
+//   - name
+//   - from
+//   - to
+func (_e *MockStorage_Expecter) GetGaugeHistory(ctx interface{}, name interface{}, from interface{}, to interface{}) *MockStorage_GetGaugeHistory_Call {
+	return &MockStorage_GetGaugeHistory_Call{Call: _e.mock.On("GetGaugeHistory", ctx, name, from, to)}
+}
+
+func (_c *MockStorage_GetGaugeHistory_Call) Run(run func(ctx context.Context, name string, from time.Time, to time.Time)) *MockStorage_GetGaugeHistory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Time), args[3].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetGaugeHistory_Call) Return(samples []storage.Sample, err error) *MockStorage_GetGaugeHistory_Call {
+	_c.Call.Return(samples, err)
+	return _c
+}
+
+func (_c *MockStorage_GetGaugeHistory_Call) RunAndReturn(run func(ctx context.Context, name string, from time.Time, to time.Time) ([]storage.Sample, error)) *MockStorage_GetGaugeHistory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetGaugeRollups provides a mock function for the type MockStorage
+func (_mock *MockStorage) GetGaugeRollups(ctx context.Context, name string, resolution time.Duration, from time.Time, to time.Time) ([]storage.Rollup, error) {
+	ret := _mock.Called(ctx, name, resolution, from, to)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetGaugeRollups")
+	}
+
+	var r0 []storage.Rollup
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Duration, time.Time, time.Time) ([]storage.Rollup, error)); ok {
+		return returnFunc(ctx, name, resolution, from, to)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, time.Duration, time.Time, time.Time) []storage.Rollup); ok {
+		r0 = returnFunc(ctx, name, resolution, from, to)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]storage.Rollup)
+		}
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, time.Duration, time.Time, time.Time) error); ok {
+		r1 = returnFunc(ctx, name, resolution, from, to)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_GetGaugeRollups_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetGaugeRollups'
+type MockStorage_GetGaugeRollups_Call struct {
+	*mock.Call
+}
+
+// GetGaugeRollups is a helper method to define mock.On call
+//   - ctx
+//   - name
+//   - resolution
+//   - from
+//   - to
+func (_e *MockStorage_Expecter) GetGaugeRollups(ctx interface{}, name interface{}, resolution interface{}, from interface{}, to interface{}) *MockStorage_GetGaugeRollups_Call {
+	return &MockStorage_GetGaugeRollups_Call{Call: _e.mock.On("GetGaugeRollups", ctx, name, resolution, from, to)}
+}
+
+func (_c *MockStorage_GetGaugeRollups_Call) Run(run func(ctx context.Context, name string, resolution time.Duration, from time.Time, to time.Time)) *MockStorage_GetGaugeRollups_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(time.Duration), args[3].(time.Time), args[4].(time.Time))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetGaugeRollups_Call) Return(rollups []storage.Rollup, err error) *MockStorage_GetGaugeRollups_Call {
+	_c.Call.Return(rollups, err)
+	return _c
+}
+
+func (_c *MockStorage_GetGaugeRollups_Call) RunAndReturn(run func(ctx context.Context, name string, resolution time.Duration, from time.Time, to time.Time) ([]storage.Rollup, error)) *MockStorage_GetGaugeRollups_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetHistogramByName provides a mock function for the type MockStorage
+func (_mock *MockStorage) GetHistogramByName(ctx context.Context, name string) (storage.Histogram, error) {
+	ret := _mock.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHistogramByName")
+	}
+
+	var r0 storage.Histogram
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (storage.Histogram, error)); ok {
+		return returnFunc(ctx, name)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) storage.Histogram); ok {
+		r0 = returnFunc(ctx, name)
+	} else {
+		r0 = ret.Get(0).(storage.Histogram)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_GetHistogramByName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetHistogramByName'
+type MockStorage_GetHistogramByName_Call struct {
+	*mock.Call
+}
+
+// GetHistogramByName is a helper method to define mock.On call
+//   - ctx
+//   - name
+func (_e *MockStorage_Expecter) GetHistogramByName(ctx interface{}, name interface{}) *MockStorage_GetHistogramByName_Call {
+	return &MockStorage_GetHistogramByName_Call{Call: _e.mock.On("GetHistogramByName", ctx, name)}
+}
+
+func (_c *MockStorage_GetHistogramByName_Call) Run(run func(ctx context.Context, name string)) *MockStorage_GetHistogramByName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetHistogramByName_Call) Return(histogram storage.Histogram, err error) *MockStorage_GetHistogramByName_Call {
+	_c.Call.Return(histogram, err)
+	return _c
+}
+
+func (_c *MockStorage_GetHistogramByName_Call) RunAndReturn(run func(ctx context.Context, name string) (storage.Histogram, error)) *MockStorage_GetHistogramByName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSetByName provides a mock function for the type MockStorage
+func (_mock *MockStorage) GetSetByName(ctx context.Context, name string) (storage.Set, error) {
+	ret := _mock.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSetByName")
+	}
+
+	var r0 storage.Set
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (storage.Set, error)); ok {
+		return returnFunc(ctx, name)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) storage.Set); ok {
+		r0 = returnFunc(ctx, name)
+	} else {
+		r0 = ret.Get(0).(storage.Set)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_GetSetByName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSetByName'
+type MockStorage_GetSetByName_Call struct {
+	*mock.Call
+}
+
+// GetSetByName is a helper method to define mock.On call
+//   - ctx
+//   - name
+func (_e *MockStorage_Expecter) GetSetByName(ctx interface{}, name interface{}) *MockStorage_GetSetByName_Call {
+	return &MockStorage_GetSetByName_Call{Call: _e.mock.On("GetSetByName", ctx, name)}
+}
+
+func (_c *MockStorage_GetSetByName_Call) Run(run func(ctx context.Context, name string)) *MockStorage_GetSetByName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetSetByName_Call) Return(set storage.Set, err error) *MockStorage_GetSetByName_Call {
+	_c.Call.Return(set, err)
+	return _c
+}
+
+func (_c *MockStorage_GetSetByName_Call) RunAndReturn(run func(ctx context.Context, name string) (storage.Set, error)) *MockStorage_GetSetByName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// GetSummaryByName provides a mock function for the type MockStorage
+func (_mock *MockStorage) GetSummaryByName(ctx context.Context, name string) (storage.Summary, error) {
+	ret := _mock.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSummaryByName")
+	}
+
+	var r0 storage.Summary
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) (storage.Summary, error)); ok {
+		return returnFunc(ctx, name)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string) storage.Summary); ok {
+		r0 = returnFunc(ctx, name)
+	} else {
+		r0 = ret.Get(0).(storage.Summary)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = returnFunc(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_GetSummaryByName_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSummaryByName'
+type MockStorage_GetSummaryByName_Call struct {
+	*mock.Call
+}
+
+// GetSummaryByName is a helper method to define mock.On call
+//   - ctx
+//   - name
+func (_e *MockStorage_Expecter) GetSummaryByName(ctx interface{}, name interface{}) *MockStorage_GetSummaryByName_Call {
+	return &MockStorage_GetSummaryByName_Call{Call: _e.mock.On("GetSummaryByName", ctx, name)}
+}
+
+func (_c *MockStorage_GetSummaryByName_Call) Run(run func(ctx context.Context, name string)) *MockStorage_GetSummaryByName_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockStorage_GetSummaryByName_Call) Return(summary storage.Summary, err error) *MockStorage_GetSummaryByName_Call {
+	_c.Call.Return(summary, err)
+	return _c
+}
+
+func (_c *MockStorage_GetSummaryByName_Call) RunAndReturn(run func(ctx context.Context, name string) (storage.Summary, error)) *MockStorage_GetSummaryByName_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Ping provides a mock function for the type MockStorage
 func (_mock *MockStorage) Ping(ctx context.Context) error {
 	ret := _mock.Called(ctx)
@@ -392,6 +1006,62 @@ func (_c *MockStorage_UpdateGauge_Call) RunAndReturn(run func(ctx context.Contex
 	return _c
 }
 
+// UpdateHistogram provides a mock function for the type MockStorage
+func (_mock *MockStorage) UpdateHistogram(ctx context.Context, name string, value storage.Histogram) (storage.Histogram, error) {
+	ret := _mock.Called(ctx, name, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateHistogram")
+	}
+
+	var r0 storage.Histogram
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, storage.Histogram) (storage.Histogram, error)); ok {
+		return returnFunc(ctx, name, value)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, storage.Histogram) storage.Histogram); ok {
+		r0 = returnFunc(ctx, name, value)
+	} else {
+		r0 = ret.Get(0).(storage.Histogram)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, storage.Histogram) error); ok {
+		r1 = returnFunc(ctx, name, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_UpdateHistogram_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateHistogram'
+type MockStorage_UpdateHistogram_Call struct {
+	*mock.Call
+}
+
+// UpdateHistogram is a helper method to define mock.On call
+//   - ctx
+//   - name
+//   - value
+func (_e *MockStorage_Expecter) UpdateHistogram(ctx interface{}, name interface{}, value interface{}) *MockStorage_UpdateHistogram_Call {
+	return &MockStorage_UpdateHistogram_Call{Call: _e.mock.On("UpdateHistogram", ctx, name, value)}
+}
+
+func (_c *MockStorage_UpdateHistogram_Call) Run(run func(ctx context.Context, name string, value storage.Histogram)) *MockStorage_UpdateHistogram_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(storage.Histogram))
+	})
+	return _c
+}
+
+func (_c *MockStorage_UpdateHistogram_Call) Return(histogram storage.Histogram, err error) *MockStorage_UpdateHistogram_Call {
+	_c.Call.Return(histogram, err)
+	return _c
+}
+
+func (_c *MockStorage_UpdateHistogram_Call) RunAndReturn(run func(ctx context.Context, name string, value storage.Histogram) (storage.Histogram, error)) *MockStorage_UpdateHistogram_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateMany provides a mock function for the type MockStorage
 func (_mock *MockStorage) UpdateMany(ctx context.Context, list storage.Database) error {
 	ret := _mock.Called(ctx, list)
@@ -437,3 +1107,115 @@ func (_c *MockStorage_UpdateMany_Call) RunAndReturn(run func(ctx context.Context
 	_c.Call.Return(run)
 	return _c
 }
+
+// UpdateSet provides a mock function for the type MockStorage
+func (_mock *MockStorage) UpdateSet(ctx context.Context, name string, value storage.Set) (storage.Set, error) {
+	ret := _mock.Called(ctx, name, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSet")
+	}
+
+	var r0 storage.Set
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, storage.Set) (storage.Set, error)); ok {
+		return returnFunc(ctx, name, value)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, storage.Set) storage.Set); ok {
+		r0 = returnFunc(ctx, name, value)
+	} else {
+		r0 = ret.Get(0).(storage.Set)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, storage.Set) error); ok {
+		r1 = returnFunc(ctx, name, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_UpdateSet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateSet'
+type MockStorage_UpdateSet_Call struct {
+	*mock.Call
+}
+
+// UpdateSet is a helper method to define mock.On call
+//   - ctx
+//   - name
+//   - value
+func (_e *MockStorage_Expecter) UpdateSet(ctx interface{}, name interface{}, value interface{}) *MockStorage_UpdateSet_Call {
+	return &MockStorage_UpdateSet_Call{Call: _e.mock.On("UpdateSet", ctx, name, value)}
+}
+
+func (_c *MockStorage_UpdateSet_Call) Run(run func(ctx context.Context, name string, value storage.Set)) *MockStorage_UpdateSet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(storage.Set))
+	})
+	return _c
+}
+
+func (_c *MockStorage_UpdateSet_Call) Return(set storage.Set, err error) *MockStorage_UpdateSet_Call {
+	_c.Call.Return(set, err)
+	return _c
+}
+
+func (_c *MockStorage_UpdateSet_Call) RunAndReturn(run func(ctx context.Context, name string, value storage.Set) (storage.Set, error)) *MockStorage_UpdateSet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateSummary provides a mock function for the type MockStorage
+func (_mock *MockStorage) UpdateSummary(ctx context.Context, name string, value storage.Summary) (storage.Summary, error) {
+	ret := _mock.Called(ctx, name, value)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateSummary")
+	}
+
+	var r0 storage.Summary
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, storage.Summary) (storage.Summary, error)); ok {
+		return returnFunc(ctx, name, value)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, storage.Summary) storage.Summary); ok {
+		r0 = returnFunc(ctx, name, value)
+	} else {
+		r0 = ret.Get(0).(storage.Summary)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, storage.Summary) error); ok {
+		r1 = returnFunc(ctx, name, value)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockStorage_UpdateSummary_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateSummary'
+type MockStorage_UpdateSummary_Call struct {
+	*mock.Call
+}
+
+// UpdateSummary is a helper method to define mock.On call
+//   - ctx
+//   - name
+//   - value
+func (_e *MockStorage_Expecter) UpdateSummary(ctx interface{}, name interface{}, value interface{}) *MockStorage_UpdateSummary_Call {
+	return &MockStorage_UpdateSummary_Call{Call: _e.mock.On("UpdateSummary", ctx, name, value)}
+}
+
+func (_c *MockStorage_UpdateSummary_Call) Run(run func(ctx context.Context, name string, value storage.Summary)) *MockStorage_UpdateSummary_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(storage.Summary))
+	})
+	return _c
+}
+
+func (_c *MockStorage_UpdateSummary_Call) Return(summary storage.Summary, err error) *MockStorage_UpdateSummary_Call {
+	_c.Call.Return(summary, err)
+	return _c
+}
+
+func (_c *MockStorage_UpdateSummary_Call) RunAndReturn(run func(ctx context.Context, name string, value storage.Summary) (storage.Summary, error)) *MockStorage_UpdateSummary_Call {
+	_c.Call.Return(run)
+	return _c
+}
@@ -0,0 +1,33 @@
+package models
+
+// BatchItem метрика пачки в ответе на обновление пачкой.
+type BatchItem struct {
+	ID     string `json:"id"`               // имя метрики
+	MType  string `json:"type"`             // тип метрики
+	Reason string `json:"reason,omitempty"` // причина отказа, только у отклоненных
+	Index  int    `json:"index"`            // номер метрики в пачке
+}
+
+// BatchResult результат обновления пачкой: принятые и отклоненные метрики.
+type BatchResult struct {
+	Accepted []BatchItem `json:"accepted"`
+	Rejected []BatchItem `json:"rejected"`
+}
+
+// NewBatchResult создает пустой BatchResult.
+func NewBatchResult() BatchResult {
+	return BatchResult{
+		Accepted: []BatchItem{},
+		Rejected: []BatchItem{},
+	}
+}
+
+// Accept добавляет в результат принятую метрику el с номером i.
+func (r *BatchResult) Accept(i int, el Metrics) {
+	r.Accepted = append(r.Accepted, BatchItem{Index: i, ID: el.ID, MType: el.MType})
+}
+
+// Reject добавляет в результат отклоненную метрику el с номером i.
+func (r *BatchResult) Reject(i int, el Metrics, err error) {
+	r.Rejected = append(r.Rejected, BatchItem{Index: i, ID: el.ID, MType: el.MType, Reason: err.Error()})
+}
@@ -0,0 +1,26 @@
+package models
+
+import (
+	"net/url"
+	"slices"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// LabelsFromQuery возвращает метки из параметров запроса, кроме reserved,
+// или nil, если меток нет. Если параметр передан несколько раз,
+// используется первое значение.
+func LabelsFromQuery(query url.Values, reserved ...string) storage.Labels {
+	var labels storage.Labels
+	for k, v := range query {
+		if len(v) == 0 || v[0] == "" || slices.Contains(reserved, k) {
+			continue
+		}
+		if labels == nil {
+			labels = storage.Labels{}
+		}
+		labels[k] = v[0]
+	}
+
+	return labels
+}
@@ -2,10 +2,23 @@ package models
 
 import "github.com/LekcRg/metrics/internal/server/storage"
 
-// Metrics модель метрики типа gauge или counter.
+// Metrics модель метрики типа gauge, counter, histogram, summary или set.
 type Metrics struct {
-	Delta *storage.Counter `json:"delta,omitempty"` // значение метрики в случае передачи counter
-	Value *storage.Gauge   `json:"value,omitempty"` // значение метрики в случае передачи gauge
-	ID    string           `json:"id"`              // имя метрики
-	MType string           `json:"type"`            // параметр, принимающий значение gauge или counter
+	Delta     *storage.Counter   `json:"delta,omitempty"`     // значение метрики в случае передачи counter
+	Value     *storage.Gauge     `json:"value,omitempty"`     // значение gauge или одно наблюдение histogram и summary
+	Histogram *storage.Histogram `json:"histogram,omitempty"` // бакеты, сумма и количество в случае передачи histogram
+	Summary   *storage.Summary   `json:"summary,omitempty"`   // количество, сумма, min и max summary за все время и окно квантилей, только в ответах
+	Set       *storage.Set       `json:"set,omitempty"`       // скетч set, собранный на стороне клиента
+	Unique    *uint64            `json:"unique,omitempty"`    // оценка количества уникальных значений set, только в ответах
+	Labels    storage.Labels     `json:"labels,omitempty"`    // метки серии, вместе с ID определяют серию
+	Meta      *storage.Metadata  `json:"meta,omitempty"`      // описание метрики ID, заменяет сохраненное
+	Quantiles map[string]float64 `json:"quantiles,omitempty"` // квантили histogram и summary (у summary — за окно), только в ответах
+	Members   []string           `json:"members,omitempty"`   // значения, добавляемые в set
+	ID        string             `json:"id"`                  // имя метрики
+	MType     string             `json:"type"`                // параметр, принимающий значение gauge, counter, histogram, summary или set
+}
+
+// Key возвращает ключ серии в хранилище.
+func (m Metrics) Key() string {
+	return storage.SeriesKey(m.ID, m.Labels)
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// RangeQuery параметры запроса значений метрики за период.
+type RangeQuery struct {
+	From   time.Time      // начало периода
+	To     time.Time      // конец периода
+	Labels storage.Labels // метки серии
+	ID     string         // имя метрики
+	MType  string         // тип метрики, gauge или counter
+	Agg    string         // функция агрегации: last, avg, min, max, sum или rate
+	Step   time.Duration  // шаг, с которым значения собираются в точки
+}
+
+// RangeResult ответ на RangeQuery.
+type RangeResult struct {
+	From   time.Time        `json:"from"`
+	To     time.Time        `json:"to"`
+	Labels storage.Labels   `json:"labels,omitempty"`
+	ID     string           `json:"id"`
+	MType  string           `json:"type"`
+	Agg    string           `json:"agg"`
+	Points []storage.Sample `json:"points"` // точки в начале каждого шага, пустые шаги пропускаются
+	Step   float64          `json:"step"`   // шаг в секундах
+}
+
+// ListQuery параметры запроса списка метрик.
+type ListQuery struct {
+	Prefix    string // префикс имени метрики
+	MType     string // тип метрики, пустой — все типы
+	PageToken string // токен страницы из ListResult, пустой — первая страница
+	Limit     int    // размер страницы, 0 — размер по умолчанию
+}
+
+// ListResult страница списка метрик, упорядоченного по типу и ключу серии.
+type ListResult struct {
+	NextPageToken string    // токен следующей страницы, пустой на последней
+	Metrics       []Metrics // метрики с текущими значениями и описаниями
+}
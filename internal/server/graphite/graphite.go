@@ -0,0 +1,305 @@
+// Package graphite принимает метрики по текстовому протоколу Graphite через TCP.
+//
+// Путь метрики становится ее именем, теги (path;tag=value) — метками.
+// Пути, подходящие под шаблон счетчиков,
+// считаются накопительными counter, остальные — gauge.
+// Значения накапливаются и записываются пачкой через UpdateMany,
+// время из строк протокола игнорируется.
+package graphite
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/services/cumulative"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+	"go.uber.org/zap"
+)
+
+const flushInterval = time.Second
+
+// ErrServerClosed возвращается из ListenAndServe после вызова Stop.
+var ErrServerClosed = errors.New("graphite: server closed")
+
+// MetricService — интерфейс сервиса метрик, который нужен для работы сервера.
+type MetricService interface {
+	GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error)
+	UpdateMany(ctx context.Context, list []models.Metrics) error
+}
+
+type Server struct {
+	service  MetricService
+	listener net.Listener
+	counter  *regexp.Regexp
+	tracker  *cumulative.Tracker
+	gauges   map[string]storage.Gauge
+	counters map[string]storage.Counter
+	conns    map[net.Conn]struct{}
+	done     chan struct{}
+	addr     string
+	wg       sync.WaitGroup
+	interval time.Duration
+	mu       sync.Mutex
+	batchMu  sync.Mutex
+	closed   bool
+}
+
+// New создает сервер. counterPattern — регулярное выражение для путей счетчиков,
+// пустая строка означает, что все метрики — gauge.
+func New(s MetricService, addr, counterPattern string) (*Server, error) {
+	var counter *regexp.Regexp
+	if counterPattern != "" {
+		var err error
+		counter, err = regexp.Compile(counterPattern)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Server{
+		service:  s,
+		addr:     addr,
+		interval: flushInterval,
+		counter:  counter,
+		tracker:  cumulative.New(),
+		gauges:   map[string]storage.Gauge{},
+		counters: map[string]storage.Counter{},
+		conns:    map[net.Conn]struct{}{},
+		done:     make(chan struct{}),
+	}, nil
+}
+
+// ListenAndServe слушает TCP и блокируется до вызова Stop.
+func (s *Server) ListenAndServe() error {
+	listener, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		listener.Close()
+		return ErrServerClosed
+	}
+	s.listener = listener
+	s.wg.Add(2)
+	s.mu.Unlock()
+
+	go s.serve()
+	go s.flushLoop()
+
+	s.wg.Wait()
+
+	return ErrServerClosed
+}
+
+// Addr возвращает адрес сокета после запуска.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.listener == nil {
+		return nil
+	}
+
+	return s.listener.Addr()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !s.isClosed() {
+				logger.Log.Error("graphite: accept error", zap.Error(err))
+			}
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		s.handleLine(scanner.Text())
+	}
+}
+
+// storedCounter возвращает текущее значение счетчика или 0, если его еще нет.
+func (s *Server) storedCounter(series models.Metrics) storage.Counter {
+	series.MType = "counter"
+	m, err := s.service.GetMetricJSON(tenantCtx(context.Background()), series)
+	if err != nil || m.Delta == nil {
+		return 0
+	}
+
+	return *m.Delta
+}
+
+func (s *Server) handleLine(line string) {
+	if line == "" {
+		return
+	}
+
+	name, labels, value, err := parseLine(line)
+	if err != nil {
+		logger.Log.Debug("graphite: skip line", zap.String("line", line), zap.Error(err))
+		return
+	}
+
+	series := models.Metrics{ID: name, Labels: labels}
+	key := series.Key()
+
+	if s.counter != nil && s.counter.MatchString(name) {
+		delta := s.tracker.Delta(tenantCtx(context.Background()), key, value, func() storage.Counter {
+			return s.storedCounter(series)
+		})
+
+		s.batchMu.Lock()
+		s.counters[key] += delta
+		s.batchMu.Unlock()
+
+		return
+	}
+
+	s.batchMu.Lock()
+	s.gauges[key] = storage.Gauge(value)
+	s.batchMu.Unlock()
+}
+
+func (s *Server) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(context.Background())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// tenantCtx возвращает контекст тенанта по умолчанию: у Graphite нет токенов,
+// поэтому его метрики пишутся в этого тенанта.
+func tenantCtx(ctx context.Context) context.Context {
+	return tenant.WithTenant(ctx, tenant.Default)
+}
+
+// seriesMetric восстанавливает имя и метки метрики по ключу серии.
+func seriesMetric(key string) models.Metrics {
+	name, labels, err := storage.ParseSeriesKey(key)
+	if err != nil || len(labels) == 0 {
+		return models.Metrics{ID: key}
+	}
+
+	return models.Metrics{ID: name, Labels: labels}
+}
+
+func (s *Server) flush(ctx context.Context) {
+	ctx = tenantCtx(ctx)
+	s.batchMu.Lock()
+	gauges, counters := s.gauges, s.counters
+	s.gauges = map[string]storage.Gauge{}
+	s.counters = map[string]storage.Counter{}
+	s.batchMu.Unlock()
+
+	if len(gauges) == 0 && len(counters) == 0 {
+		return
+	}
+
+	list := make([]models.Metrics, 0, len(gauges)+len(counters))
+	for key, v := range gauges {
+		m := seriesMetric(key)
+		m.MType, m.Value = "gauge", &v
+		list = append(list, m)
+	}
+	for key, v := range counters {
+		m := seriesMetric(key)
+		m.MType, m.Delta = "counter", &v
+		list = append(list, m)
+	}
+
+	if err := s.service.UpdateMany(ctx, list); err != nil {
+		logger.Log.Error("graphite: error while updating metrics", zap.Error(err))
+		s.restore(gauges, counters)
+	}
+}
+
+// restore возвращает незаписанные значения в накопленные, чтобы записать
+// их при следующем сбросе: трекер уже учел приросты счетчиков, и без этого
+// они бы потерялись. Более новые значения gauge не заменяются.
+func (s *Server) restore(gauges map[string]storage.Gauge, counters map[string]storage.Counter) {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+
+	for key, v := range gauges {
+		if _, ok := s.gauges[key]; !ok {
+			s.gauges[key] = v
+		}
+	}
+	for key, v := range counters {
+		s.counters[key] += v
+	}
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// Stop закрывает сокет и соединения и записывает накопленные значения.
+func (s *Server) Stop(ctx context.Context) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.flush(ctx)
+}
@@ -0,0 +1,108 @@
+package graphite
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNew(t *testing.T) {
+	_, err := New(nil, ":0", "([a-z")
+	assert.Error(t, err)
+}
+
+func TestServer(t *testing.T) {
+	stored := storage.Counter(100)
+	s := NewMockMetricService(t)
+	s.EXPECT().GetMetricJSON(mock.Anything, models.Metrics{ID: "web1.if_octets.rx", MType: "counter"}).
+		Return(models.Metrics{Delta: &stored}, nil).Once()
+
+	updated := make(chan []models.Metrics, 1)
+	s.EXPECT().UpdateMany(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, list []models.Metrics) {
+			updated <- list
+		}).Return(nil).Once()
+
+	srv, err := New(s, "127.0.0.1:0", `\.if_octets\.`)
+	require.NoError(t, err)
+	srv.interval = time.Hour
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.ListenAndServe()
+	}()
+
+	require.Eventually(t, func() bool {
+		return srv.Addr() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	conn, err := net.Dial("tcp", srv.Addr().String())
+	require.NoError(t, err)
+	_, err = conn.Write([]byte(
+		"web1.load 0.5 1700000000\n" +
+			"disk.used;mount=/;host=web1 512\n" +
+			"web1.load 0.75 1700000010\n" +
+			"web1.if_octets.rx 150 1700000000\n" +
+			"web1.if_octets.rx 170 1700000010\n" +
+			"broken line here and there\n",
+	))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	require.Eventually(t, func() bool {
+		srv.batchMu.Lock()
+		defer srv.batchMu.Unlock()
+		return srv.counters["web1.if_octets.rx"] == 70 && srv.gauges["web1.load"] == 0.75
+	}, time.Second, 10*time.Millisecond)
+
+	srv.Stop(context.Background())
+	assert.ErrorIs(t, <-served, ErrServerClosed)
+
+	load := storage.Gauge(0.75)
+	rx := storage.Counter(70)
+	used := storage.Gauge(512)
+	assert.ElementsMatch(t, []models.Metrics{
+		{ID: "web1.load", MType: "gauge", Value: &load},
+		{ID: "disk.used", MType: "gauge", Value: &used, Labels: storage.Labels{"host": "web1", "mount": "/"}},
+		{ID: "web1.if_octets.rx", MType: "counter", Delta: &rx},
+	}, <-updated)
+}
+
+func TestFlushFailure(t *testing.T) {
+	s := NewMockMetricService(t)
+	s.EXPECT().GetMetricJSON(mock.Anything, mock.Anything).
+		Return(models.Metrics{}, merrors.ErrNotFoundMetric).Once()
+	s.EXPECT().UpdateMany(mock.Anything, mock.Anything).Return(merrors.ErrMocked).Once()
+
+	var updated []models.Metrics
+	s.EXPECT().UpdateMany(mock.Anything, mock.Anything).
+		Run(func(_ context.Context, list []models.Metrics) {
+			updated = list
+		}).Return(nil).Once()
+
+	srv, err := New(s, "127.0.0.1:0", `\.if_octets\.`)
+	require.NoError(t, err)
+
+	srv.handleLine("web1.if_octets.rx 150")
+	srv.handleLine("web1.load 0.5")
+	srv.flush(context.Background())
+
+	// прирост из неудачного сброса записывается вместе с новым
+	srv.handleLine("web1.if_octets.rx 170")
+	srv.handleLine("web1.load 0.75")
+	srv.flush(context.Background())
+
+	load := storage.Gauge(0.75)
+	rx := storage.Counter(170)
+	assert.ElementsMatch(t, []models.Metrics{
+		{ID: "web1.load", MType: "gauge", Value: &load},
+		{ID: "web1.if_octets.rx", MType: "counter", Delta: &rx},
+	}, updated)
+}
@@ -0,0 +1,66 @@
+package graphite
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+)
+
+var errIncorrectLine = errors.New("incorrect graphite line")
+
+// parsePath разбирает путь с тегами вида path;tag1=v1;tag2=v2.
+// Путь не может содержать tenant.Separator: метрики Graphite пишутся
+// в тенанта по умолчанию.
+func parsePath(s string) (string, storage.Labels, error) {
+	parts := strings.Split(s, ";")
+	if parts[0] == "" {
+		return "", nil, fmt.Errorf("%w: empty path", errIncorrectLine)
+	}
+	if strings.Contains(parts[0], tenant.Separator) {
+		return "", nil, fmt.Errorf("%w: path must not contain %q", errIncorrectLine, tenant.Separator)
+	}
+	if len(parts) == 1 {
+		return parts[0], nil, nil
+	}
+
+	labels := make(storage.Labels, len(parts)-1)
+	for _, tag := range parts[1:] {
+		k, v, ok := strings.Cut(tag, "=")
+		if !ok || k == "" || v == "" {
+			return "", nil, fmt.Errorf("%w: incorrect tag %q", errIncorrectLine, tag)
+		}
+		labels[k] = v
+	}
+
+	return parts[0], labels, nil
+}
+
+// parseLine разбирает строку вида path[;tag=value...] value [timestamp].
+// Время строки не разбирается и не используется: значение записывается
+// с временем получения, как и в остальных способах записи.
+func parseLine(line string) (string, storage.Labels, float64, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return "", nil, 0, errIncorrectLine
+	}
+
+	path, labels, err := parsePath(fields[0])
+	if err != nil {
+		return "", nil, 0, err
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return "", nil, 0, fmt.Errorf("%w: %w", errIncorrectLine, err)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return "", nil, 0, fmt.Errorf("%w: value must be finite", errIncorrectLine)
+	}
+
+	return path, labels, value, nil
+}
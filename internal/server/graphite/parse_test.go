@@ -0,0 +1,90 @@
+package graphite
+
+import (
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		name       string
+		line       string
+		wantLabels storage.Labels
+		wantName   string
+		wantValue  float64
+		wantErr    bool
+	}{
+		{
+			name:      "Path value timestamp",
+			line:      "servers.web1.cpu.load 0.75 1700000000",
+			wantName:  "servers.web1.cpu.load",
+			wantValue: 0.75,
+		},
+		{
+			name:      "Without timestamp",
+			line:      "servers.web1.requests 42",
+			wantName:  "servers.web1.requests",
+			wantValue: 42,
+		},
+		{
+			name:       "Tagged path",
+			line:       "disk.used;host=web1;mount=/ 512 -1",
+			wantName:   "disk.used",
+			wantLabels: storage.Labels{"host": "web1", "mount": "/"},
+			wantValue:  512,
+		},
+		{
+			name:    "Tenant prefix",
+			line:    "team-a/cpu.load 1",
+			wantErr: true,
+		},
+		{
+			name:    "Incorrect tag",
+			line:    "disk.used;host 512",
+			wantErr: true,
+		},
+		{
+			name:    "Without value",
+			line:    "servers.web1.cpu.load",
+			wantErr: true,
+		},
+		{
+			name:    "Incorrect value",
+			line:    "servers.web1.cpu.load high 1700000000",
+			wantErr: true,
+		},
+		{
+			name:    "NaN value",
+			line:    "servers.web1.cpu.load nan 1700000000",
+			wantErr: true,
+		},
+		{
+			name:      "Timestamp is ignored",
+			line:      "servers.web1.cpu.load 1 now",
+			wantName:  "servers.web1.cpu.load",
+			wantValue: 1,
+		},
+		{
+			name:    "Too many fields",
+			line:    "servers.web1.cpu.load 1 1700000000 extra",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			name, labels, value, err := parseLine(tt.line)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errIncorrectLine)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, name)
+			assert.Equal(t, tt.wantLabels, labels)
+			assert.Equal(t, tt.wantValue, value)
+		})
+	}
+}
@@ -2,14 +2,24 @@ package grpcapi
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
 
 	"github.com/LekcRg/metrics/internal/config"
 	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/ip"
 	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
+	"github.com/LekcRg/metrics/internal/server/otlp"
 	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
 	pb "github.com/LekcRg/metrics/proto"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
 	"go.uber.org/zap"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -17,30 +27,218 @@ import (
 )
 
 type MetricService interface {
-	UpdateMany(ctx context.Context, list []models.Metrics) error
+	UpdateBatch(ctx context.Context, batchID string, list []models.Metrics, strict bool) (models.BatchResult, error)
+	DeleteMetric(ctx context.Context, reqName string, reqType string) error
+	GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error)
+	ListMetrics(ctx context.Context, q models.ListQuery) (models.ListResult, error)
+	Watch(ctx context.Context, filter hub.Filter) *hub.Subscription
 }
 
 type server struct {
 	pb.UnimplementedMetricsServer
 	service MetricService
+	done    <-chan struct{} // закрывается при остановке сервера, nil — не закрывается
 	config  config.ServerConfig
 }
 
-func NewServer(s MetricService, cfg config.ServerConfig) *grpc.Server {
+// Server — gRPC-сервер метрик. Долгие потоки завершаются при Shutdown,
+// не дожидаясь клиента.
+type Server struct {
+	*grpc.Server
+	done chan struct{}
+	once sync.Once
+}
+
+// Shutdown закрывает потоки и ждет завершения запросов, пока не отменен ctx.
+// После отмены ctx незавершенные запросы прерываются.
+func (s *Server) Shutdown(ctx context.Context) {
+	s.once.Do(func() { close(s.done) })
+
+	stopped := make(chan struct{})
+	go func() {
+		s.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		logger.Log.Error("GRPC shutdown timeout exceeded, forcing stop", zap.Error(ctx.Err()))
+		s.Stop()
+		<-stopped
+	}
+}
+
+// NewServer создает gRPC-сервер с сервисом Metrics и OTLP MetricsService.
+func NewServer(s MetricService, receiver *otlp.Receiver, cfg config.ServerConfig) *Server {
+	resolver := tenant.NewResolver(cfg.TenantTokens, cfg.TenantHeader)
 	grpcServer := grpc.NewServer(
 		grpc.ChainUnaryInterceptor(
 			logger.InterceptorLogger,
+			ip.FilterInterceptor(cfg.TrustedNetwork, pb.Metrics_DeleteMetric_FullMethodName),
+			resolver.Interceptor,
+		),
+		grpc.ChainStreamInterceptor(
+			logger.StreamInterceptorLogger,
+			resolver.StreamInterceptor,
 		),
 	)
 
+	done := make(chan struct{})
 	metricsHandler := &server{
 		service: s,
+		done:    done,
 		config:  cfg,
 	}
 
 	pb.RegisterMetricsServer(grpcServer, metricsHandler)
+	colmetricspb.RegisterMetricsServiceServer(grpcServer, &otlpServer{
+		receiver: receiver,
+		config:   cfg,
+	})
+
+	return &Server{
+		Server: grpcServer,
+		done:   done,
+	}
+}
+
+// withShutdown возвращает контекст, отменяемый также при остановке сервера.
+func (s *server) withShutdown(parent context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(parent)
+	if s.done == nil {
+		return ctx, cancel
+	}
+
+	go func() {
+		select {
+		case <-s.done:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, cancel
+}
+
+// shuttingDown сообщает, остановлен ли сервер.
+func (s *server) shuttingDown() bool {
+	select {
+	case <-s.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// histogramFromProto переводит гистограмму из protobuf, nil остается nil.
+func histogramFromProto(h *pb.Histogram) *storage.Histogram {
+	if h == nil {
+		return nil
+	}
+
+	return &storage.Histogram{
+		Buckets: h.GetBuckets(),
+		Counts:  h.GetCounts(),
+		Sum:     h.GetSum(),
+		Count:   h.GetCount(),
+	}
+}
+
+// typeFromProto переводит тип метрики из protobuf в строковый тип сервиса.
+func typeFromProto(t pb.Metric_Type) string {
+	switch t {
+	case pb.Metric_COUNTER:
+		return "counter"
+	case pb.Metric_HISTOGRAM:
+		return "histogram"
+	case pb.Metric_SUMMARY:
+		return "summary"
+	case pb.Metric_SET:
+		return "set"
+	}
+
+	return "gauge"
+}
+
+// typeToProto переводит строковый тип сервиса в тип метрики protobuf.
+func typeToProto(t string) pb.Metric_Type {
+	switch t {
+	case "counter":
+		return pb.Metric_COUNTER
+	case "histogram":
+		return pb.Metric_HISTOGRAM
+	case "summary":
+		return pb.Metric_SUMMARY
+	case "set":
+		return pb.Metric_SET
+	}
+
+	return pb.Metric_GAUGE
+}
+
+// batchItemsToProto переводит метрики результата пачки в protobuf.
+func batchItemsToProto(items []models.BatchItem) []*pb.BatchItem {
+	res := make([]*pb.BatchItem, 0, len(items))
+	for _, item := range items {
+		res = append(res, &pb.BatchItem{
+			Index:  int32(item.Index),
+			Id:     item.ID,
+			MType:  typeToProto(item.MType),
+			Reason: item.Reason,
+		})
+	}
+
+	return res
+}
+
+// rejectedStatus возвращает статус отклоненной строгой пачки
+// с причинами отказа по каждой метрике.
+func rejectedStatus(err error, rejected []models.BatchItem) error {
+	br := &errdetails.BadRequest{}
+	for _, item := range rejected {
+		br.FieldViolations = append(br.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fmt.Sprintf("metrics[%d]", item.Index),
+			Description: item.Reason,
+		})
+	}
+
+	st, detailsErr := status.New(codes.InvalidArgument, err.Error()).WithDetails(br)
+	if detailsErr != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return st.Err()
+}
+
+// metadataFromProto переводит описание метрики из protobuf, nil остается nil.
+func metadataFromProto(m *pb.Metadata) *storage.Metadata {
+	if m == nil {
+		return nil
+	}
+
+	meta := &storage.Metadata{
+		Unit: m.GetUnit(),
+		Help: m.GetHelp(),
+	}
+	if m.Type != nil {
+		meta.Type = typeFromProto(m.GetType())
+	}
+	if m.Precision != nil {
+		p := int(m.GetPrecision())
+		meta.Precision = &p
+	}
+
+	return meta
+}
+
+// setFromProto переводит регистры скетча из protobuf, пустые остаются nil.
+func setFromProto(registers []byte) *storage.Set {
+	if len(registers) == 0 {
+		return nil
+	}
 
-	return grpcServer
+	return &storage.Set{Registers: registers}
 }
 
 func (s *server) UpdateMetrics(
@@ -51,6 +249,19 @@ func (s *server) UpdateMetrics(
 		return nil, err
 	}
 
+	return s.updateMetrics(ctx, in)
+}
+
+// updateMetrics расшифровывает пачку in с проверенным хешем и обновляет метрики.
+func (s *server) updateMetrics(
+	ctx context.Context, in *pb.UpdateMetricsRequest,
+) (*pb.UpdateMetricsResponse, error) {
+	var err error
+
+	// ключ пачки и режим передаются вне зашифрованной части запроса
+	batchID := in.GetBatchId()
+	strict := in.GetStrict()
+
 	if s.config.PrivateKey != nil {
 		var b []byte
 		b, err = crypto.DecryptRSA(in.Encrypted, s.config.PrivateKey)
@@ -67,24 +278,74 @@ func (s *server) UpdateMetrics(
 	list := make([]models.Metrics, 0, len(in.Metrics))
 
 	for _, m := range in.Metrics {
-		mtype := "gauge"
-		if m.MType == pb.Metric_COUNTER {
-			mtype = "counter"
-		}
 		list = append(list, models.Metrics{
-			Delta: (*storage.Counter)(m.Delta),
-			Value: (*storage.Gauge)(m.Value),
-			MType: mtype,
-			ID:    m.Id,
+			Delta:     (*storage.Counter)(m.Delta),
+			Value:     (*storage.Gauge)(m.Value),
+			Histogram: histogramFromProto(m.Histogram),
+			Set:       setFromProto(m.Set),
+			Members:   m.Members,
+			Labels:    m.Labels,
+			Meta:      metadataFromProto(m.Meta),
+			MType:     typeFromProto(m.MType),
+			ID:        m.Id,
 		})
 	}
 
-	err = s.service.UpdateMany(ctx, list)
+	result, err := s.service.UpdateBatch(ctx, batchID, list, strict)
+	if errors.Is(err, merrors.ErrBatchRejected) {
+		return nil, rejectedStatus(err, result.Rejected)
+	}
+
+	res := &pb.UpdateMetricsResponse{
+		Accepted: batchItemsToProto(result.Accepted),
+		Rejected: batchItemsToProto(result.Rejected),
+	}
+	if errors.Is(err, merrors.ErrDuplicateBatch) {
+		res.Replayed = true
+		return res, nil
+	}
+	if errors.Is(err, merrors.ErrIncorrectHistogram) ||
+		errors.Is(err, merrors.ErrIncorrectHistogramBuckets) ||
+		errors.Is(err, merrors.ErrIncorrectHistogramValue) ||
+		errors.Is(err, merrors.ErrIncorrectSummaryValue) ||
+		errors.Is(err, merrors.ErrIncorrectSet) ||
+		errors.Is(err, merrors.ErrIncorrectMetadata) ||
+		errors.Is(err, merrors.ErrIncorrectTenantKey) ||
+		errors.Is(err, merrors.ErrIncorrectBatchID) {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if errors.Is(err, merrors.ErrSeriesQuotaExceeded) {
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	}
 	if err != nil {
 		logger.Log.Error("Error from UpdateMany service", zap.Error(err))
 		return nil, status.Error(codes.Internal, "error from service")
 	}
 
-	res := &pb.UpdateMetricsResponse{}
 	return res, nil
 }
+
+// DeleteMetric удаляет метрику вместе с историей. Доступен только
+// из доверенной подсети, если она задана (см. ip.FilterInterceptor).
+func (s *server) DeleteMetric(
+	ctx context.Context, in *pb.DeleteMetricRequest,
+) (*pb.DeleteMetricResponse, error) {
+	err := crypto.GetAndValidHMACProto(ctx, s.config.Key, in)
+	if err != nil {
+		return nil, err
+	}
+
+	key := storage.SeriesKey(in.Id, in.Labels)
+	err = s.service.DeleteMetric(ctx, key, typeFromProto(in.MType))
+	switch {
+	case errors.Is(err, merrors.ErrNotFoundMetric):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, merrors.ErrIncorrectMetricType), errors.Is(err, merrors.ErrIncorrectTenantKey):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		logger.Log.Error("Error from DeleteMetric service", zap.Error(err))
+		return nil, status.Error(codes.Internal, "error from service")
+	}
+
+	return &pb.DeleteMetricResponse{}, nil
+}
@@ -6,11 +6,14 @@ import (
 	"testing"
 
 	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
 	"github.com/LekcRg/metrics/internal/server/storage"
 	pb "github.com/LekcRg/metrics/proto"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -18,11 +21,54 @@ import (
 
 type mockMetricService struct {
 	errToReturn     error
+	hub             *hub.Hub
+	watching        chan struct{} // закрывается при вызове Watch, если задан
+	list            models.ListResult
+	metric          models.Metrics
+	result          models.BatchResult
+	listQuery       models.ListQuery
 	receivedMetrics []models.Metrics
+	batchID         string
+	deletedName     string
+	deletedType     string
+	strict          bool
+}
+
+func (m *mockMetricService) GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error) {
+	m.receivedMetrics = []models.Metrics{json}
+	return m.metric, m.errToReturn
+}
+
+func (m *mockMetricService) ListMetrics(ctx context.Context, q models.ListQuery) (models.ListResult, error) {
+	m.listQuery = q
+	return m.list, m.errToReturn
+}
+
+func (m *mockMetricService) Watch(ctx context.Context, filter hub.Filter) *hub.Subscription {
+	sub := m.hub.Subscribe("", filter)
+	if m.watching != nil {
+		close(m.watching)
+	}
+	return sub
 }
 
 func (m *mockMetricService) UpdateMany(ctx context.Context, list []models.Metrics) error {
+	_, err := m.UpdateBatch(ctx, "", list, false)
+	return err
+}
+
+func (m *mockMetricService) UpdateBatch(
+	ctx context.Context, batchID string, list []models.Metrics, strict bool,
+) (models.BatchResult, error) {
+	m.batchID = batchID
+	m.strict = strict
 	m.receivedMetrics = list
+	return m.result, m.errToReturn
+}
+
+func (m *mockMetricService) DeleteMetric(ctx context.Context, reqName string, reqType string) error {
+	m.deletedName = reqName
+	m.deletedType = reqType
 	return m.errToReturn
 }
 
@@ -35,18 +81,48 @@ func TestUpdateMetrics_HappyPath(t *testing.T) {
 		config:  serverConfig,
 	}
 
+	metaType := pb.Metric_GAUGE
+	metaPrecision := int32(0)
 	request := &pb.UpdateMetricsRequest{
 		Metrics: []*pb.Metric{
 			{
 				Id:    "TestGauge",
 				MType: pb.Metric_GAUGE,
 				Value: floatPtr(123.45),
+				Meta: &pb.Metadata{
+					Unit:      "bytes",
+					Help:      "heap",
+					Type:      &metaType,
+					Precision: &metaPrecision,
+				},
 			},
 			{
 				Id:    "TestCounter",
 				MType: pb.Metric_COUNTER,
 				Delta: intPtr(10),
 			},
+			{
+				Id:     "TestHistogram",
+				MType:  pb.Metric_HISTOGRAM,
+				Labels: map[string]string{"route": "/"},
+				Histogram: &pb.Histogram{
+					Buckets: []float64{0.1, 1},
+					Counts:  []uint64{1, 2, 0},
+					Sum:     1.05,
+					Count:   3,
+				},
+			},
+			{
+				Id:    "TestSummary",
+				MType: pb.Metric_SUMMARY,
+				Value: floatPtr(0.25),
+			},
+			{
+				Id:      "TestSet",
+				MType:   pb.Metric_SET,
+				Members: []string{"alice", "bob"},
+				Set:     []byte{1, 2},
+			},
 		},
 	}
 
@@ -55,21 +131,203 @@ func TestUpdateMetrics_HappyPath(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, response)
 
+	precision := 0
 	expectedMetrics := []models.Metrics{
 		{
 			ID:    "TestGauge",
 			MType: "gauge",
 			Value: gaugePtr(123.45),
+			Meta:  &storage.Metadata{Unit: "bytes", Help: "heap", Type: "gauge", Precision: &precision},
 		},
 		{
 			ID:    "TestCounter",
 			MType: "counter",
 			Delta: counterPtr(10),
 		},
+		{
+			ID:     "TestHistogram",
+			MType:  "histogram",
+			Labels: storage.Labels{"route": "/"},
+			Histogram: &storage.Histogram{
+				Buckets: []float64{0.1, 1},
+				Counts:  []uint64{1, 2, 0},
+				Sum:     1.05,
+				Count:   3,
+			},
+		},
+		{
+			ID:    "TestSummary",
+			MType: "summary",
+			Value: gaugePtr(0.25),
+		},
+		{
+			ID:      "TestSet",
+			MType:   "set",
+			Members: []string{"alice", "bob"},
+			Set:     &storage.Set{Registers: []uint8{1, 2}},
+		},
 	}
 	assert.Equal(t, expectedMetrics, mockService.receivedMetrics)
 }
 
+func TestUpdateMetrics_IncorrectHistogram(t *testing.T) {
+	grpcServer := &server{
+		service: &mockMetricService{errToReturn: merrors.ErrIncorrectHistogramBuckets},
+		config:  config.ServerConfig{},
+	}
+
+	request := &pb.UpdateMetricsRequest{
+		Metrics: []*pb.Metric{
+			{
+				Id:    "latency",
+				MType: pb.Metric_HISTOGRAM,
+				Value: floatPtr(0.3),
+			},
+		},
+	}
+
+	_, err := grpcServer.UpdateMetrics(context.Background(), request)
+
+	st, ok := status.FromError(err)
+	require.True(t, ok)
+	assert.Equal(t, codes.InvalidArgument, st.Code())
+}
+
+func TestUpdateMetrics_SeriesQuota(t *testing.T) {
+	grpcServer := &server{
+		service: &mockMetricService{errToReturn: merrors.ErrSeriesQuotaExceeded},
+		config:  config.ServerConfig{},
+	}
+
+	request := &pb.UpdateMetricsRequest{
+		Metrics: []*pb.Metric{
+			{
+				Id:    "TestGauge",
+				MType: pb.Metric_GAUGE,
+				Value: floatPtr(1),
+			},
+		},
+	}
+
+	_, err := grpcServer.UpdateMetrics(context.Background(), request)
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestUpdateMetrics_Batch(t *testing.T) {
+	tests := []struct {
+		errToReturn  error
+		name         string
+		wantCode     codes.Code
+		wantReplayed bool
+	}{
+		{
+			name:     "Applied",
+			wantCode: codes.OK,
+		},
+		{
+			name:         "Duplicate",
+			errToReturn:  merrors.ErrDuplicateBatch,
+			wantCode:     codes.OK,
+			wantReplayed: true,
+		},
+		{
+			name:        "Incorrect batch id",
+			errToReturn: merrors.ErrIncorrectBatchID,
+			wantCode:    codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockMetricService{errToReturn: tt.errToReturn}
+			grpcServer := &server{
+				service: mockService,
+				config:  config.ServerConfig{},
+			}
+
+			request := &pb.UpdateMetricsRequest{
+				BatchId: "batch-1",
+				Metrics: []*pb.Metric{
+					{
+						Id:    "PollCount",
+						MType: pb.Metric_COUNTER,
+						Delta: intPtr(1),
+					},
+				},
+			}
+
+			res, err := grpcServer.UpdateMetrics(context.Background(), request)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, "batch-1", mockService.batchID)
+			if tt.wantCode == codes.OK {
+				assert.Equal(t, tt.wantReplayed, res.GetReplayed())
+			}
+		})
+	}
+}
+
+func TestUpdateMetrics_Result(t *testing.T) {
+	result := models.BatchResult{
+		Accepted: []models.BatchItem{{Index: 0, ID: "PollCount", MType: "counter"}},
+		Rejected: []models.BatchItem{{Index: 1, ID: "latency", MType: "histogram", Reason: "missing metric value"}},
+	}
+
+	tests := []struct {
+		errToReturn error
+		name        string
+		wantCode    codes.Code
+		strict      bool
+	}{
+		{
+			name:     "Partial",
+			wantCode: codes.OK,
+		},
+		{
+			name:        "Strict",
+			strict:      true,
+			errToReturn: merrors.ErrBatchRejected,
+			wantCode:    codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockMetricService{result: result, errToReturn: tt.errToReturn}
+			grpcServer := &server{
+				service: mockService,
+				config:  config.ServerConfig{},
+			}
+
+			res, err := grpcServer.UpdateMetrics(context.Background(), &pb.UpdateMetricsRequest{
+				Strict: tt.strict,
+				Metrics: []*pb.Metric{
+					{Id: "PollCount", MType: pb.Metric_COUNTER, Delta: intPtr(1)},
+					{Id: "latency", MType: pb.Metric_HISTOGRAM},
+				},
+			})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.strict, mockService.strict)
+
+			if tt.wantCode != codes.OK {
+				details := status.Convert(err).Details()
+				require.Len(t, details, 1)
+				br, ok := details[0].(*errdetails.BadRequest)
+				require.True(t, ok)
+				require.Len(t, br.GetFieldViolations(), 1)
+				assert.Equal(t, "metrics[1]", br.GetFieldViolations()[0].GetField())
+				return
+			}
+
+			require.Len(t, res.GetAccepted(), 1)
+			assert.Equal(t, pb.Metric_COUNTER, res.GetAccepted()[0].GetMType())
+			require.Len(t, res.GetRejected(), 1)
+			assert.Equal(t, int32(1), res.GetRejected()[0].GetIndex())
+			assert.Equal(t, pb.Metric_HISTOGRAM, res.GetRejected()[0].GetMType())
+			assert.Equal(t, "missing metric value", res.GetRejected()[0].GetReason())
+		})
+	}
+}
+
 func TestUpdateMetrics_ServiceError(t *testing.T) {
 	mockService := &mockMetricService{
 		errToReturn: errors.New("database is down"),
@@ -153,3 +411,65 @@ func counterPtr(v storage.Counter) *storage.Counter {
 func floatPtr(v float64) *float64 {
 	return &v
 }
+
+func TestDeleteMetric(t *testing.T) {
+	tests := []struct {
+		serviceErr error
+		name       string
+		wantCode   codes.Code
+	}{
+		{
+			name:     "Positive",
+			wantCode: codes.OK,
+		},
+		{
+			name:       "Not found",
+			serviceErr: merrors.ErrNotFoundMetric,
+			wantCode:   codes.NotFound,
+		},
+		{
+			name:       "Service error",
+			serviceErr: errors.New("database is down"),
+			wantCode:   codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockMetricService{errToReturn: tt.serviceErr}
+			grpcServer := &server{
+				service: mockService,
+				config:  config.ServerConfig{},
+			}
+
+			_, err := grpcServer.DeleteMetric(context.Background(), &pb.DeleteMetricRequest{
+				Id:     "CPUutilization17",
+				MType:  pb.Metric_GAUGE,
+				Labels: map[string]string{"host": "web1"},
+			})
+
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, `CPUutilization17{host="web1"}`, mockService.deletedName)
+			assert.Equal(t, "gauge", mockService.deletedType)
+		})
+	}
+}
+
+func TestDeleteMetric_InvalidHMAC(t *testing.T) {
+	mockService := &mockMetricService{}
+	grpcServer := &server{
+		service: mockService,
+		config: config.ServerConfig{
+			CommonConfig: config.CommonConfig{
+				Key: "my-secret-key",
+			},
+		},
+	}
+
+	md := metadata.New(map[string]string{"HashSHA256": "invalid-hash"})
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+
+	_, err := grpcServer.DeleteMetric(ctx, &pb.DeleteMetricRequest{Id: "any", MType: pb.Metric_COUNTER})
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+	assert.Empty(t, mockService.deletedName)
+}
@@ -0,0 +1,59 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/otlp"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type otlpServer struct {
+	colmetricspb.UnimplementedMetricsServiceServer
+	receiver *otlp.Receiver
+	config   config.ServerConfig
+}
+
+// Export принимает метрики OTLP. HMAC проверяется так же, как в UpdateMetrics.
+// В OTLP нет поля для зашифрованного тела, поэтому при включенном RSA
+// запросы по gRPC отклоняются: зашифрованные метрики можно отправить через HTTP /v1/metrics.
+func (s *otlpServer) Export(
+	ctx context.Context, in *colmetricspb.ExportMetricsServiceRequest,
+) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	err := crypto.GetAndValidHMACProto(ctx, s.config.Key, in)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.config.PrivateKey != nil {
+		return nil, status.Error(codes.PermissionDenied, "Permission denied")
+	}
+
+	rejected, err := s.receiver.Export(ctx, in)
+	switch {
+	case errors.Is(err, merrors.ErrSeriesQuotaExceeded):
+		return nil, status.Error(codes.ResourceExhausted, err.Error())
+	case errors.Is(err, merrors.ErrIncorrectTenantKey):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		logger.Log.Error("Error from OTLP export", zap.Error(err))
+		return nil, status.Error(codes.Internal, "error from service")
+	}
+
+	res := &colmetricspb.ExportMetricsServiceResponse{}
+	if rejected > 0 {
+		res.PartialSuccess = &colmetricspb.ExportMetricsPartialSuccess{
+			RejectedDataPoints: rejected,
+			ErrorMessage:       "unsupported metric type",
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,109 @@
+package grpcapi
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/server/otlp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPExport(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	in := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{
+					{
+						Name: "cpu",
+						Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+							DataPoints: []*metricspb.NumberDataPoint{{
+								Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 1},
+							}},
+						}},
+					},
+					{
+						Name: "latency",
+						Data: &metricspb.Metric_ExponentialHistogram{ExponentialHistogram: &metricspb.ExponentialHistogram{
+							DataPoints: []*metricspb.ExponentialHistogramDataPoint{{}},
+						}},
+					},
+				},
+			}},
+		}},
+	}
+	inBytes, err := proto.Marshal(in)
+	require.NoError(t, err)
+
+	tests := []struct {
+		serviceErr   error
+		md           metadata.MD
+		name         string
+		config       config.ServerConfig
+		wantCode     codes.Code
+		wantRejected int64
+		wantMetrics  int
+	}{
+		{
+			name:         "Without key",
+			wantCode:     codes.OK,
+			wantRejected: 1,
+			wantMetrics:  1,
+		},
+		{
+			name:         "Correct HashSHA256",
+			config:       config.ServerConfig{CommonConfig: config.CommonConfig{Key: "secret"}},
+			md:           metadata.Pairs("HashSHA256", crypto.GenerateHMAC(inBytes, "secret")),
+			wantCode:     codes.OK,
+			wantRejected: 1,
+			wantMetrics:  1,
+		},
+		{
+			name:     "Metadata without HashSHA256",
+			config:   config.ServerConfig{CommonConfig: config.CommonConfig{Key: "secret"}},
+			md:       metadata.Pairs("user-agent", "otel"),
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "RSA enabled",
+			config:   config.ServerConfig{PrivateKey: priv},
+			wantCode: codes.PermissionDenied,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &mockMetricService{errToReturn: tt.serviceErr}
+			s := &otlpServer{
+				receiver: otlp.NewReceiver(service),
+				config:   tt.config,
+			}
+
+			ctx := context.Background()
+			if tt.md != nil {
+				ctx = metadata.NewIncomingContext(ctx, tt.md)
+			}
+
+			res, err := s.Export(ctx, in)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			if tt.wantCode != codes.OK {
+				return
+			}
+
+			assert.Equal(t, tt.wantRejected, res.GetPartialSuccess().GetRejectedDataPoints())
+			assert.Len(t, service.receivedMetrics, tt.wantMetrics)
+		})
+	}
+}
@@ -0,0 +1,206 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	pb "github.com/LekcRg/metrics/proto"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// metadataToProto переводит описание метрики в protobuf, nil остается nil.
+func metadataToProto(meta *storage.Metadata) *pb.Metadata {
+	if meta == nil {
+		return nil
+	}
+
+	res := &pb.Metadata{
+		Unit: meta.Unit,
+		Help: meta.Help,
+	}
+	if meta.Type != "" {
+		t := typeToProto(meta.Type)
+		res.Type = &t
+	}
+	if meta.Precision != nil {
+		p := int32(*meta.Precision)
+		res.Precision = &p
+	}
+
+	return res
+}
+
+// metricToProto переводит метрику с текущим значением в protobuf.
+func metricToProto(m models.Metrics) *pb.Metric {
+	res := &pb.Metric{
+		Id:        m.ID,
+		MType:     typeToProto(m.MType),
+		Delta:     (*int64)(m.Delta),
+		Value:     (*float64)(m.Value),
+		Labels:    m.Labels,
+		Meta:      metadataToProto(m.Meta),
+		Quantiles: m.Quantiles,
+		Unique:    m.Unique,
+	}
+	if m.Histogram != nil {
+		res.Histogram = &pb.Histogram{
+			Buckets: m.Histogram.Buckets,
+			Counts:  m.Histogram.Counts,
+			Sum:     m.Histogram.Sum,
+			Count:   m.Histogram.Count,
+		}
+	}
+	if m.Summary != nil {
+		res.Summary = &pb.Summary{
+			Sum:   m.Summary.Sum,
+			Min:   m.Summary.Min,
+			Max:   m.Summary.Max,
+			Count: m.Summary.Count,
+		}
+	}
+
+	return res
+}
+
+// GetMetric возвращает текущее значение метрики.
+func (s *server) GetMetric(ctx context.Context, in *pb.GetMetricRequest) (*pb.GetMetricResponse, error) {
+	err := crypto.GetAndValidHMACProto(ctx, s.config.Key, in)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := s.service.GetMetricJSON(ctx, models.Metrics{
+		ID:     in.GetId(),
+		MType:  typeFromProto(in.GetMType()),
+		Labels: in.GetLabels(),
+	})
+	switch {
+	case errors.Is(err, merrors.ErrNotFoundMetric):
+		return nil, status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, merrors.ErrIncorrectMetricType):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		logger.Log.Error("Error from GetMetric service", zap.Error(err))
+		return nil, status.Error(codes.Internal, "error from service")
+	}
+
+	return &pb.GetMetricResponse{Metric: metricToProto(m)}, nil
+}
+
+// ListMetrics возвращает страницу метрик с именем, начинающимся с prefix,
+// и типом m_type, если он задан. Следующая страница запрашивается
+// с next_page_token предыдущей.
+func (s *server) ListMetrics(ctx context.Context, in *pb.ListMetricsRequest) (*pb.ListMetricsResponse, error) {
+	err := crypto.GetAndValidHMACProto(ctx, s.config.Key, in)
+	if err != nil {
+		return nil, err
+	}
+
+	q := models.ListQuery{
+		Prefix:    in.GetPrefix(),
+		PageToken: in.GetPageToken(),
+		Limit:     int(in.GetPageSize()),
+	}
+	if in.MType != nil {
+		q.MType = typeFromProto(in.GetMType())
+	}
+
+	list, err := s.service.ListMetrics(ctx, q)
+	switch {
+	case errors.Is(err, merrors.ErrIncorrectMetricType), errors.Is(err, merrors.ErrIncorrectPageToken):
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	case err != nil:
+		logger.Log.Error("Error from ListMetrics service", zap.Error(err))
+		return nil, status.Error(codes.Internal, "error from service")
+	}
+
+	res := &pb.ListMetricsResponse{
+		Metrics:       make([]*pb.Metric, 0, len(list.Metrics)),
+		NextPageToken: list.NextPageToken,
+	}
+	for _, m := range list.Metrics {
+		res.Metrics = append(res.Metrics, metricToProto(m))
+	}
+
+	return res, nil
+}
+
+// WatchMetrics отправляет изменения метрик с именем, начинающимся с prefix,
+// и типом m_type, если он задан, пока клиент не закроет поток.
+// Обновленная метрика отправляется с текущим значением, удаленная — без значения.
+// Медленный клиент получает только последнее изменение серии (см. hub),
+// а отставший поток закрывается с ResourceExhausted.
+// При остановке сервера поток закрывается с Unavailable.
+func (s *server) WatchMetrics(in *pb.WatchMetricsRequest, stream pb.Metrics_WatchMetricsServer) error {
+	ctx, cancel := s.withShutdown(stream.Context())
+	defer cancel()
+
+	err := crypto.GetAndValidHMACProto(ctx, s.config.Key, in)
+	if err != nil {
+		return err
+	}
+
+	var mType string
+	if in.MType != nil {
+		mType = typeFromProto(in.GetMType())
+	}
+
+	sub := s.service.Watch(ctx, hub.MetricFilter(in.GetPrefix(), mType))
+	defer sub.Close()
+
+	for {
+		events, err := sub.Next(ctx)
+		if errors.Is(err, merrors.ErrSlowConsumer) {
+			return status.Error(codes.ResourceExhausted, err.Error())
+		}
+		if err != nil && s.shuttingDown() {
+			return status.Error(codes.Unavailable, "server is shutting down")
+		}
+		if err != nil {
+			// клиент закрыл поток
+			return nil
+		}
+
+		for _, e := range events {
+			event, ok := s.metricEvent(ctx, e)
+			if !ok {
+				continue
+			}
+			if err = stream.Send(event); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// metricEvent переводит изменение метрики в protobuf. ok == false,
+// если метрика не найдена, например удалена после изменения.
+func (s *server) metricEvent(ctx context.Context, e hub.Event) (*pb.MetricEvent, bool) {
+	name, labels, err := storage.ParseSeriesKey(e.Key)
+	if err != nil {
+		return nil, false
+	}
+	if len(labels) == 0 {
+		labels = nil
+	}
+
+	m := models.Metrics{ID: name, MType: e.MType, Labels: labels}
+	if e.Deleted {
+		return &pb.MetricEvent{Kind: pb.MetricEvent_DELETED, Metric: metricToProto(m)}, true
+	}
+
+	m, err = s.service.GetMetricJSON(ctx, m)
+	if err != nil {
+		return nil, false
+	}
+
+	return &pb.MetricEvent{Kind: pb.MetricEvent_UPDATED, Metric: metricToProto(m)}, true
+}
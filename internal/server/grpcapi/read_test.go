@@ -0,0 +1,250 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	pb "github.com/LekcRg/metrics/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestGetMetric(t *testing.T) {
+	unique := uint64(3)
+
+	tests := []struct {
+		serviceErr error
+		metric     models.Metrics
+		want       *pb.Metric
+		name       string
+		wantCode   codes.Code
+	}{
+		{
+			name: "Summary",
+			metric: models.Metrics{
+				ID:        "latency",
+				MType:     "summary",
+				Labels:    storage.Labels{"route": "/"},
+				Summary:   &storage.Summary{Sum: 3, Min: 1, Max: 2, Count: 2},
+				Quantiles: map[string]float64{"0.5": 1},
+			},
+			want: &pb.Metric{
+				Id:        "latency",
+				MType:     pb.Metric_SUMMARY,
+				Labels:    map[string]string{"route": "/"},
+				Summary:   &pb.Summary{Sum: 3, Min: 1, Max: 2, Count: 2},
+				Quantiles: map[string]float64{"0.5": 1},
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Set",
+			metric:   models.Metrics{ID: "users", MType: "set", Unique: &unique},
+			want:     &pb.Metric{Id: "users", MType: pb.Metric_SET, Unique: &unique},
+			wantCode: codes.OK,
+		},
+		{
+			name:       "Not found",
+			serviceErr: merrors.ErrNotFoundMetric,
+			wantCode:   codes.NotFound,
+		},
+		{
+			name:       "Service error",
+			serviceErr: errors.New("database is down"),
+			wantCode:   codes.Internal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := &mockMetricService{metric: tt.metric, errToReturn: tt.serviceErr}
+			grpcServer := &server{
+				service: mockService,
+				config:  config.ServerConfig{},
+			}
+
+			res, err := grpcServer.GetMetric(context.Background(), &pb.GetMetricRequest{
+				Id:     "latency",
+				MType:  pb.Metric_SUMMARY,
+				Labels: map[string]string{"route": "/"},
+			})
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, []models.Metrics{
+				{ID: "latency", MType: "summary", Labels: storage.Labels{"route": "/"}},
+			}, mockService.receivedMetrics)
+
+			if tt.wantCode == codes.OK {
+				assert.True(t, proto.Equal(tt.want, res.GetMetric()), res.GetMetric().String())
+			}
+		})
+	}
+}
+
+func TestListMetrics(t *testing.T) {
+	gauge := pb.Metric_GAUGE
+
+	tests := []struct {
+		serviceErr error
+		in         *pb.ListMetricsRequest
+		name       string
+		wantQuery  models.ListQuery
+		wantCode   codes.Code
+	}{
+		{
+			name:      "All types",
+			in:        &pb.ListMetricsRequest{Prefix: "Heap", PageSize: 10, PageToken: "token"},
+			wantQuery: models.ListQuery{Prefix: "Heap", Limit: 10, PageToken: "token"},
+			wantCode:  codes.OK,
+		},
+		{
+			name:      "Type",
+			in:        &pb.ListMetricsRequest{MType: &gauge},
+			wantQuery: models.ListQuery{MType: "gauge"},
+			wantCode:  codes.OK,
+		},
+		{
+			name:       "Incorrect page token",
+			in:         &pb.ListMetricsRequest{PageToken: "!"},
+			wantQuery:  models.ListQuery{PageToken: "!"},
+			serviceErr: merrors.ErrIncorrectPageToken,
+			wantCode:   codes.InvalidArgument,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			val := storage.Gauge(1)
+			mockService := &mockMetricService{
+				errToReturn: tt.serviceErr,
+				list: models.ListResult{
+					Metrics:       []models.Metrics{{ID: "HeapAlloc", MType: "gauge", Value: &val}},
+					NextPageToken: "next",
+				},
+			}
+			grpcServer := &server{
+				service: mockService,
+				config:  config.ServerConfig{},
+			}
+
+			res, err := grpcServer.ListMetrics(context.Background(), tt.in)
+			assert.Equal(t, tt.wantCode, status.Code(err))
+			assert.Equal(t, tt.wantQuery, mockService.listQuery)
+
+			if tt.wantCode == codes.OK {
+				require.Len(t, res.GetMetrics(), 1)
+				assert.Equal(t, "HeapAlloc", res.GetMetrics()[0].GetId())
+				assert.Equal(t, 1.0, res.GetMetrics()[0].GetValue())
+				assert.Equal(t, "next", res.GetNextPageToken())
+			}
+		})
+	}
+}
+
+func TestWatchMetrics(t *testing.T) {
+	val := storage.Gauge(2)
+	h := hub.New()
+	mockService := &mockMetricService{
+		hub:      h,
+		watching: make(chan struct{}),
+		metric:   models.Metrics{ID: "HeapAlloc", MType: "gauge", Value: &val},
+	}
+	client := newStreamClient(t, &server{
+		service: mockService,
+		config:  config.ServerConfig{},
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	gauge := pb.Metric_GAUGE
+	stream, err := client.WatchMetrics(ctx, &pb.WatchMetricsRequest{Prefix: "Heap", MType: &gauge})
+	require.NoError(t, err)
+
+	select {
+	case <-mockService.watching:
+	case <-ctx.Done():
+		t.Fatal("WatchMetrics did not subscribe")
+	}
+
+	h.Publish(
+		hub.Event{Key: "Alloc", MType: "gauge"},
+		hub.Event{Key: "HeapAlloc", MType: "counter"},
+		hub.Event{Key: "HeapAlloc", MType: "gauge"},
+	)
+
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.MetricEvent_UPDATED, event.GetKind())
+	assert.Equal(t, "HeapAlloc", event.GetMetric().GetId())
+	assert.Equal(t, 2.0, event.GetMetric().GetValue())
+
+	h.Publish(hub.Event{Key: `HeapInuse{host="web1"}`, MType: "gauge", Deleted: true})
+
+	event, err = stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, pb.MetricEvent_DELETED, event.GetKind())
+	assert.Equal(t, "HeapInuse", event.GetMetric().GetId())
+	assert.Equal(t, map[string]string{"host": "web1"}, event.GetMetric().GetLabels())
+	assert.Nil(t, event.GetMetric().Value)
+}
+
+func TestWatchMetrics_InvalidHMAC(t *testing.T) {
+	client := newStreamClient(t, &server{
+		service: &mockMetricService{hub: hub.New()},
+		config: config.ServerConfig{
+			CommonConfig: config.CommonConfig{Key: "my-secret-key"},
+		},
+	})
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "HashSHA256", "invalid-hash")
+	stream, err := client.WatchMetrics(ctx, &pb.WatchMetricsRequest{})
+	require.NoError(t, err)
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+}
+
+func TestWatchMetrics_Shutdown(t *testing.T) {
+	mockService := &mockMetricService{
+		hub:      hub.New(),
+		watching: make(chan struct{}),
+	}
+	srv := NewServer(mockService, nil, config.ServerConfig{})
+	client := serveBufconn(t, srv.Server)
+
+	stream, err := client.WatchMetrics(context.Background(), &pb.WatchMetricsRequest{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	select {
+	case <-mockService.watching:
+	case <-ctx.Done():
+		t.Fatal("WatchMetrics did not subscribe")
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.Shutdown(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Shutdown is blocked by an open watch")
+	}
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
@@ -0,0 +1,86 @@
+package grpcapi
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	pb "github.com/LekcRg/metrics/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// StreamMetrics принимает пачки метрик кадрами одного долгоживущего потока.
+// Кадры обрабатываются по порядку, на каждый отправляется FrameAck
+// с номером кадра. Ошибка кадра передается в FrameAck и не закрывает поток.
+// Хеш кадра передается в самом кадре, а не в метаданных потока.
+// При остановке сервера поток закрывается с Unavailable, кадры без FrameAck
+// не обработаны.
+func (s *server) StreamMetrics(stream pb.Metrics_StreamMetricsServer) error {
+	ctx, cancel := s.withShutdown(stream.Context())
+	defer cancel()
+
+	frames := make(chan *pb.MetricsFrame)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			frame, err := stream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+
+			select {
+			case frames <- frame:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		var frame *pb.MetricsFrame
+		select {
+		case frame = <-frames:
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		case <-ctx.Done():
+			if s.shuttingDown() {
+				return status.Error(codes.Unavailable, "server is shutting down")
+			}
+			return ctx.Err()
+		}
+
+		ack := &pb.FrameAck{Seq: frame.GetSeq()}
+		// принятый кадр обрабатывается до конца и при остановке сервера
+		res, err := s.handleFrame(stream.Context(), frame)
+		if err != nil {
+			st := status.Convert(err)
+			ack.Code = int32(st.Code())
+			ack.Message = st.Message()
+		}
+		ack.Response = res
+
+		if err = stream.Send(ack); err != nil {
+			return err
+		}
+	}
+}
+
+// handleFrame проверяет хеш кадра и обновляет метрики его пачки.
+func (s *server) handleFrame(ctx context.Context, frame *pb.MetricsFrame) (*pb.UpdateMetricsResponse, error) {
+	in := frame.GetRequest()
+	if in == nil {
+		return nil, status.Error(codes.InvalidArgument, "empty frame")
+	}
+
+	if err := crypto.ValidHMACProto(s.config.Key, frame.GetHash(), in); err != nil {
+		return nil, err
+	}
+
+	return s.updateMetrics(ctx, in)
+}
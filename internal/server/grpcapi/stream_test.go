@@ -0,0 +1,170 @@
+package grpcapi
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/merrors"
+	pb "github.com/LekcRg/metrics/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func newStreamClient(t *testing.T, srv *server) pb.MetricsClient {
+	s := grpc.NewServer()
+	pb.RegisterMetricsServer(s, srv)
+
+	return serveBufconn(t, s)
+}
+
+func serveBufconn(t *testing.T, s *grpc.Server) pb.MetricsClient {
+	lis := bufconn.Listen(1024 * 1024)
+
+	go s.Serve(lis)
+	t.Cleanup(s.Stop)
+
+	conn, err := grpc.NewClient(
+		"passthrough://bufnet",
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithContextDialer(func(ctx context.Context, addr string) (net.Conn, error) {
+			return lis.Dial()
+		}),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return pb.NewMetricsClient(conn)
+}
+
+func TestStreamMetrics(t *testing.T) {
+	const key = "secret-key"
+
+	mockService := &mockMetricService{}
+	client := newStreamClient(t, &server{
+		service: mockService,
+		config: config.ServerConfig{
+			CommonConfig: config.CommonConfig{Key: key},
+		},
+	})
+
+	stream, err := client.StreamMetrics(context.Background())
+	require.NoError(t, err)
+
+	req := &pb.UpdateMetricsRequest{
+		BatchId: "batch-1",
+		Metrics: []*pb.Metric{
+			{Id: "PollCount", MType: pb.Metric_COUNTER, Delta: intPtr(1)},
+		},
+	}
+	b, err := crypto.MarshalProto(req)
+	require.NoError(t, err)
+	hash := crypto.GenerateHMAC(b, key)
+
+	tests := []struct {
+		frame    *pb.MetricsFrame
+		name     string
+		wantCode codes.Code
+	}{
+		{
+			name:     "Correct frame",
+			frame:    &pb.MetricsFrame{Seq: 1, Request: req, Hash: hash},
+			wantCode: codes.OK,
+		},
+		{
+			name:     "Invalid hash",
+			frame:    &pb.MetricsFrame{Seq: 2, Request: req, Hash: "invalid"},
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "Empty frame",
+			frame:    &pb.MetricsFrame{Seq: 3},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name:     "Stream is open after errors",
+			frame:    &pb.MetricsFrame{Seq: 4, Request: req, Hash: hash},
+			wantCode: codes.OK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, stream.Send(tt.frame))
+
+			ack, err := stream.Recv()
+			require.NoError(t, err)
+			assert.Equal(t, tt.frame.GetSeq(), ack.GetSeq())
+			assert.Equal(t, tt.wantCode, codes.Code(ack.GetCode()))
+		})
+	}
+
+	assert.Equal(t, "batch-1", mockService.batchID)
+	require.NoError(t, stream.CloseSend())
+}
+
+func TestStreamMetrics_ServiceError(t *testing.T) {
+	client := newStreamClient(t, &server{
+		service: &mockMetricService{errToReturn: merrors.ErrSeriesQuotaExceeded},
+		config:  config.ServerConfig{},
+	})
+
+	stream, err := client.StreamMetrics(context.Background())
+	require.NoError(t, err)
+
+	err = stream.Send(&pb.MetricsFrame{
+		Seq: 1,
+		Request: &pb.UpdateMetricsRequest{
+			Metrics: []*pb.Metric{{Id: "Alloc", MType: pb.Metric_GAUGE, Value: floatPtr(1)}},
+		},
+	})
+	require.NoError(t, err)
+
+	ack, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, codes.ResourceExhausted, codes.Code(ack.GetCode()))
+	assert.Equal(t, merrors.ErrSeriesQuotaExceeded.Error(), ack.GetMessage())
+}
+
+func TestStreamMetrics_Shutdown(t *testing.T) {
+	srv := NewServer(&mockMetricService{}, nil, config.ServerConfig{})
+	client := serveBufconn(t, srv.Server)
+
+	stream, err := client.StreamMetrics(context.Background())
+	require.NoError(t, err)
+
+	err = stream.Send(&pb.MetricsFrame{
+		Seq: 1,
+		Request: &pb.UpdateMetricsRequest{
+			Metrics: []*pb.Metric{{Id: "Alloc", MType: pb.Metric_GAUGE, Value: floatPtr(1)}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	stopped := make(chan struct{})
+	go func() {
+		srv.Shutdown(ctx)
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(3 * time.Second):
+		t.Fatal("Shutdown is blocked by an open stream")
+	}
+
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
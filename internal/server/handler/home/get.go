@@ -60,14 +60,27 @@ var styles = `<style>
 </style>`
 
 // generateHTMLListItem генерирует html тэг li с именем и значением метрики.
-func generateHTMLListItem(name string, value string) string {
-	openLiName := `<li class="sub-list__item"><div class="sub-list__name">`
+// Описание метрики help, если есть, выводится подсказкой.
+func generateHTMLListItem(name string, value string, help string) string {
+	openLi := `<li class="sub-list__item"`
+	openTitle := ` title="`
+	closeTitle := `"`
+	openName := `><div class="sub-list__name">`
 	openDivValue := `:</div><div class="sub-list__value">`
 	closeLi := `</div></li>`
-	itemLen := len(openLiName) + len(openDivValue) + len(closeLi) + len(name) + len(value)
+	itemLen := len(openLi) + len(openName) + len(openDivValue) + len(closeLi) + len(name) + len(value)
+	if help != "" {
+		itemLen += len(openTitle) + len(closeTitle) + len(help)
+	}
 	var res strings.Builder
 	res.Grow(itemLen)
-	res.WriteString(openLiName)
+	res.WriteString(openLi)
+	if help != "" {
+		res.WriteString(openTitle)
+		res.WriteString(html.EscapeString(help))
+		res.WriteString(closeTitle)
+	}
+	res.WriteString(openName)
 	res.WriteString(html.EscapeString(name))
 	res.WriteString(openDivValue)
 	res.WriteString(html.EscapeString(value))
@@ -119,17 +132,20 @@ func wrapHTML(gaugeList []string, counterList []string) string {
 }
 
 // generateHTML из списка метрик генерирует HTML-страницу.
+// Значения форматируются по описаниям метрик: с их точностью и единицей измерения.
 func generateHTML(list storage.Database) string {
 	gaugeList := make([]string, 0, len(list.Gauge))
 	counterList := make([]string, 0, len(list.Counter))
 	for key, value := range list.Gauge {
+		meta := list.Metadata.Lookup(key)
 		gaugeList = append(gaugeList,
-			generateHTMLListItem(key, strconv.FormatFloat(float64(value), 'f', 3, 64)))
+			generateHTMLListItem(key, meta.WithUnit(meta.FormatFloat(float64(value))), meta.Help))
 	}
 
 	for key, value := range list.Counter {
+		meta := list.Metadata.Lookup(key)
 		counterList = append(counterList,
-			generateHTMLListItem(key, strconv.FormatInt(int64(value), 10)))
+			generateHTMLListItem(key, meta.WithUnit(strconv.FormatInt(int64(value), 10)), meta.Help))
 	}
 	return wrapHTML(gaugeList, counterList)
 }
@@ -63,6 +63,11 @@ func BenchmarkGenerateHTML(b *testing.B) {
 	b.ReportAllocs()
 }
 
+var (
+	zeroPrecision = 0
+	sixPrecision  = 6
+)
+
 func Test_generateHTML(t *testing.T) {
 	type args struct {
 		list storage.Database
@@ -83,6 +88,22 @@ func Test_generateHTML(t *testing.T) {
 			wantStatus:   http.StatusOK,
 			wantContains: []string{"A", "1.1", "B", "2"},
 		},
+		{
+			name: "Metrics with metadata",
+			db: storage.Database{
+				Gauge:   map[string]storage.Gauge{"HeapAlloc": 2048, "GCCPUFraction": 0.123456789},
+				Counter: map[string]storage.Counter{`PollCount{host="a"}`: 2},
+				Metadata: storage.MetadataCollection{
+					"HeapAlloc":     {Unit: "bytes", Precision: &zeroPrecision, Help: "Heap <size>"},
+					"GCCPUFraction": {Precision: &sixPrecision},
+					"PollCount":     {Unit: "polls"},
+				},
+			},
+			wantStatus: http.StatusOK,
+			wantContains: []string{
+				`title="Heap &lt;size&gt;"`, ">2048 bytes<", ">0.123457<", ">2 polls<",
+			},
+		},
 		{
 			name:         "Empty metrics",
 			db:           storage.Database{},
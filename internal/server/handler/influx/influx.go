@@ -0,0 +1,14 @@
+// Package influx принимает метрики в формате InfluxDB line protocol.
+package influx
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/models"
+)
+
+// MetricService — интерфейс сервиса метрик, который нужен для работы хендлера.
+type MetricService interface {
+	GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error)
+	UpdateMany(ctx context.Context, list []models.Metrics) error
+}
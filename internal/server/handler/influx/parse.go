@@ -0,0 +1,196 @@
+package influx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var errIncorrectLine = errors.New("incorrect line protocol")
+
+// field — числовое поле точки. Строковые и логические поля пропускаются.
+type field struct {
+	key     string
+	value   float64
+	integer bool // значение с суффиксом i
+}
+
+type point struct {
+	time        time.Time
+	tags        map[string]string
+	measurement string
+	fields      []field
+}
+
+// split делит строку по sep, пропуская экранированные символы
+// и, если quoted, содержимое двойных кавычек.
+func split(s string, sep byte, quoted bool) []string {
+	var (
+		parts    []string
+		start    int
+		inQuotes bool
+	)
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\':
+			i++
+		case quoted && s[i] == '"':
+			inQuotes = !inQuotes
+		case s[i] == sep && !inQuotes:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+
+	return append(parts, s[start:])
+}
+
+// cutKey делит строку по первому неэкранированному '='.
+func cutKey(s string) (string, string, bool) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '=':
+			return s[:i], s[i+1:], true
+		}
+	}
+
+	return s, "", false
+}
+
+var unescaper = strings.NewReplacer(`\,`, ",", `\=`, "=", `\ `, " ", `\"`, `"`, `\\`, `\`)
+
+func unescape(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+
+	return unescaper.Replace(s)
+}
+
+// parseFieldValue возвращает значение числового поля.
+// ok == false для строк и логических значений.
+func parseFieldValue(raw string) (value float64, integer, ok bool, err error) {
+	if raw == "" {
+		return 0, false, false, errIncorrectLine
+	}
+
+	switch raw {
+	case "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE":
+		return 0, false, false, nil
+	}
+
+	if raw[0] == '"' {
+		if len(raw) < 2 || raw[len(raw)-1] != '"' {
+			return 0, false, false, fmt.Errorf("%w: unterminated string", errIncorrectLine)
+		}
+		return 0, false, false, nil
+	}
+
+	switch raw[len(raw)-1] {
+	case 'i':
+		v, err := strconv.ParseInt(raw[:len(raw)-1], 10, 64)
+		return float64(v), true, err == nil, err
+	case 'u':
+		v, err := strconv.ParseUint(raw[:len(raw)-1], 10, 64)
+		return float64(v), false, err == nil, err
+	}
+
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return 0, false, false, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, false, false, fmt.Errorf("%w: value must be finite", errIncorrectLine)
+	}
+
+	return v, false, true, nil
+}
+
+// precisions — множители для параметра precision.
+var precisions = map[string]time.Duration{
+	"":   time.Nanosecond,
+	"ns": time.Nanosecond,
+	"n":  time.Nanosecond,
+	"us": time.Microsecond,
+	"u":  time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+}
+
+// parseLine разбирает строку measurement[,tag=v...] field=v[,field=v...] [timestamp].
+func parseLine(line string, precision time.Duration, now time.Time) (point, error) {
+	sections := split(line, ' ', true)
+	if len(sections) < 2 || len(sections) > 3 {
+		return point{}, fmt.Errorf("%w: expected measurement, fields and timestamp", errIncorrectLine)
+	}
+
+	keys := split(sections[0], ',', false)
+	p := point{
+		measurement: unescape(keys[0]),
+		time:        now,
+	}
+	if p.measurement == "" {
+		return point{}, fmt.Errorf("%w: empty measurement", errIncorrectLine)
+	}
+
+	for _, tag := range keys[1:] {
+		k, v, ok := cutKey(tag)
+		if !ok || k == "" || v == "" {
+			return point{}, fmt.Errorf("%w: incorrect tag %q", errIncorrectLine, tag)
+		}
+		if p.tags == nil {
+			p.tags = map[string]string{}
+		}
+		p.tags[unescape(k)] = unescape(v)
+	}
+
+	for _, f := range split(sections[1], ',', true) {
+		k, raw, ok := cutKey(f)
+		if !ok || k == "" {
+			return point{}, fmt.Errorf("%w: incorrect field %q", errIncorrectLine, f)
+		}
+
+		value, integer, numeric, err := parseFieldValue(raw)
+		if err != nil {
+			return point{}, fmt.Errorf("%w: incorrect value of field %q", errIncorrectLine, k)
+		}
+		if numeric {
+			p.fields = append(p.fields, field{key: unescape(k), value: value, integer: integer})
+		}
+	}
+
+	if len(sections) == 3 {
+		ts, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return point{}, fmt.Errorf("%w: incorrect timestamp", errIncorrectLine)
+		}
+		p.time = time.Unix(0, ts*int64(precision))
+	}
+
+	return p, nil
+}
+
+// parse разбирает тело запроса. Пустые строки и комментарии пропускаются.
+func parse(body string, precision time.Duration, now time.Time) ([]point, error) {
+	var points []point
+	for i, line := range strings.Split(body, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		p, err := parseLine(line, precision, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		points = append(points, p)
+	}
+
+	return points, nil
+}
@@ -0,0 +1,121 @@
+package influx
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	now := time.Unix(100, 0)
+
+	tests := []struct {
+		name      string
+		line      string
+		want      point
+		precision time.Duration
+		wantErr   bool
+	}{
+		{
+			name:      "Measurement, tags, fields and timestamp",
+			line:      "cpu,host=web1,region=eu usage_idle=92.5,usage_user=3i 1700000000000000000",
+			precision: time.Nanosecond,
+			want: point{
+				measurement: "cpu",
+				tags:        map[string]string{"host": "web1", "region": "eu"},
+				fields: []field{
+					{key: "usage_idle", value: 92.5},
+					{key: "usage_user", value: 3, integer: true},
+				},
+				time: time.Unix(1700000000, 0),
+			},
+		},
+		{
+			name:      "Without timestamp and tags, seconds precision",
+			line:      "mem used=1024u",
+			precision: time.Second,
+			want: point{
+				measurement: "mem",
+				fields:      []field{{key: "used", value: 1024}},
+				time:        now,
+			},
+		},
+		{
+			name:      "Escaped characters and non-numeric fields",
+			line:      `disk\ io,path=C:\\,mount=/data\,1 reads=5i,state="ok, fine",healthy=true 1700000000`,
+			precision: time.Second,
+			want: point{
+				measurement: "disk io",
+				tags:        map[string]string{"path": `C:\`, "mount": "/data,1"},
+				fields:      []field{{key: "reads", value: 5, integer: true}},
+				time:        time.Unix(1700000000, 0),
+			},
+		},
+		{
+			name:      "Without fields",
+			line:      "cpu,host=web1",
+			precision: time.Nanosecond,
+			wantErr:   true,
+		},
+		{
+			name:      "Incorrect field value",
+			line:      "cpu usage=high",
+			precision: time.Nanosecond,
+			wantErr:   true,
+		},
+		{
+			name:      "NaN field value",
+			line:      "cpu usage=NaN",
+			precision: time.Nanosecond,
+			wantErr:   true,
+		},
+		{
+			name:      "Infinite field value",
+			line:      "cpu usage=1,load=-Inf",
+			precision: time.Nanosecond,
+			wantErr:   true,
+		},
+		{
+			name:      "Incorrect tag",
+			line:      "cpu,host usage=1",
+			precision: time.Nanosecond,
+			wantErr:   true,
+		},
+		{
+			name:      "Incorrect timestamp",
+			line:      "cpu usage=1 yesterday",
+			precision: time.Nanosecond,
+			wantErr:   true,
+		},
+		{
+			name:      "Unterminated string",
+			line:      `cpu state="ok`,
+			precision: time.Nanosecond,
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLine(tt.line, tt.precision, now)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errIncorrectLine)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	body := "# comment\n\ncpu usage=1\r\nmem used=2i\n"
+	points, err := parse(body, time.Nanosecond, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, points, 2)
+
+	_, err = parse("cpu usage=1\nbroken\n", time.Nanosecond, time.Now())
+	assert.ErrorContains(t, err, "line 2")
+}
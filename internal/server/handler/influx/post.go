@@ -0,0 +1,165 @@
+package influx
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/services/cumulative"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+// receiver хранит состояние между запросами.
+type receiver struct {
+	s          MetricService
+	tracker    *cumulative.Tracker
+	cumulative map[string]bool
+}
+
+// metricName возвращает имя метрики для поля: measurement_field,
+// а для поля value — просто measurement, как это делает Telegraf.
+func metricName(measurement, key string) string {
+	if key == "value" {
+		return measurement
+	}
+
+	return measurement + "_" + key
+}
+
+// storedCounter возвращает текущее значение счетчика или 0, если его еще нет.
+func (rc *receiver) storedCounter(ctx context.Context, series models.Metrics) storage.Counter {
+	series.MType = "counter"
+	m, err := rc.s.GetMetricJSON(ctx, series)
+	if err != nil || m.Delta == nil {
+		return 0
+	}
+
+	return *m.Delta
+}
+
+// convert переводит точки в метрики. Точки обрабатываются в порядке времени:
+// для gauge остается последнее значение, для counter суммируются приросты,
+// которые считаются в пачке batch.
+func (rc *receiver) convert(ctx context.Context, batch *cumulative.Batch, points []point) []models.Metrics {
+	sort.SliceStable(points, func(i, j int) bool {
+		return points[i].time.Before(points[j].time)
+	})
+
+	var (
+		order    []string
+		series   = map[string]models.Metrics{}
+		gauges   = map[string]storage.Gauge{}
+		counters = map[string]storage.Counter{}
+	)
+
+	for _, p := range points {
+		for _, f := range p.fields {
+			m := models.Metrics{ID: metricName(p.measurement, f.key), Labels: p.tags}
+			key := m.Key()
+			if _, ok := series[key]; !ok {
+				series[key] = m
+				order = append(order, key)
+			}
+
+			if f.integer && rc.cumulative[p.measurement] {
+				counters[key] += batch.Delta(ctx, key, f.value, func() storage.Counter {
+					return rc.storedCounter(ctx, m)
+				})
+
+				continue
+			}
+
+			gauges[key] = storage.Gauge(f.value)
+		}
+	}
+
+	list := make([]models.Metrics, 0, len(order))
+	for _, key := range order {
+		m := series[key]
+		if v, ok := counters[key]; ok {
+			m.MType, m.Delta = "counter", &v
+		} else {
+			v := gauges[key]
+			m.MType, m.Value = "gauge", &v
+		}
+		list = append(list, m)
+	}
+
+	return list
+}
+
+// Post — хендлер записи в формате InfluxDB line protocol.
+// Каждое числовое поле становится gauge с именем measurement_field, теги
+// становятся метками серии. Целые поля (с суффиксом i) измерений
+// из cumulative считаются накопительными counter.
+// Время точек задает только порядок значений, в хранилище записывается время получения.
+// Если задан key, тело проверяется по заголовку HashSHA256.
+func Post(s MetricService, cumulativeMeasurements []string, key string) http.HandlerFunc {
+	rc := &receiver{
+		s:          s,
+		tracker:    cumulative.New(),
+		cumulative: map[string]bool{},
+	}
+	for _, m := range cumulativeMeasurements {
+		rc.cumulative[m] = true
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		precision, ok := precisions[r.URL.Query().Get("precision")]
+		if !ok {
+			http.Error(w, "Bad request: incorrect precision", http.StatusBadRequest)
+			return
+		}
+
+		defer r.Body.Close()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.Log.Error("/write: body reading error", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if key != "" && r.Header.Get("HashSHA256") != crypto.GenerateHMAC(body, key) {
+			http.Error(w, "Bad request: incorrect HashSHA256", http.StatusBadRequest)
+			return
+		}
+
+		points, err := parse(string(body), precision, time.Now())
+		if err != nil {
+			logger.Log.Info("/write: parse error", zap.Error(err))
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		batch := rc.tracker.Begin()
+		list := rc.convert(r.Context(), batch, points)
+		err = s.UpdateMany(r.Context(), list)
+		if err != nil {
+			// повтор запроса должен получить те же приросты
+			batch.Rollback()
+		}
+
+		switch {
+		case errors.Is(err, merrors.ErrSeriesQuotaExceeded):
+			http.Error(w, "Too many series: "+err.Error(), http.StatusTooManyRequests)
+			return
+		case errors.Is(err, merrors.ErrIncorrectTenantKey):
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logger.Log.Error("/write: error while updating metrics", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,193 @@
+package influx
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func gaugeMetric(id string, v storage.Gauge, labels ...string) models.Metrics {
+	return models.Metrics{ID: id, MType: "gauge", Value: &v, Labels: labelsOf(labels...)}
+}
+
+func counterMetric(id string, v storage.Counter, labels ...string) models.Metrics {
+	return models.Metrics{ID: id, MType: "counter", Delta: &v, Labels: labelsOf(labels...)}
+}
+
+func labelsOf(kv ...string) storage.Labels {
+	if len(kv) == 0 {
+		return nil
+	}
+
+	labels := storage.Labels{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		labels[kv[i]] = kv[i+1]
+	}
+
+	return labels
+}
+
+func TestPost(t *testing.T) {
+	stored := storage.Counter(10)
+
+	tests := []struct {
+		serviceErr error
+		name       string
+		url        string
+		body       string
+		want       []models.Metrics
+		wantCode   int
+	}{
+		{
+			name: "Gauges take value with latest timestamp",
+			url:  "/write?precision=s",
+			body: "cpu,host=web1 usage=2,value=7 1700000010\n" +
+				"cpu,host=web1 usage=1 1700000000\n",
+			want: []models.Metrics{
+				gaugeMetric("cpu_usage", 2, "host", "web1"),
+				gaugeMetric("cpu", 7, "host", "web1"),
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name: "Integer fields of cumulative measurement are counters",
+			url:  "/write",
+			body: "net,iface=eth0 bytes_recv=15i,drop_rate=0.5 1\n" +
+				"net,iface=eth0 bytes_recv=25i 2\n" +
+				"mem used=100i 1\n",
+			want: []models.Metrics{
+				counterMetric("net_bytes_recv", 15, "iface", "eth0"),
+				gaugeMetric("net_drop_rate", 0.5, "iface", "eth0"),
+				gaugeMetric("mem_used", 100),
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Incorrect precision",
+			url:      "/write?precision=h",
+			body:     "cpu usage=1",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Incorrect line",
+			url:      "/write",
+			body:     "cpu usage=1\ncpu",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Service error",
+			url:        "/write",
+			body:       "cpu usage=1",
+			serviceErr: merrors.ErrMocked,
+			want:       []models.Metrics{gaugeMetric("cpu_usage", 1)},
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricService(t)
+			s.EXPECT().GetMetricJSON(mock.Anything, mock.Anything).
+				Return(models.Metrics{Delta: &stored}, nil).Maybe()
+			if tt.want != nil {
+				s.EXPECT().UpdateMany(mock.Anything, tt.want).Return(tt.serviceErr).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, tt.url, strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+
+			Post(s, []string{"net"}, "")(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+		})
+	}
+}
+
+func TestPostRetry(t *testing.T) {
+	stored := storage.Counter(10)
+
+	s := NewMockMetricService(t)
+	s.EXPECT().GetMetricJSON(mock.Anything, mock.Anything).
+		Return(models.Metrics{Delta: &stored}, nil)
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("net_bytes", 5, "host", "a")}).
+		Return(merrors.ErrMocked).Once()
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("net_bytes", 5, "host", "a")}).
+		Return(nil).Once()
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("net_bytes", 3, "host", "a")}).
+		Return(nil).Once()
+
+	h := Post(s, []string{"net"}, "")
+	for _, step := range []struct {
+		body     string
+		wantCode int
+	}{
+		{body: "net,host=a bytes=15i", wantCode: http.StatusInternalServerError},
+		// повтор после ошибки не теряет прирост
+		{body: "net,host=a bytes=15i", wantCode: http.StatusNoContent},
+		{body: "net,host=a bytes=18i", wantCode: http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/write", strings.NewReader(step.body))
+		w := httptest.NewRecorder()
+
+		h(w, req)
+
+		res := w.Result()
+		res.Body.Close()
+		assert.Equal(t, step.wantCode, res.StatusCode)
+	}
+}
+
+func TestPostHMAC(t *testing.T) {
+	const key = "secret"
+	body := "cpu usage=1"
+
+	tests := []struct {
+		name     string
+		hash     string
+		wantCode int
+	}{
+		{
+			name:     "Correct HashSHA256",
+			hash:     crypto.GenerateHMAC([]byte(body), key),
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Incorrect HashSHA256",
+			hash:     crypto.GenerateHMAC([]byte(body), "other"),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Without HashSHA256",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricService(t)
+			if tt.wantCode == http.StatusNoContent {
+				s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{gaugeMetric("cpu_usage", 1)}).Return(nil).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/write", strings.NewReader(body))
+			if tt.hash != "" {
+				req.Header.Set("HashSHA256", tt.hash)
+			}
+			w := httptest.NewRecorder()
+
+			Post(s, nil, key)(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+		})
+	}
+}
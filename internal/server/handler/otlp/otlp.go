@@ -0,0 +1,13 @@
+// Package otlp принимает метрики OpenTelemetry по OTLP/HTTP.
+package otlp
+
+import (
+	"context"
+
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+// Exporter — интерфейс приемника OTLP, который нужен для работы хендлера.
+type Exporter interface {
+	Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (int64, error)
+}
@@ -0,0 +1,101 @@
+package otlp
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	contentTypeProto = "application/x-protobuf"
+	contentTypeJSON  = "application/json"
+)
+
+// Post — хендлер OTLP/HTTP для метрик.
+// Тело принимается в protobuf или JSON, ответ отдается в том же формате.
+// Если задан key, тело проверяется по заголовку HashSHA256.
+func Post(s Exporter, key string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		contentType := r.Header.Get("Content-Type")
+		isJSON := strings.HasPrefix(contentType, contentTypeJSON)
+		if !isJSON && !strings.HasPrefix(contentType, contentTypeProto) {
+			http.Error(w, "Unsupported media type", http.StatusUnsupportedMediaType)
+			return
+		}
+
+		defer r.Body.Close()
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.Log.Error("/v1/metrics: body reading error", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if key != "" && r.Header.Get("HashSHA256") != crypto.GenerateHMAC(body, key) {
+			http.Error(w, "Bad request: incorrect HashSHA256", http.StatusBadRequest)
+			return
+		}
+
+		req := &colmetricspb.ExportMetricsServiceRequest{}
+		if isJSON {
+			err = protojson.Unmarshal(body, req)
+		} else {
+			err = proto.Unmarshal(body, req)
+		}
+		if err != nil {
+			logger.Log.Info("/v1/metrics: decode error", zap.Error(err))
+			http.Error(w, "Bad request: incorrect body", http.StatusBadRequest)
+			return
+		}
+
+		rejected, err := s.Export(r.Context(), req)
+		switch {
+		case errors.Is(err, merrors.ErrSeriesQuotaExceeded):
+			http.Error(w, "Too many series: "+err.Error(), http.StatusTooManyRequests)
+			return
+		case errors.Is(err, merrors.ErrIncorrectTenantKey):
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logger.Log.Error("/v1/metrics: error while exporting metrics", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		res := &colmetricspb.ExportMetricsServiceResponse{}
+		if rejected > 0 {
+			res.PartialSuccess = &colmetricspb.ExportMetricsPartialSuccess{
+				RejectedDataPoints: rejected,
+				ErrorMessage:       "unsupported metric type",
+			}
+		}
+
+		var out []byte
+		if isJSON {
+			out, err = protojson.Marshal(res)
+		} else {
+			out, err = proto.Marshal(res)
+		}
+		if err != nil {
+			logger.Log.Error("/v1/metrics: error while marshal response", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if isJSON {
+			w.Header().Set("Content-Type", contentTypeJSON)
+		} else {
+			w.Header().Set("Content-Type", contentTypeProto)
+		}
+		w.Write(out)
+	}
+}
@@ -0,0 +1,132 @@
+package otlp
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestPost(t *testing.T) {
+	req := &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{}},
+	}
+	protoBody, err := proto.Marshal(req)
+	require.NoError(t, err)
+	jsonBody, err := protojson.Marshal(req)
+	require.NoError(t, err)
+
+	tests := []struct {
+		exportErr    error
+		name         string
+		contentType  string
+		key          string
+		hash         string
+		body         []byte
+		rejected     int64
+		wantCode     int
+		wantRejected int64
+		export       bool
+	}{
+		{
+			name:        "Protobuf",
+			contentType: "application/x-protobuf",
+			body:        protoBody,
+			export:      true,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:         "JSON with partial success",
+			contentType:  "application/json",
+			body:         jsonBody,
+			export:       true,
+			rejected:     3,
+			wantCode:     http.StatusOK,
+			wantRejected: 3,
+		},
+		{
+			name:        "Correct HashSHA256",
+			contentType: "application/x-protobuf",
+			body:        protoBody,
+			key:         "secret",
+			hash:        crypto.GenerateHMAC(protoBody, "secret"),
+			export:      true,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:        "Incorrect HashSHA256",
+			contentType: "application/x-protobuf",
+			body:        protoBody,
+			key:         "secret",
+			hash:        "incorrect",
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "Unsupported Content-Type",
+			contentType: "text/plain",
+			body:        protoBody,
+			wantCode:    http.StatusUnsupportedMediaType,
+		},
+		{
+			name:        "Incorrect body",
+			contentType: "application/x-protobuf",
+			body:        []byte{0x0a, 0x10, 0x01},
+			wantCode:    http.StatusBadRequest,
+		},
+		{
+			name:        "Export error",
+			contentType: "application/x-protobuf",
+			body:        protoBody,
+			export:      true,
+			exportErr:   merrors.ErrMocked,
+			wantCode:    http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockExporter(t)
+			if tt.export {
+				s.EXPECT().Export(mock.Anything, mock.Anything).Return(tt.rejected, tt.exportErr).Once()
+			}
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/metrics", bytes.NewReader(tt.body))
+			r.Header.Set("Content-Type", tt.contentType)
+			if tt.hash != "" {
+				r.Header.Set("HashSHA256", tt.hash)
+			}
+			w := httptest.NewRecorder()
+
+			Post(s, tt.key)(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			out, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.contentType, res.Header.Get("Content-Type"))
+
+			resp := &colmetricspb.ExportMetricsServiceResponse{}
+			if tt.contentType == "application/json" {
+				require.NoError(t, protojson.Unmarshal(out, resp))
+			} else {
+				require.NoError(t, proto.Unmarshal(out, resp))
+			}
+			assert.Equal(t, tt.wantRejected, resp.GetPartialSuccess().GetRejectedDataPoints())
+		})
+	}
+}
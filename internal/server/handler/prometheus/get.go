@@ -0,0 +1,41 @@
+package prometheus
+
+import (
+	"io"
+	"net/http"
+	"strings"
+)
+
+// acceptsOpenMetrics проверяет, запрашивает ли клиент формат OpenMetrics.
+func acceptsOpenMetrics(accept string) bool {
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, _, _ := strings.Cut(part, ";")
+		if strings.TrimSpace(mediaType) == "application/openmetrics-text" {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Get возвращает HTTP-хендлер, который отдает все метрики для Prometheus.
+// Формат ответа выбирается по заголовку Accept.
+func Get(s MetricService) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		all, err := s.GetAllMetrics(r.Context())
+		if err != nil {
+			http.Error(w, "Internal error 500", http.StatusInternalServerError)
+			return
+		}
+
+		openMetrics := acceptsOpenMetrics(r.Header.Get("Accept"))
+		contentType := ContentTypeText
+		if openMetrics {
+			contentType = ContentTypeOpenMetrics
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.WriteHeader(http.StatusOK)
+		io.WriteString(w, Render(all, openMetrics))
+	}
+}
@@ -0,0 +1,80 @@
+package prometheus
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	db := storage.Database{
+		Gauge:   storage.GaugeCollection{"HeapAlloc": 1},
+		Counter: storage.CounterCollection{"PollCount": 2},
+	}
+
+	tests := []struct {
+		serviceErr   error
+		name         string
+		accept       string
+		wantType     string
+		wantContains string
+		wantCode     int
+	}{
+		{
+			name:         "Default text format",
+			wantCode:     http.StatusOK,
+			wantType:     ContentTypeText,
+			wantContains: "PollCount 2\n",
+		},
+		{
+			name:         "Prometheus text accept header",
+			accept:       "text/plain;version=0.0.4;q=0.5,*/*;q=0.1",
+			wantCode:     http.StatusOK,
+			wantType:     ContentTypeText,
+			wantContains: "PollCount 2\n",
+		},
+		{
+			name:         "OpenMetrics accept header",
+			accept:       "application/openmetrics-text;version=1.0.0,text/plain;q=0.5",
+			wantCode:     http.StatusOK,
+			wantType:     ContentTypeOpenMetrics,
+			wantContains: "PollCount_total 2\n# EOF\n",
+		},
+		{
+			name:       "Service error",
+			serviceErr: merrors.ErrMocked,
+			wantCode:   http.StatusInternalServerError,
+			wantType:   "text/plain; charset=utf-8",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricService(t)
+			s.EXPECT().GetAllMetrics(mock.Anything).Return(db, tt.serviceErr)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+
+			Get(s)(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+			assert.Equal(t, tt.wantType, res.Header.Get("Content-Type"))
+
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			assert.Contains(t, string(body), tt.wantContains)
+		})
+	}
+}
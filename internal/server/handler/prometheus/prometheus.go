@@ -0,0 +1,360 @@
+// Package prometheus отдает метрики в текстовом формате Prometheus и OpenMetrics.
+package prometheus
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+// MetricService — интерфейс сервиса метрик, который нужен для работы хендлера.
+type MetricService interface {
+	GetAllMetrics(ctx context.Context) (storage.Database, error)
+}
+
+const (
+	// ContentTypeText — тип ответа в текстовом формате Prometheus.
+	ContentTypeText = "text/plain; version=0.0.4; charset=utf-8"
+	// ContentTypeOpenMetrics — тип ответа в формате OpenMetrics.
+	ContentTypeOpenMetrics = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+)
+
+// sample — одна строка значения. У histogram и summary к имени добавляется suffix.
+type sample struct {
+	suffix string
+	labels string // отрендеренные метки вида {k="v"}
+	value  string
+}
+
+// family — группа серий одной метрики.
+type family struct {
+	name     string
+	original string
+	mType    string
+	meta     storage.Metadata
+	samples  []sample
+}
+
+// series — серия из хранилища до группировки по семействам.
+type series struct {
+	family   string
+	original string
+	mType    string
+	labels   string
+	samples  []sample
+}
+
+// SanitizeName приводит имя метрики к виду [a-zA-Z_:][a-zA-Z0-9_:]*,
+// заменяя недопустимые символы на подчеркивание.
+func SanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+// SanitizeLabelName приводит имя метки к виду [a-zA-Z_][a-zA-Z0-9_]*:
+// в отличие от имени метрики двоеточие в нем недопустимо.
+func SanitizeLabelName(name string) string {
+	return sanitize(name, false)
+}
+
+func sanitize(name string, colon bool) string {
+	if name == "" {
+		return "_"
+	}
+
+	var b strings.Builder
+	b.Grow(len(name) + 1)
+	for i, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r == '_', colon && r == ':':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+// escapeHelp экранирует текст для строки # HELP.
+func escapeHelp(help string) string {
+	help = strings.ReplaceAll(help, `\`, `\\`)
+	return strings.ReplaceAll(help, "\n", `\n`)
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// escapeLabelValue экранирует значение метки.
+func escapeLabelValue(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	return strings.ReplaceAll(v, "\n", `\n`)
+}
+
+// renderLabels возвращает метки в виде {k1="v1",k2="v2"} или пустую строку.
+// Если после санитизации имена разных меток совпали, остается первая
+// по порядку имен метка.
+func renderLabels(labels storage.Labels) string {
+	names := labels.Names()
+	if len(names) == 0 {
+		return ""
+	}
+
+	seen := make(map[string]struct{}, len(names))
+	var b strings.Builder
+	b.WriteByte('{')
+	for _, name := range names {
+		label := SanitizeLabelName(name)
+		if _, ok := seen[label]; ok {
+			continue
+		}
+		if len(seen) > 0 {
+			b.WriteByte(',')
+		}
+		seen[label] = struct{}{}
+
+		b.WriteString(label)
+		b.WriteString(`="`)
+		b.WriteString(escapeLabelValue(labels[name]))
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+func newSeries(key, mType string) (series, storage.Labels) {
+	name, labels, err := storage.ParseSeriesKey(key)
+	if err != nil {
+		name, labels = key, nil
+	}
+
+	return series{
+		family:   SanitizeName(name),
+		original: name,
+		mType:    mType,
+		labels:   renderLabels(labels),
+	}, labels
+}
+
+// histogramSamples возвращает накопительные бакеты name_bucket{le="..."},
+// name_sum и name_count.
+func histogramSamples(h storage.Histogram, labels storage.Labels, rendered string) []sample {
+	samples := make([]sample, 0, len(h.Counts)+2)
+
+	var cumulative uint64
+	for i, c := range h.Counts {
+		cumulative += c
+		le := "+Inf"
+		if i < len(h.Buckets) {
+			le = formatFloat(h.Buckets[i])
+		}
+
+		bucket := labels.Copy()
+		bucket["le"] = le
+		samples = append(samples, sample{
+			suffix: "_bucket",
+			labels: renderLabels(bucket),
+			value:  strconv.FormatUint(cumulative, 10),
+		})
+	}
+
+	return append(samples,
+		sample{suffix: "_sum", labels: rendered, value: formatFloat(h.Sum)},
+		sample{suffix: "_count", labels: rendered, value: strconv.FormatUint(h.Count, 10)},
+	)
+}
+
+// summaryQuantiles — квантили summary в выдаче.
+var summaryQuantiles = []float64{0.5, 0.9, 0.99}
+
+// summarySamples возвращает квантили за окно name{quantile="..."},
+// а также name_sum и name_count за все время.
+func summarySamples(sm storage.Summary, labels storage.Labels, rendered string, now time.Time) []sample {
+	samples := make([]sample, 0, len(summaryQuantiles)+2)
+
+	for _, q := range summaryQuantiles {
+		quantile := labels.Copy()
+		quantile["quantile"] = formatFloat(q)
+		samples = append(samples, sample{
+			labels: renderLabels(quantile),
+			value:  formatFloat(sm.Quantile(q, now)),
+		})
+	}
+
+	return append(samples,
+		sample{suffix: "_sum", labels: rendered, value: formatFloat(sm.Sum)},
+		sample{suffix: "_count", labels: rendered, value: strconv.FormatUint(sm.Count, 10)},
+	)
+}
+
+// collectFamilies собирает отсортированный список метрик, серии одной метрики
+// группируются в одно семейство. Если после санитизации имена разных метрик
+// совпали, остается первая метрика.
+func collectFamilies(list storage.Database, openMetrics bool) []family {
+	all := make([]series, 0,
+		len(list.Gauge)+len(list.Counter)+len(list.Histogram)+len(list.Summary)+len(list.Set))
+
+	for key, val := range list.Gauge {
+		s, _ := newSeries(key, "gauge")
+		s.samples = []sample{{labels: s.labels, value: formatFloat(float64(val))}}
+		all = append(all, s)
+	}
+
+	for key, val := range list.Counter {
+		s, _ := newSeries(key, "counter")
+		s.samples = []sample{{labels: s.labels, value: strconv.FormatInt(int64(val), 10)}}
+		if openMetrics {
+			// В OpenMetrics суффикс _total есть только у значения, не у семейства.
+			s.family = strings.TrimSuffix(s.family, "_total")
+			s.samples[0].suffix = "_total"
+		}
+		all = append(all, s)
+	}
+
+	for key, val := range list.Histogram {
+		s, labels := newSeries(key, "histogram")
+		s.samples = histogramSamples(val, labels, s.labels)
+		all = append(all, s)
+	}
+
+	now := time.Now()
+	for key, val := range list.Summary {
+		s, labels := newSeries(key, "summary")
+		s.samples = summarySamples(val, labels, s.labels, now)
+		all = append(all, s)
+	}
+
+	// set отдается как gauge с оценкой количества уникальных значений
+	for key, val := range list.Set {
+		s, _ := newSeries(key, "gauge")
+		s.samples = []sample{{labels: s.labels, value: strconv.FormatUint(val.Count(), 10)}}
+		all = append(all, s)
+	}
+
+	sort.Slice(all, func(i, j int) bool {
+		a, b := all[i], all[j]
+		if a.family != b.family {
+			return a.family < b.family
+		}
+		if a.original != b.original {
+			return a.original < b.original
+		}
+		if a.mType != b.mType {
+			return a.mType < b.mType
+		}
+		return a.labels < b.labels
+	})
+
+	var res []family
+	for _, s := range all {
+		if len(res) > 0 && res[len(res)-1].name == s.family {
+			last := &res[len(res)-1]
+			if last.original != s.original || last.mType != s.mType {
+				logger.Log.Warn("duplicate metric name after sanitizing",
+					zap.String("name", s.family), zap.String("original", s.original))
+				continue
+			}
+			last.samples = append(last.samples, s.samples...)
+			continue
+		}
+
+		res = append(res, family{
+			name:     s.family,
+			original: s.original,
+			mType:    s.mType,
+			meta:     list.Metadata[s.original],
+			samples:  s.samples,
+		})
+	}
+
+	return res
+}
+
+// help возвращает текст # HELP: описание метрики, если оно задано.
+func (f family) help() string {
+	if f.meta.Help != "" {
+		return f.meta.Help
+	}
+
+	return f.mType + " metric " + f.original
+}
+
+// unit возвращает единицу измерения для # UNIT или пустую строку,
+// если имя метрики не оканчивается на нее.
+func (f family) unit() string {
+	if f.meta.Unit == "" {
+		return ""
+	}
+
+	unit := SanitizeName(f.meta.Unit)
+	if !strings.HasSuffix(f.name, "_"+unit) {
+		return ""
+	}
+
+	return unit
+}
+
+// Render возвращает все метрики в текстовом формате Prometheus
+// или в формате OpenMetrics, если openMetrics == true.
+// Значения выводятся без округления, точность из описаний не применяется.
+// Единица измерения выводится только в OpenMetrics и только если имя
+// метрики оканчивается на нее, как требует формат.
+func Render(list storage.Database, openMetrics bool) string {
+	var b strings.Builder
+
+	for _, f := range collectFamilies(list, openMetrics) {
+		b.WriteString("# HELP ")
+		b.WriteString(f.name)
+		b.WriteString(" ")
+		b.WriteString(escapeHelp(f.help()))
+		b.WriteString("\n# TYPE ")
+		b.WriteString(f.name)
+		b.WriteString(" ")
+		b.WriteString(f.mType)
+		b.WriteString("\n")
+		if unit := f.unit(); openMetrics && unit != "" {
+			b.WriteString("# UNIT ")
+			b.WriteString(f.name)
+			b.WriteString(" ")
+			b.WriteString(unit)
+			b.WriteString("\n")
+		}
+		for _, smp := range f.samples {
+			b.WriteString(f.name)
+			b.WriteString(smp.suffix)
+			b.WriteString(smp.labels)
+			b.WriteString(" ")
+			b.WriteString(smp.value)
+			b.WriteString("\n")
+		}
+	}
+
+	if openMetrics {
+		b.WriteString("# EOF\n")
+	}
+
+	return b.String()
+}
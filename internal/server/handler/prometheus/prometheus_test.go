@@ -0,0 +1,233 @@
+package prometheus
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSanitizeName(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "Valid name", in: "HeapAlloc", want: "HeapAlloc"},
+		{name: "Colon and underscore", in: "http:requests_total", want: "http:requests_total"},
+		{name: "Dots and dashes", in: "cpu.load-1m", want: "cpu_load_1m"},
+		{name: "Leading digit", in: "1minute", want: "_1minute"},
+		{name: "Unicode", in: "память", want: "______"},
+		{name: "Empty", in: "", want: "_"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, SanitizeName(tt.in))
+		})
+	}
+}
+
+func TestSanitizeLabelName(t *testing.T) {
+	assert.Equal(t, "http_method", SanitizeLabelName("http:method"))
+	assert.Equal(t, "_1code", SanitizeLabelName("1code"))
+	assert.Equal(t, "_", SanitizeLabelName(""))
+}
+
+func TestRenderLabels(t *testing.T) {
+	assert.Equal(t, "", renderLabels(nil))
+	assert.Equal(t, `{a_b="1",code="200"}`, renderLabels(storage.Labels{
+		"a-b":  "1",
+		"a:b":  "2",
+		"a.b":  "3",
+		"code": "200",
+	}))
+}
+
+func TestRender(t *testing.T) {
+	rpc := storage.NewSummary(time.Hour)
+	for _, v := range []float64{1, 3, 2} {
+		rpc.Observe(v, time.Now())
+	}
+
+	users := storage.NewSet()
+	users.Add("alice")
+	users.Add("bob")
+
+	db := storage.Database{
+		Gauge: storage.GaugeCollection{
+			"HeapAlloc":                    1024.5,
+			"cpu.util":                     storage.Gauge(math.Inf(1)),
+			"dup-metric":                   1,
+			`temp{room="hall"}`:            20,
+			`temp{room="kitchen \"new\""}`: 21.5,
+		},
+		Counter: storage.CounterCollection{
+			"PollCount":      3,
+			"requests_total": 7,
+			"dup_metric":     2,
+			`requests_total{code="200",http.method="GET"}`: 4,
+		},
+		Histogram: storage.HistogramCollection{
+			`latency{route="/"}`: {
+				Buckets: []float64{0.1, 1},
+				Counts:  []uint64{1, 2, 1},
+				Sum:     3.25,
+				Count:   4,
+			},
+		},
+		Summary: storage.SummaryCollection{"rpc": rpc},
+		Set:     storage.SetCollection{`users{region="eu"}`: users},
+	}
+
+	tests := []struct {
+		name        string
+		want        string
+		openMetrics bool
+	}{
+		{
+			name: "Text format",
+			want: "# HELP HeapAlloc gauge metric HeapAlloc\n" +
+				"# TYPE HeapAlloc gauge\n" +
+				"HeapAlloc 1024.5\n" +
+				"# HELP PollCount counter metric PollCount\n" +
+				"# TYPE PollCount counter\n" +
+				"PollCount 3\n" +
+				"# HELP cpu_util gauge metric cpu.util\n" +
+				"# TYPE cpu_util gauge\n" +
+				"cpu_util +Inf\n" +
+				"# HELP dup_metric gauge metric dup-metric\n" +
+				"# TYPE dup_metric gauge\n" +
+				"dup_metric 1\n" +
+				"# HELP latency histogram metric latency\n" +
+				"# TYPE latency histogram\n" +
+				`latency_bucket{le="0.1",route="/"} 1` + "\n" +
+				`latency_bucket{le="1",route="/"} 3` + "\n" +
+				`latency_bucket{le="+Inf",route="/"} 4` + "\n" +
+				`latency_sum{route="/"} 3.25` + "\n" +
+				`latency_count{route="/"} 4` + "\n" +
+				"# HELP requests_total counter metric requests_total\n" +
+				"# TYPE requests_total counter\n" +
+				"requests_total 7\n" +
+				`requests_total{code="200",http_method="GET"} 4` + "\n" +
+				"# HELP rpc summary metric rpc\n" +
+				"# TYPE rpc summary\n" +
+				`rpc{quantile="0.5"} 2` + "\n" +
+				`rpc{quantile="0.9"} 2.8` + "\n" +
+				`rpc{quantile="0.99"} 2.98` + "\n" +
+				"rpc_sum 6\n" +
+				"rpc_count 3\n" +
+				"# HELP temp gauge metric temp\n" +
+				"# TYPE temp gauge\n" +
+				`temp{room="hall"} 20` + "\n" +
+				`temp{room="kitchen \"new\""} 21.5` + "\n" +
+				"# HELP users gauge metric users\n" +
+				"# TYPE users gauge\n" +
+				`users{region="eu"} 2` + "\n",
+		},
+		{
+			name:        "OpenMetrics format",
+			openMetrics: true,
+			want: "# HELP HeapAlloc gauge metric HeapAlloc\n" +
+				"# TYPE HeapAlloc gauge\n" +
+				"HeapAlloc 1024.5\n" +
+				"# HELP PollCount counter metric PollCount\n" +
+				"# TYPE PollCount counter\n" +
+				"PollCount_total 3\n" +
+				"# HELP cpu_util gauge metric cpu.util\n" +
+				"# TYPE cpu_util gauge\n" +
+				"cpu_util +Inf\n" +
+				"# HELP dup_metric gauge metric dup-metric\n" +
+				"# TYPE dup_metric gauge\n" +
+				"dup_metric 1\n" +
+				"# HELP latency histogram metric latency\n" +
+				"# TYPE latency histogram\n" +
+				`latency_bucket{le="0.1",route="/"} 1` + "\n" +
+				`latency_bucket{le="1",route="/"} 3` + "\n" +
+				`latency_bucket{le="+Inf",route="/"} 4` + "\n" +
+				`latency_sum{route="/"} 3.25` + "\n" +
+				`latency_count{route="/"} 4` + "\n" +
+				"# HELP requests counter metric requests_total\n" +
+				"# TYPE requests counter\n" +
+				"requests_total 7\n" +
+				`requests_total{code="200",http_method="GET"} 4` + "\n" +
+				"# HELP rpc summary metric rpc\n" +
+				"# TYPE rpc summary\n" +
+				`rpc{quantile="0.5"} 2` + "\n" +
+				`rpc{quantile="0.9"} 2.8` + "\n" +
+				`rpc{quantile="0.99"} 2.98` + "\n" +
+				"rpc_sum 6\n" +
+				"rpc_count 3\n" +
+				"# HELP temp gauge metric temp\n" +
+				"# TYPE temp gauge\n" +
+				`temp{room="hall"} 20` + "\n" +
+				`temp{room="kitchen \"new\""} 21.5` + "\n" +
+				"# HELP users gauge metric users\n" +
+				"# TYPE users gauge\n" +
+				`users{region="eu"} 2` + "\n" +
+				"# EOF\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(db, tt.openMetrics))
+		})
+	}
+}
+
+func TestRenderMetadata(t *testing.T) {
+	precision := 0
+	db := storage.Database{
+		Gauge: storage.GaugeCollection{
+			"HeapAlloc":                1024.5,
+			`heap_bytes{host="a"}`:     2048,
+			"request_duration_seconds": 0.25,
+		},
+		Metadata: storage.MetadataCollection{
+			"HeapAlloc":                {Unit: "bytes", Precision: &precision, Help: "Heap size\nin bytes"},
+			"heap_bytes":               {Unit: "bytes"},
+			"request_duration_seconds": {Unit: "ms"},
+		},
+	}
+
+	tests := []struct {
+		name        string
+		want        string
+		openMetrics bool
+	}{
+		{
+			name: "Text format",
+			want: "# HELP HeapAlloc Heap size\\nin bytes\n" +
+				"# TYPE HeapAlloc gauge\n" +
+				"HeapAlloc 1024.5\n" +
+				"# HELP heap_bytes gauge metric heap_bytes\n" +
+				"# TYPE heap_bytes gauge\n" +
+				`heap_bytes{host="a"} 2048` + "\n" +
+				"# HELP request_duration_seconds gauge metric request_duration_seconds\n" +
+				"# TYPE request_duration_seconds gauge\n" +
+				"request_duration_seconds 0.25\n",
+		},
+		{
+			name:        "OpenMetrics",
+			openMetrics: true,
+			want: "# HELP HeapAlloc Heap size\\nin bytes\n" +
+				"# TYPE HeapAlloc gauge\n" +
+				"HeapAlloc 1024.5\n" +
+				"# HELP heap_bytes gauge metric heap_bytes\n" +
+				"# TYPE heap_bytes gauge\n" +
+				"# UNIT heap_bytes bytes\n" +
+				`heap_bytes{host="a"} 2048` + "\n" +
+				"# HELP request_duration_seconds gauge metric request_duration_seconds\n" +
+				"# TYPE request_duration_seconds gauge\n" +
+				"request_duration_seconds 0.25\n" +
+				"# EOF\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Render(db, tt.openMetrics))
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package query
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultRange = time.Hour
+	defaultStep  = time.Minute
+	defaultAgg   = "last"
+)
+
+// parseTime разбирает время в формате RFC3339 или unix-время в секундах.
+func parseTime(val string, def time.Time) (time.Time, error) {
+	if val == "" {
+		return def, nil
+	}
+
+	if sec, err := strconv.ParseFloat(val, 64); err == nil {
+		return time.UnixMilli(int64(sec * 1000)), nil
+	}
+
+	return time.Parse(time.RFC3339, val)
+}
+
+// parseStep разбирает шаг в формате time.Duration (30s, 1m) или в секундах.
+func parseStep(val string) (time.Duration, error) {
+	if val == "" {
+		return defaultStep, nil
+	}
+
+	if sec, err := strconv.ParseFloat(val, 64); err == nil {
+		return time.Duration(sec * float64(time.Second)), nil
+	}
+
+	return time.ParseDuration(val)
+}
+
+func parseQuery(r *http.Request) (models.RangeQuery, error) {
+	params := r.URL.Query()
+
+	to, err := parseTime(params.Get("to"), time.Now())
+	if err != nil {
+		return models.RangeQuery{}, fmt.Errorf("incorrect to: %w", err)
+	}
+
+	from, err := parseTime(params.Get("from"), to.Add(-defaultRange))
+	if err != nil {
+		return models.RangeQuery{}, fmt.Errorf("incorrect from: %w", err)
+	}
+
+	step, err := parseStep(params.Get("step"))
+	if err != nil {
+		return models.RangeQuery{}, fmt.Errorf("incorrect step: %w", err)
+	}
+
+	agg := params.Get("agg")
+	if agg == "" {
+		agg = defaultAgg
+	}
+
+	return models.RangeQuery{
+		ID:     chi.URLParam(r, "name"),
+		MType:  chi.URLParam(r, "type"),
+		Labels: models.LabelsFromQuery(params, "from", "to", "step", "agg"),
+		From:   from,
+		To:     to,
+		Step:   step,
+		Agg:    agg,
+	}, nil
+}
+
+func isBadRequest(err error) bool {
+	return errors.Is(err, merrors.ErrIncorrectMetricType) ||
+		errors.Is(err, merrors.ErrIncorrectTimeRange) ||
+		errors.Is(err, merrors.ErrIncorrectStep) ||
+		errors.Is(err, merrors.ErrTooManyPoints) ||
+		errors.Is(err, merrors.ErrIncorrectAggregation)
+}
+
+// Get — хендлер для получения значений метрики за период.
+// Тип и имя метрики берутся из URL, параметры from, to, step и agg — из query string.
+// Остальные параметры query string считаются метками серии.
+func Get(s MetricQuerier) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, err := parseQuery(r)
+		if err != nil {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		res, err := s.QueryRange(r.Context(), q)
+		if err != nil {
+			if isBadRequest(err) {
+				http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			logger.Log.Error("/query: error while querying metric", zap.Error(err))
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		body, err := json.Marshal(res)
+		if err != nil {
+			logger.Log.Error("/query: error while marshal json")
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
@@ -0,0 +1,143 @@
+package query
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	to := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	from := to.Add(-time.Hour)
+	result := models.RangeResult{
+		ID:    "HeapAlloc",
+		MType: "gauge",
+		From:  from,
+		To:    to,
+		Step:  60,
+		Agg:   "avg",
+		Points: []storage.Sample{
+			{Time: from, Value: 1.5},
+		},
+	}
+
+	tests := []struct {
+		serviceErr error
+		wantQuery  *models.RangeQuery
+		name       string
+		url        string
+		wantCode   int
+	}{
+		{
+			name: "RFC3339 time and duration step",
+			url:  "/?from=2025-01-01T11:00:00Z&to=2025-01-01T12:00:00Z&step=1m&agg=avg",
+			wantQuery: &models.RangeQuery{
+				ID:    "HeapAlloc",
+				MType: "gauge",
+				From:  from,
+				To:    to,
+				Step:  time.Minute,
+				Agg:   "avg",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Unix time and step in seconds",
+			url:  "/?from=1735729200&to=1735732800&step=60",
+			wantQuery: &models.RangeQuery{
+				ID:    "HeapAlloc",
+				MType: "gauge",
+				From:  time.Unix(1735729200, 0),
+				To:    time.Unix(1735732800, 0),
+				Step:  time.Minute,
+				Agg:   "last",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name: "Other parameters are labels",
+			url:  "/?from=1735729200&to=1735732800&host=web1&region=eu",
+			wantQuery: &models.RangeQuery{
+				ID:     "HeapAlloc",
+				MType:  "gauge",
+				Labels: storage.Labels{"host": "web1", "region": "eu"},
+				From:   time.Unix(1735729200, 0),
+				To:     time.Unix(1735732800, 0),
+				Step:   time.Minute,
+				Agg:    "last",
+			},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Incorrect from",
+			url:      "/?from=yesterday",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Incorrect step",
+			url:      "/?step=often",
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Service validation error",
+			url:        "/?agg=median",
+			serviceErr: merrors.ErrIncorrectAggregation,
+			wantCode:   http.StatusBadRequest,
+		},
+		{
+			name:       "Service internal error",
+			url:        "/",
+			serviceErr: merrors.ErrMocked,
+			wantCode:   http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricQuerier(t)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", "HeapAlloc")
+			rctx.URLParams.Add("type", "gauge")
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+			r = r.WithContext(ctx)
+
+			switch {
+			case tt.wantQuery != nil:
+				s.EXPECT().QueryRange(ctx, *tt.wantQuery).Return(result, nil)
+			case tt.serviceErr != nil:
+				s.EXPECT().QueryRange(ctx, mock.Anything).
+					Return(models.RangeResult{}, tt.serviceErr)
+			}
+
+			Get(s)(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+			var got models.RangeResult
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+			assert.Equal(t, result.Points, got.Points)
+			assert.Equal(t, result.Step, got.Step)
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package query
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/models"
+)
+
+// MetricQuerier — интерфейс для получения значений метрики за период.
+type MetricQuerier interface {
+	QueryRange(ctx context.Context, q models.RangeQuery) (models.RangeResult, error)
+}
@@ -0,0 +1,210 @@
+package remotewrite
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Типы метрик из MetricMetadata.
+const (
+	metaUnknown = iota
+	metaCounter
+	metaGauge
+	metaHistogram
+	metaGaugeHistogram
+	metaSummary
+)
+
+type sample struct {
+	Value     float64
+	Timestamp int64
+}
+
+type timeSeries struct {
+	Labels  map[string]string
+	Samples []sample
+}
+
+type metadata struct {
+	Family string
+	Type   int
+}
+
+// writeRequest — prometheus.WriteRequest, из которого читаются только нужные поля.
+type writeRequest struct {
+	Series   []timeSeries
+	Metadata []metadata
+}
+
+// fieldFunc обрабатывает поле сообщения и возвращает число прочитанных байт.
+// Если вернуть 0, поле будет пропущено.
+type fieldFunc func(num protowire.Number, typ protowire.Type, b []byte) (int, error)
+
+// walk обходит поля protobuf-сообщения.
+func walk(b []byte, fn fieldFunc) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, err := fn(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			n = protowire.ConsumeFieldValue(num, typ, b)
+		}
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+	}
+
+	return nil
+}
+
+// consumeBytes читает значение length-delimited поля.
+func consumeBytes(typ protowire.Type, b []byte) ([]byte, int, error) {
+	if typ != protowire.BytesType {
+		return nil, 0, fmt.Errorf("unexpected wire type %d", typ)
+	}
+
+	v, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return nil, 0, protowire.ParseError(n)
+	}
+
+	return v, n, nil
+}
+
+func decodeLabel(b []byte) (name, value string, err error) {
+	err = walk(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num != 1 && num != 2 {
+			return 0, nil
+		}
+
+		v, n, err := consumeBytes(typ, b)
+		if err != nil {
+			return 0, err
+		}
+
+		if num == 1 {
+			name = string(v)
+		} else {
+			value = string(v)
+		}
+
+		return n, nil
+	})
+
+	return name, value, err
+}
+
+func decodeSample(b []byte) (sample, error) {
+	var s sample
+	err := walk(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			v, n := protowire.ConsumeFixed64(b)
+			s.Value = math.Float64frombits(v)
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			s.Timestamp = int64(v)
+			return n, nil
+		}
+
+		return 0, nil
+	})
+
+	return s, err
+}
+
+func decodeTimeSeries(b []byte) (timeSeries, error) {
+	ts := timeSeries{Labels: map[string]string{}}
+	err := walk(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num != 1 && num != 2 {
+			return 0, nil
+		}
+
+		v, n, err := consumeBytes(typ, b)
+		if err != nil {
+			return 0, err
+		}
+
+		if num == 1 {
+			name, value, err := decodeLabel(v)
+			if err != nil {
+				return 0, fmt.Errorf("label: %w", err)
+			}
+			ts.Labels[name] = value
+		} else {
+			s, err := decodeSample(v)
+			if err != nil {
+				return 0, fmt.Errorf("sample: %w", err)
+			}
+			ts.Samples = append(ts.Samples, s)
+		}
+
+		return n, nil
+	})
+
+	return ts, err
+}
+
+func decodeMetadata(b []byte) (metadata, error) {
+	var m metadata
+	err := walk(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, n := protowire.ConsumeVarint(b)
+			m.Type = int(v)
+			return n, nil
+		case num == 2:
+			v, n, err := consumeBytes(typ, b)
+			m.Family = string(v)
+			return n, err
+		}
+
+		return 0, nil
+	})
+
+	return m, err
+}
+
+// decodeWriteRequest разбирает несжатое тело запроса remote_write.
+func decodeWriteRequest(b []byte) (writeRequest, error) {
+	var req writeRequest
+	err := walk(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num != 1 && num != 3 {
+			return 0, nil
+		}
+
+		v, n, err := consumeBytes(typ, b)
+		if err != nil {
+			return 0, err
+		}
+
+		if num == 1 {
+			ts, err := decodeTimeSeries(v)
+			if err != nil {
+				return 0, fmt.Errorf("timeseries: %w", err)
+			}
+			req.Series = append(req.Series, ts)
+		} else {
+			m, err := decodeMetadata(v)
+			if err != nil {
+				return 0, fmt.Errorf("metadata: %w", err)
+			}
+			req.Metadata = append(req.Metadata, m)
+		}
+
+		return n, nil
+	})
+
+	return req, err
+}
@@ -0,0 +1,120 @@
+package remotewrite
+
+import (
+	"math"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// encodeWriteRequest собирает WriteRequest так же, как это делает Prometheus.
+func encodeWriteRequest(req writeRequest) []byte {
+	var b []byte
+	for _, ts := range req.Series {
+		var tsb []byte
+
+		names := make([]string, 0, len(ts.Labels))
+		for name := range ts.Labels {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			var lb []byte
+			lb = protowire.AppendTag(lb, 1, protowire.BytesType)
+			lb = protowire.AppendString(lb, name)
+			lb = protowire.AppendTag(lb, 2, protowire.BytesType)
+			lb = protowire.AppendString(lb, ts.Labels[name])
+
+			tsb = protowire.AppendTag(tsb, 1, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, lb)
+		}
+
+		for _, s := range ts.Samples {
+			var sb []byte
+			sb = protowire.AppendTag(sb, 1, protowire.Fixed64Type)
+			sb = protowire.AppendFixed64(sb, math.Float64bits(s.Value))
+			sb = protowire.AppendTag(sb, 2, protowire.VarintType)
+			sb = protowire.AppendVarint(sb, uint64(s.Timestamp))
+
+			tsb = protowire.AppendTag(tsb, 2, protowire.BytesType)
+			tsb = protowire.AppendBytes(tsb, sb)
+		}
+
+		b = protowire.AppendTag(b, 1, protowire.BytesType)
+		b = protowire.AppendBytes(b, tsb)
+	}
+
+	for _, m := range req.Metadata {
+		var mb []byte
+		mb = protowire.AppendTag(mb, 1, protowire.VarintType)
+		mb = protowire.AppendVarint(mb, uint64(m.Type))
+		mb = protowire.AppendTag(mb, 2, protowire.BytesType)
+		mb = protowire.AppendString(mb, m.Family)
+		// help — должен быть пропущен
+		mb = protowire.AppendTag(mb, 4, protowire.BytesType)
+		mb = protowire.AppendString(mb, "help text")
+
+		b = protowire.AppendTag(b, 3, protowire.BytesType)
+		b = protowire.AppendBytes(b, mb)
+	}
+
+	return b
+}
+
+func TestDecodeWriteRequest(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []byte
+		want    writeRequest
+		wantErr bool
+	}{
+		{
+			name: "Series and metadata",
+			body: encodeWriteRequest(writeRequest{
+				Series: []timeSeries{{
+					Labels:  map[string]string{"__name__": "up", "job": "api"},
+					Samples: []sample{{Value: 1, Timestamp: 1000}, {Value: 0.5, Timestamp: 2000}},
+				}},
+				Metadata: []metadata{{Family: "up", Type: metaGauge}},
+			}),
+			want: writeRequest{
+				Series: []timeSeries{{
+					Labels:  map[string]string{"__name__": "up", "job": "api"},
+					Samples: []sample{{Value: 1, Timestamp: 1000}, {Value: 0.5, Timestamp: 2000}},
+				}},
+				Metadata: []metadata{{Family: "up", Type: metaGauge}},
+			},
+		},
+		{
+			name: "Empty body",
+			body: []byte{},
+			want: writeRequest{},
+		},
+		{
+			name:    "Truncated body",
+			body:    encodeWriteRequest(writeRequest{Series: []timeSeries{{Labels: map[string]string{"a": "b"}}}})[:5],
+			wantErr: true,
+		},
+		{
+			name:    "Incorrect wire type of timeseries",
+			body:    protowire.AppendVarint(protowire.AppendTag(nil, 1, protowire.VarintType), 1),
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeWriteRequest(tt.body)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
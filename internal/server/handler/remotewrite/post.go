@@ -0,0 +1,225 @@
+package remotewrite
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/services/cumulative"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/golang/snappy"
+	"go.uber.org/zap"
+)
+
+const nameLabel = "__name__"
+
+// receiver хранит состояние между запросами: типы семейств из метаданных
+// и последние накопительные значения счетчиков.
+type receiver struct {
+	s        MetricService
+	tracker  *cumulative.Tracker
+	families map[string]int
+	mu       sync.RWMutex
+}
+
+func (rc *receiver) learn(list []metadata) {
+	if len(list) == 0 {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	for _, m := range list {
+		rc.families[m.Family] = m.Type
+	}
+}
+
+// familyType ищет тип семейства метрики, отбрасывая суффиксы серий.
+func (rc *receiver) familyType(name string) (family string, typ int) {
+	rc.mu.RLock()
+	defer rc.mu.RUnlock()
+
+	if t, ok := rc.families[name]; ok {
+		return name, t
+	}
+
+	for _, suffix := range []string{"_total", "_bucket", "_count", "_sum"} {
+		family, ok := strings.CutSuffix(name, suffix)
+		if !ok {
+			continue
+		}
+
+		if t, ok := rc.families[family]; ok {
+			return family, t
+		}
+	}
+
+	return name, metaUnknown
+}
+
+// isCounter определяет, является ли серия накопительным счетчиком.
+// Без метаданных тип угадывается по суффиксу имени.
+func (rc *receiver) isCounter(name string) bool {
+	family, typ := rc.familyType(name)
+
+	switch typ {
+	case metaCounter:
+		return true
+	case metaHistogram, metaSummary:
+		return name == family+"_bucket" || name == family+"_count"
+	case metaUnknown:
+		return strings.HasSuffix(name, "_total") ||
+			strings.HasSuffix(name, "_bucket") ||
+			strings.HasSuffix(name, "_count")
+	}
+
+	return false
+}
+
+// storedCounter возвращает текущее значение счетчика или 0, если его еще нет.
+func (rc *receiver) storedCounter(ctx context.Context, series models.Metrics) storage.Counter {
+	series.MType = "counter"
+	m, err := rc.s.GetMetricJSON(ctx, series)
+	if err != nil || m.Delta == nil {
+		return 0
+	}
+
+	return *m.Delta
+}
+
+// convert переводит серии в метрики. Для gauge берется последнее значение,
+// для counter — сумма приростов по всем значениям серии.
+// NaN (в том числе stale-маркеры Prometheus) пропускаются.
+// Приросты счетчиков считаются в пачке batch.
+func (rc *receiver) convert(ctx context.Context, batch *cumulative.Batch, series []timeSeries) []models.Metrics {
+	list := make([]models.Metrics, 0, len(series))
+
+	for _, ts := range series {
+		name := ts.Labels[nameLabel]
+		if name == "" {
+			continue
+		}
+		delete(ts.Labels, nameLabel)
+		m := models.Metrics{ID: name}
+		if len(ts.Labels) > 0 {
+			m.Labels = ts.Labels
+		}
+		key := m.Key()
+
+		if rc.isCounter(name) {
+			var (
+				delta storage.Counter
+				found bool
+			)
+			for _, smp := range ts.Samples {
+				if math.IsNaN(smp.Value) {
+					continue
+				}
+				found = true
+				delta += batch.Delta(ctx, key, smp.Value, func() storage.Counter {
+					return rc.storedCounter(ctx, m)
+				})
+			}
+
+			if found {
+				m.MType, m.Delta = "counter", &delta
+				list = append(list, m)
+			}
+
+			continue
+		}
+
+		for i := len(ts.Samples) - 1; i >= 0; i-- {
+			if math.IsNaN(ts.Samples[i].Value) {
+				continue
+			}
+
+			value := storage.Gauge(ts.Samples[i].Value)
+			m.MType, m.Value = "gauge", &value
+			list = append(list, m)
+
+			break
+		}
+	}
+
+	return list
+}
+
+// Post — хендлер Prometheus remote_write.
+// Принимает сжатый snappy protobuf WriteRequest, метки серии
+// (кроме __name__) сохраняются как метки метрики.
+// Если задан key, сжатое тело проверяется по заголовку HashSHA256.
+func Post(s MetricService, key string) http.HandlerFunc {
+	rc := &receiver{
+		s:        s,
+		tracker:  cumulative.New(),
+		families: map[string]int{},
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Encoding") != "snappy" {
+			http.Error(w, "Bad request: incorrect Content-Encoding", http.StatusBadRequest)
+			return
+		}
+
+		defer r.Body.Close()
+		compressed, err := io.ReadAll(r.Body)
+		if err != nil {
+			logger.Log.Error("/api/v1/write: body reading error", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		if key != "" && r.Header.Get("HashSHA256") != crypto.GenerateHMAC(compressed, key) {
+			http.Error(w, "Bad request: incorrect HashSHA256", http.StatusBadRequest)
+			return
+		}
+
+		body, err := snappy.Decode(nil, compressed)
+		if err != nil {
+			http.Error(w, "Bad request: incorrect snappy body", http.StatusBadRequest)
+			return
+		}
+
+		req, err := decodeWriteRequest(body)
+		if err != nil {
+			logger.Log.Info("/api/v1/write: decode error", zap.Error(err))
+			http.Error(w, "Bad request: incorrect protobuf body", http.StatusBadRequest)
+			return
+		}
+
+		rc.learn(req.Metadata)
+
+		batch := rc.tracker.Begin()
+		list := rc.convert(r.Context(), batch, req.Series)
+		err = s.UpdateMany(r.Context(), list)
+		if err != nil {
+			// повтор запроса должен получить те же приросты
+			batch.Rollback()
+		}
+
+		switch {
+		case errors.Is(err, merrors.ErrSeriesQuotaExceeded):
+			http.Error(w, "Too many series: "+err.Error(), http.StatusTooManyRequests)
+			return
+		case errors.Is(err, merrors.ErrIncorrectTenantKey):
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			logger.Log.Error("/api/v1/write: error while updating metrics", zap.Error(err))
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
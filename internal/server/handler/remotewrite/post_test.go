@@ -0,0 +1,267 @@
+package remotewrite
+
+import (
+	"bytes"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func gaugeMetric(id string, v storage.Gauge, labels ...string) models.Metrics {
+	return models.Metrics{ID: id, MType: "gauge", Value: &v, Labels: labelsOf(labels...)}
+}
+
+func counterMetric(id string, v storage.Counter, labels ...string) models.Metrics {
+	return models.Metrics{ID: id, MType: "counter", Delta: &v, Labels: labelsOf(labels...)}
+}
+
+func labelsOf(kv ...string) storage.Labels {
+	if len(kv) == 0 {
+		return nil
+	}
+
+	labels := storage.Labels{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		labels[kv[i]] = kv[i+1]
+	}
+
+	return labels
+}
+
+func TestPost(t *testing.T) {
+	stored := storage.Counter(3)
+
+	tests := []struct {
+		serviceErr error
+		name       string
+		encoding   string
+		body       []byte
+		want       []models.Metrics
+		wantCode   int
+	}{
+		{
+			name:     "Gauge takes last value",
+			encoding: "snappy",
+			body: snappy.Encode(nil, encodeWriteRequest(writeRequest{
+				Series: []timeSeries{{
+					Labels:  map[string]string{"__name__": "temperature", "room": "kitchen"},
+					Samples: []sample{{Value: 20}, {Value: 21.5}, {Value: math.NaN()}},
+				}},
+			})),
+			want:     []models.Metrics{gaugeMetric("temperature", 21.5, "room", "kitchen")},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Counter by suffix starts from stored value",
+			encoding: "snappy",
+			body: snappy.Encode(nil, encodeWriteRequest(writeRequest{
+				Series: []timeSeries{{
+					Labels:  map[string]string{"__name__": "requests_total"},
+					Samples: []sample{{Value: 10}, {Value: 12}},
+				}},
+			})),
+			want:     []models.Metrics{counterMetric("requests_total", 9)},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Type from metadata",
+			encoding: "snappy",
+			body: snappy.Encode(nil, encodeWriteRequest(writeRequest{
+				Series: []timeSeries{
+					{
+						Labels:  map[string]string{"__name__": "processed"},
+						Samples: []sample{{Value: 5}},
+					},
+					{
+						Labels:  map[string]string{"__name__": "queue_count"},
+						Samples: []sample{{Value: 7}},
+					},
+				},
+				Metadata: []metadata{
+					{Family: "processed", Type: metaCounter},
+					{Family: "queue_count", Type: metaGauge},
+				},
+			})),
+			want: []models.Metrics{
+				counterMetric("processed", 2),
+				gaugeMetric("queue_count", 7),
+			},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Series without name and stale markers are skipped",
+			encoding: "snappy",
+			body: snappy.Encode(nil, encodeWriteRequest(writeRequest{
+				Series: []timeSeries{
+					{
+						Labels:  map[string]string{"job": "api"},
+						Samples: []sample{{Value: 1}},
+					},
+					{
+						Labels:  map[string]string{"__name__": "up"},
+						Samples: []sample{{Value: math.Float64frombits(0x7ff0000000000002)}},
+					},
+				},
+			})),
+			want:     []models.Metrics{},
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Incorrect Content-Encoding",
+			body:     encodeWriteRequest(writeRequest{}),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Incorrect snappy body",
+			encoding: "snappy",
+			body:     []byte{0xff, 0xff, 0xff},
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Incorrect protobuf body",
+			encoding: "snappy",
+			body:     snappy.Encode(nil, []byte{0x0a, 0x10, 0x01}),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:       "Service error",
+			encoding:   "snappy",
+			serviceErr: merrors.ErrMocked,
+			body: snappy.Encode(nil, encodeWriteRequest(writeRequest{
+				Series: []timeSeries{{
+					Labels:  map[string]string{"__name__": "up"},
+					Samples: []sample{{Value: 1}},
+				}},
+			})),
+			want:     []models.Metrics{gaugeMetric("up", 1)},
+			wantCode: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricService(t)
+			s.EXPECT().GetMetricJSON(mock.Anything, mock.Anything).
+				Return(models.Metrics{Delta: &stored}, nil).Maybe()
+			if tt.want != nil {
+				s.EXPECT().UpdateMany(mock.Anything, tt.want).Return(tt.serviceErr).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/x-protobuf")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			w := httptest.NewRecorder()
+
+			Post(s, "")(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+		})
+	}
+}
+
+func TestPostRetry(t *testing.T) {
+	stored := storage.Counter(3)
+	body := func(v float64) []byte {
+		return snappy.Encode(nil, encodeWriteRequest(writeRequest{
+			Series: []timeSeries{{
+				Labels:  map[string]string{"__name__": "requests_total"},
+				Samples: []sample{{Value: v}},
+			}},
+		}))
+	}
+
+	s := NewMockMetricService(t)
+	s.EXPECT().GetMetricJSON(mock.Anything, mock.Anything).
+		Return(models.Metrics{Delta: &stored}, nil)
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("requests_total", 7)}).
+		Return(merrors.ErrMocked).Once()
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("requests_total", 7)}).
+		Return(nil).Once()
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("requests_total", 5)}).
+		Return(nil).Once()
+
+	h := Post(s, "")
+	for _, step := range []struct {
+		value    float64
+		wantCode int
+	}{
+		{value: 10, wantCode: http.StatusInternalServerError},
+		// повтор после ошибки не теряет прирост
+		{value: 10, wantCode: http.StatusNoContent},
+		{value: 15, wantCode: http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(body(step.value)))
+		req.Header.Set("Content-Encoding", "snappy")
+		w := httptest.NewRecorder()
+
+		h(w, req)
+
+		res := w.Result()
+		res.Body.Close()
+		assert.Equal(t, step.wantCode, res.StatusCode)
+	}
+}
+
+func TestPostHMAC(t *testing.T) {
+	const key = "secret"
+	body := snappy.Encode(nil, encodeWriteRequest(writeRequest{
+		Series: []timeSeries{{
+			Labels:  map[string]string{"__name__": "up"},
+			Samples: []sample{{Value: 1}},
+		}},
+	}))
+
+	tests := []struct {
+		name     string
+		hash     string
+		wantCode int
+	}{
+		{
+			name:     "Correct HashSHA256",
+			hash:     crypto.GenerateHMAC(body, key),
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "Incorrect HashSHA256",
+			hash:     crypto.GenerateHMAC(body, "other"),
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:     "Without HashSHA256",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricService(t)
+			if tt.wantCode == http.StatusNoContent {
+				s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{gaugeMetric("up", 1)}).Return(nil).Once()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/write", bytes.NewReader(body))
+			req.Header.Set("Content-Encoding", "snappy")
+			if tt.hash != "" {
+				req.Header.Set("HashSHA256", tt.hash)
+			}
+			w := httptest.NewRecorder()
+
+			Post(s, key)(w, req)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+		})
+	}
+}
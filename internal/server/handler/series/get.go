@@ -0,0 +1,49 @@
+package series
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/go-chi/chi/v5"
+	"go.uber.org/zap"
+)
+
+// Get — хендлер для получения всех серий метрики.
+// Тип и имя берутся из URL, фильтр по меткам — из параметра match
+// в формате {host="web1",region=~"eu-.*"}.
+func Get(s SeriesFinder) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		matchers, err := storage.ParseMatchers(r.URL.Query().Get("match"))
+		if err != nil {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		list, err := s.FindMetrics(r.Context(), chi.URLParam(r, "name"), chi.URLParam(r, "type"), matchers)
+		if err != nil {
+			if errors.Is(err, merrors.ErrIncorrectMatchers) {
+				http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
+			logger.Log.Error("/series: error while finding series", zap.Error(err))
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		body, err := json.Marshal(list)
+		if err != nil {
+			logger.Log.Error("/series: error while marshal json")
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}
+}
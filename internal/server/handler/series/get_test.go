@@ -0,0 +1,96 @@
+package series
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGet(t *testing.T) {
+	web1 := storage.Gauge(0.5)
+	web2 := storage.Gauge(0.7)
+	found := []models.Metrics{
+		{ID: "cpu", MType: "gauge", Value: &web1, Labels: storage.Labels{"host": "web1"}},
+		{ID: "cpu", MType: "gauge", Value: &web2, Labels: storage.Labels{"host": "web2"}},
+	}
+
+	tests := []struct {
+		serviceErr   error
+		name         string
+		url          string
+		wantMatchers int
+		wantCode     int
+		callService  bool
+	}{
+		{
+			name:        "Without matchers",
+			url:         "/",
+			callService: true,
+			wantCode:    http.StatusOK,
+		},
+		{
+			name:         "With matchers",
+			url:          "/?match=" + `{host=~"web.*",region!="us"}`,
+			callService:  true,
+			wantMatchers: 2,
+			wantCode:     http.StatusOK,
+		},
+		{
+			name:     "Incorrect matchers",
+			url:      "/?match=" + `{host=~"("}`,
+			wantCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Service internal error",
+			url:         "/",
+			callService: true,
+			serviceErr:  merrors.ErrMocked,
+			wantCode:    http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockSeriesFinder(t)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", "cpu")
+			rctx.URLParams.Add("type", "gauge")
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+			r = r.WithContext(ctx)
+
+			if tt.callService {
+				s.EXPECT().FindMetrics(ctx, "cpu", "gauge", mock.MatchedBy(func(m []storage.Matcher) bool {
+					return len(m) == tt.wantMatchers
+				})).Return(found, tt.serviceErr)
+			}
+
+			Get(s)(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+
+			assert.Equal(t, "application/json", res.Header.Get("Content-Type"))
+			var got []models.Metrics
+			require.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+			assert.Equal(t, found, got)
+		})
+	}
+}
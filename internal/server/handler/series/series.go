@@ -0,0 +1,13 @@
+package series
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// SeriesFinder — интерфейс для поиска серий метрики по меткам.
+type SeriesFinder interface {
+	FindMetrics(ctx context.Context, reqName string, reqType string, matchers []storage.Matcher) ([]models.Metrics, error)
+}
@@ -0,0 +1,143 @@
+package stream
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// WriteTimeout — время на отправку событий клиенту.
+	// Клиент, который не успевает их принять, отключается.
+	WriteTimeout = 10 * time.Second
+	// KeepAlive — период комментариев, по которым клиент и прокси видят, что поток жив.
+	KeepAlive = 15 * time.Second
+)
+
+var metricTypes = []string{"counter", "gauge", "histogram", "set", "summary"}
+
+// Get — хендлер потока изменений метрик в формате Server-Sent Events.
+// Параметр name отбирает метрики по началу имени, type — по типу.
+// Обновленная метрика отправляется событием update с текущим значением,
+// удаленная — событием delete без значения. Отставший клиент получает
+// событие error и отключается.
+func Get(s Watcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		mType := r.URL.Query().Get("type")
+		if mType != "" && !slices.Contains(metricTypes, mType) {
+			http.Error(w, "Bad request: "+merrors.ErrIncorrectMetricType.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := r.Context()
+		sub := s.Watch(ctx, hub.MetricFilter(r.URL.Query().Get("name"), mType))
+		defer sub.Close()
+
+		rc := http.NewResponseController(w)
+		defer rc.SetWriteDeadline(time.Time{})
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		if err := send(w, rc, []byte(": connected\n\n")); err != nil {
+			return
+		}
+
+		for {
+			waitCtx, cancel := context.WithTimeout(ctx, KeepAlive)
+			events, err := sub.Next(waitCtx)
+			cancel()
+
+			var buf bytes.Buffer
+			switch {
+			case errors.Is(err, merrors.ErrSlowConsumer):
+				logger.Log.Info("/stream: slow consumer disconnected", zap.String("remote", r.RemoteAddr))
+				writeEvent(&buf, "error", []byte(err.Error()))
+				send(w, rc, buf.Bytes())
+				return
+			case ctx.Err() != nil:
+				// клиент отключился
+				return
+			case err != nil:
+				buf.WriteString(": ping\n\n")
+			}
+
+			for _, e := range events {
+				name, data, ok := metricEvent(ctx, s, e)
+				if ok {
+					writeEvent(&buf, name, data)
+				}
+			}
+
+			if buf.Len() == 0 {
+				continue
+			}
+			if err = send(w, rc, buf.Bytes()); err != nil {
+				logger.Log.Info("/stream: client disconnected", zap.Error(err))
+				return
+			}
+		}
+	}
+}
+
+// send отправляет b клиенту, не дольше WriteTimeout.
+func send(w http.ResponseWriter, rc *http.ResponseController, b []byte) error {
+	err := rc.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	if err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+
+	if _, err = w.Write(b); err != nil {
+		return err
+	}
+
+	return rc.Flush()
+}
+
+func writeEvent(buf *bytes.Buffer, name string, data []byte) {
+	fmt.Fprintf(buf, "event: %s\ndata: %s\n\n", name, data)
+}
+
+// metricEvent возвращает имя и данные события об изменении метрики.
+// ok == false, если метрика не найдена, например удалена после изменения.
+func metricEvent(ctx context.Context, s Watcher, e hub.Event) (string, []byte, bool) {
+	name, labels, err := storage.ParseSeriesKey(e.Key)
+	if err != nil {
+		return "", nil, false
+	}
+	if len(labels) == 0 {
+		labels = nil
+	}
+
+	event := "delete"
+	m := models.Metrics{ID: name, MType: e.MType, Labels: labels}
+	if !e.Deleted {
+		event = "update"
+		m, err = s.GetMetricJSON(ctx, m)
+		if err != nil {
+			return "", nil, false
+		}
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		logger.Log.Error("/stream: error while marshal json", zap.Error(err))
+		return "", nil, false
+	}
+
+	return event, data, true
+}
@@ -0,0 +1,173 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// watch подписывает на h и сообщает о подписке в watching.
+func watch(h *hub.Hub, watching chan struct{}) func(context.Context, hub.Filter) *hub.Subscription {
+	return func(_ context.Context, filter hub.Filter) *hub.Subscription {
+		defer close(watching)
+		return h.Subscribe("", filter)
+	}
+}
+
+// connect открывает поток url и ждет подписки.
+func connect(t *testing.T, url string, watching chan struct{}) *bufio.Reader {
+	t.Helper()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	require.NoError(t, err)
+	res, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+
+	select {
+	case <-watching:
+	case <-ctx.Done():
+		t.Fatal("stream did not subscribe")
+	}
+
+	return bufio.NewReader(res.Body)
+}
+
+// nextEvent читает следующее событие потока, пропуская комментарии.
+func nextEvent(t *testing.T, r *bufio.Reader) (string, string) {
+	t.Helper()
+
+	var event, data string
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && event != "":
+			return event, data
+		case strings.HasPrefix(line, "event: "):
+			event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestGet(t *testing.T) {
+	h := hub.New()
+	watching := make(chan struct{})
+	val := storage.Gauge(2)
+
+	s := NewMockWatcher(t)
+	s.EXPECT().Watch(mock.Anything, mock.Anything).RunAndReturn(watch(h, watching))
+	s.EXPECT().GetMetricJSON(mock.Anything, models.Metrics{ID: "HeapAlloc", MType: "gauge"}).
+		Return(models.Metrics{ID: "HeapAlloc", MType: "gauge", Value: &val}, nil)
+	s.EXPECT().GetMetricJSON(mock.Anything, models.Metrics{ID: "HeapInuse", MType: "gauge"}).
+		Return(models.Metrics{}, merrors.ErrNotFoundMetric)
+
+	ts := httptest.NewServer(Get(s))
+	t.Cleanup(ts.Close)
+	r := connect(t, ts.URL+"?name=Heap&type=gauge", watching)
+
+	h.Publish(
+		hub.Event{Key: "Alloc", MType: "gauge"},
+		hub.Event{Key: "HeapAlloc", MType: "counter"},
+		hub.Event{Key: "HeapInuse", MType: "gauge"},
+		hub.Event{Key: "HeapAlloc", MType: "gauge"},
+	)
+
+	event, data := nextEvent(t, r)
+	assert.Equal(t, "update", event)
+	assert.JSONEq(t, `{"id":"HeapAlloc","type":"gauge","value":2}`, data)
+
+	h.Publish(hub.Event{Key: `HeapAlloc{host="web1"}`, MType: "gauge", Deleted: true})
+
+	event, data = nextEvent(t, r)
+	assert.Equal(t, "delete", event)
+	assert.JSONEq(t, `{"id":"HeapAlloc","type":"gauge","labels":{"host":"web1"}}`, data)
+}
+
+func TestGet_SlowConsumer(t *testing.T) {
+	h := hub.New()
+	watching := make(chan struct{})
+	reading := make(chan struct{})
+	release := make(chan struct{})
+	val := storage.Gauge(1)
+
+	s := NewMockWatcher(t)
+	s.EXPECT().Watch(mock.Anything, mock.Anything).RunAndReturn(watch(h, watching))
+	s.EXPECT().GetMetricJSON(mock.Anything, models.Metrics{ID: "Alloc", MType: "gauge"}).
+		RunAndReturn(func(context.Context, models.Metrics) (models.Metrics, error) {
+			// клиент занят, пока изменения копятся в подписке
+			close(reading)
+			<-release
+			return models.Metrics{ID: "Alloc", MType: "gauge", Value: &val}, nil
+		})
+
+	ts := httptest.NewServer(Get(s))
+	t.Cleanup(ts.Close)
+	r := connect(t, ts.URL, watching)
+
+	h.Publish(hub.Event{Key: "Alloc", MType: "gauge"})
+	<-reading
+
+	events := make([]hub.Event, 0, hub.MaxPending+1)
+	for i := range hub.MaxPending + 1 {
+		events = append(events, hub.Event{Key: "Metric" + strconv.Itoa(i), MType: "counter"})
+	}
+	h.Publish(events...)
+	close(release)
+
+	event, _ := nextEvent(t, r)
+	assert.Equal(t, "update", event)
+
+	event, data := nextEvent(t, r)
+	assert.Equal(t, "error", event)
+	assert.Equal(t, merrors.ErrSlowConsumer.Error(), data)
+
+	_, err := r.ReadString('\n')
+	assert.Error(t, err)
+}
+
+func TestGet_IncorrectType(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+	}{
+		{name: "Unknown type", url: "/?type=unknown"},
+		{name: "Type in another case", url: "/?type=Gauge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockWatcher(t)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.url, nil)
+			Get(s)(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		})
+	}
+}
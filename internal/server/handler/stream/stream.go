@@ -0,0 +1,14 @@
+package stream
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
+)
+
+// Watcher — интерфейс для подписки на изменения метрик.
+type Watcher interface {
+	Watch(ctx context.Context, filter hub.Filter) *hub.Subscription
+	GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error)
+}
@@ -115,7 +115,14 @@ func ExamplePostMany() {
 		fmt.Println("create jsonSend err")
 	}
 
-	s.On("UpdateMany", mock.Anything, metrics).Return(nil)
+	result := models.BatchResult{
+		Accepted: []models.BatchItem{
+			{Index: 0, ID: "example1", MType: "gauge"},
+			{Index: 1, ID: "example2", MType: "counter"},
+		},
+		Rejected: []models.BatchItem{},
+	}
+	s.On("UpdateBatch", mock.Anything, "", metrics, false).Return(result, nil)
 
 	router := chi.NewRouter()
 	router.Post("/updates", PostMany(s, ""))
@@ -143,6 +150,6 @@ func ExamplePostMany() {
 	fmt.Println(res.Status)
 
 	// Output:
-	// Success
+	// {"accepted":[{"id":"example1","type":"gauge","index":0},{"id":"example2","type":"counter","index":1}],"rejected":[]}
 	// 200 OK
 }
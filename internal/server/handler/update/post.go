@@ -1,16 +1,20 @@
 package update
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"strings"
 
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
 	"github.com/go-chi/chi/v5"
 )
 
 // Post — хендлер для обновления или создания метрик.
-// Получает данные о метрике из URL.
+// Получает данные о метрике из URL, метки — из параметров запроса.
 func Post(s MetricUpdater) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		contentType := r.Header.Get("Content-type")
@@ -24,10 +28,14 @@ func Post(s MetricUpdater) http.HandlerFunc {
 		}
 
 		reqType := chi.URLParam(r, "type")
-		reqName := chi.URLParam(r, "name")
+		reqName := storage.SeriesKey(chi.URLParam(r, "name"), models.LabelsFromQuery(r.URL.Query()))
 		reqValue := chi.URLParam(r, "value")
 
 		err := s.UpdateMetric(r.Context(), reqName, reqType, reqValue)
+		if errors.Is(err, merrors.ErrSeriesQuotaExceeded) {
+			http.Error(w, "Too many series: "+err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		if err != nil {
 			textErr := fmt.Sprintf("Bad request: %s", err)
 			http.Error(w, textErr, http.StatusBadRequest)
@@ -2,13 +2,16 @@ package update
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/LekcRg/metrics/internal/crypto"
 	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/models"
 	"go.uber.org/zap"
 )
@@ -32,6 +35,18 @@ func validateSHA256(w http.ResponseWriter, r *http.Request,
 	return nil
 }
 
+// isBadRequest определяет ошибки пачки, вызванные некорректными данными.
+func isBadRequest(err error) bool {
+	return errors.Is(err, merrors.ErrIncorrectHistogram) ||
+		errors.Is(err, merrors.ErrIncorrectHistogramBuckets) ||
+		errors.Is(err, merrors.ErrIncorrectHistogramValue) ||
+		errors.Is(err, merrors.ErrIncorrectSummaryValue) ||
+		errors.Is(err, merrors.ErrIncorrectSet) ||
+		errors.Is(err, merrors.ErrIncorrectMetadata) ||
+		errors.Is(err, merrors.ErrIncorrectTenantKey) ||
+		errors.Is(err, merrors.ErrIncorrectBatchID)
+}
+
 func validateAndGetBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
 	contentType := r.Header.Get("Content-type")
 
@@ -64,6 +79,10 @@ func PostJSON(s MetricUpdater) http.HandlerFunc {
 		}
 
 		newMetric, err := s.UpdateMetricJSON(r.Context(), parsedBody)
+		if errors.Is(err, merrors.ErrSeriesQuotaExceeded) {
+			http.Error(w, "Too many series: "+err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
@@ -82,10 +101,49 @@ func PostJSON(s MetricUpdater) http.HandlerFunc {
 	}
 }
 
+// IdempotencyKeyHeader — заголовок с ключом пачки PostMany.
+// Пачка с ключом применяется один раз, повторы только подтверждаются
+// с заголовком ReplayedHeader.
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// ReplayedHeader — заголовок ответа на повтор уже примененной пачки.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// StrictParam — параметр запроса PostMany: при strict=true пачка
+// с некорректной метрикой не применяется.
+const StrictParam = "strict"
+
+// writeBatchResult отправляет результат пачки в формате JSON.
+func writeBatchResult(w http.ResponseWriter, res models.BatchResult, status int) {
+	body, err := json.Marshal(res)
+	if err != nil {
+		logger.Log.Error("/updates: error while generate json response", zap.Error(err))
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
 // PostMany — хендлер для обновления или создания сразу нескольких метрик.
-// Метрики передаются в формате JSON ([]models.Metrics).
+// Метрики передаются в формате JSON ([]models.Metrics), ключ пачки —
+// в заголовке IdempotencyKeyHeader, строгий режим — в параметре StrictParam.
+// В ответе принятые и отклоненные с причиной метрики (models.BatchResult),
+// строгая пачка с отклоненными метриками возвращается с кодом 400.
 func PostMany(s MetricUpdater, key string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		strict := false
+		if val := r.URL.Query().Get(StrictParam); val != "" {
+			var err error
+			strict, err = strconv.ParseBool(val)
+			if err != nil {
+				http.Error(w, "Bad request: incorrect strict", http.StatusBadRequest)
+				return
+			}
+		}
+
 		body, err := validateAndGetBody(w, r)
 		if err != nil {
 			logger.Log.Error("/update: body reading error", zap.Error(err))
@@ -107,13 +165,31 @@ func PostMany(s MetricUpdater, key string) http.HandlerFunc {
 			return
 		}
 
-		err = s.UpdateMany(r.Context(), parsedBody)
+		res, err := s.UpdateBatch(r.Context(), r.Header.Get(IdempotencyKeyHeader), parsedBody, strict)
+		if errors.Is(err, merrors.ErrBatchRejected) {
+			writeBatchResult(w, res, http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, merrors.ErrDuplicateBatch) {
+			// пачка уже применена, повтор подтверждается без изменений
+			w.Header().Set(ReplayedHeader, "true")
+			err = nil
+		}
+		if errors.Is(err, merrors.ErrSeriesQuotaExceeded) {
+			http.Error(w, "Too many series: "+err.Error(), http.StatusTooManyRequests)
+			return
+		}
 		if err != nil {
+			if isBadRequest(err) {
+				http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+
 			logger.Log.Error(err.Error())
 			http.Error(w, "Internal server error", http.StatusInternalServerError)
 			return
 		}
 
-		w.Write([]byte("Success"))
+		writeBatchResult(w, res, http.StatusOK)
 	}
 }
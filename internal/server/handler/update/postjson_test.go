@@ -161,9 +161,13 @@ func TestPostMany(t *testing.T) {
 		body         string
 		SHA256       string
 		key          string
+		batchID      string
+		strict       string
 		input        []models.Metrics
+		result       models.BatchResult
 		want         want
-		serviceError bool
+		serviceErr   error
+		wantReplayed bool
 	}{
 		{
 			name: "Change one counter",
@@ -243,7 +247,82 @@ func TestPostMany(t *testing.T) {
 			want: want{
 				code: http.StatusInternalServerError,
 			},
-			serviceError: true,
+			serviceErr: merrors.ErrMocked,
+		},
+		{
+			name:    "With idempotency key",
+			batchID: "batch-1",
+			input: []models.Metrics{
+				counter1,
+			},
+			want: want{
+				code: http.StatusOK,
+			},
+		},
+		{
+			name:    "Duplicate batch",
+			batchID: "batch-1",
+			input: []models.Metrics{
+				counter1,
+			},
+			want: want{
+				code: http.StatusOK,
+			},
+			serviceErr:   merrors.ErrDuplicateBatch,
+			wantReplayed: true,
+		},
+		{
+			name:    "Incorrect idempotency key",
+			batchID: strings.Repeat("a", 129),
+			input: []models.Metrics{
+				counter1,
+			},
+			want: want{
+				code: http.StatusBadRequest,
+			},
+			serviceErr: merrors.ErrIncorrectBatchID,
+		},
+		{
+			name: "Partially rejected",
+			input: []models.Metrics{
+				counter1,
+				{ID: "counter-3", MType: "counter"},
+			},
+			result: models.BatchResult{
+				Accepted: []models.BatchItem{{Index: 0, ID: counter1.ID, MType: "counter"}},
+				Rejected: []models.BatchItem{
+					{Index: 1, ID: "counter-3", MType: "counter", Reason: merrors.ErrMissingMetricValue.Error()},
+				},
+			},
+			want: want{
+				code: http.StatusOK,
+			},
+		},
+		{
+			name:   "Strict rejected",
+			strict: "true",
+			input: []models.Metrics{
+				counter1,
+				{ID: "counter-3", MType: "counter"},
+			},
+			result: models.BatchResult{
+				Accepted: []models.BatchItem{{Index: 0, ID: counter1.ID, MType: "counter"}},
+				Rejected: []models.BatchItem{
+					{Index: 1, ID: "counter-3", MType: "counter", Reason: merrors.ErrMissingMetricValue.Error()},
+				},
+			},
+			want: want{
+				code: http.StatusBadRequest,
+			},
+			serviceErr: merrors.ErrBatchRejected,
+		},
+		{
+			name:   "Incorrect strict",
+			strict: "maybe",
+			body:   "[]",
+			want: want{
+				code: http.StatusBadRequest,
+			},
 		},
 		{
 			name: "Valid with SHA256",
@@ -283,13 +362,8 @@ func TestPostMany(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			s := NewMockMetricUpdater(t)
 			if len(tt.input) > 0 {
-				var err error = nil
-
-				if tt.serviceError {
-					err = merrors.ErrMocked
-				}
-				s.EXPECT().UpdateMany(context.Background(), tt.input).
-					Return(err)
+				s.EXPECT().UpdateBatch(context.Background(), tt.batchID, tt.input, tt.strict == "true").
+					Return(tt.result, tt.serviceErr)
 			}
 
 			w := httptest.NewRecorder()
@@ -304,7 +378,11 @@ func TestPostMany(t *testing.T) {
 				reader = strings.NewReader(tt.body)
 			}
 
-			req := httptest.NewRequest(http.MethodPost, "/", reader)
+			target := "/"
+			if tt.strict != "" {
+				target += "?" + StrictParam + "=" + tt.strict
+			}
+			req := httptest.NewRequest(http.MethodPost, target, reader)
 
 			if tt.key != "" {
 				sha := tt.SHA256
@@ -320,6 +398,9 @@ func TestPostMany(t *testing.T) {
 				contentType = tt.contentType
 			}
 			req.Header.Add("Content-Type", contentType)
+			if tt.batchID != "" {
+				req.Header.Set(IdempotencyKeyHeader, tt.batchID)
+			}
 
 			h := PostMany(s, tt.key)
 			h(w, req)
@@ -327,10 +408,15 @@ func TestPostMany(t *testing.T) {
 			resp := w.Result()
 			defer resp.Body.Close()
 			assert.Equal(t, tt.want.code, resp.StatusCode)
+			assert.Equal(t, tt.wantReplayed, resp.Header.Get(ReplayedHeader) == "true")
 
-			if tt.want.code != 200 || tt.input == nil {
+			if tt.input == nil || (tt.want.code != http.StatusOK && tt.serviceErr != merrors.ErrBatchRejected) {
 				return
 			}
+
+			var result models.BatchResult
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+			assert.Equal(t, tt.result, result)
 		})
 	}
 }
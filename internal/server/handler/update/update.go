@@ -10,5 +10,5 @@ import (
 type MetricUpdater interface {
 	UpdateMetric(ctx context.Context, reqName string, reqType string, reqValue string) error
 	UpdateMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error)
-	UpdateMany(ctx context.Context, list []models.Metrics) error
+	UpdateBatch(ctx context.Context, batchID string, list []models.Metrics, strict bool) (models.BatchResult, error)
 }
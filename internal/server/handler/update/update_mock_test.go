@@ -38,48 +38,59 @@ func (_m *MockMetricUpdater) EXPECT() *MockMetricUpdater_Expecter {
 	return &MockMetricUpdater_Expecter{mock: &_m.Mock}
 }
 
-// UpdateMany provides a mock function for the type MockMetricUpdater
-func (_mock *MockMetricUpdater) UpdateMany(ctx context.Context, list []models.Metrics) error {
-	ret := _mock.Called(ctx, list)
+// UpdateBatch provides a mock function for the type MockMetricUpdater
+func (_mock *MockMetricUpdater) UpdateBatch(ctx context.Context, batchID string, list []models.Metrics, strict bool) (models.BatchResult, error) {
+	ret := _mock.Called(ctx, batchID, list, strict)
 
 	if len(ret) == 0 {
-		panic("no return value specified for UpdateMany")
+		panic("no return value specified for UpdateBatch")
 	}
 
-	var r0 error
-	if returnFunc, ok := ret.Get(0).(func(context.Context, []models.Metrics) error); ok {
-		r0 = returnFunc(ctx, list)
+	var r0 models.BatchResult
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []models.Metrics, bool) (models.BatchResult, error)); ok {
+		return returnFunc(ctx, batchID, list, strict)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, []models.Metrics, bool) models.BatchResult); ok {
+		r0 = returnFunc(ctx, batchID, list, strict)
 	} else {
-		r0 = ret.Error(0)
+		r0 = ret.Get(0).(models.BatchResult)
 	}
-	return r0
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, []models.Metrics, bool) error); ok {
+		r1 = returnFunc(ctx, batchID, list, strict)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
 }
 
-// MockMetricUpdater_UpdateMany_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateMany'
-type MockMetricUpdater_UpdateMany_Call struct {
+// MockMetricUpdater_UpdateBatch_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateBatch'
+type MockMetricUpdater_UpdateBatch_Call struct {
 	*mock.Call
 }
 
-// UpdateMany is a helper method to define mock.On call
+// UpdateBatch is a helper method to define mock.On call
 //   - ctx
+//   - batchID
 //   - list
-func (_e *MockMetricUpdater_Expecter) UpdateMany(ctx interface{}, list interface{}) *MockMetricUpdater_UpdateMany_Call {
-	return &MockMetricUpdater_UpdateMany_Call{Call: _e.mock.On("UpdateMany", ctx, list)}
+//   - strict
+func (_e *MockMetricUpdater_Expecter) UpdateBatch(ctx interface{}, batchID interface{}, list interface{}, strict interface{}) *MockMetricUpdater_UpdateBatch_Call {
+	return &MockMetricUpdater_UpdateBatch_Call{Call: _e.mock.On("UpdateBatch", ctx, batchID, list, strict)}
 }
 
-func (_c *MockMetricUpdater_UpdateMany_Call) Run(run func(ctx context.Context, list []models.Metrics)) *MockMetricUpdater_UpdateMany_Call {
+func (_c *MockMetricUpdater_UpdateBatch_Call) Run(run func(ctx context.Context, batchID string, list []models.Metrics, strict bool)) *MockMetricUpdater_UpdateBatch_Call {
 	_c.Call.Run(func(args mock.Arguments) {
-		run(args[0].(context.Context), args[1].([]models.Metrics))
+		run(args[0].(context.Context), args[1].(string), args[2].([]models.Metrics), args[3].(bool))
 	})
 	return _c
 }
 
-func (_c *MockMetricUpdater_UpdateMany_Call) Return(err error) *MockMetricUpdater_UpdateMany_Call {
-	_c.Call.Return(err)
+func (_c *MockMetricUpdater_UpdateBatch_Call) Return(batchResult models.BatchResult, err error) *MockMetricUpdater_UpdateBatch_Call {
+	_c.Call.Return(batchResult, err)
 	return _c
 }
 
-func (_c *MockMetricUpdater_UpdateMany_Call) RunAndReturn(run func(ctx context.Context, list []models.Metrics) error) *MockMetricUpdater_UpdateMany_Call {
+func (_c *MockMetricUpdater_UpdateBatch_Call) RunAndReturn(run func(ctx context.Context, batchID string, list []models.Metrics, strict bool) (models.BatchResult, error)) *MockMetricUpdater_UpdateBatch_Call {
 	_c.Call.Return(run)
 	return _c
 }
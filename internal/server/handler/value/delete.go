@@ -0,0 +1,35 @@
+package value
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/go-chi/chi/v5"
+)
+
+// Delete — хендлер для удаления метрики по типу и имени из URL вместе
+// с ее историей. Метки серии передаются параметрами запроса.
+func Delete(s MetricDeleter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		reqType := chi.URLParam(r, "type")
+		reqName := storage.SeriesKey(chi.URLParam(r, "name"), models.LabelsFromQuery(r.URL.Query()))
+
+		err := s.DeleteMetric(r.Context(), reqName, reqType)
+		switch {
+		case errors.Is(err, merrors.ErrNotFoundMetric):
+			http.Error(w, "Not found", http.StatusNotFound)
+			return
+		case errors.Is(err, merrors.ErrIncorrectMetricType):
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		case err != nil:
+			http.Error(w, "Internal error", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
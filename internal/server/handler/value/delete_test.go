@@ -0,0 +1,80 @@
+package value
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelete(t *testing.T) {
+	tests := []struct {
+		serviceErr error
+		name       string
+		mType      string
+		query      string
+		key        string
+		code       int
+	}{
+		{
+			name:  "Positive",
+			mType: "gauge",
+			key:   "CPUutilization17",
+			code:  http.StatusOK,
+		},
+		{
+			name:  "Labels from query string",
+			mType: "counter",
+			query: "?host=web1",
+			key:   `CPUutilization17{host="web1"}`,
+			code:  http.StatusOK,
+		},
+		{
+			name:       "Not found",
+			mType:      "gauge",
+			key:        "CPUutilization17",
+			serviceErr: merrors.ErrNotFoundMetric,
+			code:       http.StatusNotFound,
+		},
+		{
+			name:       "Incorrect type",
+			mType:      "unknown",
+			key:        "CPUutilization17",
+			serviceErr: merrors.ErrIncorrectMetricType,
+			code:       http.StatusBadRequest,
+		},
+		{
+			name:       "Service error",
+			mType:      "gauge",
+			key:        "CPUutilization17",
+			serviceErr: merrors.ErrMocked,
+			code:       http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricDeleter(t)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", "CPUutilization17")
+			rctx.URLParams.Add("type", tt.mType)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodDelete, "/"+tt.query, nil)
+			ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+			r = r.WithContext(ctx)
+
+			s.EXPECT().DeleteMetric(ctx, tt.key, tt.mType).Return(tt.serviceErr)
+
+			Delete(s)(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.code, res.StatusCode)
+		})
+	}
+}
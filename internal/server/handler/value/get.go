@@ -1,17 +1,68 @@
 package value
 
 import (
+	"errors"
 	"io"
 	"net/http"
+	"strconv"
 
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
 	"github.com/go-chi/chi/v5"
 )
 
+// defaultQuantile — квантиль histogram и summary, если параметр q не передан.
+const defaultQuantile = "0.5"
+
+// getQuantile возвращает квантиль histogram или summary из параметра q.
+func getQuantile(w http.ResponseWriter, r *http.Request, reqType string, s MetricGetter) {
+	query := r.URL.Query()
+	rawQ := query.Get("q")
+	if rawQ == "" {
+		rawQ = defaultQuantile
+	}
+
+	q, err := strconv.ParseFloat(rawQ, 64)
+	if err != nil {
+		http.Error(w, "Bad request: incorrect q", http.StatusBadRequest)
+		return
+	}
+
+	reqName := storage.SeriesKey(chi.URLParam(r, "name"), models.LabelsFromQuery(query, "q"))
+	getter := s.GetHistogramQuantile
+	if reqType == "summary" {
+		getter = s.GetSummaryQuantile
+	}
+
+	res, err := getter(r.Context(), reqName, q)
+	if err != nil {
+		if errors.Is(err, merrors.ErrIncorrectQuantile) {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	io.WriteString(w, strconv.FormatFloat(res, 'f', -1, 64))
+}
+
 // Get — хендлер для получения метрики по типу и имени из URL.
+// Метки серии передаются параметрами запроса. Для histogram и summary
+// возвращается квантиль из параметра q (по умолчанию медиана).
 func Get(s MetricGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		reqType := chi.URLParam(r, "type")
-		reqName := chi.URLParam(r, "name")
+		if reqType == "histogram" || reqType == "summary" {
+			getQuantile(w, r, reqType, s)
+			return
+		}
+
+		reqName := storage.SeriesKey(chi.URLParam(r, "name"), models.LabelsFromQuery(r.URL.Query()))
 
 		res, err := s.GetMetric(r.Context(), reqName, reqType)
 
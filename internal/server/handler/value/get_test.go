@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"testing"
 
 	"github.com/LekcRg/metrics/internal/merrors"
@@ -21,6 +22,8 @@ func TestGet(t *testing.T) {
 		name  string
 		mType string
 		value string
+		query string
+		key   string
 	}
 	tests := []struct {
 		metric       metric
@@ -53,6 +56,20 @@ func TestGet(t *testing.T) {
 				value:       "1234",
 			},
 		},
+		{
+			name: "Labels from query string",
+			metric: metric{
+				name:  "cpu",
+				mType: "gauge",
+				query: "?region=eu&host=web1",
+				key:   `cpu{host="web1",region="eu"}`,
+			},
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+				value:       "0.5",
+			},
+		},
 		{
 			name:         "Service return error",
 			serviceError: true,
@@ -75,7 +92,7 @@ func TestGet(t *testing.T) {
 			rctx.URLParams.Add("type", tt.metric.mType)
 
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r := httptest.NewRequest(http.MethodGet, "/"+tt.metric.query, nil)
 
 			ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
 			r = r.WithContext(ctx)
@@ -87,8 +104,12 @@ func TestGet(t *testing.T) {
 				if tt.serviceError {
 					err = merrors.ErrMocked
 				}
+				key := tt.metric.name
+				if tt.metric.key != "" {
+					key = tt.metric.key
+				}
 				s.EXPECT().
-					GetMetric(ctx, tt.metric.name, tt.metric.mType).
+					GetMetric(ctx, key, tt.metric.mType).
 					Return(tt.want.value, err)
 			}
 
@@ -108,3 +129,87 @@ func TestGet(t *testing.T) {
 		})
 	}
 }
+
+func TestGetHistogram(t *testing.T) {
+	tests := []struct {
+		serviceErr error
+		name       string
+		query      string
+		key        string
+		want       string
+		q          float64
+		code       int
+		callsSvc   bool
+	}{
+		{
+			name:     "Default median",
+			key:      "latency",
+			q:        0.5,
+			want:     "0.25",
+			code:     http.StatusOK,
+			callsSvc: true,
+		},
+		{
+			name:     "Quantile and labels",
+			query:    "?q=0.99&route=%2F",
+			key:      `latency{route="/"}`,
+			q:        0.99,
+			want:     "1",
+			code:     http.StatusOK,
+			callsSvc: true,
+		},
+		{
+			name:  "Incorrect q",
+			query: "?q=abc",
+			code:  http.StatusBadRequest,
+		},
+		{
+			name:       "Quantile out of range",
+			query:      "?q=2",
+			key:        "latency",
+			q:          2,
+			serviceErr: merrors.ErrIncorrectQuantile,
+			code:       http.StatusBadRequest,
+			callsSvc:   true,
+		},
+		{
+			name:       "Not found",
+			key:        "latency",
+			q:          0.5,
+			serviceErr: merrors.ErrNotFoundMetric,
+			code:       http.StatusNotFound,
+			callsSvc:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricGetter(t)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("name", "latency")
+			rctx.URLParams.Add("type", "histogram")
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, "/"+tt.query, nil)
+			ctx := context.WithValue(r.Context(), chi.RouteCtxKey, rctx)
+			r = r.WithContext(ctx)
+
+			if tt.callsSvc {
+				var res float64
+				if tt.serviceErr == nil {
+					res, _ = strconv.ParseFloat(tt.want, 64)
+				}
+				s.EXPECT().GetHistogramQuantile(ctx, tt.key, tt.q).Return(res, tt.serviceErr)
+			}
+
+			Get(s)(w, r)
+
+			res := w.Result()
+			defer res.Body.Close()
+			assert.Equal(t, tt.code, res.StatusCode)
+			if tt.code == http.StatusOK {
+				assert.Equal(t, tt.want, w.Body.String())
+			}
+		})
+	}
+}
@@ -10,4 +10,11 @@ import (
 type MetricGetter interface {
 	GetMetric(ctx context.Context, reqName string, reqType string) (string, error)
 	GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error)
+	GetHistogramQuantile(ctx context.Context, reqName string, q float64) (float64, error)
+	GetSummaryQuantile(ctx context.Context, reqName string, q float64) (float64, error)
+}
+
+// MetricDeleter — интерфейс для удаления метрики.
+type MetricDeleter interface {
+	DeleteMetric(ctx context.Context, reqName string, reqType string) error
 }
@@ -11,6 +11,80 @@ import (
 	mock "github.com/stretchr/testify/mock"
 )
 
+// NewMockMetricDeleter creates a new instance of MockMetricDeleter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockMetricDeleter(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockMetricDeleter {
+	mock := &MockMetricDeleter{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockMetricDeleter is an autogenerated mock type for the MetricDeleter type
+type MockMetricDeleter struct {
+	mock.Mock
+}
+
+type MockMetricDeleter_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockMetricDeleter) EXPECT() *MockMetricDeleter_Expecter {
+	return &MockMetricDeleter_Expecter{mock: &_m.Mock}
+}
+
+// DeleteMetric provides a mock function for the type MockMetricDeleter
+func (_mock *MockMetricDeleter) DeleteMetric(ctx context.Context, reqName string, reqType string) error {
+	ret := _mock.Called(ctx, reqName, reqType)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DeleteMetric")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, string) error); ok {
+		r0 = returnFunc(ctx, reqName, reqType)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockMetricDeleter_DeleteMetric_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DeleteMetric'
+type MockMetricDeleter_DeleteMetric_Call struct {
+	*mock.Call
+}
+
+// DeleteMetric is a helper method to define mock.On call
+//   - ctx
+//   - reqName
+//   - reqType
+func (_e *MockMetricDeleter_Expecter) DeleteMetric(ctx interface{}, reqName interface{}, reqType interface{}) *MockMetricDeleter_DeleteMetric_Call {
+	return &MockMetricDeleter_DeleteMetric_Call{Call: _e.mock.On("DeleteMetric", ctx, reqName, reqType)}
+}
+
+func (_c *MockMetricDeleter_DeleteMetric_Call) Run(run func(ctx context.Context, reqName string, reqType string)) *MockMetricDeleter_DeleteMetric_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string))
+	})
+	return _c
+}
+
+func (_c *MockMetricDeleter_DeleteMetric_Call) Return(err error) *MockMetricDeleter_DeleteMetric_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockMetricDeleter_DeleteMetric_Call) RunAndReturn(run func(ctx context.Context, reqName string, reqType string) error) *MockMetricDeleter_DeleteMetric_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // NewMockMetricGetter creates a new instance of MockMetricGetter. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func NewMockMetricGetter(t interface {
@@ -38,6 +112,62 @@ func (_m *MockMetricGetter) EXPECT() *MockMetricGetter_Expecter {
 	return &MockMetricGetter_Expecter{mock: &_m.Mock}
 }
 
+// GetHistogramQuantile provides a mock function for the type MockMetricGetter
+func (_mock *MockMetricGetter) GetHistogramQuantile(ctx context.Context, reqName string, q float64) (float64, error) {
+	ret := _mock.Called(ctx, reqName, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetHistogramQuantile")
+	}
+
+	var r0 float64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, float64) (float64, error)); ok {
+		return returnFunc(ctx, reqName, q)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, float64) float64); ok {
+		r0 = returnFunc(ctx, reqName, q)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, float64) error); ok {
+		r1 = returnFunc(ctx, reqName, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockMetricGetter_GetHistogramQuantile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetHistogramQuantile'
+type MockMetricGetter_GetHistogramQuantile_Call struct {
+	*mock.Call
+}
+
+// GetHistogramQuantile is a helper method to define mock.On call
+//   - ctx
+//   - reqName
+//   - q
+func (_e *MockMetricGetter_Expecter) GetHistogramQuantile(ctx interface{}, reqName interface{}, q interface{}) *MockMetricGetter_GetHistogramQuantile_Call {
+	return &MockMetricGetter_GetHistogramQuantile_Call{Call: _e.mock.On("GetHistogramQuantile", ctx, reqName, q)}
+}
+
+func (_c *MockMetricGetter_GetHistogramQuantile_Call) Run(run func(ctx context.Context, reqName string, q float64)) *MockMetricGetter_GetHistogramQuantile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(float64))
+	})
+	return _c
+}
+
+func (_c *MockMetricGetter_GetHistogramQuantile_Call) Return(f float64, err error) *MockMetricGetter_GetHistogramQuantile_Call {
+	_c.Call.Return(f, err)
+	return _c
+}
+
+func (_c *MockMetricGetter_GetHistogramQuantile_Call) RunAndReturn(run func(ctx context.Context, reqName string, q float64) (float64, error)) *MockMetricGetter_GetHistogramQuantile_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // GetMetric provides a mock function for the type MockMetricGetter
 func (_mock *MockMetricGetter) GetMetric(ctx context.Context, reqName string, reqType string) (string, error) {
 	ret := _mock.Called(ctx, reqName, reqType)
@@ -148,3 +278,59 @@ func (_c *MockMetricGetter_GetMetricJSON_Call) RunAndReturn(run func(ctx context
 	_c.Call.Return(run)
 	return _c
 }
+
+// GetSummaryQuantile provides a mock function for the type MockMetricGetter
+func (_mock *MockMetricGetter) GetSummaryQuantile(ctx context.Context, reqName string, q float64) (float64, error) {
+	ret := _mock.Called(ctx, reqName, q)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetSummaryQuantile")
+	}
+
+	var r0 float64
+	var r1 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, float64) (float64, error)); ok {
+		return returnFunc(ctx, reqName, q)
+	}
+	if returnFunc, ok := ret.Get(0).(func(context.Context, string, float64) float64); ok {
+		r0 = returnFunc(ctx, reqName, q)
+	} else {
+		r0 = ret.Get(0).(float64)
+	}
+	if returnFunc, ok := ret.Get(1).(func(context.Context, string, float64) error); ok {
+		r1 = returnFunc(ctx, reqName, q)
+	} else {
+		r1 = ret.Error(1)
+	}
+	return r0, r1
+}
+
+// MockMetricGetter_GetSummaryQuantile_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetSummaryQuantile'
+type MockMetricGetter_GetSummaryQuantile_Call struct {
+	*mock.Call
+}
+
+// GetSummaryQuantile is a helper method to define mock.On call
+//   - ctx
+//   - reqName
+//   - q
+func (_e *MockMetricGetter_Expecter) GetSummaryQuantile(ctx interface{}, reqName interface{}, q interface{}) *MockMetricGetter_GetSummaryQuantile_Call {
+	return &MockMetricGetter_GetSummaryQuantile_Call{Call: _e.mock.On("GetSummaryQuantile", ctx, reqName, q)}
+}
+
+func (_c *MockMetricGetter_GetSummaryQuantile_Call) Run(run func(ctx context.Context, reqName string, q float64)) *MockMetricGetter_GetSummaryQuantile_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(float64))
+	})
+	return _c
+}
+
+func (_c *MockMetricGetter_GetSummaryQuantile_Call) Return(f float64, err error) *MockMetricGetter_GetSummaryQuantile_Call {
+	_c.Call.Return(f, err)
+	return _c
+}
+
+func (_c *MockMetricGetter_GetSummaryQuantile_Call) RunAndReturn(run func(ctx context.Context, reqName string, q float64) (float64, error)) *MockMetricGetter_GetSummaryQuantile_Call {
+	_c.Call.Return(run)
+	return _c
+}
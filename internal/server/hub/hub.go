@@ -0,0 +1,176 @@
+// Package hub рассылает изменения метрик подписчикам.
+//
+// Публикация не блокируется медленным подписчиком: непрочитанные события
+// одной серии заменяются последним, поэтому подписчик может пропустить
+// промежуточные изменения, но не последнее. Если непрочитанными остаются
+// изменения больше MaxPending серий, подписка считается отставшей
+// и закрывается с merrors.ErrSlowConsumer.
+package hub
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// MaxPending — наибольшее число серий с непрочитанными изменениями в подписке.
+const MaxPending = 10000
+
+// Event — изменение серии метрики.
+type Event struct {
+	Tenant  string // тенант серии, см. tenant.FromContext
+	Key     string // ключ серии (см. storage.SeriesKey)
+	MType   string // тип метрики
+	Deleted bool   // серия удалена
+}
+
+// id возвращает идентификатор серии события, по которому события объединяются.
+func (e Event) id() string {
+	return e.MType + " " + e.Key
+}
+
+// Filter отбирает события подписки, nil — все события тенанта.
+type Filter func(Event) bool
+
+// MetricFilter отбирает изменения метрик с именем, начинающимся с prefix,
+// и типом mType, если он не пустой.
+func MetricFilter(prefix string, mType string) Filter {
+	return func(e Event) bool {
+		if mType != "" && e.MType != mType {
+			return false
+		}
+
+		name, _, err := storage.ParseSeriesKey(e.Key)
+		return err == nil && strings.HasPrefix(name, prefix)
+	}
+}
+
+// Hub рассылает события подписчикам. Нулевой *Hub события не рассылает.
+type Hub struct {
+	subs map[*Subscription]struct{}
+	mu   sync.RWMutex
+}
+
+// New создает Hub.
+func New() *Hub {
+	return &Hub{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Publish передает события подписчикам их тенанта.
+func (h *Hub) Publish(events ...Event) {
+	if h == nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for sub := range h.subs {
+		for _, e := range events {
+			if e.Tenant == sub.tenant && (sub.filter == nil || sub.filter(e)) {
+				sub.add(e)
+			}
+		}
+	}
+}
+
+// Subscribe подписывает на события тенанта tenant, подходящие под filter.
+// Подписку нужно закрыть через Close.
+func (h *Hub) Subscribe(tenant string, filter Filter) *Subscription {
+	sub := &Subscription{
+		hub:     h,
+		tenant:  tenant,
+		filter:  filter,
+		pending: make(map[string]int),
+		notify:  make(chan struct{}, 1),
+		limit:   MaxPending,
+	}
+
+	if h != nil {
+		h.mu.Lock()
+		h.subs[sub] = struct{}{}
+		h.mu.Unlock()
+	}
+
+	return sub
+}
+
+// Subscription — подписка на события Hub.
+type Subscription struct {
+	hub     *Hub
+	filter  Filter
+	pending map[string]int // номер непрочитанного события серии в events
+	notify  chan struct{}
+	tenant  string
+	events  []Event
+	limit   int
+	mu      sync.Mutex
+	slow    bool // подписчик отстал, события больше не копятся
+}
+
+// add добавляет событие к непрочитанным, заменяя непрочитанное событие той же серии.
+func (s *Subscription) add(e Event) {
+	s.mu.Lock()
+	switch i, ok := s.pending[e.id()]; {
+	case s.slow:
+	case ok:
+		s.events[i] = e
+	case len(s.events) >= s.limit:
+		s.slow = true
+		s.events = nil
+		clear(s.pending)
+	default:
+		s.pending[e.id()] = len(s.events)
+		s.events = append(s.events, e)
+	}
+	s.mu.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Next ждет и возвращает непрочитанные события в порядке поступления.
+// Ошибка возвращается после отмены ctx или merrors.ErrSlowConsumer,
+// если подписчик отстал.
+func (s *Subscription) Next(ctx context.Context) ([]Event, error) {
+	for {
+		s.mu.Lock()
+		if s.slow {
+			s.mu.Unlock()
+			return nil, merrors.ErrSlowConsumer
+		}
+		if len(s.events) > 0 {
+			events := s.events
+			s.events = nil
+			clear(s.pending)
+			s.mu.Unlock()
+
+			return events, nil
+		}
+		s.mu.Unlock()
+
+		select {
+		case <-s.notify:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// Close отписывает от событий.
+func (s *Subscription) Close() {
+	if s.hub == nil {
+		return
+	}
+
+	s.hub.mu.Lock()
+	delete(s.hub.subs, s)
+	s.hub.mu.Unlock()
+}
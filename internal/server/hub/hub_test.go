@@ -0,0 +1,140 @@
+package hub
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubscription(t *testing.T) {
+	h := New()
+	ctx := context.Background()
+
+	all := h.Subscribe("", nil)
+	defer all.Close()
+	gauges := h.Subscribe("", func(e Event) bool { return e.MType == "gauge" })
+	defer gauges.Close()
+	other := h.Subscribe("team-a", nil)
+	defer other.Close()
+
+	h.Publish(
+		Event{Key: "Alloc", MType: "gauge"},
+		Event{Key: "PollCount", MType: "counter"},
+		Event{Key: "Alloc", MType: "gauge", Deleted: true},
+	)
+
+	got, err := all.Next(ctx)
+	require.NoError(t, err)
+	// непрочитанное событие серии заменяется последним, порядок сохраняется
+	assert.Equal(t, []Event{
+		{Key: "Alloc", MType: "gauge", Deleted: true},
+		{Key: "PollCount", MType: "counter"},
+	}, got)
+
+	got, err = gauges.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Event{{Key: "Alloc", MType: "gauge", Deleted: true}}, got)
+
+	h.Publish(Event{Tenant: "team-a", Key: "Alloc", MType: "gauge"})
+	got, err = other.Next(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Event{{Tenant: "team-a", Key: "Alloc", MType: "gauge"}}, got)
+
+	ctx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = all.Next(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestSubscriptionWait(t *testing.T) {
+	h := New()
+	sub := h.Subscribe("", nil)
+	defer sub.Close()
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		h.Publish(Event{Key: "Alloc", MType: "gauge"})
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	got, err := sub.Next(ctx)
+	require.NoError(t, err)
+	assert.Len(t, got, 1)
+}
+
+func TestClose(t *testing.T) {
+	h := New()
+	sub := h.Subscribe("", nil)
+	sub.Close()
+
+	h.Publish(Event{Key: "Alloc", MType: "gauge"})
+	assert.Empty(t, sub.events)
+	assert.Empty(t, h.subs)
+}
+
+func TestNilHub(t *testing.T) {
+	var h *Hub
+	h.Publish(Event{Key: "Alloc", MType: "gauge"})
+
+	sub := h.Subscribe("", nil)
+	sub.Close()
+}
+
+func TestSlowConsumer(t *testing.T) {
+	h := New()
+	sub := h.Subscribe("", nil)
+	defer sub.Close()
+	sub.limit = 2
+
+	// изменения уже ожидающей серии не увеличивают очередь
+	h.Publish(
+		Event{Key: "Alloc", MType: "gauge"},
+		Event{Key: "PollCount", MType: "counter"},
+		Event{Key: "Alloc", MType: "gauge"},
+	)
+	got, err := sub.Next(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, got, 2)
+
+	h.Publish(
+		Event{Key: "Alloc", MType: "gauge"},
+		Event{Key: "PollCount", MType: "counter"},
+		Event{Key: "RandomValue", MType: "gauge"},
+	)
+	assert.Empty(t, sub.events)
+
+	_, err = sub.Next(context.Background())
+	require.ErrorIs(t, err, merrors.ErrSlowConsumer)
+
+	h.Publish(Event{Key: "Alloc", MType: "gauge"})
+	_, err = sub.Next(context.Background())
+	assert.ErrorIs(t, err, merrors.ErrSlowConsumer)
+}
+
+func TestMetricFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		prefix string
+		mType  string
+		event  Event
+		want   bool
+	}{
+		{name: "All", event: Event{Key: "Alloc", MType: "gauge"}, want: true},
+		{name: "Prefix", prefix: "Heap", event: Event{Key: "HeapAlloc", MType: "gauge"}, want: true},
+		{name: "Prefix with labels", prefix: "cpu", event: Event{Key: `cpu{host="web1"}`, MType: "gauge"}, want: true},
+		{name: "Other prefix", prefix: "Heap", event: Event{Key: "Alloc", MType: "gauge"}},
+		{name: "Type", mType: "counter", event: Event{Key: "PollCount", MType: "counter"}, want: true},
+		{name: "Other type", mType: "counter", event: Event{Key: "Alloc", MType: "gauge"}},
+		{name: "Incorrect key", event: Event{Key: "cpu{", MType: "gauge"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, MetricFilter(tt.prefix, tt.mType)(tt.event))
+		})
+	}
+}
@@ -0,0 +1,241 @@
+package otlp
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/services/cumulative"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+)
+
+// batch собирает метрики одного запроса. Серии хранятся по ключу (см. storage.SeriesKey).
+type batch struct {
+	ctx      context.Context
+	rc       *Receiver
+	deltas   *cumulative.Batch // приросты накопительных сумм запроса
+	gauges   map[string]storage.Gauge
+	counters map[string]storage.Counter
+	series   map[string]models.Metrics
+	order    []string
+	rejected int64
+}
+
+func newBatch(ctx context.Context, rc *Receiver) *batch {
+	return &batch{
+		ctx:      ctx,
+		rc:       rc,
+		deltas:   rc.tracker.Begin(),
+		gauges:   map[string]storage.Gauge{},
+		counters: map[string]storage.Counter{},
+		series:   map[string]models.Metrics{},
+	}
+}
+
+// attributes добавляет к labels атрибуты с простыми значениями.
+func attributes(labels storage.Labels, attrs []*commonpb.KeyValue) storage.Labels {
+	res := make(storage.Labels, len(labels)+len(attrs))
+	for k, v := range labels {
+		res[k] = v
+	}
+
+	for _, kv := range attrs {
+		switch v := kv.GetValue().GetValue().(type) {
+		case *commonpb.AnyValue_StringValue:
+			res[kv.GetKey()] = v.StringValue
+		case *commonpb.AnyValue_IntValue:
+			res[kv.GetKey()] = strconv.FormatInt(v.IntValue, 10)
+		case *commonpb.AnyValue_DoubleValue:
+			res[kv.GetKey()] = strconv.FormatFloat(v.DoubleValue, 'f', -1, 64)
+		case *commonpb.AnyValue_BoolValue:
+			res[kv.GetKey()] = strconv.FormatBool(v.BoolValue)
+		}
+	}
+
+	return res
+}
+
+func numberValue(dp *metricspb.NumberDataPoint) float64 {
+	if v, ok := dp.GetValue().(*metricspb.NumberDataPoint_AsInt); ok {
+		return float64(v.AsInt)
+	}
+
+	return dp.GetAsDouble()
+}
+
+// sortedByTime возвращает точки в порядке времени.
+func sortedByTime[T interface{ GetTimeUnixNano() uint64 }](points []T) []T {
+	res := make([]T, len(points))
+	copy(res, points)
+	sort.SliceStable(res, func(i, j int) bool {
+		return res[i].GetTimeUnixNano() < res[j].GetTimeUnixNano()
+	})
+
+	return res
+}
+
+// push запоминает серию и возвращает ее ключ.
+func (b *batch) push(name string, labels storage.Labels) string {
+	m := models.Metrics{ID: name}
+	if len(labels) > 0 {
+		m.Labels = labels
+	}
+	key := m.Key()
+	if _, ok := b.series[key]; !ok {
+		b.series[key] = m
+		b.order = append(b.order, key)
+	}
+
+	return key
+}
+
+func (b *batch) setGauge(name string, labels storage.Labels, v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	key := b.push(name, labels)
+	b.gauges[key] = storage.Gauge(v)
+}
+
+// addGauge прибавляет v к gauge, при первом появлении — к значению из хранилища.
+func (b *batch) addGauge(name string, labels storage.Labels, v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	key := b.push(name, labels)
+	cur, ok := b.gauges[key]
+	if !ok {
+		cur = b.rc.storedGauge(b.ctx, b.series[key])
+	}
+
+	b.gauges[key] = cur + storage.Gauge(v)
+}
+
+func (b *batch) addCounter(name string, labels storage.Labels, delta storage.Counter) {
+	key := b.push(name, labels)
+	b.counters[key] += delta
+}
+
+// addCumulative переводит накопительное значение в прирост счетчика.
+func (b *batch) addCumulative(name string, labels storage.Labels, v float64) {
+	if math.IsNaN(v) {
+		return
+	}
+
+	key := b.push(name, labels)
+	b.counters[key] += b.deltas.Delta(b.ctx, key, v, func() storage.Counter {
+		return b.rc.storedCounter(b.ctx, b.series[key])
+	})
+}
+
+func (b *batch) addMetric(resource storage.Labels, m *metricspb.Metric) {
+	name := m.GetName()
+
+	switch data := m.GetData().(type) {
+	case *metricspb.Metric_Gauge:
+		for _, dp := range sortedByTime(data.Gauge.GetDataPoints()) {
+			b.setGauge(name, attributes(resource, dp.GetAttributes()), numberValue(dp))
+		}
+	case *metricspb.Metric_Sum:
+		b.addSum(resource, name, data.Sum)
+	case *metricspb.Metric_Histogram:
+		b.addHistogram(resource, name, data.Histogram)
+	case *metricspb.Metric_ExponentialHistogram:
+		b.rejected += int64(len(data.ExponentialHistogram.GetDataPoints()))
+	case *metricspb.Metric_Summary:
+		b.rejected += int64(len(data.Summary.GetDataPoints()))
+	}
+}
+
+func (b *batch) addSum(resource storage.Labels, name string, sum *metricspb.Sum) {
+	delta := sum.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+
+	for _, dp := range sortedByTime(sum.GetDataPoints()) {
+		labels := attributes(resource, dp.GetAttributes())
+		v := numberValue(dp)
+
+		switch {
+		case sum.GetIsMonotonic() && delta:
+			b.addCounter(name, labels, storage.Counter(math.Round(v)))
+		case sum.GetIsMonotonic():
+			b.addCumulative(name, labels, v)
+		case delta:
+			b.addGauge(name, labels, v)
+		default:
+			b.setGauge(name, labels, v)
+		}
+	}
+}
+
+func (b *batch) addHistogram(resource storage.Labels, name string, h *metricspb.Histogram) {
+	delta := h.GetAggregationTemporality() == metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA
+
+	for _, dp := range sortedByTime(h.GetDataPoints()) {
+		labels := attributes(resource, dp.GetAttributes())
+
+		// в OTLP бакеты не накопительные, в Prometheus-стиле le — накопительные
+		var (
+			bucketLabels []storage.Labels
+			bucketCounts []uint64
+			total        uint64
+		)
+		bounds := dp.GetExplicitBounds()
+		for i, c := range dp.GetBucketCounts() {
+			total += c
+			le := "+Inf"
+			if i < len(bounds) {
+				le = strconv.FormatFloat(bounds[i], 'f', -1, 64)
+			}
+
+			bucket := attributes(labels, nil)
+			bucket["le"] = le
+			bucketLabels = append(bucketLabels, bucket)
+			bucketCounts = append(bucketCounts, total)
+		}
+
+		if delta {
+			b.addCounter(name+"_count", labels, storage.Counter(dp.GetCount()))
+			for i, bucket := range bucketLabels {
+				b.addCounter(name+"_bucket", bucket, storage.Counter(bucketCounts[i]))
+			}
+			if dp.Sum != nil {
+				b.addGauge(name+"_sum", labels, dp.GetSum())
+			}
+
+			continue
+		}
+
+		b.addCumulative(name+"_count", labels, float64(dp.GetCount()))
+		for i, bucket := range bucketLabels {
+			b.addCumulative(name+"_bucket", bucket, float64(bucketCounts[i]))
+		}
+		if dp.Sum != nil {
+			b.setGauge(name+"_sum", labels, dp.GetSum())
+		}
+	}
+}
+
+// list возвращает метрики в порядке их появления в запросе.
+func (b *batch) list() []models.Metrics {
+	list := make([]models.Metrics, 0, len(b.order))
+	for _, key := range b.order {
+		if v, ok := b.counters[key]; ok {
+			m := b.series[key]
+			m.MType, m.Delta = "counter", &v
+			list = append(list, m)
+		}
+		if v, ok := b.gauges[key]; ok {
+			m := b.series[key]
+			m.MType, m.Value = "gauge", &v
+			list = append(list, m)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,89 @@
+// Package otlp переводит метрики OpenTelemetry (OTLP) в gauge и counter.
+//
+// Соответствие типов:
+//   - Gauge — gauge;
+//   - монотонный Sum с DELTA — counter с приростом;
+//   - монотонный Sum с CUMULATIVE — counter, прирост считается от прошлого значения;
+//   - немонотонный Sum — gauge (DELTA прибавляется к текущему значению);
+//   - Histogram — counter name_count и name_bucket{le="X"}, gauge name_sum.
+//
+// Атрибуты ресурса и точки становятся метками серии.
+// Остальные типы не поддерживаются и возвращаются как отклоненные точки.
+package otlp
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/services/cumulative"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+)
+
+// MetricService — интерфейс сервиса метрик, который нужен для приема OTLP.
+type MetricService interface {
+	GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error)
+	UpdateMany(ctx context.Context, list []models.Metrics) error
+}
+
+// Receiver принимает OTLP-запросы. Один Receiver используется и для gRPC, и для HTTP,
+// чтобы накопительные значения считались одинаково.
+type Receiver struct {
+	service MetricService
+	tracker *cumulative.Tracker
+}
+
+func NewReceiver(s MetricService) *Receiver {
+	return &Receiver{
+		service: s,
+		tracker: cumulative.New(),
+	}
+}
+
+// Export записывает метрики из запроса и возвращает количество отклоненных точек.
+func (rc *Receiver) Export(ctx context.Context, req *colmetricspb.ExportMetricsServiceRequest) (int64, error) {
+	b := newBatch(ctx, rc)
+	for _, rm := range req.GetResourceMetrics() {
+		resource := attributes(nil, rm.GetResource().GetAttributes())
+		for _, sm := range rm.GetScopeMetrics() {
+			for _, m := range sm.GetMetrics() {
+				b.addMetric(resource, m)
+			}
+		}
+	}
+
+	list := b.list()
+	if len(list) == 0 {
+		return b.rejected, nil
+	}
+
+	err := rc.service.UpdateMany(ctx, list)
+	if err != nil {
+		// повтор экспорта должен получить те же приросты
+		b.deltas.Rollback()
+	}
+
+	return b.rejected, err
+}
+
+// storedCounter возвращает текущее значение счетчика или 0, если его еще нет.
+func (rc *Receiver) storedCounter(ctx context.Context, series models.Metrics) storage.Counter {
+	series.MType = "counter"
+	m, err := rc.service.GetMetricJSON(ctx, series)
+	if err != nil || m.Delta == nil {
+		return 0
+	}
+
+	return *m.Delta
+}
+
+// storedGauge возвращает текущее значение gauge или 0, если его еще нет.
+func (rc *Receiver) storedGauge(ctx context.Context, series models.Metrics) storage.Gauge {
+	series.MType = "gauge"
+	m, err := rc.service.GetMetricJSON(ctx, series)
+	if err != nil || m.Value == nil {
+		return 0
+	}
+
+	return *m.Value
+}
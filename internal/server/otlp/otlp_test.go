@@ -0,0 +1,227 @@
+package otlp
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func stringAttr(k, v string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{Key: k, Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: v}}}
+}
+
+func request(metrics ...*metricspb.Metric) *colmetricspb.ExportMetricsServiceRequest {
+	return &colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			Resource: &resourcepb.Resource{Attributes: []*commonpb.KeyValue{stringAttr("service.name", "api")}},
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: metrics,
+			}},
+		}},
+	}
+}
+
+func intPoint(ts uint64, v int64, attrs ...*commonpb.KeyValue) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{TimeUnixNano: ts, Value: &metricspb.NumberDataPoint_AsInt{AsInt: v}, Attributes: attrs}
+}
+
+func doublePoint(ts uint64, v float64) *metricspb.NumberDataPoint {
+	return &metricspb.NumberDataPoint{TimeUnixNano: ts, Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: v}}
+}
+
+func gaugeMetric(id string, v storage.Gauge, labels ...string) models.Metrics {
+	return models.Metrics{ID: id, MType: "gauge", Value: &v, Labels: labelsOf(labels...)}
+}
+
+func counterMetric(id string, v storage.Counter, labels ...string) models.Metrics {
+	return models.Metrics{ID: id, MType: "counter", Delta: &v, Labels: labelsOf(labels...)}
+}
+
+func labelsOf(kv ...string) storage.Labels {
+	if len(kv) == 0 {
+		return nil
+	}
+
+	labels := storage.Labels{}
+	for i := 0; i+1 < len(kv); i += 2 {
+		labels[kv[i]] = kv[i+1]
+	}
+
+	return labels
+}
+
+func TestExport(t *testing.T) {
+	storedCounter := storage.Counter(4)
+	storedGauge := storage.Gauge(10)
+
+	tests := []struct {
+		serviceErr   error
+		req          *colmetricspb.ExportMetricsServiceRequest
+		name         string
+		want         []models.Metrics
+		wantRejected int64
+	}{
+		{
+			name: "Gauge takes latest point, attributes become labels",
+			req: request(&metricspb.Metric{
+				Name: "cpu.usage",
+				Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+					doublePoint(2, 0.7),
+					doublePoint(1, 0.5),
+				}}},
+			}),
+			want: []models.Metrics{gaugeMetric("cpu.usage", 0.7, "service.name", "api")},
+		},
+		{
+			name: "Monotonic sums",
+			req: request(
+				&metricspb.Metric{
+					Name: "requests.delta",
+					Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						IsMonotonic:            true,
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+						DataPoints:             []*metricspb.NumberDataPoint{intPoint(1, 3), intPoint(2, 2)},
+					}},
+				},
+				&metricspb.Metric{
+					Name: "requests.total",
+					Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						IsMonotonic:            true,
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+						DataPoints:             []*metricspb.NumberDataPoint{intPoint(1, 10, stringAttr("code", "200"))},
+					}},
+				},
+			),
+			want: []models.Metrics{
+				counterMetric("requests.delta", 5, "service.name", "api"),
+				counterMetric("requests.total", 6, "code", "200", "service.name", "api"),
+			},
+		},
+		{
+			name: "Non-monotonic sums",
+			req: request(
+				&metricspb.Metric{
+					Name: "queue.size",
+					Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+						DataPoints:             []*metricspb.NumberDataPoint{intPoint(1, 7)},
+					}},
+				},
+				&metricspb.Metric{
+					Name: "connections",
+					Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_DELTA,
+						DataPoints:             []*metricspb.NumberDataPoint{intPoint(1, -2), intPoint(2, 5)},
+					}},
+				},
+			),
+			want: []models.Metrics{
+				gaugeMetric("queue.size", 7, "service.name", "api"),
+				gaugeMetric("connections", 13, "service.name", "api"),
+			},
+		},
+		{
+			name: "Cumulative histogram and unsupported types",
+			req: request(
+				&metricspb.Metric{
+					Name: "latency",
+					Data: &metricspb.Metric_Histogram{Histogram: &metricspb.Histogram{
+						AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+						DataPoints: []*metricspb.HistogramDataPoint{{
+							Count:          8,
+							Sum:            proto.Float64(1.5),
+							ExplicitBounds: []float64{0.1},
+							BucketCounts:   []uint64{5, 3},
+						}},
+					}},
+				},
+				&metricspb.Metric{
+					Name: "size",
+					Data: &metricspb.Metric_Summary{Summary: &metricspb.Summary{
+						DataPoints: []*metricspb.SummaryDataPoint{{Count: 1}, {Count: 2}},
+					}},
+				},
+			),
+			want: []models.Metrics{
+				counterMetric("latency_count", 4, "service.name", "api"),
+				counterMetric("latency_bucket", 1, "le", "0.1", "service.name", "api"),
+				counterMetric("latency_bucket", 4, "le", "+Inf", "service.name", "api"),
+				gaugeMetric("latency_sum", 1.5, "service.name", "api"),
+			},
+			wantRejected: 2,
+		},
+		{
+			name: "Service error",
+			req: request(&metricspb.Metric{
+				Name: "cpu.usage",
+				Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{DataPoints: []*metricspb.NumberDataPoint{
+					doublePoint(1, 1),
+				}}},
+			}),
+			serviceErr: merrors.ErrMocked,
+			want:       []models.Metrics{gaugeMetric("cpu.usage", 1, "service.name", "api")},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewMockMetricService(t)
+			s.EXPECT().GetMetricJSON(mock.Anything, mock.MatchedBy(func(m models.Metrics) bool {
+				return m.MType == "counter"
+			})).Return(models.Metrics{Delta: &storedCounter}, nil).Maybe()
+			s.EXPECT().GetMetricJSON(mock.Anything, mock.MatchedBy(func(m models.Metrics) bool {
+				return m.MType == "gauge"
+			})).Return(models.Metrics{Value: &storedGauge}, nil).Maybe()
+			s.EXPECT().UpdateMany(mock.Anything, tt.want).Return(tt.serviceErr).Once()
+
+			rejected, err := NewReceiver(s).Export(context.Background(), tt.req)
+			assert.ErrorIs(t, err, tt.serviceErr)
+			assert.Equal(t, tt.wantRejected, rejected)
+		})
+	}
+}
+
+func TestExportRetry(t *testing.T) {
+	storedCounter := storage.Counter(4)
+	total := func(v int64) *colmetricspb.ExportMetricsServiceRequest {
+		return request(&metricspb.Metric{
+			Name: "requests.total",
+			Data: &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+				IsMonotonic:            true,
+				AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+				DataPoints:             []*metricspb.NumberDataPoint{intPoint(1, v)},
+			}},
+		})
+	}
+
+	s := NewMockMetricService(t)
+	s.EXPECT().GetMetricJSON(mock.Anything, mock.Anything).
+		Return(models.Metrics{Delta: &storedCounter}, nil)
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("requests.total", 6, "service.name", "api")}).
+		Return(merrors.ErrMocked).Once()
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("requests.total", 6, "service.name", "api")}).
+		Return(nil).Once()
+	s.EXPECT().UpdateMany(mock.Anything, []models.Metrics{counterMetric("requests.total", 2, "service.name", "api")}).
+		Return(nil).Once()
+
+	rc := NewReceiver(s)
+	ctx := context.Background()
+
+	_, err := rc.Export(ctx, total(10))
+	require.ErrorIs(t, err, merrors.ErrMocked)
+	// повтор после ошибки не теряет прирост
+	_, err = rc.Export(ctx, total(10))
+	require.NoError(t, err)
+	_, err = rc.Export(ctx, total(12))
+	require.NoError(t, err)
+}
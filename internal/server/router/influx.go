@@ -0,0 +1,30 @@
+package router
+
+import (
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/ip"
+	"github.com/LekcRg/metrics/internal/server/handler/influx"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/go-chi/chi/v5"
+)
+
+func InfluxRoutes(
+	r chi.Router, metricService metric.MetricService, cfg config.ServerConfig,
+) {
+	var cumulative []string
+	for _, m := range strings.Split(cfg.InfluxCumulative, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			cumulative = append(cumulative, m)
+		}
+	}
+
+	r.Group(func(r chi.Router) {
+		if cfg.TrustedSubnet != "" {
+			r.Use(ip.FilterMiddleware(cfg.TrustedNetwork))
+		}
+
+		r.Post("/write", influx.Post(&metricService, cumulative, cfg.Key))
+	})
+}
@@ -0,0 +1,96 @@
+package router
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/cgzip"
+	"github.com/LekcRg/metrics/internal/server/services/dbping"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/server/services/store"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInfluxRoutes(t *testing.T) {
+	network := netip.MustParsePrefix("192.168.1.0/24")
+	db, _ := memstorage.New()
+	config := testdata.TestServerConfig
+	config.TrustedSubnet = network.String()
+	config.TrustedNetwork = &network
+	config.InfluxCumulative = "net, diskio"
+	store := store.NewStore(db, config)
+	service := metric.NewMetricsService(db, config, store)
+	pingService := dbping.NewPing(db, config)
+	r := NewRouter(NewRouterArgs{
+		MetricService: *service,
+		PingService:   *pingService,
+		Cfg:           config,
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		realIP   string
+		body     string
+		gzipped  bool
+		wantCode int
+	}{
+		{
+			name:     "#1[POST] Gzipped body from trusted subnet",
+			realIP:   "192.168.1.10",
+			body:     "cpu usage=1.5\nnet bytes_recv=10i",
+			gzipped:  true,
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "#2[POST] Plain body from trusted subnet",
+			realIP:   "192.168.1.10",
+			body:     "net bytes_recv=30i",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "#3[POST] Untrusted subnet",
+			realIP:   "10.0.0.1",
+			body:     "cpu usage=2",
+			wantCode: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				req *http.Request
+				err error
+			)
+			if tt.gzipped {
+				req, err = cgzip.GetGzippedReq(context.Background(), ts.URL+"/write", []byte(tt.body))
+			} else {
+				req, err = http.NewRequest(http.MethodPost, ts.URL+"/write", strings.NewReader(tt.body))
+			}
+			require.NoError(t, err)
+			req.Header.Set("X-Real-IP", tt.realIP)
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+
+	gauge, err := db.GetGaugeByName(context.Background(), "cpu_usage")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Gauge(1.5), gauge)
+
+	counter, err := db.GetCounterByName(context.Background(), "net_bytes_recv")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(30), counter)
+}
@@ -0,0 +1,18 @@
+package router
+
+import (
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/ip"
+	"github.com/LekcRg/metrics/internal/server/handler/otlp"
+	"github.com/go-chi/chi/v5"
+)
+
+func OTLPRoutes(r chi.Router, exporter otlp.Exporter, cfg config.ServerConfig) {
+	r.Group(func(r chi.Router) {
+		if cfg.TrustedSubnet != "" {
+			r.Use(ip.FilterMiddleware(cfg.TrustedNetwork))
+		}
+
+		r.Post("/v1/metrics", otlp.Post(exporter, cfg.Key))
+	})
+}
@@ -0,0 +1,101 @@
+package router
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/crypto"
+	"github.com/LekcRg/metrics/internal/server/services/dbping"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/server/services/store"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestOTLPRoutes(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	db, _ := memstorage.New()
+	config := testdata.TestServerConfig
+	config.PrivateKey = priv
+	config.TenantTokens = map[string]string{"tok1": "team-a"}
+	store := store.NewStore(db, config)
+	service := metric.NewMetricsService(db, config, store)
+	pingService := dbping.NewPing(db, config)
+	r := NewRouter(NewRouterArgs{
+		MetricService: *service,
+		PingService:   *pingService,
+		Cfg:           config,
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	body, err := proto.Marshal(&colmetricspb.ExportMetricsServiceRequest{
+		ResourceMetrics: []*metricspb.ResourceMetrics{{
+			ScopeMetrics: []*metricspb.ScopeMetrics{{
+				Metrics: []*metricspb.Metric{{
+					Name: "cpu",
+					Data: &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{
+						DataPoints: []*metricspb.NumberDataPoint{{
+							Value: &metricspb.NumberDataPoint_AsDouble{AsDouble: 1},
+						}},
+					}},
+				}},
+			}},
+		}},
+	})
+	require.NoError(t, err)
+
+	encrypted, err := crypto.EncryptRSA(body, &priv.PublicKey)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		authorization string
+		body          []byte
+		wantCode      int
+	}{
+		{
+			name:          "#1[POST] Encrypted body",
+			authorization: "Bearer tok1",
+			body:          encrypted,
+			wantCode:      http.StatusOK,
+		},
+		{
+			name:          "#2[POST] Unencrypted body with rsa",
+			authorization: "Bearer tok1",
+			body:          body,
+			wantCode:      http.StatusInternalServerError,
+		},
+		{
+			name:          "#3[POST] Unknown tenant token",
+			authorization: "Bearer tok2",
+			body:          encrypted,
+			wantCode:      http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/v1/metrics", bytes.NewReader(tt.body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/x-protobuf")
+			req.Header.Set("Authorization", tt.authorization)
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/LekcRg/metrics/internal/server/handler/err"
+	"github.com/LekcRg/metrics/internal/server/handler/query"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/go-chi/chi/v5"
+)
+
+func QueryRoutes(r chi.Router, metricService metric.MetricService) {
+	r.Route("/query", func(r chi.Router) {
+		r.Route("/{type:counter|gauge}", func(r chi.Router) {
+			r.Get("/{name}", query.Get(&metricService))
+		})
+		r.Get("/{type}/{name}", err.ErrorBadRequest)
+	})
+}
@@ -0,0 +1,90 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/server/services/store"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryRoutes(t *testing.T) {
+	queryStorage, _ := memstorage.New()
+	config := testdata.TestServerConfig
+	store := store.NewStore(queryStorage, config)
+	service := metric.NewMetricsService(queryStorage, config, store)
+
+	r := chi.NewRouter()
+	QueryRoutes(r, *service)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	queryStorage.UpdateGauge(context.Background(), "one", storage.Gauge(1))
+	queryStorage.UpdateGauge(context.Background(), "one", storage.Gauge(3))
+	queryStorage.UpdateCounter(context.Background(), "two", storage.Counter(5))
+
+	type want struct {
+		points int
+		code   int
+	}
+	tests := []struct {
+		name string
+		url  string
+		want want
+	}{
+		{
+			name: "#1[GET] Gauge with defaults",
+			url:  "/query/gauge/one?agg=avg",
+			want: want{code: http.StatusOK, points: 1},
+		},
+		{
+			name: "#2[GET] Counter sum",
+			url:  "/query/counter/two?step=1h&agg=sum",
+			want: want{code: http.StatusOK, points: 1},
+		},
+		{
+			name: "#3[GET] Unknown metric",
+			url:  "/query/gauge/three",
+			want: want{code: http.StatusOK, points: 0},
+		},
+		{
+			name: "#4[GET] Incorrect type",
+			url:  "/query/integer/one",
+			want: want{code: http.StatusBadRequest},
+		},
+		{
+			name: "#5[GET] Incorrect aggregation",
+			url:  "/query/gauge/one?agg=median",
+			want: want{code: http.StatusBadRequest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.url, nil)
+			require.NoError(t, err)
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.want.code, resp.StatusCode)
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+
+			var res models.RangeResult
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			assert.Len(t, res.Points, tt.want.points)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package router
+
+import (
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/ip"
+	"github.com/LekcRg/metrics/internal/server/handler/remotewrite"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/go-chi/chi/v5"
+)
+
+func RemoteWriteRoutes(
+	r chi.Router, metricService metric.MetricService, cfg config.ServerConfig,
+) {
+	r.Group(func(r chi.Router) {
+		if cfg.TrustedSubnet != "" {
+			r.Use(ip.FilterMiddleware(cfg.TrustedNetwork))
+		}
+
+		r.Post("/api/v1/write", remotewrite.Post(&metricService, cfg.Key))
+	})
+}
@@ -0,0 +1,71 @@
+package router
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/server/services/dbping"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/server/services/store"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemoteWriteRoutes(t *testing.T) {
+	priv, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	storage, _ := memstorage.New()
+	config := testdata.TestServerConfig
+	config.PrivateKey = priv
+	store := store.NewStore(storage, config)
+	service := metric.NewMetricsService(storage, config, store)
+	pingService := dbping.NewPing(storage, config)
+	r := NewRouter(NewRouterArgs{
+		MetricService: *service,
+		PingService:   *pingService,
+		Cfg:           config,
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		encoding string
+		wantCode int
+	}{
+		{
+			name:     "#1[POST] Unencrypted body is not decrypted with rsa",
+			encoding: "snappy",
+			wantCode: http.StatusNoContent,
+		},
+		{
+			name:     "#2[POST] Without Content-Encoding",
+			wantCode: http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := snappy.Encode(nil, []byte{})
+			req, err := http.NewRequest(http.MethodPost, ts.URL+"/api/v1/write", bytes.NewReader(body))
+			require.NoError(t, err)
+			req.Header.Set("Content-Type", "application/x-protobuf")
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+		})
+	}
+}
@@ -7,18 +7,26 @@ import (
 	"github.com/LekcRg/metrics/internal/logger"
 	"github.com/LekcRg/metrics/internal/server/handler/home"
 	"github.com/LekcRg/metrics/internal/server/handler/ping"
+	"github.com/LekcRg/metrics/internal/server/handler/prometheus"
+	"github.com/LekcRg/metrics/internal/server/otlp"
 	"github.com/LekcRg/metrics/internal/server/services/dbping"
 	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/tenant"
 	"github.com/go-chi/chi/v5"
 )
 
 type NewRouterArgs struct {
+	OTLPReceiver  *otlp.Receiver // если nil, создается новый
 	MetricService metric.MetricService
 	PingService   dbping.PingService
 	Cfg           config.ServerConfig
 }
 
 func NewRouter(args NewRouterArgs) chi.Router {
+	if args.OTLPReceiver == nil {
+		args.OTLPReceiver = otlp.NewReceiver(&args.MetricService)
+	}
+
 	r := chi.NewRouter()
 	r.Use(logger.RequestLogger)
 
@@ -26,12 +34,29 @@ func NewRouter(args NewRouterArgs) chi.Router {
 	r.Use(cgzip.GzipHandle)
 	r.Use(cgzip.GzipBody)
 
-	r.Use(crypto.RsaMiddleware(args.Cfg.PrivateKey))
+	// /ping проверяет сервер целиком и не зависит от тенанта.
+	r.With(crypto.RsaMiddleware(args.Cfg.PrivateKey)).Get("/ping", ping.Ping(args.PingService))
+
+	r.Group(func(r chi.Router) {
+		r.Use(tenant.NewResolver(args.Cfg.TenantTokens, args.Cfg.TenantHeader).Middleware)
+
+		// Внешние отправители не шифруют тело запроса, поэтому их роуты вне RSA.
+		RemoteWriteRoutes(r, args.MetricService, args.Cfg)
+		InfluxRoutes(r, args.MetricService, args.Cfg)
+
+		r.Group(func(r chi.Router) {
+			r.Use(crypto.RsaMiddleware(args.Cfg.PrivateKey))
 
-	r.Get("/", home.Get(&args.MetricService))
-	r.Get("/ping", ping.Ping(args.PingService))
-	UpdateRoutes(r, args.MetricService, args.Cfg)
-	ValueRoutes(r, args.MetricService)
+			r.Get("/", home.Get(&args.MetricService))
+			r.Get("/metrics", prometheus.Get(&args.MetricService))
+			UpdateRoutes(r, args.MetricService, args.Cfg)
+			ValueRoutes(r, args.MetricService, args.Cfg)
+			QueryRoutes(r, args.MetricService)
+			SeriesRoutes(r, args.MetricService)
+			StreamRoutes(r, args.MetricService)
+			OTLPRoutes(r, args.OTLPReceiver, args.Cfg)
+		})
+	})
 
 	return r
 }
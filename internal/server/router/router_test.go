@@ -1,6 +1,7 @@
 package router
 
 import (
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"testing"
@@ -9,6 +10,7 @@ import (
 	"github.com/LekcRg/metrics/internal/server/services/metric"
 	"github.com/LekcRg/metrics/internal/server/services/store"
 	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/server/storage/tenantstorage"
 	"github.com/LekcRg/metrics/internal/testdata"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -45,6 +47,14 @@ func TestNewRouter(t *testing.T) {
 				contentType: "text/html",
 			},
 		},
+		{
+			name: "#2 Get request /metrics",
+			url:  "/metrics",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; version=0.0.4; charset=utf-8",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -60,3 +70,106 @@ func TestNewRouter(t *testing.T) {
 		})
 	}
 }
+
+func TestNewRouterTenants(t *testing.T) {
+	db, _ := memstorage.New()
+	config := testdata.TestServerConfig
+	config.TenantTokens = map[string]string{"tok-a": "team-a", "tok-b": "team-b"}
+	store := store.NewStore(db, config)
+	updateService := metric.NewMetricsService(tenantstorage.New(db, 1), config, store)
+	pingService := dbping.NewPing(db, config)
+	r := NewRouter(NewRouterArgs{
+		MetricService: *updateService,
+		PingService:   *pingService,
+		Cfg:           config,
+	})
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		token    string
+		wantBody string
+		wantCode int
+	}{
+		{
+			name:     "Update team-a",
+			method:   http.MethodPost,
+			url:      "/update/gauge/RandomValue/1",
+			token:    "tok-a",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Update team-b",
+			method:   http.MethodPost,
+			url:      "/update/gauge/RandomValue/2",
+			token:    "tok-b",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "Value team-a",
+			method:   http.MethodGet,
+			url:      "/value/gauge/RandomValue",
+			token:    "tok-a",
+			wantCode: http.StatusOK,
+			wantBody: "1",
+		},
+		{
+			name:     "Value team-b",
+			method:   http.MethodGet,
+			url:      "/value/gauge/RandomValue",
+			token:    "tok-b",
+			wantCode: http.StatusOK,
+			wantBody: "2",
+		},
+		{
+			name:     "Value of default tenant",
+			method:   http.MethodGet,
+			url:      "/value/gauge/RandomValue",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "Series quota",
+			method:   http.MethodPost,
+			url:      "/update/gauge/Alloc/1",
+			token:    "tok-a",
+			wantCode: http.StatusTooManyRequests,
+		},
+		{
+			name:     "Unknown token",
+			method:   http.MethodGet,
+			url:      "/value/gauge/RandomValue",
+			token:    "tok-c",
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Ping does not depend on tenant",
+			method:   http.MethodGet,
+			url:      "/ping",
+			token:    "tok-c",
+			wantCode: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.url, nil)
+			require.NoError(t, err)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.wantCode, resp.StatusCode)
+			if tt.wantBody != "" {
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.wantBody, string(body))
+			}
+		})
+	}
+}
@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/LekcRg/metrics/internal/server/handler/err"
+	"github.com/LekcRg/metrics/internal/server/handler/series"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/go-chi/chi/v5"
+)
+
+func SeriesRoutes(r chi.Router, metricService metric.MetricService) {
+	r.Route("/series", func(r chi.Router) {
+		r.Route("/{type:counter|gauge}", func(r chi.Router) {
+			r.Get("/{name}", series.Get(&metricService))
+		})
+		r.Get("/{type}/{name}", err.ErrorBadRequest)
+	})
+}
@@ -0,0 +1,98 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/server/services/store"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesRoutes(t *testing.T) {
+	seriesStorage, _ := memstorage.New()
+	config := testdata.TestServerConfig
+	store := store.NewStore(seriesStorage, config)
+	service := metric.NewMetricsService(seriesStorage, config, store)
+
+	r := chi.NewRouter()
+	SeriesRoutes(r, *service)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	seriesStorage.UpdateGauge(ctx, "cpu", storage.Gauge(1))
+	seriesStorage.UpdateGauge(ctx, `cpu{host="web1",region="eu"}`, storage.Gauge(2))
+	seriesStorage.UpdateGauge(ctx, `cpu{host="web2",region="us"}`, storage.Gauge(3))
+	seriesStorage.UpdateGauge(ctx, `mem{host="web1"}`, storage.Gauge(4))
+
+	type want struct {
+		series int
+		code   int
+	}
+	tests := []struct {
+		name string
+		url  string
+		want want
+	}{
+		{
+			name: "#1[GET] All series",
+			url:  "/series/gauge/cpu",
+			want: want{code: http.StatusOK, series: 3},
+		},
+		{
+			name: "#2[GET] Equality matcher",
+			url:  "/series/gauge/cpu?match=" + url.QueryEscape(`{host="web1"}`),
+			want: want{code: http.StatusOK, series: 1},
+		},
+		{
+			name: "#3[GET] Regexp matcher",
+			url:  "/series/gauge/cpu?match=" + url.QueryEscape(`{host=~"web.*",region!="us"}`),
+			want: want{code: http.StatusOK, series: 1},
+		},
+		{
+			name: "#4[GET] Unknown metric",
+			url:  "/series/counter/cpu",
+			want: want{code: http.StatusOK, series: 0},
+		},
+		{
+			name: "#5[GET] Incorrect matchers",
+			url:  "/series/gauge/cpu?match=" + url.QueryEscape(`{host=}`),
+			want: want{code: http.StatusBadRequest},
+		},
+		{
+			name: "#6[GET] Incorrect type",
+			url:  "/series/integer/cpu",
+			want: want{code: http.StatusBadRequest},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.url, nil)
+			require.NoError(t, err)
+
+			resp, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.want.code, resp.StatusCode)
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+
+			var res []models.Metrics
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&res))
+			assert.Len(t, res, tt.want.series)
+		})
+	}
+}
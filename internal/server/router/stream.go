@@ -0,0 +1,11 @@
+package router
+
+import (
+	"github.com/LekcRg/metrics/internal/server/handler/stream"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/go-chi/chi/v5"
+)
+
+func StreamRoutes(r chi.Router, metricService metric.MetricService) {
+	r.Get("/stream", stream.Get(&metricService))
+}
@@ -0,0 +1,75 @@
+package router
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/services/dbping"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/server/services/store"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamRoutes(t *testing.T) {
+	db, _ := memstorage.New()
+	config := testdata.TestServerConfig
+	store := store.NewStore(db, config)
+	service := metric.NewMetricsService(db, config, store)
+	pingService := dbping.NewPing(db, config)
+	r := NewRouter(NewRouterArgs{
+		MetricService: *service,
+		PingService:   *pingService,
+		Cfg:           config,
+	})
+	ts := httptest.NewServer(r)
+	t.Cleanup(ts.Close)
+
+	res, err := http.Get(ts.URL + "/stream?type=unknown")
+	require.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	t.Cleanup(cancel)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, ts.URL+"/stream?name=Heap&type=gauge", nil)
+	require.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+	res, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { res.Body.Close() })
+
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	assert.Equal(t, "text/event-stream", res.Header.Get("Content-Type"))
+	assert.Empty(t, res.Header.Get("Content-Encoding"))
+
+	body := bufio.NewReader(res.Body)
+	line, err := body.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, ": connected\n", line)
+
+	for _, url := range []string{"/update/gauge/Alloc/1", "/update/gauge/HeapAlloc/2.5"} {
+		res, err := http.Post(ts.URL+url, "text/plain", nil)
+		require.NoError(t, err)
+		res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	}
+
+	var lines []string
+	for len(lines) < 2 {
+		line, err := body.ReadString('\n')
+		require.NoError(t, err)
+		if line = strings.TrimSuffix(line, "\n"); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	assert.Equal(t, "event: update", lines[0])
+	assert.JSONEq(t, `{"id":"HeapAlloc","type":"gauge","value":2.5}`, strings.TrimPrefix(lines[1], "data: "))
+}
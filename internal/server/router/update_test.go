@@ -1,12 +1,16 @@
 package router
 
 import (
+	"context"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/LekcRg/metrics/internal/server/services/metric"
 	"github.com/LekcRg/metrics/internal/server/services/store"
+	"github.com/LekcRg/metrics/internal/server/storage"
 	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
 	"github.com/LekcRg/metrics/internal/testdata"
 
@@ -17,10 +21,10 @@ import (
 )
 
 func TestUpdateRoutes(t *testing.T) {
-	storage, _ := memstorage.New()
+	db, _ := memstorage.New()
 	config := testdata.TestServerConfig
-	store := store.NewStore(storage, config)
-	updateService := metric.NewMetricsService(storage, config, store)
+	store := store.NewStore(db, config)
+	updateService := metric.NewMetricsService(db, config, store)
 	r := chi.NewRouter()
 	UpdateRoutes(r, *updateService, config)
 	ts := httptest.NewServer(r)
@@ -123,6 +127,46 @@ func TestUpdateRoutes(t *testing.T) {
 				contentType: "text/plain; charset=utf-8",
 			},
 		},
+		{
+			name: "#13[POST] Positive histogram observation",
+			url:  "/update/histogram/twelve/0.3",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name: "#14[POST] Negative request with wrong histogram value",
+			url:  "/update/histogram/twelve/fast",
+			want: want{
+				code:        http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name: "#15[POST] Positive summary observation",
+			url:  "/update/summary/thirteen/0.3",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name: "#16[POST] Negative request with wrong summary value",
+			url:  "/update/summary/thirteen/NaN",
+			want: want{
+				code:        http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name: "#17[POST] Positive set member",
+			url:  "/update/set/fourteen/alice",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -138,3 +182,72 @@ func TestUpdateRoutes(t *testing.T) {
 		})
 	}
 }
+
+const updatesBody = `[
+	{"id":"PollCount","type":"counter","delta":1},
+	{"id":"RandomValue","type":"gauge","value":0.5},
+	{"id":"Alloc","type":"gauge","value":1024},
+	{"id":"requests","type":"counter","delta":2,"labels":{"host":"web1"}}
+]`
+
+func newUpdatesHandler(t testing.TB) (http.Handler, *memstorage.MemStorage) {
+	db, err := memstorage.New()
+	require.NoError(t, err)
+	config := testdata.TestServerConfig
+	updateService := metric.NewMetricsService(db, config, store.NewStore(db, config))
+	r := chi.NewRouter()
+	UpdateRoutes(r, *updateService, config)
+
+	return r, db
+}
+
+func postUpdates(h http.Handler) int {
+	req := httptest.NewRequest(http.MethodPost, "/updates/", strings.NewReader(updatesBody))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	return rec.Code
+}
+
+func TestUpdatesParallel(t *testing.T) {
+	h, db := newUpdatesHandler(t)
+
+	const (
+		workers  = 16
+		requests = 50
+	)
+
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range requests {
+				assert.Equal(t, http.StatusOK, postUpdates(h))
+			}
+		}()
+	}
+	wg.Wait()
+
+	ctx := context.Background()
+	pollCount, err := db.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(workers*requests), pollCount)
+
+	labeled, err := db.GetCounterByName(ctx, `requests{host="web1"}`)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(2*workers*requests), labeled)
+}
+
+func BenchmarkUpdatesParallel(b *testing.B) {
+	h, _ := newUpdatesHandler(b)
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if code := postUpdates(h); code != http.StatusOK {
+				b.Errorf("unexpected status %d", code)
+			}
+		}
+	})
+}
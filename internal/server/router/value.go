@@ -1,17 +1,26 @@
 package router
 
 import (
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/ip"
 	"github.com/LekcRg/metrics/internal/server/handler/err"
 	"github.com/LekcRg/metrics/internal/server/handler/value"
 	"github.com/LekcRg/metrics/internal/server/services/metric"
 	"github.com/go-chi/chi/v5"
 )
 
-func ValueRoutes(r chi.Router, metricService metric.MetricService) {
+func ValueRoutes(r chi.Router, metricService metric.MetricService, cfg config.ServerConfig) {
 	r.Route("/value", func(r chi.Router) {
 		r.Post("/", value.Post(&metricService))
-		r.Route("/{type:counter|gauge}", func(r chi.Router) {
+		r.Route("/{type:counter|gauge|histogram|summary|set}", func(r chi.Router) {
 			r.Get("/{name}", value.Get(&metricService))
+			r.Group(func(r chi.Router) {
+				if cfg.TrustedSubnet != "" {
+					r.Use(ip.FilterMiddleware(cfg.TrustedNetwork))
+				}
+
+				r.Delete("/{name}", value.Delete(&metricService))
+			})
 		})
 		r.Get("/{type}/{name}", err.ErrorBadRequest)
 	})
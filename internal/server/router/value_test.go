@@ -5,7 +5,9 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
 	"testing"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/server/services/metric"
 	"github.com/LekcRg/metrics/internal/server/services/store"
@@ -25,7 +27,7 @@ func TestValueRoutes(t *testing.T) {
 	store := store.NewStore(valueStorage, config)
 	updateService := metric.NewMetricsService(valueStorage, config, store)
 	r := chi.NewRouter()
-	ValueRoutes(r, *updateService)
+	ValueRoutes(r, *updateService, config)
 	ts := httptest.NewServer(r)
 	defer ts.Close()
 	require.NotNil(t, valueStorage)
@@ -33,6 +35,19 @@ func TestValueRoutes(t *testing.T) {
 	valueStorage.UpdateCounter(context.Background(), "two", storage.Counter(12345))
 	valueStorage.UpdateGauge(context.Background(), "five", storage.Gauge(-123.45))
 	valueStorage.UpdateCounter(context.Background(), "six", storage.Counter(-12345))
+	valueStorage.UpdateHistogram(context.Background(), "seven", storage.Histogram{
+		Buckets: []float64{1, 2}, Counts: []uint64{10, 10, 5}, Sum: 30, Count: 25,
+	})
+	summary := storage.NewSummary(time.Minute)
+	for _, v := range []float64{1, 2, 3, 4, 5} {
+		summary.Observe(v, time.Now())
+	}
+	valueStorage.UpdateSummary(context.Background(), "eight", summary)
+	users := storage.NewSet()
+	for _, u := range []string{"alice", "bob", "alice"} {
+		users.Add(u)
+	}
+	valueStorage.UpdateSet(context.Background(), "ten", users)
 
 	type want struct {
 		contentType string
@@ -98,6 +113,67 @@ func TestValueRoutes(t *testing.T) {
 				response:    "",
 			},
 		},
+		{
+			name: "#7[GET] Histogram median",
+			url:  "/value/histogram/seven",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+				response:    "1.25",
+			},
+		},
+		{
+			name: "#8[GET] Histogram quantile",
+			url:  "/value/histogram/seven?q=0.2",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+				response:    "0.5",
+			},
+		},
+		{
+			name: "#9[GET] Histogram incorrect quantile",
+			url:  "/value/histogram/seven?q=1.5",
+			want: want{
+				code:        http.StatusBadRequest,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name: "#10[GET] Summary median",
+			url:  "/value/summary/eight",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+				response:    "3",
+			},
+		},
+		{
+			name: "#11[GET] Summary quantile",
+			url:  "/value/summary/eight?q=0.75",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+				response:    "4",
+			},
+		},
+		{
+			name: "#12[GET] Summary not found",
+			url:  "/value/summary/nine",
+			want: want{
+				code:        http.StatusNotFound,
+				contentType: "text/plain; charset=utf-8",
+			},
+		},
+		{
+			name: "#13[GET] Set unique count",
+			url:  "/value/set/ten",
+			want: want{
+				code:        http.StatusOK,
+				contentType: "text/plain; charset=utf-8",
+				response:    "2",
+			},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -117,3 +193,93 @@ func TestValueRoutes(t *testing.T) {
 		})
 	}
 }
+
+func TestValueDeleteRoutes(t *testing.T) {
+	network := netip.MustParsePrefix("192.168.1.0/24")
+	db, _ := memstorage.New()
+	config := testdata.TestServerConfig
+	config.TrustedSubnet = network.String()
+	config.TrustedNetwork = &network
+	service := metric.NewMetricsService(db, config, store.NewStore(db, config))
+	r := chi.NewRouter()
+	ValueRoutes(r, *service, config)
+	ts := httptest.NewServer(r)
+	defer ts.Close()
+
+	ctx := context.Background()
+	_, err := db.UpdateGauge(ctx, "CPUutilization17", 12.5)
+	require.NoError(t, err)
+	_, err = db.UpdateCounter(ctx, "PollCount", 1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		realIP   string
+		wantCode int
+	}{
+		{
+			name:     "#1[DELETE] Untrusted subnet",
+			method:   http.MethodDelete,
+			url:      "/value/gauge/CPUutilization17",
+			realIP:   "10.0.0.1",
+			wantCode: http.StatusForbidden,
+		},
+		{
+			name:     "#2[DELETE] Trusted subnet",
+			method:   http.MethodDelete,
+			url:      "/value/gauge/CPUutilization17",
+			realIP:   "192.168.1.10",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "#3[GET] Deleted metric",
+			method:   http.MethodGet,
+			url:      "/value/gauge/CPUutilization17",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "#4[DELETE] Already deleted",
+			method:   http.MethodDelete,
+			url:      "/value/gauge/CPUutilization17",
+			realIP:   "192.168.1.10",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "#5[DELETE] Other type with same name",
+			method:   http.MethodDelete,
+			url:      "/value/gauge/PollCount",
+			realIP:   "192.168.1.10",
+			wantCode: http.StatusNotFound,
+		},
+		{
+			name:     "#6[GET] Other metrics are kept",
+			method:   http.MethodGet,
+			url:      "/value/counter/PollCount",
+			wantCode: http.StatusOK,
+		},
+		{
+			name:     "#7[DELETE] Unknown type",
+			method:   http.MethodDelete,
+			url:      "/value/unknown/PollCount",
+			realIP:   "192.168.1.10",
+			wantCode: http.StatusMethodNotAllowed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, ts.URL+tt.url, nil)
+			require.NoError(t, err)
+			if tt.realIP != "" {
+				req.Header.Set("X-Real-IP", tt.realIP)
+			}
+
+			res, err := ts.Client().Do(req)
+			require.NoError(t, err)
+			defer res.Body.Close()
+
+			assert.Equal(t, tt.wantCode, res.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package serverapp
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+)
+
+// parseBuckets разбирает границы бакетов гистограммы через запятую.
+// Пустая строка означает границы по умолчанию.
+func parseBuckets(val string) ([]float64, error) {
+	if val == "" {
+		return slices.Clone(storage.DefaultBuckets), nil
+	}
+
+	parts := strings.Split(val, ",")
+	buckets := make([]float64, 0, len(parts))
+	for _, part := range parts {
+		b, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		buckets = append(buckets, b)
+	}
+
+	if err := storage.ValidateBuckets(buckets); err != nil {
+		return nil, err
+	}
+
+	return buckets, nil
+}
+
+// parseConfig разбирает строковые настройки сервера: заполняет бакеты
+// гистограммы и токены тенантов в cfg и возвращает политику хранения.
+func parseConfig(cfg *config.ServerConfig) (storage.RetentionPolicy, error) {
+	var err error
+
+	cfg.Buckets, err = parseBuckets(cfg.HistogramBuckets)
+	if err != nil {
+		return storage.RetentionPolicy{}, fmt.Errorf("incorrect histogram buckets: %w", err)
+	}
+
+	cfg.TenantTokens, err = tenant.ParseTokens(cfg.Tenants)
+	if err != nil {
+		return storage.RetentionPolicy{}, fmt.Errorf("incorrect tenants: %w", err)
+	}
+
+	policy, err := storage.ParseRetention(cfg.Retention)
+	if err != nil {
+		return storage.RetentionPolicy{}, fmt.Errorf("incorrect retention policy: %w", err)
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,77 @@
+package serverapp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfig(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.ServerConfig
+		wantBuckets []float64
+		wantTokens  map[string]string
+		wantPolicy  storage.RetentionPolicy
+		wantErr     bool
+	}{
+		{
+			name:        "Defaults",
+			wantBuckets: storage.DefaultBuckets,
+		},
+		{
+			name:        "Histogram buckets",
+			cfg:         config.ServerConfig{HistogramBuckets: "0.1, 1,10"},
+			wantBuckets: []float64{0.1, 1, 10},
+		},
+		{
+			name:        "Tenants",
+			cfg:         config.ServerConfig{Tenants: "tok1:team-a, tok2:team-b"},
+			wantBuckets: storage.DefaultBuckets,
+			wantTokens:  map[string]string{"tok1": "team-a", "tok2": "team-b"},
+		},
+		{
+			name:        "Retention policy",
+			cfg:         config.ServerConfig{Retention: "raw:24h,1m:30d"},
+			wantBuckets: storage.DefaultBuckets,
+			wantPolicy: storage.RetentionPolicy{
+				Raw:    24 * time.Hour,
+				Levels: []storage.RollupLevel{{Resolution: time.Minute, Retention: 30 * 24 * time.Hour}},
+			},
+		},
+		{
+			name:    "Incorrect buckets",
+			cfg:     config.ServerConfig{HistogramBuckets: "10,1"},
+			wantErr: true,
+		},
+		{
+			name:    "Incorrect tenants",
+			cfg:     config.ServerConfig{Tenants: "tok1"},
+			wantErr: true,
+		},
+		{
+			name:    "Incorrect retention",
+			cfg:     config.ServerConfig{Retention: "raw"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := tt.cfg
+			policy, err := parseConfig(&cfg)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBuckets, cfg.Buckets)
+			assert.Equal(t, tt.wantTokens, cfg.TenantTokens)
+			assert.Equal(t, tt.wantPolicy, policy)
+		})
+	}
+}
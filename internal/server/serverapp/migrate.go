@@ -0,0 +1,101 @@
+package serverapp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/server/storage/postgres"
+	"github.com/LekcRg/metrics/internal/server/storage/sqlite"
+	"go.uber.org/zap"
+)
+
+// MigrateCommand — имя подкоманды сервера для миграций postgres:
+//
+//	server migrate [up | down [N] | version] [флаги сервера]
+//
+// up применяет все миграции, down откатывает N последних (по умолчанию одну),
+// version выводит текущую версию схемы. Без действия выполняется up.
+const MigrateCommand = "migrate"
+
+type migrateAction struct {
+	name  string
+	steps int
+}
+
+// splitMigrateArgs отделяет действие и его аргументы от флагов сервера.
+func splitMigrateArgs(args []string) ([]string, []string) {
+	i := 0
+	for i < len(args) && !strings.HasPrefix(args[i], "-") {
+		i++
+	}
+
+	return args[:i], args[i:]
+}
+
+func parseMigrateAction(args []string) (migrateAction, error) {
+	res := migrateAction{name: "up", steps: 1}
+	if len(args) > 0 {
+		res.name = args[0]
+	}
+
+	switch {
+	case (res.name == "up" || res.name == "version") && len(args) <= 1:
+	case res.name == "down" && len(args) == 2:
+		steps, err := strconv.Atoi(args[1])
+		if err != nil || steps <= 0 {
+			return migrateAction{}, fmt.Errorf("incorrect number of migrations %q", args[1])
+		}
+		res.steps = steps
+	case res.name == "down" && len(args) == 1:
+	default:
+		return migrateAction{}, fmt.Errorf("incorrect migrate action %q, must be up, down [N] or version",
+			strings.Join(args, " "))
+	}
+
+	return res, nil
+}
+
+// Migrate выполняет подкоманду migrate над базой из конфигурации сервера.
+// args — аргументы после имени подкоманды.
+func Migrate(ctx context.Context, args []string) error {
+	actionArgs, flags := splitMigrateArgs(args)
+	cfg := config.LoadServerCfg(flags...)
+	logger.Initialize(cfg.LogLvl, cfg.IsDev)
+
+	action, err := parseMigrateAction(actionArgs)
+	if err != nil {
+		return err
+	}
+	if cfg.DatabaseDSN == "" || sqlite.IsDSN(cfg.DatabaseDSN) {
+		return errors.New("migrations need postgres database DSN")
+	}
+
+	db, err := postgres.Connect(ctx, cfg.DatabaseDSN)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch action.name {
+	case "up":
+		err = postgres.MigrateUp(ctx, db)
+	case "down":
+		err = postgres.MigrateDown(ctx, db, action.steps)
+	}
+	if err != nil {
+		return err
+	}
+
+	version, err := postgres.SchemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+
+	logger.Log.Info("database schema version", zap.Int("version", version))
+	return nil
+}
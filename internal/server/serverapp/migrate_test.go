@@ -0,0 +1,85 @@
+package serverapp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitMigrateArgs(t *testing.T) {
+	action, flags := splitMigrateArgs([]string{"down", "2", "-d", "postgresql://localhost"})
+	assert.Equal(t, []string{"down", "2"}, action)
+	assert.Equal(t, []string{"-d", "postgresql://localhost"}, flags)
+
+	action, flags = splitMigrateArgs([]string{"-d", "postgresql://localhost"})
+	assert.Empty(t, action)
+	assert.Equal(t, []string{"-d", "postgresql://localhost"}, flags)
+}
+
+func TestParseMigrateAction(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    migrateAction
+		wantErr bool
+	}{
+		{
+			name: "Default up",
+			want: migrateAction{name: "up", steps: 1},
+		},
+		{
+			name: "Up",
+			args: []string{"up"},
+			want: migrateAction{name: "up", steps: 1},
+		},
+		{
+			name: "Down one",
+			args: []string{"down"},
+			want: migrateAction{name: "down", steps: 1},
+		},
+		{
+			name: "Down several",
+			args: []string{"down", "3"},
+			want: migrateAction{name: "down", steps: 3},
+		},
+		{
+			name: "Version",
+			args: []string{"version"},
+			want: migrateAction{name: "version", steps: 1},
+		},
+		{
+			name:    "Down zero",
+			args:    []string{"down", "0"},
+			wantErr: true,
+		},
+		{
+			name:    "Down not a number",
+			args:    []string{"down", "all"},
+			wantErr: true,
+		},
+		{
+			name:    "Up with steps",
+			args:    []string{"up", "1"},
+			wantErr: true,
+		},
+		{
+			name:    "Unknown action",
+			args:    []string{"redo"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseMigrateAction(tt.args)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -8,24 +8,35 @@ import (
 	"net/http"
 	"os"
 	"sync"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/config"
 	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/server/graphite"
 	"github.com/LekcRg/metrics/internal/server/grpcapi"
+	"github.com/LekcRg/metrics/internal/server/otlp"
 	"github.com/LekcRg/metrics/internal/server/router"
 	"github.com/LekcRg/metrics/internal/server/services/dbping"
+	"github.com/LekcRg/metrics/internal/server/services/expiry"
 	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/server/services/retention"
 	"github.com/LekcRg/metrics/internal/server/services/store"
+	"github.com/LekcRg/metrics/internal/server/statsd"
 	"github.com/LekcRg/metrics/internal/server/storage"
 	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
 	"github.com/LekcRg/metrics/internal/server/storage/postgres"
+	"github.com/LekcRg/metrics/internal/server/storage/sqlite"
+	"github.com/LekcRg/metrics/internal/server/storage/tenantstorage"
+	"github.com/LekcRg/metrics/internal/server/storage/wal"
+	"github.com/LekcRg/metrics/internal/tenant"
 	"go.uber.org/zap"
-	"google.golang.org/grpc"
 )
 
 type App struct {
 	server     *http.Server
-	grpcServer *grpc.Server
+	grpcServer *grpcapi.Server
+	statsd     *statsd.Server
+	graphite   *graphite.Server
 	store      *store.Store
 	db         storage.Storage
 	config     config.ServerConfig
@@ -35,10 +46,17 @@ func initDB(ctx context.Context, cfg config.ServerConfig) (storage.Storage, erro
 	var db storage.Storage
 	var err error
 
-	if cfg.DatabaseDSN != "" {
+	switch {
+	case sqlite.IsDSN(cfg.DatabaseDSN):
+		logger.Log.Info("Create sqlite storage")
+		db, err = sqlite.NewSQLite(ctx, cfg)
+	case cfg.DatabaseDSN != "":
 		logger.Log.Info("create pg storage")
 		db, err = postgres.NewPostgres(ctx, cfg)
-	} else {
+	case cfg.WALDir != "":
+		logger.Log.Info("Create write-ahead log storage in " + cfg.WALDir)
+		db, err = wal.New(ctx, cfg)
+	default:
 		logger.Log.Info("Create memstorage")
 		db, err = memstorage.New()
 	}
@@ -49,6 +67,11 @@ func initDB(ctx context.Context, cfg config.ServerConfig) (storage.Storage, erro
 func New(ctx context.Context, wg *sync.WaitGroup) (*App, error) {
 	config := config.LoadServerCfg(os.Args[1:]...)
 	logger.Initialize(config.LogLvl, config.IsDev)
+
+	policy, err := parseConfig(&config)
+	if err != nil {
+		return nil, err
+	}
 	cfgString := fmt.Sprintf("%+v\n", config)
 	logger.Log.Info(cfgString)
 
@@ -63,38 +86,84 @@ func New(ctx context.Context, wg *sync.WaitGroup) (*App, error) {
 	logger.Log.Info("Create dbping service")
 	ping := dbping.NewPing(db, config)
 
+	// Хранилище тенантов без тенанта в контексте работает с метриками всех
+	// тенантов, поэтому его получают и фоновые задачи: удаления освобождают квоты.
+	metricDB := db
+	if tenant.NewResolver(config.TenantTokens, config.TenantHeader).Enabled() {
+		logger.Log.Info("Enable tenants")
+		metricDB = tenantstorage.New(db, config.TenantMaxSeries)
+	}
+
 	logger.Log.Info("Create metric service")
-	metricService := metric.NewMetricsService(db, config, store)
+	metricService := metric.NewMetricsService(metricDB, config, store)
+	metricService.Retention = policy
+
+	otlpReceiver := otlp.NewReceiver(metricService)
 
 	logger.Log.Info("Create router")
 	router := router.NewRouter(router.NewRouterArgs{
+		OTLPReceiver:  otlpReceiver,
 		MetricService: *metricService,
 		PingService:   *ping,
 		Cfg:           config,
 	})
 
-	if config.Restore {
+	// журнал сам сохраняет и восстанавливает значения, файл store с ним не нужен
+	fileStore := store
+	if config.WALDir != "" {
+		fileStore = nil
+	}
+
+	if fileStore != nil && config.Restore {
 		store.Restore(ctx)
 	}
 
-	if !config.SyncSave && config.StoreInterval > 0 {
+	if fileStore != nil && !config.SyncSave && config.StoreInterval > 0 {
 		wg.Add(1)
 		logger.Log.Info("Start saving store")
 		go store.StartSaving(ctx, wg)
 	}
 
+	if policy.Enabled() {
+		wg.Add(1)
+		logger.Log.Info("Start retention")
+		go retention.New(metricDB, policy, config).Start(ctx, wg)
+	}
+
+	if config.MetricTTL > 0 {
+		wg.Add(1)
+		logger.Log.Info("Start metrics expiry")
+		go expiry.New(metricDB, config).Start(ctx, wg)
+	}
+
 	server := &http.Server{
 		Addr:    config.Addr,
 		Handler: router,
 	}
 
-	grpcServer := grpcapi.NewServer(metricService, config)
+	grpcServer := grpcapi.NewServer(metricService, otlpReceiver, config)
+
+	var statsdServer *statsd.Server
+	if config.StatsDAddr != "" {
+		flush := time.Duration(config.StatsDFlushInterval) * time.Second
+		statsdServer = statsd.New(metricService, config.StatsDAddr, flush)
+	}
+
+	var graphiteServer *graphite.Server
+	if config.GraphiteAddr != "" {
+		graphiteServer, err = graphite.New(metricService, config.GraphiteAddr, config.GraphiteCounterPattern)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	return &App{
 		config:     config,
 		server:     server,
 		grpcServer: grpcServer,
-		store:      store,
+		statsd:     statsdServer,
+		graphite:   graphiteServer,
+		store:      fileStore,
 		db:         db,
 	}, nil
 }
@@ -124,6 +193,32 @@ func (app *App) Start(wg *sync.WaitGroup) {
 		logger.Log.Info("GRPC server goroutine exited")
 		wg.Done()
 	}()
+
+	if app.statsd != nil {
+		wg.Add(1)
+		go func() {
+			logger.Log.Info("Starting StatsD server")
+			if err := app.statsd.ListenAndServe(); err != statsd.ErrServerClosed {
+				logger.Log.Error("StatsD server error", zap.Error(err))
+			}
+
+			logger.Log.Info("StatsD server goroutine exited")
+			wg.Done()
+		}()
+	}
+
+	if app.graphite != nil {
+		wg.Add(1)
+		go func() {
+			logger.Log.Info("Starting Graphite server")
+			if err := app.graphite.ListenAndServe(); err != graphite.ErrServerClosed {
+				logger.Log.Error("Graphite server error", zap.Error(err))
+			}
+
+			logger.Log.Info("Graphite server goroutine exited")
+			wg.Done()
+		}()
+	}
 }
 
 func (app *App) Stop(ctx context.Context) {
@@ -141,7 +236,13 @@ func (app *App) Stop(ctx context.Context) {
 		}
 	}
 	if app.grpcServer != nil {
-		app.grpcServer.GracefulStop()
+		app.grpcServer.Shutdown(ctx)
+	}
+	if app.statsd != nil {
+		app.statsd.Stop(ctx)
+	}
+	if app.graphite != nil {
+		app.graphite.Stop(ctx)
 	}
 	if app.store != nil {
 		err := app.store.Save(context.Background())
@@ -0,0 +1,164 @@
+// Package cumulative переводит накопительные значения счетчиков в приросты.
+//
+// Prometheus и другие внешние источники присылают counter как общее значение
+// с момента старта процесса, а хранилище ожидает прирост, который нужно прибавить.
+//
+// Накопительные значения бывают дробными (например, *_seconds_total), а counter
+// в хранилище целый. Трекер помнит значения без округления, а прирост считается
+// по целым частям значений, поэтому сумма приростов равна целой части значения
+// источника и дробные части не накапливают расхождение.
+package cumulative
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+)
+
+// TTL — время, после которого трекер забывает серию без новых значений.
+// Забытая серия при следующем появлении считается от значения в хранилище.
+const TTL = time.Hour
+
+type entry struct {
+	seen  time.Time
+	value float64
+}
+
+type Tracker struct {
+	last  map[string]entry
+	swept time.Time
+	now   func() time.Time
+	mu    sync.Mutex
+}
+
+func New() *Tracker {
+	return &Tracker{
+		last: make(map[string]entry),
+		now:  time.Now,
+	}
+}
+
+// Batch — приросты одной пачки. Если пачку не удалось записать,
+// Rollback возвращает трекер к значениям до пачки, и повтор пачки
+// получает те же приросты.
+type Batch struct {
+	t    *Tracker
+	prev map[string]change
+}
+
+// change — изменение серии в пачке.
+type change struct {
+	prev  float64 // значение до пачки
+	set   float64 // значение после пачки
+	found bool    // серия была в трекере до пачки
+}
+
+// Begin начинает пачку.
+func (t *Tracker) Begin() *Batch {
+	return &Batch{
+		t:    t,
+		prev: make(map[string]change),
+	}
+}
+
+// Delta возвращает прирост накопительного значения серии key
+// тенанта из ctx и сразу запоминает значение, без отката.
+func (t *Tracker) Delta(
+	ctx context.Context, key string, value float64, base func() storage.Counter,
+) storage.Counter {
+	return t.Begin().Delta(ctx, key, value, base)
+}
+
+// lookup возвращает последнее значение серии.
+func (t *Tracker) lookup(key string) (float64, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	e, ok := t.last[key]
+	return e.value, ok
+}
+
+// sweep удаляет серии, которые не обновлялись дольше TTL. Вызывается под mu.
+func (t *Tracker) sweep(now time.Time) {
+	if now.Sub(t.swept) < TTL {
+		return
+	}
+	t.swept = now
+
+	for key, e := range t.last {
+		if now.Sub(e.seen) > TTL {
+			delete(t.last, key)
+		}
+	}
+}
+
+// Delta возвращает прирост накопительного значения серии key
+// тенанта из ctx.
+//
+// При первом появлении серии прирост считается от base — текущего значения
+// в хранилище, чтобы после перезапуска сервера значения не удваивались.
+// base вызывается без блокировки трекера.
+// Уменьшение значения считается сбросом счетчика: приростом становится само значение.
+// Прирост — разность целых частей значений (см. описание пакета).
+func (b *Batch) Delta(
+	ctx context.Context, key string, value float64, base func() storage.Counter,
+) storage.Counter {
+	if id, ok := tenant.FromContext(ctx); ok {
+		key = id + tenant.Separator + key
+	}
+
+	last, ok := b.t.lookup(key)
+	if !ok {
+		last = float64(base())
+	}
+
+	t := b.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// пока читалось хранилище, серию мог запомнить другой запрос
+	if e, found := t.last[key]; found && !ok {
+		last, ok = e.value, true
+	}
+
+	now := t.now()
+	t.sweep(now)
+	t.last[key] = entry{value: value, seen: now}
+
+	c, seen := b.prev[key]
+	if !seen {
+		c = change{prev: last, found: ok}
+	}
+	c.set = value
+	b.prev[key] = c
+
+	if value < last {
+		return storage.Counter(math.Floor(value))
+	}
+
+	return storage.Counter(math.Floor(value) - math.Floor(last))
+}
+
+// Rollback отменяет значения пачки, которые после нее никто не менял.
+func (b *Batch) Rollback() {
+	t := b.t
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for key, c := range b.prev {
+		if e, ok := t.last[key]; !ok || e.value != c.set {
+			continue
+		}
+
+		if c.found {
+			t.last[key] = entry{value: c.prev, seen: t.now()}
+		} else {
+			delete(t.last, key)
+		}
+	}
+	clear(b.prev)
+}
@@ -0,0 +1,149 @@
+package cumulative
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDelta(t *testing.T) {
+	type step struct {
+		value float64
+		want  storage.Counter
+	}
+	tests := []struct {
+		name  string
+		steps []step
+		base  storage.Counter
+	}{
+		{
+			name:  "New series starts from stored value",
+			base:  0,
+			steps: []step{{value: 10, want: 10}, {value: 15, want: 5}, {value: 15, want: 0}},
+		},
+		{
+			name:  "Stored value is subtracted on first sight",
+			base:  8,
+			steps: []step{{value: 10, want: 2}, {value: 12.9, want: 2}},
+		},
+		{
+			name:  "Counter reset",
+			base:  0,
+			steps: []step{{value: 100, want: 100}, {value: 3, want: 3}, {value: 5, want: 2}},
+		},
+		{
+			name: "Fractional values do not drift",
+			base: 0,
+			steps: []step{
+				{value: 0.4, want: 0}, {value: 0.8, want: 0}, {value: 1.2, want: 1},
+				{value: 1.6, want: 0}, {value: 2.5, want: 1}, {value: 2.9, want: 0},
+			},
+		},
+		{
+			name:  "Fractional counter reset",
+			base:  0,
+			steps: []step{{value: 10.5, want: 10}, {value: 0.7, want: 0}, {value: 1.1, want: 1}},
+		},
+		{
+			name:  "Source restarted while server was down",
+			base:  100,
+			steps: []step{{value: 7, want: 7}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tr := New()
+			calls := 0
+			base := func() storage.Counter {
+				calls++
+				return tt.base
+			}
+
+			for _, s := range tt.steps {
+				assert.Equal(t, s.want, tr.Delta(context.Background(), "series", s.value, base))
+			}
+			assert.Equal(t, 1, calls)
+		})
+	}
+}
+
+func TestDeltaTenants(t *testing.T) {
+	tr := New()
+	base := func() storage.Counter { return 0 }
+	ctxA := tenant.WithTenant(context.Background(), "a")
+	ctxB := tenant.WithTenant(context.Background(), "b")
+
+	assert.Equal(t, storage.Counter(10), tr.Delta(ctxA, "series", 10, base))
+	assert.Equal(t, storage.Counter(3), tr.Delta(ctxB, "series", 3, base))
+	assert.Equal(t, storage.Counter(5), tr.Delta(ctxA, "series", 15, base))
+	assert.Equal(t, storage.Counter(7), tr.Delta(context.Background(), "series", 7, base))
+}
+
+func TestBatchRollback(t *testing.T) {
+	tr := New()
+	ctx := context.Background()
+	base := func() storage.Counter { return 0 }
+
+	assert.Equal(t, storage.Counter(10), tr.Delta(ctx, "known", 10, base))
+
+	// запись пачки не удалась, повтор получает те же приросты
+	for range 2 {
+		b := tr.Begin()
+		assert.Equal(t, storage.Counter(5), b.Delta(ctx, "known", 15, base))
+		assert.Equal(t, storage.Counter(3), b.Delta(ctx, "new", 3, base))
+		assert.Equal(t, storage.Counter(2), b.Delta(ctx, "new", 5, base))
+		b.Rollback()
+	}
+
+	b := tr.Begin()
+	assert.Equal(t, storage.Counter(5), b.Delta(ctx, "known", 15, base))
+	assert.Equal(t, storage.Counter(20), tr.Delta(ctx, "known", 35, base))
+	// серию уже изменил другой запрос, откат ее не трогает
+	b.Rollback()
+	assert.Equal(t, storage.Counter(5), tr.Delta(ctx, "known", 40, base))
+}
+
+func TestDeltaBaseWithoutLock(t *testing.T) {
+	tr := New()
+	ctx := context.Background()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		tr.Delta(ctx, "first", 10, func() storage.Counter {
+			// другая серия не ждет чтения хранилища
+			return tr.Delta(ctx, "second", 3, func() storage.Counter { return 0 })
+		})
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("base is called under the tracker lock")
+	}
+	assert.Equal(t, storage.Counter(2), tr.Delta(ctx, "first", 12, nil))
+}
+
+func TestTTL(t *testing.T) {
+	tr := New()
+	ctx := context.Background()
+	now := time.Now()
+	tr.now = func() time.Time { return now }
+	base := func() storage.Counter { return 4 }
+
+	assert.Equal(t, storage.Counter(6), tr.Delta(ctx, "stale", 10, base))
+	now = now.Add(TTL / 2)
+	assert.Equal(t, storage.Counter(6), tr.Delta(ctx, "active", 10, base))
+
+	now = now.Add(TTL)
+	assert.Equal(t, storage.Counter(1), tr.Delta(ctx, "active", 11, base))
+	assert.NotContains(t, tr.last, "stale")
+	assert.Contains(t, tr.last, "active")
+
+	// забытая серия снова считается от значения в хранилище
+	assert.Equal(t, storage.Counter(8), tr.Delta(ctx, "stale", 12, base))
+}
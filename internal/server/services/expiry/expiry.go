@@ -0,0 +1,65 @@
+// Package expiry — фоновая задача, которая удаляет метрики, не обновлявшиеся
+// дольше MetricTTL из конфигурации, например метрики выведенных из работы хостов.
+package expiry
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+// maxInterval ограничивает период проверки, чтобы при большом TTL
+// метрики удалялись не намного позже срока.
+const maxInterval = time.Minute
+
+type Expiry struct {
+	db  storage.Storage
+	ttl time.Duration
+}
+
+func New(db storage.Storage, cfg config.ServerConfig) *Expiry {
+	return &Expiry{
+		db:  db,
+		ttl: time.Duration(cfg.MetricTTL) * time.Second,
+	}
+}
+
+// Apply удаляет метрики, не обновлявшиеся дольше ttl.
+func (e Expiry) Apply(ctx context.Context) ([]storage.MetricRef, error) {
+	list, err := e.db.DeleteStale(ctx, time.Now().Add(-e.ttl))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range list {
+		logger.Log.Info("deleted stale metric", zap.String("type", ref.MType), zap.String("name", ref.Name))
+	}
+
+	return list, nil
+}
+
+// Start проверяет метрики сразу и затем периодически, пока не отменен ctx.
+func (e Expiry) Start(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(min(e.ttl, maxInterval))
+	defer ticker.Stop()
+
+	for {
+		if _, err := e.Apply(ctx); err != nil && ctx.Err() == nil {
+			logger.Log.Error("error while deleting stale metrics", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Log.Info("Stopped metrics expiry")
+			return
+		case <-ticker.C:
+		}
+	}
+}
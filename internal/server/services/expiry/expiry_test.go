@@ -0,0 +1,80 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/server/storage/tenantstorage"
+	"github.com/LekcRg/metrics/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	stale := []storage.MetricRef{{MType: "gauge", Name: "CPUutilization17"}}
+
+	db := mocks.NewMockStorage(t)
+	start := time.Now()
+	db.EXPECT().DeleteStale(mock.Anything, mock.MatchedBy(func(before time.Time) bool {
+		return !before.Before(start.Add(-time.Hour)) && before.Before(start.Add(-time.Hour+time.Minute))
+	})).Return(stale, nil).Once()
+
+	e := New(db, config.ServerConfig{MetricTTL: 3600})
+	got, err := e.Apply(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, stale, got)
+}
+
+func TestApplyError(t *testing.T) {
+	db := mocks.NewMockStorage(t)
+	db.EXPECT().DeleteStale(mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+
+	_, err := New(db, config.ServerConfig{MetricTTL: 60}).Apply(context.Background())
+	assert.Error(t, err)
+}
+
+func TestStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	db := mocks.NewMockStorage(t)
+	db.EXPECT().DeleteStale(mock.Anything, mock.Anything).
+		Run(func(context.Context, time.Time) {
+			cancel()
+		}).
+		Return(nil, nil)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go New(db, config.ServerConfig{MetricTTL: 60}).Start(ctx, wg)
+	wg.Wait()
+}
+
+func TestApplyFreesTenantQuota(t *testing.T) {
+	mem, err := memstorage.New()
+	require.NoError(t, err)
+	db := tenantstorage.New(mem, 1)
+	ctx := tenant.WithTenant(context.Background(), "team-a")
+
+	_, err = db.UpdateGauge(ctx, "Alloc", 1)
+	require.NoError(t, err)
+	_, err = db.UpdateGauge(ctx, "HeapAlloc", 1)
+	require.ErrorIs(t, err, merrors.ErrSeriesQuotaExceeded)
+
+	e := New(db, config.ServerConfig{MetricTTL: 60})
+	e.ttl = -time.Minute
+	got, err := e.Apply(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []storage.MetricRef{{MType: "gauge", Name: "team-a/Alloc"}}, got)
+
+	// удаленная серия больше не занимает квоту
+	_, err = db.UpdateGauge(ctx, "HeapAlloc", 1)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,33 @@
+package metric
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/hub"
+	"go.uber.org/zap"
+)
+
+// DeleteMetric удаляет метрику вместе с историей. reqName — ключ серии (см. storage.SeriesKey).
+func (s *MetricService) DeleteMetric(ctx context.Context, reqName string, reqType string) error {
+	err := s.db.DeleteMetric(ctx, reqType, reqName)
+	if err != nil {
+		if !errors.Is(err, merrors.ErrNotFoundMetric) && !errors.Is(err, merrors.ErrIncorrectMetricType) {
+			logger.Log.Error("error while deleting metric", zap.Error(err))
+		}
+		return err
+	}
+
+	s.publish(ctx, hub.Event{Key: reqName, MType: reqType, Deleted: true})
+
+	if s.Config.SyncSave {
+		err = s.store.Save(ctx)
+		if err != nil {
+			logger.Log.Error("Error while saving store")
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package metric
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDeleteMetric(t *testing.T) {
+	tests := []struct {
+		dbErr    error
+		wantErr  error
+		name     string
+		reqType  string
+		syncSave bool
+	}{
+		{
+			name:    "Positive",
+			reqType: "gauge",
+		},
+		{
+			name:     "Positive with sync save",
+			reqType:  "counter",
+			syncSave: true,
+		},
+		{
+			name:    "Not found",
+			reqType: "gauge",
+			dbErr:   merrors.ErrNotFoundMetric,
+			wantErr: merrors.ErrNotFoundMetric,
+		},
+		{
+			name:    "Incorrect type",
+			reqType: "unknown",
+			dbErr:   merrors.ErrIncorrectMetricType,
+			wantErr: merrors.ErrIncorrectMetricType,
+		},
+		{
+			name:    "Storage error",
+			reqType: "set",
+			dbErr:   errors.New("db error"),
+			wantErr: errors.New("db error"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			st.EXPECT().DeleteMetric(ctx, tt.reqType, gaugeName).Return(tt.dbErr)
+
+			store := NewMockStore(t)
+			if tt.syncSave {
+				store.EXPECT().Save(ctx).Return(nil)
+			}
+
+			s := &MetricService{
+				Config: config.ServerConfig{SyncSave: tt.syncSave},
+				db:     st,
+				store:  store,
+			}
+			err := s.DeleteMetric(ctx, gaugeName, tt.reqType)
+
+			if tt.wantErr != nil {
+				assert.EqualError(t, err, tt.wantErr.Error())
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/logger"
 	"github.com/LekcRg/metrics/internal/merrors"
@@ -11,6 +12,7 @@ import (
 	"github.com/LekcRg/metrics/internal/server/storage"
 )
 
+// GetMetric возвращает значение метрики строкой. reqName — ключ серии (см. storage.SeriesKey).
 func (s *MetricService) GetMetric(ctx context.Context, reqName string, reqType string) (string, error) {
 	var (
 		resVal string
@@ -26,6 +28,10 @@ func (s *MetricService) GetMetric(ctx context.Context, reqName string, reqType s
 		var val storage.Gauge
 		val, err = s.db.GetGaugeByName(ctx, reqName)
 		resVal = strconv.FormatFloat(float64(val), 'f', -1, 64)
+	case "set":
+		var val storage.Set
+		val, err = s.db.GetSetByName(ctx, reqName)
+		resVal = strconv.FormatUint(val.Count(), 10)
 	default:
 		return "", merrors.ErrIncorrectMetricType
 	}
@@ -39,33 +45,59 @@ func (s *MetricService) GetMetric(ctx context.Context, reqName string, reqType s
 
 func (s *MetricService) GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error) {
 	reqType := json.MType
-	reqName := json.ID
+	key := json.Key()
 
 	switch reqType {
 	case "counter":
-		val, err := s.db.GetCounterByName(ctx, reqName)
+		val, err := s.db.GetCounterByName(ctx, key)
 		if err != nil {
 			logger.Log.Info("not found counter value")
 			return models.Metrics{}, merrors.ErrNotFoundMetric
 		}
 
 		return models.Metrics{
-			ID:    reqName,
-			MType: reqType,
-			Delta: &val,
+			ID:     json.ID,
+			MType:  reqType,
+			Labels: json.Labels,
+			Delta:  &val,
 		}, nil
 	case "gauge":
-		val, err := s.db.GetGaugeByName(ctx, reqName)
+		val, err := s.db.GetGaugeByName(ctx, key)
 		if err != nil {
 			logger.Log.Error("not found gauge value")
 			return models.Metrics{}, merrors.ErrNotFoundMetric
 		}
 
 		return models.Metrics{
-			ID:    reqName,
-			MType: reqType,
-			Value: &val,
+			ID:     json.ID,
+			MType:  reqType,
+			Labels: json.Labels,
+			Value:  &val,
 		}, nil
+	case "histogram":
+		val, err := s.db.GetHistogramByName(ctx, key)
+		if err != nil {
+			logger.Log.Info("not found histogram value")
+			return models.Metrics{}, merrors.ErrNotFoundMetric
+		}
+
+		return histogramMetric(json, val), nil
+	case "summary":
+		val, err := s.db.GetSummaryByName(ctx, key)
+		if err != nil {
+			logger.Log.Info("not found summary value")
+			return models.Metrics{}, merrors.ErrNotFoundMetric
+		}
+
+		return summaryMetric(json, val, time.Now()), nil
+	case "set":
+		val, err := s.db.GetSetByName(ctx, key)
+		if err != nil {
+			logger.Log.Info("not found set value")
+			return models.Metrics{}, merrors.ErrNotFoundMetric
+		}
+
+		return setMetric(json, val), nil
 	}
 
 	return models.Metrics{}, merrors.ErrIncorrectMetricType
@@ -0,0 +1,119 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"math"
+	"strconv"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+// reportQuantiles — квантили, которые возвращаются вместе с гистограммой.
+var reportQuantiles = []float64{0.5, 0.9, 0.99}
+
+// buckets возвращает границы бакетов для серии key: сохраненные,
+// если гистограмма уже есть, иначе из конфига.
+func (s *MetricService) buckets(ctx context.Context, key string) []float64 {
+	if h, err := s.db.GetHistogramByName(ctx, key); err == nil {
+		return h.Buckets
+	}
+	if len(s.Config.Buckets) > 0 {
+		return s.Config.Buckets
+	}
+
+	return storage.DefaultBuckets
+}
+
+// observe добавляет одно наблюдение в гистограмму key.
+func (s *MetricService) observe(ctx context.Context, key string, v float64) (storage.Histogram, error) {
+	if err := storage.ValidateObservation(v); err != nil {
+		return storage.Histogram{}, err
+	}
+
+	h := storage.NewHistogram(s.buckets(ctx, key))
+	h.Observe(v)
+
+	return s.db.UpdateHistogram(ctx, key, h)
+}
+
+// histogramMetric возвращает модель гистограммы с оценками квантилей.
+func histogramMetric(json models.Metrics, h storage.Histogram) models.Metrics {
+	res := models.Metrics{
+		ID:        json.ID,
+		MType:     "histogram",
+		Labels:    json.Labels,
+		Histogram: &h,
+	}
+
+	if h.Count > 0 {
+		res.Quantiles = make(map[string]float64, len(reportQuantiles))
+		for _, q := range reportQuantiles {
+			res.Quantiles[strconv.FormatFloat(q, 'g', -1, 64)] = h.Quantile(q)
+		}
+	}
+
+	return res
+}
+
+// HandleHistogramUpdate обновляет гистограмму. Если передан Histogram,
+// его бакеты прибавляются к сохраненным, если Value — это одно наблюдение.
+func (s *MetricService) HandleHistogramUpdate(ctx context.Context, json models.Metrics) (models.Metrics, error) {
+	if json.MType != "histogram" {
+		return models.Metrics{}, merrors.ErrIncorrectMetricType
+	}
+
+	var (
+		h   storage.Histogram
+		err error
+	)
+	switch {
+	case json.Histogram != nil:
+		if err = json.Histogram.Validate(); err != nil {
+			return models.Metrics{}, err
+		}
+		h, err = s.db.UpdateHistogram(ctx, json.Key(), *json.Histogram)
+	case json.Value != nil:
+		h, err = s.observe(ctx, json.Key(), float64(*json.Value))
+	default:
+		return models.Metrics{}, merrors.ErrMissingMetricValue
+	}
+
+	if err != nil {
+		if errors.Is(err, merrors.ErrIncorrectHistogramBuckets) ||
+			errors.Is(err, merrors.ErrIncorrectHistogramValue) || isTenantError(err) {
+			return models.Metrics{}, err
+		}
+
+		logger.Log.Error("error while getting new histogram value", zap.Error(err))
+		return models.Metrics{}, merrors.ErrCannotGetNewMetricValue
+	}
+
+	if s.Config.SyncSave {
+		err := s.store.Save(ctx)
+		if err != nil {
+			logger.Log.Error("Error while saving store")
+		}
+	}
+
+	return histogramMetric(json, h), nil
+}
+
+// GetHistogramQuantile возвращает оценку квантиля q гистограммы.
+// reqName — ключ серии (см. storage.SeriesKey).
+func (s *MetricService) GetHistogramQuantile(ctx context.Context, reqName string, q float64) (float64, error) {
+	if q < 0 || q > 1 || math.IsNaN(q) {
+		return 0, merrors.ErrIncorrectQuantile
+	}
+
+	h, err := s.db.GetHistogramByName(ctx, reqName)
+	if err != nil {
+		return 0, merrors.ErrNotFoundMetric
+	}
+
+	return h.Quantile(q), nil
+}
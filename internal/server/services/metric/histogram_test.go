@@ -0,0 +1,169 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandleHistogramUpdate(t *testing.T) {
+	buckets := []float64{1, 2}
+	merged := storage.Histogram{Buckets: buckets, Counts: []uint64{1, 1, 0}, Sum: 2, Count: 2}
+
+	tests := []struct {
+		wantErr   error
+		dbErr     error
+		want      *storage.Histogram
+		name      string
+		json      models.Metrics
+		wantStore storage.Histogram
+	}{
+		{
+			name:      "Observation",
+			json:      models.Metrics{ID: "latency", MType: "histogram", Value: ptrGauge(1.5)},
+			wantStore: storage.Histogram{Buckets: buckets, Counts: []uint64{0, 1, 0}, Sum: 1.5, Count: 1},
+			want:      &merged,
+		},
+		{
+			name: "Histogram",
+			json: models.Metrics{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+				Buckets: buckets, Counts: []uint64{1, 0, 0}, Sum: 0.5, Count: 1,
+			}},
+			wantStore: storage.Histogram{Buckets: buckets, Counts: []uint64{1, 0, 0}, Sum: 0.5, Count: 1},
+			want:      &merged,
+		},
+		{
+			name: "Invalid histogram",
+			json: models.Metrics{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+				Buckets: buckets, Counts: []uint64{1}, Count: 1,
+			}},
+			wantErr: merrors.ErrIncorrectHistogram,
+		},
+		{
+			name: "Different buckets",
+			json: models.Metrics{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+				Buckets: buckets, Counts: []uint64{1, 0, 0}, Sum: 0.5, Count: 1,
+			}},
+			wantStore: storage.Histogram{Buckets: buckets, Counts: []uint64{1, 0, 0}, Sum: 0.5, Count: 1},
+			dbErr:     merrors.ErrIncorrectHistogramBuckets,
+			wantErr:   merrors.ErrIncorrectHistogramBuckets,
+		},
+		{
+			name:      "Storage error",
+			json:      models.Metrics{ID: "latency", MType: "histogram", Value: ptrGauge(1.5)},
+			wantStore: storage.Histogram{Buckets: buckets, Counts: []uint64{0, 1, 0}, Sum: 1.5, Count: 1},
+			dbErr:     errors.New("db error"),
+			wantErr:   merrors.ErrCannotGetNewMetricValue,
+		},
+		{
+			name:    "Infinite observation",
+			json:    models.Metrics{ID: "latency", MType: "histogram", Value: ptrGauge(math.Inf(1))},
+			wantErr: merrors.ErrIncorrectHistogramValue,
+		},
+		{
+			name: "Infinite sum",
+			json: models.Metrics{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+				Buckets: buckets, Counts: []uint64{0, 0, 1}, Sum: math.Inf(1), Count: 1,
+			}},
+			wantErr: merrors.ErrIncorrectHistogramValue,
+		},
+		{
+			name:    "Without value",
+			json:    models.Metrics{ID: "latency", MType: "histogram"},
+			wantErr: merrors.ErrMissingMetricValue,
+		},
+		{
+			name:    "Wrong type",
+			json:    models.Metrics{ID: "latency", MType: "gauge", Value: ptrGauge(1)},
+			wantErr: merrors.ErrIncorrectMetricType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			ctx := context.Background()
+
+			if tt.json.Value != nil && tt.wantStore.Counts != nil {
+				st.EXPECT().GetHistogramByName(ctx, "latency").
+					Return(storage.Histogram{}, merrors.ErrNotFoundMetric)
+			}
+			if tt.wantStore.Counts != nil {
+				var res storage.Histogram
+				if tt.want != nil {
+					res = *tt.want
+				}
+				st.EXPECT().UpdateHistogram(ctx, "latency", tt.wantStore).Return(res, tt.dbErr)
+			}
+
+			cfg := testdata.TestServerConfig
+			cfg.Buckets = buckets
+			s := &MetricService{
+				Config: cfg,
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			got, err := s.HandleHistogramUpdate(ctx, tt.json)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, "histogram", got.MType)
+			assert.Equal(t, tt.want, got.Histogram)
+			assert.Len(t, got.Quantiles, len(reportQuantiles))
+		})
+	}
+}
+
+func TestGetHistogramQuantile(t *testing.T) {
+	h := storage.Histogram{Buckets: []float64{1, 2}, Counts: []uint64{10, 10, 5}, Count: 25}
+
+	tests := []struct {
+		wantErr error
+		dbErr   error
+		name    string
+		q       float64
+		want    float64
+		callDB  bool
+	}{
+		{name: "Median", q: 0.5, want: 1.25, callDB: true},
+		{name: "Not found", q: 0.5, dbErr: merrors.ErrNotFoundMetric, wantErr: merrors.ErrNotFoundMetric, callDB: true},
+		{name: "Quantile above 1", q: 1.1, wantErr: merrors.ErrIncorrectQuantile},
+		{name: "Negative quantile", q: -0.1, wantErr: merrors.ErrIncorrectQuantile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			ctx := context.Background()
+			if tt.callDB {
+				st.EXPECT().GetHistogramByName(ctx, "latency").Return(h, tt.dbErr)
+			}
+
+			s := &MetricService{
+				Config: testdata.TestServerConfig,
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			got, err := s.GetHistogramQuantile(ctx, "latency", tt.q)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package metric
+
+import (
+	"context"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+func (s *MetricService) GetMetricHistory(
+	ctx context.Context, reqName string, reqType string, from, to time.Time,
+) ([]storage.Sample, error) {
+	if from.After(to) {
+		return nil, merrors.ErrIncorrectTimeRange
+	}
+
+	var (
+		list []storage.Sample
+		err  error
+	)
+
+	switch reqType {
+	case "counter":
+		list, err = s.db.GetCounterHistory(ctx, reqName, from, to)
+	case "gauge":
+		list, err = s.db.GetGaugeHistory(ctx, reqName, from, to)
+	default:
+		return nil, merrors.ErrIncorrectMetricType
+	}
+
+	if err != nil {
+		logger.Log.Error("error while getting metric history", zap.Error(err))
+		return nil, err
+	}
+
+	return list, nil
+}
+
+// GetMetricRollups возвращает свертки истории с шагом resolution
+// за интервал [from, to].
+func (s *MetricService) GetMetricRollups(
+	ctx context.Context, reqName string, reqType string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	if from.After(to) {
+		return nil, merrors.ErrIncorrectTimeRange
+	}
+
+	var (
+		list []storage.Rollup
+		err  error
+	)
+
+	switch reqType {
+	case "counter":
+		list, err = s.db.GetCounterRollups(ctx, reqName, resolution, from, to)
+	case "gauge":
+		list, err = s.db.GetGaugeRollups(ctx, reqName, resolution, from, to)
+	default:
+		return nil, merrors.ErrIncorrectMetricType
+	}
+
+	if err != nil {
+		logger.Log.Error("error while getting metric rollups", zap.Error(err))
+		return nil, err
+	}
+
+	return list, nil
+}
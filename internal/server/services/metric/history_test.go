@@ -0,0 +1,101 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetMetricHistory(t *testing.T) {
+	to := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	from := to.Add(-time.Hour)
+	samples := []storage.Sample{
+		{Time: from.Add(time.Minute), Value: 1},
+		{Time: from.Add(2 * time.Minute), Value: 2},
+	}
+
+	tests := []struct {
+		from    time.Time
+		to      time.Time
+		wantErr error
+		dbErr   error
+		name    string
+		reqType string
+		want    []storage.Sample
+	}{
+		{
+			name:    "Gauge history",
+			reqType: "gauge",
+			from:    from,
+			to:      to,
+			want:    samples,
+		},
+		{
+			name:    "Counter history",
+			reqType: "counter",
+			from:    from,
+			to:      to,
+			want:    samples,
+		},
+		{
+			name:    "Storage error",
+			reqType: "gauge",
+			from:    from,
+			to:      to,
+			dbErr:   merrors.ErrMocked,
+			wantErr: merrors.ErrMocked,
+		},
+		{
+			name:    "Incorrect type",
+			reqType: "test",
+			from:    from,
+			to:      to,
+			wantErr: merrors.ErrIncorrectMetricType,
+		},
+		{
+			name:    "From after to",
+			reqType: "gauge",
+			from:    to,
+			to:      from,
+			wantErr: merrors.ErrIncorrectTimeRange,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+
+			if !tt.from.After(tt.to) {
+				switch tt.reqType {
+				case "counter":
+					st.EXPECT().GetCounterHistory(ctx, counterName, tt.from, tt.to).
+						Return(tt.want, tt.dbErr)
+				case "gauge":
+					st.EXPECT().GetGaugeHistory(ctx, counterName, tt.from, tt.to).
+						Return(tt.want, tt.dbErr)
+				}
+			}
+
+			s := &MetricService{
+				Config: config.ServerConfig{},
+				db:     st,
+				store:  NewMockStore(t),
+			}
+			got, err := s.GetMetricHistory(ctx, counterName, tt.reqType, tt.from, tt.to)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
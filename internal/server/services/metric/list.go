@@ -0,0 +1,164 @@
+package metric
+
+import (
+	"cmp"
+	"context"
+	"encoding/base64"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultPageSize — размер страницы ListMetrics по умолчанию.
+	DefaultPageSize = 100
+	// MaxPageSize — наибольший размер страницы ListMetrics.
+	MaxPageSize = 1000
+)
+
+// metricTypes — типы метрик в порядке ListMetrics.
+var metricTypes = []string{"counter", "gauge", "histogram", "set", "summary"}
+
+// listEntry — серия в списке метрик.
+type listEntry struct {
+	mType string
+	key   string
+}
+
+func (e listEntry) compare(o listEntry) int {
+	return cmp.Or(cmp.Compare(e.mType, o.mType), cmp.Compare(e.key, o.key))
+}
+
+// token возвращает токен страницы, которая начинается после e.
+func (e listEntry) token() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(e.mType + "\x00" + e.key))
+}
+
+func parsePageToken(token string) (listEntry, error) {
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return listEntry{}, merrors.ErrIncorrectPageToken
+	}
+
+	mType, key, ok := strings.Cut(string(b), "\x00")
+	if !ok || !slices.Contains(metricTypes, mType) {
+		return listEntry{}, merrors.ErrIncorrectPageToken
+	}
+
+	return listEntry{mType: mType, key: key}, nil
+}
+
+// seriesKeys возвращает отсортированные ключи серий типа mType.
+func seriesKeys(all storage.Database, mType string) []string {
+	switch mType {
+	case "counter":
+		return slices.Sorted(maps.Keys(all.Counter))
+	case "gauge":
+		return slices.Sorted(maps.Keys(all.Gauge))
+	case "histogram":
+		return slices.Sorted(maps.Keys(all.Histogram))
+	case "set":
+		return slices.Sorted(maps.Keys(all.Set))
+	case "summary":
+		return slices.Sorted(maps.Keys(all.Summary))
+	}
+
+	return nil
+}
+
+// dbMetric возвращает модель серии key типа mType из all.
+func dbMetric(all storage.Database, mType string, key string, json models.Metrics, now time.Time) models.Metrics {
+	switch mType {
+	case "counter":
+		val := all.Counter[key]
+		json.Delta = &val
+	case "gauge":
+		val := all.Gauge[key]
+		json.Value = &val
+	case "histogram":
+		json = histogramMetric(json, all.Histogram[key])
+	case "set":
+		json = setMetric(json, all.Set[key])
+	case "summary":
+		json = summaryMetric(json, all.Summary[key], now)
+	}
+
+	if meta, ok := all.Metadata[json.ID]; ok {
+		json.Meta = &meta
+	}
+
+	return json
+}
+
+// ListMetrics возвращает страницу метрик типа q.MType с именем,
+// которое начинается с q.Prefix. Страницы упорядочены по типу и ключу серии,
+// поэтому новые серии не сдвигают уже полученные страницы.
+func (s *MetricService) ListMetrics(ctx context.Context, q models.ListQuery) (models.ListResult, error) {
+	types := metricTypes
+	if q.MType != "" {
+		if !slices.Contains(metricTypes, q.MType) {
+			return models.ListResult{}, merrors.ErrIncorrectMetricType
+		}
+		types = []string{q.MType}
+	}
+
+	limit := q.Limit
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	limit = min(limit, MaxPageSize)
+
+	var after listEntry
+	if q.PageToken != "" {
+		var err error
+		after, err = parsePageToken(q.PageToken)
+		if err != nil {
+			return models.ListResult{}, err
+		}
+	}
+
+	all, err := s.db.GetAll(ctx)
+	if err != nil {
+		logger.Log.Error("error while listing metrics", zap.Error(err))
+		return models.ListResult{}, err
+	}
+
+	now := time.Now()
+	res := models.ListResult{Metrics: []models.Metrics{}}
+	var last listEntry
+
+	for _, mType := range types {
+		for _, key := range seriesKeys(all, mType) {
+			e := listEntry{mType: mType, key: key}
+			if q.PageToken != "" && e.compare(after) <= 0 {
+				continue
+			}
+
+			name, labels, err := storage.ParseSeriesKey(key)
+			if err != nil || !strings.HasPrefix(name, q.Prefix) {
+				continue
+			}
+			if len(labels) == 0 {
+				labels = nil
+			}
+
+			if len(res.Metrics) == limit {
+				res.NextPageToken = last.token()
+				return res, nil
+			}
+
+			json := models.Metrics{ID: name, MType: mType, Labels: labels}
+			res.Metrics = append(res.Metrics, dbMetric(all, mType, key, json, now))
+			last = e
+		}
+	}
+
+	return res, nil
+}
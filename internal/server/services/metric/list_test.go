@@ -0,0 +1,130 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestListMetrics(t *testing.T) {
+	all := storage.Database{
+		Gauge: storage.GaugeCollection{
+			"Alloc":                1,
+			"HeapAlloc":            2,
+			`cpu{host="web1"}`:     3,
+			"RandomValue":          4,
+			"Alloc/not-a-series-{": 5,
+		},
+		Counter: storage.CounterCollection{"PollCount": 5},
+		Set:     storage.SetCollection{"users": storage.NewSet()},
+		Metadata: storage.MetadataCollection{
+			"Alloc": {Unit: "bytes"},
+		},
+	}
+	alloc, heapAlloc, cpu, random := storage.Gauge(1), storage.Gauge(2), storage.Gauge(3), storage.Gauge(4)
+	pollCount := storage.Counter(5)
+	unique := uint64(0)
+
+	tests := []struct {
+		wantErr  error
+		name     string
+		query    models.ListQuery
+		want     []models.Metrics
+		wantNext bool
+	}{
+		{
+			name:  "All types",
+			query: models.ListQuery{Limit: 10},
+			want: []models.Metrics{
+				{ID: "PollCount", MType: "counter", Delta: &pollCount},
+				{ID: "Alloc", MType: "gauge", Value: &alloc, Meta: &storage.Metadata{Unit: "bytes"}},
+				{ID: "HeapAlloc", MType: "gauge", Value: &heapAlloc},
+				{ID: "RandomValue", MType: "gauge", Value: &random},
+				{ID: "cpu", MType: "gauge", Value: &cpu, Labels: storage.Labels{"host": "web1"}},
+				{ID: "users", MType: "set", Unique: &unique},
+			},
+		},
+		{
+			name:     "Type and page size",
+			query:    models.ListQuery{MType: "gauge", Limit: 2},
+			want:     []models.Metrics{{ID: "Alloc", MType: "gauge", Value: &alloc, Meta: &storage.Metadata{Unit: "bytes"}}, {ID: "HeapAlloc", MType: "gauge", Value: &heapAlloc}},
+			wantNext: true,
+		},
+		{
+			name:  "Prefix",
+			query: models.ListQuery{Prefix: "cp"},
+			want:  []models.Metrics{{ID: "cpu", MType: "gauge", Value: &cpu, Labels: storage.Labels{"host": "web1"}}},
+		},
+		{
+			name:    "Incorrect type",
+			query:   models.ListQuery{MType: "unknown"},
+			wantErr: merrors.ErrIncorrectMetricType,
+		},
+		{
+			name:    "Incorrect page token",
+			query:   models.ListQuery{PageToken: "!"},
+			wantErr: merrors.ErrIncorrectPageToken,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			if tt.wantErr == nil {
+				st.EXPECT().GetAll(ctx).Return(all, nil)
+			}
+
+			s := &MetricService{
+				Config: config.ServerConfig{},
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			res, err := s.ListMetrics(ctx, tt.query)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, res.Metrics)
+			assert.Equal(t, tt.wantNext, res.NextPageToken != "")
+		})
+	}
+}
+
+func TestListMetricsPages(t *testing.T) {
+	all := storage.Database{
+		Gauge:   storage.GaugeCollection{"a": 1, "b": 2, "c": 3},
+		Counter: storage.CounterCollection{"a": 1, "b": 2},
+	}
+
+	st := mocks.NewMockStorage(t)
+	st.EXPECT().GetAll(ctx).Return(all, nil)
+	s := &MetricService{
+		Config: config.ServerConfig{},
+		db:     st,
+		store:  NewMockStore(t),
+	}
+
+	var got []string
+	q := models.ListQuery{Limit: 2}
+	for range 5 {
+		res, err := s.ListMetrics(ctx, q)
+		require.NoError(t, err)
+		for _, m := range res.Metrics {
+			got = append(got, m.MType+" "+m.ID)
+		}
+		if res.NextPageToken == "" {
+			break
+		}
+		q.PageToken = res.NextPageToken
+	}
+
+	assert.Equal(t, []string{"counter a", "counter b", "gauge a", "gauge b", "gauge c"}, got)
+}
@@ -0,0 +1,30 @@
+package metric
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// validMetadata проверяет описание метрики, если оно передано вместе со значением.
+func validMetadata(json models.Metrics) error {
+	if json.Meta == nil {
+		return nil
+	}
+
+	return json.Meta.Validate()
+}
+
+// updateMetadata сохраняет описание метрики, если оно передано вместе
+// со значением. Вызывается после обновления значения и проверки validMetadata,
+// чтобы отклоненный запрос не менял описание.
+func (s *MetricService) updateMetadata(ctx context.Context, json models.Metrics) error {
+	if json.Meta == nil {
+		return nil
+	}
+
+	return s.db.UpdateMany(ctx, storage.Database{
+		Metadata: storage.MetadataCollection{json.ID: *json.Meta},
+	})
+}
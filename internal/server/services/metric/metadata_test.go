@@ -0,0 +1,136 @@
+package metric
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateManyMetadata(t *testing.T) {
+	precision := 0
+	negative := -1
+
+	tests := []struct {
+		wantDBData *storage.Database
+		wantErr    error
+		name       string
+		metrics    []models.Metrics
+	}{
+		{
+			name: "Metadata with value",
+			metrics: []models.Metrics{
+				{
+					ID:    "HeapAlloc",
+					MType: "gauge",
+					Value: ptrGauge(2048),
+					Meta:  &storage.Metadata{Unit: "bytes", Precision: &precision},
+				},
+			},
+			wantDBData: &storage.Database{
+				Gauge:     storage.GaugeCollection{"HeapAlloc": 2048},
+				Counter:   storage.CounterCollection{},
+				Histogram: storage.HistogramCollection{},
+				Summary:   storage.SummaryCollection{},
+				Set:       storage.SetCollection{},
+				Metadata: storage.MetadataCollection{
+					"HeapAlloc": {Unit: "bytes", Precision: &precision},
+				},
+			},
+		},
+		{
+			name: "Metadata without value",
+			metrics: []models.Metrics{
+				{
+					ID:     "temp",
+					MType:  "gauge",
+					Labels: storage.Labels{"room": "hall"},
+					Meta:   &storage.Metadata{Unit: "celsius"},
+				},
+			},
+			wantDBData: &storage.Database{
+				Gauge:     storage.GaugeCollection{},
+				Counter:   storage.CounterCollection{},
+				Histogram: storage.HistogramCollection{},
+				Summary:   storage.SummaryCollection{},
+				Set:       storage.SetCollection{},
+				Metadata:  storage.MetadataCollection{"temp": {Unit: "celsius"}},
+			},
+		},
+		{
+			name: "Incorrect metadata",
+			metrics: []models.Metrics{
+				{
+					ID:    "HeapAlloc",
+					MType: "gauge",
+					Value: ptrGauge(2048),
+					Meta:  &storage.Metadata{Precision: &negative},
+				},
+			},
+			wantErr: merrors.ErrIncorrectMetadata,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			ctx := context.Background()
+			if tt.wantDBData != nil {
+				st.EXPECT().UpdateMany(ctx, *tt.wantDBData).Return(nil)
+			}
+
+			s := &MetricService{
+				Config: testdata.TestServerConfig,
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			_, err := s.UpdateBatch(ctx, "", tt.metrics, true)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestUpdateMetricJSONMetadata(t *testing.T) {
+	ctx := context.Background()
+	st := mocks.NewMockStorage(t)
+	st.EXPECT().UpdateMany(ctx, storage.Database{
+		Metadata: storage.MetadataCollection{"PollCount": {Help: "polls"}},
+	}).Return(nil).Once()
+	st.EXPECT().UpdateCounter(ctx, "PollCount", storage.Counter(1)).Return(1, nil).Once()
+
+	s := &MetricService{
+		Config: testdata.TestServerConfig,
+		db:     st,
+		store:  NewMockStore(t),
+	}
+
+	res, err := s.UpdateMetricJSON(ctx, models.Metrics{
+		ID:    "PollCount",
+		MType: "counter",
+		Delta: ptrCounter(1),
+		Meta:  &storage.Metadata{Help: "polls"},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, storage.Counter(1), *res.Delta)
+
+	_, err = s.UpdateMetricJSON(ctx, models.Metrics{
+		ID:    "PollCount",
+		MType: "counter",
+		Delta: ptrCounter(1),
+		Meta:  &storage.Metadata{Type: "meter"},
+	})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectMetadata)
+
+	// описание не сохраняется, если значение не обновилось
+	_, err = s.UpdateMetricJSON(ctx, models.Metrics{
+		ID:    "PollCount",
+		MType: "counter",
+		Meta:  &storage.Metadata{Help: "lost"},
+	})
+	assert.ErrorIs(t, err, merrors.ErrMissingMetricValue)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 
 	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/server/hub"
 	"github.com/LekcRg/metrics/internal/server/storage"
 )
 
@@ -12,9 +13,11 @@ type Store interface {
 }
 
 type MetricService struct {
-	db     storage.Storage
-	store  Store
-	Config config.ServerConfig
+	db        storage.Storage
+	store     Store
+	hub       *hub.Hub // изменения метрик для Watch
+	Config    config.ServerConfig
+	Retention storage.RetentionPolicy // политика хранения для чтения истории
 }
 
 func NewMetricsService(db storage.Storage, config config.ServerConfig, store Store) *MetricService {
@@ -22,5 +25,6 @@ func NewMetricsService(db storage.Storage, config config.ServerConfig, store Sto
 		Config: config,
 		db:     db,
 		store:  store,
+		hub:    hub.New(),
 	}
 }
@@ -0,0 +1,157 @@
+package metric
+
+import (
+	"context"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// maxPoints ограничивает количество шагов в одном запросе.
+const maxPoints = 11000
+
+func isAggregation(agg string) bool {
+	switch agg {
+	case "last", "avg", "min", "max", "sum", "rate":
+		return true
+	}
+
+	return false
+}
+
+// appendSamples дописывает семплы к сверткам как свертки из одного семпла.
+func appendSamples(rollups []storage.Rollup, samples []storage.Sample) []storage.Rollup {
+	for _, sample := range samples {
+		r := storage.Rollup{Time: sample.Time}
+		r.Add(sample.Value)
+		rollups = append(rollups, r)
+	}
+
+	return rollups
+}
+
+// resample раскладывает отсортированные по времени семплы по шагам
+// начиная с from и сворачивает каждый шаг функцией agg.
+func resample(
+	samples []storage.Sample, mType string, from time.Time, step time.Duration, agg string,
+) []storage.Sample {
+	return resampleRollups(appendSamples(nil, samples), mType, from, step, agg)
+}
+
+// resampleRollups работает как resample, но по уже свернутой истории.
+// Свертка, начавшаяся раньше from, относится к первому шагу.
+func resampleRollups(
+	rollups []storage.Rollup, mType string, from time.Time, step time.Duration, agg string,
+) []storage.Sample {
+	buckets := make([]storage.Rollup, 0)
+	for _, r := range rollups {
+		idx := max(int(r.Time.Sub(from)/step), 0)
+		start := from.Add(time.Duration(idx) * step)
+		if len(buckets) == 0 || !buckets[len(buckets)-1].Time.Equal(start) {
+			buckets = append(buckets, storage.Rollup{Time: start})
+		}
+		buckets[len(buckets)-1].Merge(r)
+	}
+
+	points := make([]storage.Sample, 0, len(buckets))
+	for i, b := range buckets {
+		var value float64
+		switch agg {
+		case "last":
+			value = b.Last
+		case "avg":
+			value = b.Avg()
+		case "min":
+			value = b.Min
+		case "max":
+			value = b.Max
+		case "sum":
+			value = b.Sum
+		case "rate":
+			// Семплы counter хранят прирост, поэтому скорость — сумма за шаг.
+			// Для gauge считаем изменение относительно предыдущего шага.
+			if mType == "counter" {
+				value = b.Sum / step.Seconds()
+			} else {
+				prev := b.First
+				if i > 0 {
+					prev = buckets[i-1].Last
+				}
+				value = (b.Last - prev) / step.Seconds()
+			}
+		}
+
+		points = append(points, storage.Sample{Time: b.Time, Value: value})
+	}
+
+	return points
+}
+
+// queryPoints читает историю за интервал запроса. Если сырые семплы
+// за начало интервала уже удалены политикой хранения, берутся свертки
+// самого подробного уровня, который еще хранит from, а после последней
+// свертки — сырые семплы.
+func (s *MetricService) queryPoints(ctx context.Context, key string, q models.RangeQuery) ([]storage.Sample, error) {
+	policy := s.Retention
+	now := time.Now()
+
+	var rollups []storage.Rollup
+	from := q.From
+	if policy.Enabled() && q.From.Before(now.Add(-policy.Raw)) {
+		if level, ok := policy.Level(q.From, now); ok {
+			var err error
+			rollups, err = s.GetMetricRollups(ctx, key, q.MType, level.Resolution, q.From, q.To)
+			if err != nil {
+				return nil, err
+			}
+			if len(rollups) > 0 {
+				from = rollups[len(rollups)-1].Time.Add(level.Resolution)
+			}
+		}
+	}
+
+	if !from.After(q.To) {
+		samples, err := s.GetMetricHistory(ctx, key, q.MType, from, q.To)
+		if err != nil {
+			return nil, err
+		}
+		rollups = appendSamples(rollups, samples)
+	}
+
+	return resampleRollups(rollups, q.MType, q.From, q.Step, q.Agg), nil
+}
+
+func (s *MetricService) QueryRange(
+	ctx context.Context, q models.RangeQuery,
+) (models.RangeResult, error) {
+	if q.From.After(q.To) {
+		return models.RangeResult{}, merrors.ErrIncorrectTimeRange
+	}
+	if q.Step <= 0 {
+		return models.RangeResult{}, merrors.ErrIncorrectStep
+	}
+	if !isAggregation(q.Agg) {
+		return models.RangeResult{}, merrors.ErrIncorrectAggregation
+	}
+	if q.To.Sub(q.From)/q.Step > maxPoints {
+		return models.RangeResult{}, merrors.ErrTooManyPoints
+	}
+
+	points, err := s.queryPoints(ctx, storage.SeriesKey(q.ID, q.Labels), q)
+	if err != nil {
+		return models.RangeResult{}, err
+	}
+
+	return models.RangeResult{
+		ID:     q.ID,
+		Labels: q.Labels,
+		MType:  q.MType,
+		From:   q.From,
+		To:     q.To,
+		Step:   q.Step.Seconds(),
+		Agg:    q.Agg,
+		Points: points,
+	}, nil
+}
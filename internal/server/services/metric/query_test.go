@@ -0,0 +1,171 @@
+package metric
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResample(t *testing.T) {
+	from := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	at := func(sec int) time.Time {
+		return from.Add(time.Duration(sec) * time.Second)
+	}
+	samples := []storage.Sample{
+		{Time: at(0), Value: 4},
+		{Time: at(30), Value: 2},
+		{Time: at(59), Value: 6},
+		// второй шаг пустой
+		{Time: at(125), Value: 12},
+	}
+
+	tests := []struct {
+		name  string
+		mType string
+		agg   string
+		want  []float64
+	}{
+		{name: "last", mType: "gauge", agg: "last", want: []float64{6, 12}},
+		{name: "avg", mType: "gauge", agg: "avg", want: []float64{4, 12}},
+		{name: "min", mType: "gauge", agg: "min", want: []float64{2, 12}},
+		{name: "max", mType: "gauge", agg: "max", want: []float64{6, 12}},
+		{name: "sum", mType: "counter", agg: "sum", want: []float64{12, 12}},
+		{name: "counter rate", mType: "counter", agg: "rate", want: []float64{0.2, 0.2}},
+		{name: "gauge rate", mType: "gauge", agg: "rate", want: []float64{2.0 / 60, 0.1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resample(samples, tt.mType, from, time.Minute, tt.agg)
+
+			require.Len(t, got, len(tt.want))
+			assert.Equal(t, at(0), got[0].Time)
+			assert.Equal(t, at(120), got[1].Time)
+			for i, want := range tt.want {
+				assert.InDelta(t, want, got[i].Value, 1e-9)
+			}
+		})
+	}
+}
+
+func TestQueryRange(t *testing.T) {
+	to := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	from := to.Add(-time.Hour)
+	samples := []storage.Sample{
+		{Time: from.Add(10 * time.Second), Value: 1},
+		{Time: from.Add(20 * time.Second), Value: 3},
+	}
+	query := models.RangeQuery{
+		ID:    gaugeName,
+		MType: "gauge",
+		From:  from,
+		To:    to,
+		Step:  time.Minute,
+		Agg:   "avg",
+	}
+
+	tests := []struct {
+		wantErr error
+		modify  func(q *models.RangeQuery)
+		name    string
+		callDB  bool
+	}{
+		{
+			name:   "Positive",
+			modify: func(q *models.RangeQuery) {},
+			callDB: true,
+		},
+		{
+			name:    "Inverted range",
+			modify:  func(q *models.RangeQuery) { q.From, q.To = q.To, q.From },
+			wantErr: merrors.ErrIncorrectTimeRange,
+		},
+		{
+			name:    "Zero step",
+			modify:  func(q *models.RangeQuery) { q.Step = 0 },
+			wantErr: merrors.ErrIncorrectStep,
+		},
+		{
+			name:    "Unknown aggregation",
+			modify:  func(q *models.RangeQuery) { q.Agg = "median" },
+			wantErr: merrors.ErrIncorrectAggregation,
+		},
+		{
+			name:    "Too many points",
+			modify:  func(q *models.RangeQuery) { q.Step = time.Millisecond },
+			wantErr: merrors.ErrTooManyPoints,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			q := query
+			tt.modify(&q)
+
+			if tt.callDB {
+				st.EXPECT().GetGaugeHistory(ctx, gaugeName, from, to).Return(samples, nil)
+			}
+
+			s := &MetricService{
+				Config: config.ServerConfig{},
+				db:     st,
+				store:  NewMockStore(t),
+			}
+			got, err := s.QueryRange(ctx, q)
+
+			if tt.wantErr != nil {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, float64(60), got.Step)
+			assert.Equal(t, []storage.Sample{{Time: from, Value: 2}}, got.Points)
+		})
+	}
+}
+
+func TestQueryRangeRollups(t *testing.T) {
+	policy, err := storage.ParseRetention("raw:1h,1m:30d")
+	require.NoError(t, err)
+
+	from := storage.BucketStart(time.Now().Add(-3*time.Hour), time.Minute)
+	to := from.Add(3 * time.Minute)
+	rollups := []storage.Rollup{
+		{Time: from, First: 1, Last: 5, Min: 1, Max: 5, Sum: 6, Count: 2},
+		{Time: from.Add(time.Minute), First: 2, Last: 2, Min: 2, Max: 2, Sum: 2, Count: 1},
+	}
+	samples := []storage.Sample{
+		{Time: from.Add(2*time.Minute + time.Second), Value: 7},
+	}
+
+	st := mocks.NewMockStorage(t)
+	st.EXPECT().GetGaugeRollups(ctx, gaugeName, time.Minute, from, to).Return(rollups, nil)
+	st.EXPECT().GetGaugeHistory(ctx, gaugeName, from.Add(2*time.Minute), to).Return(samples, nil)
+
+	s := &MetricService{
+		Retention: policy,
+		db:        st,
+		store:     NewMockStore(t),
+	}
+	got, err := s.QueryRange(ctx, models.RangeQuery{
+		ID:    gaugeName,
+		MType: "gauge",
+		From:  from,
+		To:    to,
+		Step:  2 * time.Minute,
+		Agg:   "max",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Sample{
+		{Time: from, Value: 5},
+		{Time: from.Add(2 * time.Minute), Value: 7},
+	}, got.Points)
+}
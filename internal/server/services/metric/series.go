@@ -0,0 +1,40 @@
+package metric
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+// FindMetrics возвращает все серии метрики, метки которых подходят под matchers.
+func (s *MetricService) FindMetrics(
+	ctx context.Context, reqName string, reqType string, matchers []storage.Matcher,
+) ([]models.Metrics, error) {
+	list, err := s.db.FindSeries(ctx, reqType, reqName, matchers)
+	if err != nil {
+		logger.Log.Error("error while finding series", zap.Error(err))
+		return nil, err
+	}
+
+	res := make([]models.Metrics, 0, len(list))
+	for _, series := range list {
+		m := models.Metrics{
+			ID:     series.Name,
+			MType:  reqType,
+			Labels: series.Labels,
+		}
+		if reqType == "counter" {
+			val := storage.Counter(series.Value)
+			m.Delta = &val
+		} else {
+			val := storage.Gauge(series.Value)
+			m.Value = &val
+		}
+		res = append(res, m)
+	}
+
+	return res, nil
+}
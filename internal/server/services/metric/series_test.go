@@ -0,0 +1,73 @@
+package metric
+
+import (
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindMetrics(t *testing.T) {
+	found := []storage.Series{
+		{Key: `cpu{host="web1"}`, Name: "cpu", Labels: storage.Labels{"host": "web1"}, Value: 2},
+		{Key: `cpu{host="web2"}`, Name: "cpu", Labels: storage.Labels{"host": "web2"}, Value: 3},
+	}
+	g1, g2 := storage.Gauge(2), storage.Gauge(3)
+	c1, c2 := storage.Counter(2), storage.Counter(3)
+
+	tests := []struct {
+		dbErr   error
+		name    string
+		reqType string
+		want    []models.Metrics
+	}{
+		{
+			name:    "Gauge series",
+			reqType: "gauge",
+			want: []models.Metrics{
+				{ID: "cpu", MType: "gauge", Value: &g1, Labels: storage.Labels{"host": "web1"}},
+				{ID: "cpu", MType: "gauge", Value: &g2, Labels: storage.Labels{"host": "web2"}},
+			},
+		},
+		{
+			name:    "Counter series",
+			reqType: "counter",
+			want: []models.Metrics{
+				{ID: "cpu", MType: "counter", Delta: &c1, Labels: storage.Labels{"host": "web1"}},
+				{ID: "cpu", MType: "counter", Delta: &c2, Labels: storage.Labels{"host": "web2"}},
+			},
+		},
+		{
+			name:    "Storage error",
+			reqType: "gauge",
+			dbErr:   merrors.ErrMocked,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			st.EXPECT().FindSeries(ctx, tt.reqType, "cpu", []storage.Matcher(nil)).
+				Return(found, tt.dbErr)
+
+			s := &MetricService{
+				Config: config.ServerConfig{},
+				db:     st,
+				store:  NewMockStore(t),
+			}
+			got, err := s.FindMetrics(ctx, "cpu", tt.reqType, nil)
+
+			if tt.dbErr != nil {
+				assert.ErrorIs(t, err, tt.dbErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package metric
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+// setFromMetric собирает скетч из скетча Set и значений Members.
+func setFromMetric(json models.Metrics) (storage.Set, error) {
+	var st storage.Set
+	if json.Set != nil {
+		if err := json.Set.Validate(); err != nil {
+			return storage.Set{}, err
+		}
+		st = json.Set.Copy()
+	}
+
+	for _, member := range json.Members {
+		st.Add(member)
+	}
+
+	if st.Registers == nil {
+		return storage.Set{}, merrors.ErrMissingMetricValue
+	}
+
+	return st, nil
+}
+
+// setMetric возвращает модель set с оценкой количества уникальных значений.
+func setMetric(json models.Metrics, st storage.Set) models.Metrics {
+	unique := st.Count()
+
+	return models.Metrics{
+		ID:     json.ID,
+		MType:  "set",
+		Labels: json.Labels,
+		Unique: &unique,
+	}
+}
+
+// HandleSetUpdate добавляет значения Members и скетч Set в сохраненный скетч.
+func (s *MetricService) HandleSetUpdate(ctx context.Context, json models.Metrics) (models.Metrics, error) {
+	if json.MType != "set" {
+		return models.Metrics{}, merrors.ErrIncorrectMetricType
+	}
+
+	value, err := setFromMetric(json)
+	if err != nil {
+		return models.Metrics{}, err
+	}
+
+	st, err := s.db.UpdateSet(ctx, json.Key(), value)
+	if err != nil {
+		if errors.Is(err, merrors.ErrIncorrectSet) || isTenantError(err) {
+			return models.Metrics{}, err
+		}
+
+		logger.Log.Error("error while getting new set value", zap.Error(err))
+		return models.Metrics{}, merrors.ErrCannotGetNewMetricValue
+	}
+
+	if s.Config.SyncSave {
+		err := s.store.Save(ctx)
+		if err != nil {
+			logger.Log.Error("Error while saving store")
+		}
+	}
+
+	return setMetric(json, st), nil
+}
+
+// addSet объединяет значения и скетч из el со скетчем серии в пачке.
+func addSet(list storage.SetCollection, el models.Metrics) error {
+	value, err := setFromMetric(el)
+	if err != nil {
+		return err
+	}
+
+	key := el.Key()
+	st := list[key]
+	if err = st.Merge(value); err != nil {
+		return err
+	}
+	list[key] = st
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setOf(members ...string) storage.Set {
+	st := storage.NewSet()
+	for _, m := range members {
+		st.Add(m)
+	}
+
+	return st
+}
+
+func TestHandleSetUpdate(t *testing.T) {
+	sketch := setOf("carol")
+
+	tests := []struct {
+		wantErr   error
+		dbErr     error
+		wantStore *storage.Set
+		name      string
+		json      models.Metrics
+		want      uint64
+	}{
+		{
+			name:      "Members",
+			json:      models.Metrics{ID: "users", MType: "set", Members: []string{"alice", "bob", "alice"}},
+			wantStore: ptrSet(setOf("alice", "bob")),
+			want:      2,
+		},
+		{
+			name: "Sketch and members",
+			json: models.Metrics{
+				ID: "users", MType: "set", Members: []string{"alice"}, Set: &sketch,
+			},
+			wantStore: ptrSet(setOf("alice", "carol")),
+			want:      2,
+		},
+		{
+			name: "Incorrect sketch",
+			json: models.Metrics{
+				ID: "users", MType: "set", Set: &storage.Set{Registers: []uint8{1}},
+			},
+			wantErr: merrors.ErrIncorrectSet,
+		},
+		{
+			name:      "Storage error",
+			json:      models.Metrics{ID: "users", MType: "set", Members: []string{"alice"}},
+			wantStore: ptrSet(setOf("alice")),
+			dbErr:     errors.New("db error"),
+			wantErr:   merrors.ErrCannotGetNewMetricValue,
+		},
+		{
+			name:    "Without members",
+			json:    models.Metrics{ID: "users", MType: "set"},
+			wantErr: merrors.ErrMissingMetricValue,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			ctx := context.Background()
+			if tt.wantStore != nil {
+				st.EXPECT().UpdateSet(ctx, "users", *tt.wantStore).Return(*tt.wantStore, tt.dbErr)
+			}
+
+			s := &MetricService{
+				Config: testdata.TestServerConfig,
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			got, err := s.HandleSetUpdate(ctx, tt.json)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, got.Unique)
+			assert.Equal(t, tt.want, *got.Unique)
+		})
+	}
+}
+
+func TestUpdateManySet(t *testing.T) {
+	st := mocks.NewMockStorage(t)
+	ctx := context.Background()
+
+	// один и тот же set от двух агентов: значениями и готовым скетчем
+	agent2 := setOf("bob", "carol")
+	st.EXPECT().UpdateMany(ctx, storage.Database{
+		Gauge:     storage.GaugeCollection{},
+		Counter:   storage.CounterCollection{},
+		Histogram: storage.HistogramCollection{},
+		Summary:   storage.SummaryCollection{},
+		Set:       storage.SetCollection{"users": setOf("alice", "bob", "carol")},
+	}).Return(nil)
+
+	s := &MetricService{
+		Config: testdata.TestServerConfig,
+		db:     st,
+		store:  NewMockStore(t),
+	}
+
+	err := s.UpdateMany(ctx, []models.Metrics{
+		{ID: "users", MType: "set", Members: []string{"alice", "bob"}},
+		{ID: "users", MType: "set", Set: &agent2},
+	})
+	require.NoError(t, err)
+}
+
+func ptrSet(st storage.Set) *storage.Set {
+	return &st
+}
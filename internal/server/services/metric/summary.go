@@ -0,0 +1,109 @@
+package metric
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+// summaryWindow возвращает окно для квантилей summary из конфига.
+func (s *MetricService) summaryWindow() time.Duration {
+	if s.Config.SummaryWindow > 0 {
+		return time.Duration(s.Config.SummaryWindow) * time.Second
+	}
+
+	return storage.DefaultSummaryWindow
+}
+
+// newSummary возвращает summary с одним наблюдением v в момент now.
+func (s *MetricService) newSummary(v float64, now time.Time) (storage.Summary, error) {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return storage.Summary{}, merrors.ErrIncorrectSummaryValue
+	}
+
+	sm := storage.NewSummary(s.summaryWindow())
+	sm.Observe(v, now)
+
+	return sm, nil
+}
+
+// summaryMetric возвращает модель summary с квантилями за окно и Count, Sum,
+// Min и Max за все время (см. storage.Summary). Сами наблюдения в ответ не попадают.
+func summaryMetric(json models.Metrics, sm storage.Summary, now time.Time) models.Metrics {
+	res := models.Metrics{
+		ID:     json.ID,
+		MType:  "summary",
+		Labels: json.Labels,
+	}
+
+	quantiles := make(map[string]float64, len(reportQuantiles))
+	for _, q := range reportQuantiles {
+		if v := sm.Quantile(q, now); !math.IsNaN(v) {
+			quantiles[strconv.FormatFloat(q, 'g', -1, 64)] = v
+		}
+	}
+	if len(quantiles) > 0 {
+		res.Quantiles = quantiles
+	}
+
+	sm.Observations = nil
+	res.Summary = &sm
+
+	return res
+}
+
+// HandleSummaryUpdate добавляет наблюдение Value в summary.
+func (s *MetricService) HandleSummaryUpdate(ctx context.Context, json models.Metrics) (models.Metrics, error) {
+	if json.MType != "summary" {
+		return models.Metrics{}, merrors.ErrIncorrectMetricType
+	}
+	if json.Value == nil {
+		return models.Metrics{}, merrors.ErrMissingMetricValue
+	}
+
+	now := time.Now()
+	value, err := s.newSummary(float64(*json.Value), now)
+	if err != nil {
+		return models.Metrics{}, err
+	}
+
+	sm, err := s.db.UpdateSummary(ctx, json.Key(), value)
+	if isTenantError(err) {
+		return models.Metrics{}, err
+	}
+	if err != nil {
+		logger.Log.Error("error while getting new summary value", zap.Error(err))
+		return models.Metrics{}, merrors.ErrCannotGetNewMetricValue
+	}
+
+	if s.Config.SyncSave {
+		err := s.store.Save(ctx)
+		if err != nil {
+			logger.Log.Error("Error while saving store")
+		}
+	}
+
+	return summaryMetric(json, sm, now), nil
+}
+
+// GetSummaryQuantile возвращает квантиль q summary за окно.
+// reqName — ключ серии (см. storage.SeriesKey).
+func (s *MetricService) GetSummaryQuantile(ctx context.Context, reqName string, q float64) (float64, error) {
+	if q < 0 || q > 1 || math.IsNaN(q) {
+		return 0, merrors.ErrIncorrectQuantile
+	}
+
+	sm, err := s.db.GetSummaryByName(ctx, reqName)
+	if err != nil {
+		return 0, merrors.ErrNotFoundMetric
+	}
+
+	return sm.Quantile(q, time.Now()), nil
+}
@@ -0,0 +1,170 @@
+package metric
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/testdata"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// isObservation проверяет, что summary содержит одно наблюдение v с окном window.
+func isObservation(v float64, window time.Duration) any {
+	return mock.MatchedBy(func(sm storage.Summary) bool {
+		return sm.Count == 1 && sm.Sum == v && sm.Window == window &&
+			len(sm.Observations) == 1 && sm.Observations[0].Value == v
+	})
+}
+
+func TestHandleSummaryUpdate(t *testing.T) {
+	stored := storage.NewSummary(time.Minute)
+	stored.Observe(1, time.Now())
+	stored.Observe(3, time.Now())
+
+	tests := []struct {
+		wantErr error
+		dbErr   error
+		name    string
+		json    models.Metrics
+		callDB  bool
+	}{
+		{
+			name:   "Observation",
+			json:   models.Metrics{ID: "rpc", MType: "summary", Value: ptrGauge(3)},
+			callDB: true,
+		},
+		{
+			name:    "Storage error",
+			json:    models.Metrics{ID: "rpc", MType: "summary", Value: ptrGauge(3)},
+			dbErr:   errors.New("db error"),
+			wantErr: merrors.ErrCannotGetNewMetricValue,
+			callDB:  true,
+		},
+		{
+			name:    "NaN value",
+			json:    models.Metrics{ID: "rpc", MType: "summary", Value: ptrGauge(math.NaN())},
+			wantErr: merrors.ErrIncorrectSummaryValue,
+		},
+		{
+			name:    "Without value",
+			json:    models.Metrics{ID: "rpc", MType: "summary"},
+			wantErr: merrors.ErrMissingMetricValue,
+		},
+		{
+			name:    "Wrong type",
+			json:    models.Metrics{ID: "rpc", MType: "gauge", Value: ptrGauge(3)},
+			wantErr: merrors.ErrIncorrectMetricType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			ctx := context.Background()
+			if tt.callDB {
+				st.EXPECT().UpdateSummary(ctx, "rpc", isObservation(3, time.Minute)).
+					Return(stored.Copy(), tt.dbErr)
+			}
+
+			cfg := testdata.TestServerConfig
+			cfg.SummaryWindow = 60
+			s := &MetricService{
+				Config: cfg,
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			got, err := s.HandleSummaryUpdate(ctx, tt.json)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, got.Summary)
+			assert.Nil(t, got.Summary.Observations)
+			assert.Equal(t, uint64(2), got.Summary.Count)
+			assert.InDelta(t, 2.0, got.Quantiles["0.5"], 1e-9)
+		})
+	}
+}
+
+func TestUpdateManySummary(t *testing.T) {
+	st := mocks.NewMockStorage(t)
+	ctx := context.Background()
+
+	st.EXPECT().UpdateMany(ctx, mock.MatchedBy(func(db storage.Database) bool {
+		sm, ok := db.Summary["rpc"]
+		return ok && sm.Count == 2 && sm.Sum == 3 && sm.Min == 1 && sm.Max == 2 &&
+			sm.Window == storage.DefaultSummaryWindow
+	})).Return(nil)
+
+	s := &MetricService{
+		Config: testdata.TestServerConfig,
+		db:     st,
+		store:  NewMockStore(t),
+	}
+
+	err := s.UpdateMany(ctx, []models.Metrics{
+		{ID: "rpc", MType: "summary", Value: ptrGauge(1)},
+		{ID: "rpc", MType: "summary", Value: ptrGauge(2)},
+	})
+	require.NoError(t, err)
+
+	_, err = s.UpdateBatch(ctx, "", []models.Metrics{
+		{ID: "rpc", MType: "summary", Value: ptrGauge(math.Inf(1))},
+	}, true)
+	assert.ErrorIs(t, err, merrors.ErrIncorrectSummaryValue)
+}
+
+func TestGetSummaryQuantile(t *testing.T) {
+	sm := storage.NewSummary(time.Minute)
+	for _, v := range []float64{1, 2, 3} {
+		sm.Observe(v, time.Now())
+	}
+
+	tests := []struct {
+		wantErr error
+		dbErr   error
+		name    string
+		q       float64
+		want    float64
+		callDB  bool
+	}{
+		{name: "Median", q: 0.5, want: 2, callDB: true},
+		{name: "Not found", q: 0.5, dbErr: merrors.ErrNotFoundMetric, wantErr: merrors.ErrNotFoundMetric, callDB: true},
+		{name: "Quantile above 1", q: 1.1, wantErr: merrors.ErrIncorrectQuantile},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			ctx := context.Background()
+			if tt.callDB {
+				st.EXPECT().GetSummaryByName(ctx, "rpc").Return(sm, tt.dbErr)
+			}
+
+			s := &MetricService{
+				Config: testdata.TestServerConfig,
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			got, err := s.GetSummaryQuantile(ctx, "rpc", tt.q)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.InDelta(t, tt.want, got, 1e-9)
+		})
+	}
+}
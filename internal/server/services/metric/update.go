@@ -2,33 +2,75 @@ package metric
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/logger"
 	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
 	"github.com/LekcRg/metrics/internal/server/storage"
 )
 
+// isTenantError определяет ошибки записи, вызванные ограничениями тенанта.
+func isTenantError(err error) bool {
+	return errors.Is(err, merrors.ErrSeriesQuotaExceeded) ||
+		errors.Is(err, merrors.ErrIncorrectTenantKey)
+}
+
+// UpdateMetric обновляет метрику. reqName — ключ серии (см. storage.SeriesKey).
 func (s *MetricService) UpdateMetric(ctx context.Context, reqName string, reqType string, reqValue string) error {
-	// TODO: Check errors after db
 	switch reqType {
 	case "counter":
 		value, err := strconv.ParseInt(reqValue, 0, 64)
 		if err != nil {
 			return merrors.ErrIncorrectCounterValue
 		}
-		s.db.UpdateCounter(ctx, reqName, storage.Counter(value))
+		if _, err = s.db.UpdateCounter(ctx, reqName, storage.Counter(value)); err != nil {
+			return err
+		}
 	case "gauge":
 		value, err := strconv.ParseFloat(reqValue, 64)
 		if err != nil {
 			return merrors.ErrIncorrectGaugeValue
 		}
-		s.db.UpdateGauge(ctx, reqName, storage.Gauge(value))
+		if _, err = s.db.UpdateGauge(ctx, reqName, storage.Gauge(value)); err != nil {
+			return err
+		}
+	case "histogram":
+		value, err := strconv.ParseFloat(reqValue, 64)
+		if err != nil {
+			return merrors.ErrIncorrectHistogramValue
+		}
+		if _, err = s.observe(ctx, reqName, value); err != nil {
+			return err
+		}
+	case "summary":
+		value, err := strconv.ParseFloat(reqValue, 64)
+		if err != nil {
+			return merrors.ErrIncorrectSummaryValue
+		}
+		sm, err := s.newSummary(value, time.Now())
+		if err != nil {
+			return err
+		}
+		if _, err = s.db.UpdateSummary(ctx, reqName, sm); err != nil {
+			return err
+		}
+	case "set":
+		st := storage.NewSet()
+		st.Add(reqValue)
+		if _, err := s.db.UpdateSet(ctx, reqName, st); err != nil {
+			return err
+		}
 	default:
 		return merrors.ErrIncorrectMetricType
 	}
 
+	s.publish(ctx, hub.Event{Key: reqName, MType: reqType})
+
 	if s.Config.SyncSave {
 		err := s.store.Save(ctx)
 		if err != nil {
@@ -47,17 +89,21 @@ func (s *MetricService) HandleCounterUpdate(ctx context.Context, json models.Met
 		return models.Metrics{}, merrors.ErrIncorrectMetricType
 	}
 
-	newVal, err := s.db.UpdateCounter(ctx, json.ID, *json.Delta)
+	newVal, err := s.db.UpdateCounter(ctx, json.Key(), *json.Delta)
 
+	if isTenantError(err) {
+		return models.Metrics{}, err
+	}
 	if err != nil {
 		logger.Log.Error("error while getting new counter value")
 		return models.Metrics{}, merrors.ErrCannotGetNewMetricValue
 	}
 
 	return models.Metrics{
-		ID:    json.ID,
-		MType: json.MType,
-		Delta: &newVal,
+		ID:     json.ID,
+		MType:  json.MType,
+		Labels: json.Labels,
+		Delta:  &newVal,
 	}, nil
 }
 
@@ -68,8 +114,11 @@ func (s *MetricService) HandleGaugeUpdate(ctx context.Context, json models.Metri
 	if json.MType != "gauge" {
 		return models.Metrics{}, merrors.ErrIncorrectMetricType
 	}
-	newVal, err := s.db.UpdateGauge(ctx, json.ID, *json.Value)
+	newVal, err := s.db.UpdateGauge(ctx, json.Key(), *json.Value)
 
+	if isTenantError(err) {
+		return models.Metrics{}, err
+	}
 	if err != nil {
 		logger.Log.Error("error while getting new gauge value")
 		return models.Metrics{}, merrors.ErrCannotGetNewMetricValue
@@ -83,40 +132,215 @@ func (s *MetricService) HandleGaugeUpdate(ctx context.Context, json models.Metri
 	}
 
 	return models.Metrics{
-		ID:    json.ID,
-		MType: json.MType,
-		Value: &newVal,
+		ID:     json.ID,
+		MType:  json.MType,
+		Labels: json.Labels,
+		Value:  &newVal,
 	}, nil
 }
 
+// UpdateMetricJSON обновляет метрику и ее описание, если оно передано.
+// Описание сохраняется только после успешного обновления значения.
 func (s *MetricService) UpdateMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error) {
+	if err := validMetadata(json); err != nil {
+		return models.Metrics{}, err
+	}
+
+	var (
+		res models.Metrics
+		err error
+	)
+
 	switch json.MType {
 	case "gauge":
-		return s.HandleGaugeUpdate(ctx, json)
+		res, err = s.HandleGaugeUpdate(ctx, json)
 	case "counter":
-		return s.HandleCounterUpdate(ctx, json)
+		res, err = s.HandleCounterUpdate(ctx, json)
+	case "histogram":
+		res, err = s.HandleHistogramUpdate(ctx, json)
+	case "summary":
+		res, err = s.HandleSummaryUpdate(ctx, json)
+	case "set":
+		res, err = s.HandleSetUpdate(ctx, json)
 	default:
 		return models.Metrics{}, merrors.ErrIncorrectMetricType
 	}
+	if err != nil {
+		return res, err
+	}
+
+	if err = s.updateMetadata(ctx, json); err != nil {
+		return models.Metrics{}, err
+	}
+	s.publish(ctx, hub.Event{Key: json.Key(), MType: json.MType})
+
+	return res, nil
 }
 
+// UpdateMany обновляет метрики пачкой вместе с переданными описаниями. Гистограммы одной серии
+// объединяются, наблюдения (Value) попадают в бакеты сохраненной гистограммы.
+// Наблюдения summary одной серии собираются в один summary,
+// значения и скетчи set одной серии — в один скетч.
+// Некорректные метрики пропускаются, остальные применяются (см. UpdateBatch).
 func (s *MetricService) UpdateMany(ctx context.Context, list []models.Metrics) error {
+	_, err := s.UpdateBatch(ctx, "", list, false)
+	return err
+}
+
+// UpdateBatch работает как UpdateMany и возвращает принятые и отклоненные метрики.
+// Пачка с непустым ключом batchID применяется один раз: повтор возвращает
+// merrors.ErrDuplicateBatch (см. storage.BatchTTL). Без strict некорректные
+// метрики пропускаются, со strict пачка с ними не применяется
+// и возвращается merrors.ErrBatchRejected. Пачка без принятых метрик
+// хранилище не меняет.
+func (s *MetricService) UpdateBatch(
+	ctx context.Context, batchID string, list []models.Metrics, strict bool,
+) (models.BatchResult, error) {
+	res := models.NewBatchResult()
+	if err := storage.ValidateBatchID(batchID); err != nil {
+		return res, err
+	}
+
 	newVals := storage.Database{
-		Gauge:   storage.GaugeCollection{},
-		Counter: storage.CounterCollection{},
+		BatchID:   batchID,
+		Gauge:     storage.GaugeCollection{},
+		Counter:   storage.CounterCollection{},
+		Histogram: storage.HistogramCollection{},
+		Summary:   storage.SummaryCollection{},
+		Set:       storage.SetCollection{},
 	}
 
 	if len(list) == 0 {
-		return nil
+		return res, nil
+	}
+
+	now := time.Now()
+	var rejectErr error
+
+	for i, el := range list {
+		if err := s.addItem(ctx, &newVals, el, now); err != nil {
+			res.Reject(i, el, err)
+			if rejectErr == nil {
+				rejectErr = fmt.Errorf("%w: metric %d: %w", merrors.ErrBatchRejected, i, err)
+			}
+			continue
+		}
+		res.Accept(i, el)
+	}
+
+	if strict && rejectErr != nil {
+		return res, rejectErr
+	}
+	if len(res.Accepted) == 0 {
+		return res, nil
+	}
+
+	if err := s.db.UpdateMany(ctx, newVals); err != nil {
+		return res, err
 	}
 
-	for _, el := range list {
-		if el.MType == "gauge" && el.Value != nil {
-			newVals.Gauge[el.ID] = *el.Value
-		} else if el.MType == "counter" && el.Delta != nil {
-			newVals.Counter[el.ID] += *el.Delta
+	events := make([]hub.Event, 0, len(res.Accepted))
+	for _, item := range res.Accepted {
+		el := list[item.Index]
+		events = append(events, hub.Event{Key: el.Key(), MType: el.MType})
+	}
+	s.publish(ctx, events...)
+
+	return res, nil
+}
+
+// addItem проверяет метрику el и добавляет ее в пачку newVals.
+// Метрика без значения принимается, только если у нее есть описание.
+// Некорректная метрика не меняет пачку.
+func (s *MetricService) addItem(ctx context.Context, newVals *storage.Database, el models.Metrics, now time.Time) error {
+	if el.ID == "" {
+		return merrors.ErrIncorrectMetricName
+	}
+	if el.Meta != nil {
+		if err := el.Meta.Validate(); err != nil {
+			return err
+		}
+	}
+
+	err := s.addValue(ctx, newVals, el, now)
+	if errors.Is(err, merrors.ErrMissingMetricValue) && el.Meta != nil {
+		err = nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if el.Meta != nil {
+		if newVals.Metadata == nil {
+			newVals.Metadata = storage.MetadataCollection{}
 		}
+		newVals.Metadata[el.ID] = *el.Meta
 	}
 
-	return s.db.UpdateMany(ctx, newVals)
+	return nil
+}
+
+// addValue добавляет значение метрики el в пачку newVals.
+func (s *MetricService) addValue(ctx context.Context, newVals *storage.Database, el models.Metrics, now time.Time) error {
+	switch el.MType {
+	case "gauge":
+		if el.Value == nil {
+			return merrors.ErrMissingMetricValue
+		}
+		newVals.Gauge[el.Key()] = *el.Value
+	case "counter":
+		if el.Delta == nil {
+			return merrors.ErrMissingMetricValue
+		}
+		newVals.Counter[el.Key()] += *el.Delta
+	case "histogram":
+		return s.addHistogram(ctx, newVals.Histogram, el)
+	case "summary":
+		if el.Value == nil {
+			return merrors.ErrMissingMetricValue
+		}
+		value, err := s.newSummary(float64(*el.Value), now)
+		if err != nil {
+			return err
+		}
+		sm := newVals.Summary[el.Key()]
+		sm.Merge(value)
+		newVals.Summary[el.Key()] = sm
+	case "set":
+		return addSet(newVals.Set, el)
+	default:
+		return merrors.ErrIncorrectMetricType
+	}
+
+	return nil
+}
+
+// addHistogram добавляет гистограмму или наблюдение из el в пачку.
+func (s *MetricService) addHistogram(ctx context.Context, list storage.HistogramCollection, el models.Metrics) error {
+	key := el.Key()
+	h, ok := list[key]
+
+	switch {
+	case el.Histogram != nil:
+		if err := el.Histogram.Validate(); err != nil {
+			return err
+		}
+		if err := h.Merge(*el.Histogram); err != nil {
+			return err
+		}
+	case el.Value != nil:
+		if err := storage.ValidateObservation(float64(*el.Value)); err != nil {
+			return err
+		}
+		if !ok {
+			h = storage.NewHistogram(s.buckets(ctx, key))
+		}
+		h.Observe(float64(*el.Value))
+	default:
+		return merrors.ErrMissingMetricValue
+	}
+
+	list[key] = h
+
+	return nil
 }
@@ -3,6 +3,7 @@ package metric
 import (
 	"context"
 	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/LekcRg/metrics/internal/config"
@@ -481,10 +482,37 @@ func TestUpdateMany(t *testing.T) {
 				Counter: storage.CounterCollection{
 					"counter1": 5,
 				},
+				Histogram: storage.HistogramCollection{},
+				Summary:   storage.SummaryCollection{},
+				Set:       storage.SetCollection{},
 			},
 			dbErr:   nil,
 			wantErr: nil,
 		},
+		{
+			name: "Invalid metric is skipped",
+			metrics: []models.Metrics{
+				{
+					ID:    "",
+					MType: "gauge",
+					Value: ptrGauge(1),
+				},
+				{
+					ID:    "gauge1",
+					MType: "gauge",
+					Value: ptrGauge(1.5),
+				},
+			},
+			wantDBData: storage.Database{
+				Gauge: storage.GaugeCollection{
+					"gauge1": 1.5,
+				},
+				Counter:   storage.CounterCollection{},
+				Histogram: storage.HistogramCollection{},
+				Summary:   storage.SummaryCollection{},
+				Set:       storage.SetCollection{},
+			},
+		},
 		{
 			name: "DB returns error",
 			metrics: []models.Metrics{
@@ -498,7 +526,10 @@ func TestUpdateMany(t *testing.T) {
 				Gauge: storage.GaugeCollection{
 					"gauge1": 2.2,
 				},
-				Counter: storage.CounterCollection{},
+				Counter:   storage.CounterCollection{},
+				Histogram: storage.HistogramCollection{},
+				Summary:   storage.SummaryCollection{},
+				Set:       storage.SetCollection{},
 			},
 			dbErr:   merrors.ErrMocked,
 			wantErr: merrors.ErrMocked,
@@ -532,6 +563,224 @@ func TestUpdateMany(t *testing.T) {
 	}
 }
 
+func TestUpdateBatch(t *testing.T) {
+	ctx := context.Background()
+	list := []models.Metrics{{ID: "PollCount", MType: "counter", Delta: ptrCounter(1)}}
+
+	st := mocks.NewMockStorage(t)
+	st.EXPECT().UpdateMany(ctx, storage.Database{
+		Gauge:     storage.GaugeCollection{},
+		Counter:   storage.CounterCollection{"PollCount": 1},
+		Histogram: storage.HistogramCollection{},
+		Summary:   storage.SummaryCollection{},
+		Set:       storage.SetCollection{},
+		BatchID:   "batch-1",
+	}).Return(merrors.ErrDuplicateBatch)
+
+	s := &MetricService{
+		Config: testdata.TestServerConfig,
+		db:     st,
+		store:  NewMockStore(t),
+	}
+
+	_, err := s.UpdateBatch(ctx, "batch-1", list, false)
+	assert.ErrorIs(t, err, merrors.ErrDuplicateBatch)
+
+	_, err = s.UpdateBatch(ctx, strings.Repeat("a", storage.MaxBatchIDLen+1), list, false)
+	assert.ErrorIs(t, err, merrors.ErrIncorrectBatchID)
+}
+
+func TestUpdateBatchResult(t *testing.T) {
+	list := []models.Metrics{
+		{ID: "PollCount", MType: "counter", Delta: ptrCounter(1)},
+		{ID: "Alloc", MType: "gauge"},
+		{ID: "", MType: "gauge", Value: ptrGauge(1)},
+		{ID: "Users", MType: "unknown", Value: ptrGauge(1)},
+		{ID: "Sessions", MType: "set"},
+		{ID: "RandomValue", MType: "gauge", Value: ptrGauge(2), Meta: &storage.Metadata{Type: "unknown"}},
+		{ID: "HeapAlloc", MType: "gauge", Value: ptrGauge(3)},
+	}
+	wantResult := models.BatchResult{
+		Accepted: []models.BatchItem{
+			{Index: 0, ID: "PollCount", MType: "counter"},
+			{Index: 6, ID: "HeapAlloc", MType: "gauge"},
+		},
+		Rejected: []models.BatchItem{
+			{Index: 1, ID: "Alloc", MType: "gauge", Reason: merrors.ErrMissingMetricValue.Error()},
+			{Index: 2, ID: "", MType: "gauge", Reason: merrors.ErrIncorrectMetricName.Error()},
+			{Index: 3, ID: "Users", MType: "unknown", Reason: merrors.ErrIncorrectMetricType.Error()},
+			{Index: 4, ID: "Sessions", MType: "set", Reason: merrors.ErrMissingMetricValue.Error()},
+			{Index: 5, ID: "RandomValue", MType: "gauge", Reason: merrors.ErrIncorrectMetadata.Error()},
+		},
+	}
+
+	tests := []struct {
+		wantErr error
+		name    string
+		strict  bool
+	}{
+		{
+			name: "Invalid metrics are skipped",
+		},
+		{
+			name:    "Strict batch is rejected",
+			strict:  true,
+			wantErr: merrors.ErrBatchRejected,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			st := mocks.NewMockStorage(t)
+			if !tt.strict {
+				st.EXPECT().UpdateMany(ctx, storage.Database{
+					Gauge:     storage.GaugeCollection{"HeapAlloc": 3},
+					Counter:   storage.CounterCollection{"PollCount": 1},
+					Histogram: storage.HistogramCollection{},
+					Summary:   storage.SummaryCollection{},
+					Set:       storage.SetCollection{},
+				}).Return(nil)
+			}
+
+			s := &MetricService{
+				Config: testdata.TestServerConfig,
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			res, err := s.UpdateBatch(ctx, "", list, tt.strict)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				assert.ErrorIs(t, err, merrors.ErrMissingMetricValue)
+			} else {
+				require.NoError(t, err)
+			}
+			assert.Equal(t, wantResult, res)
+		})
+	}
+}
+
+func TestUpdateManyHistogram(t *testing.T) {
+	buckets := []float64{0.1, 1}
+
+	tests := []struct {
+		wantDBData *storage.Database
+		stored     *storage.Histogram
+		wantErr    error
+		name       string
+		metrics    []models.Metrics
+	}{
+		{
+			name: "Observations use configured buckets",
+			metrics: []models.Metrics{
+				{ID: "latency", MType: "histogram", Value: ptrGauge(0.05)},
+				{ID: "latency", MType: "histogram", Value: ptrGauge(0.5)},
+			},
+			wantDBData: &storage.Database{
+				Gauge:   storage.GaugeCollection{},
+				Counter: storage.CounterCollection{},
+				Histogram: storage.HistogramCollection{
+					"latency": {Buckets: buckets, Counts: []uint64{1, 1, 0}, Sum: 0.55, Count: 2},
+				},
+				Summary: storage.SummaryCollection{},
+				Set:     storage.SetCollection{},
+			},
+		},
+		{
+			name: "Observations use stored buckets",
+			stored: &storage.Histogram{
+				Buckets: []float64{5}, Counts: []uint64{1, 0}, Sum: 1, Count: 1,
+			},
+			metrics: []models.Metrics{
+				{ID: "latency", MType: "histogram", Value: ptrGauge(7)},
+			},
+			wantDBData: &storage.Database{
+				Gauge:   storage.GaugeCollection{},
+				Counter: storage.CounterCollection{},
+				Histogram: storage.HistogramCollection{
+					"latency": {Buckets: []float64{5}, Counts: []uint64{0, 1}, Sum: 7, Count: 1},
+				},
+				Summary: storage.SummaryCollection{},
+				Set:     storage.SetCollection{},
+			},
+		},
+		{
+			name: "Histograms of one series are merged",
+			metrics: []models.Metrics{
+				{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+					Buckets: buckets, Counts: []uint64{1, 0, 0}, Sum: 0.05, Count: 1,
+				}},
+				{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+					Buckets: buckets, Counts: []uint64{0, 2, 1}, Sum: 3, Count: 3,
+				}},
+			},
+			wantDBData: &storage.Database{
+				Gauge:   storage.GaugeCollection{},
+				Counter: storage.CounterCollection{},
+				Histogram: storage.HistogramCollection{
+					"latency": {Buckets: buckets, Counts: []uint64{1, 2, 1}, Sum: 3.05, Count: 4},
+				},
+				Summary: storage.SummaryCollection{},
+				Set:     storage.SetCollection{},
+			},
+		},
+		{
+			name: "Count does not match buckets",
+			metrics: []models.Metrics{
+				{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+					Buckets: buckets, Counts: []uint64{1, 0, 0}, Sum: 0.05, Count: 2,
+				}},
+			},
+			wantErr: merrors.ErrIncorrectHistogram,
+		},
+		{
+			name: "Different buckets in one batch",
+			metrics: []models.Metrics{
+				{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+					Buckets: buckets, Counts: []uint64{1, 0, 0}, Sum: 0.05, Count: 1,
+				}},
+				{ID: "latency", MType: "histogram", Histogram: &storage.Histogram{
+					Buckets: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.05, Count: 1,
+				}},
+			},
+			wantErr: merrors.ErrIncorrectHistogramBuckets,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := mocks.NewMockStorage(t)
+			ctx := context.Background()
+
+			if tt.metrics[0].Value != nil {
+				if tt.stored != nil {
+					st.EXPECT().GetHistogramByName(ctx, "latency").Return(*tt.stored, nil)
+				} else {
+					st.EXPECT().GetHistogramByName(ctx, "latency").
+						Return(storage.Histogram{}, merrors.ErrNotFoundMetric)
+				}
+			}
+			if tt.wantDBData != nil {
+				st.EXPECT().UpdateMany(ctx, *tt.wantDBData).Return(nil)
+			}
+
+			cfg := testdata.TestServerConfig
+			cfg.Buckets = buckets
+			s := &MetricService{
+				Config: cfg,
+				db:     st,
+				store:  NewMockStore(t),
+			}
+
+			_, err := s.UpdateBatch(ctx, "", tt.metrics, true)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
 func ptrGauge(val float64) *storage.Gauge {
 	v := storage.Gauge(val)
 	return &v
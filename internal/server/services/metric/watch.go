@@ -0,0 +1,31 @@
+package metric
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/server/hub"
+	"github.com/LekcRg/metrics/internal/tenant"
+)
+
+// publish сообщает подписчикам Watch об изменении серий тенанта запроса.
+func (s *MetricService) publish(ctx context.Context, events ...hub.Event) {
+	if len(events) == 0 {
+		return
+	}
+
+	id, _ := tenant.FromContext(ctx)
+	for i := range events {
+		events[i].Tenant = id
+	}
+
+	s.hub.Publish(events...)
+}
+
+// Watch подписывает на изменения метрик тенанта запроса, подходящие под filter.
+// Подписку нужно закрыть через Close. Изменения, сделанные в обход сервиса
+// (очистка по TTL и ретеншну), не публикуются.
+func (s *MetricService) Watch(ctx context.Context, filter hub.Filter) *hub.Subscription {
+	id, _ := tenant.FromContext(ctx)
+
+	return s.hub.Subscribe(id, filter)
+}
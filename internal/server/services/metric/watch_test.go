@@ -0,0 +1,67 @@
+package metric
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/hub"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatch(t *testing.T) {
+	st := mocks.NewMockStorage(t)
+	s := NewMetricsService(st, config.ServerConfig{}, NewMockStore(t))
+
+	teamCtx := tenant.WithTenant(ctx, "team-a")
+	sub := s.Watch(ctx, nil)
+	defer sub.Close()
+	teamSub := s.Watch(teamCtx, func(e hub.Event) bool { return e.MType == "counter" })
+	defer teamSub.Close()
+
+	next := func(sub *hub.Subscription) []hub.Event {
+		t.Helper()
+		waitCtx, cancel := context.WithTimeout(ctx, time.Second)
+		defer cancel()
+		events, err := sub.Next(waitCtx)
+		require.NoError(t, err)
+		return events
+	}
+
+	st.EXPECT().UpdateGauge(ctx, `Alloc{host="web1"}`, storage.Gauge(1)).Return(1, nil)
+	_, err := s.UpdateMetricJSON(ctx, models.Metrics{
+		ID: "Alloc", MType: "gauge", Labels: storage.Labels{"host": "web1"}, Value: ptrGauge(1),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []hub.Event{{Key: `Alloc{host="web1"}`, MType: "gauge"}}, next(sub))
+
+	st.EXPECT().UpdateMany(teamCtx, mock.Anything).Return(nil)
+	_, err = s.UpdateBatch(teamCtx, "", []models.Metrics{
+		{ID: "PollCount", MType: "counter", Delta: ptrCounter(1)},
+		{ID: "RandomValue", MType: "gauge", Value: ptrGauge(1)},
+		{ID: "Broken", MType: "counter"},
+	}, false)
+	require.NoError(t, err)
+	assert.Equal(t, []hub.Event{{Tenant: "team-a", Key: "PollCount", MType: "counter"}}, next(teamSub))
+
+	st.EXPECT().DeleteMetric(ctx, "gauge", "Alloc").Return(nil).Once()
+	require.NoError(t, s.DeleteMetric(ctx, "Alloc", "gauge"))
+	assert.Equal(t, []hub.Event{{Key: "Alloc", MType: "gauge", Deleted: true}}, next(sub))
+
+	// неудачные изменения не публикуются
+	st.EXPECT().DeleteMetric(ctx, "gauge", "Alloc").Return(merrors.ErrNotFoundMetric)
+	require.Error(t, s.DeleteMetric(ctx, "Alloc", "gauge"))
+
+	waitCtx, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	defer cancel()
+	_, err = sub.Next(waitCtx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
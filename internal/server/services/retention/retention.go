@@ -0,0 +1,54 @@
+// Package retention — фоновая задача, которая сворачивает историю метрик
+// и удаляет устаревшие семплы по политике хранения из конфигурации.
+package retention
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"go.uber.org/zap"
+)
+
+type Retention struct {
+	db       storage.Storage
+	policy   storage.RetentionPolicy
+	interval time.Duration
+}
+
+func New(db storage.Storage, policy storage.RetentionPolicy, cfg config.ServerConfig) *Retention {
+	return &Retention{
+		db:       db,
+		policy:   policy,
+		interval: time.Duration(cfg.RetentionInterval) * time.Second,
+	}
+}
+
+// Apply один раз применяет политику хранения на текущий момент.
+func (r Retention) Apply(ctx context.Context) error {
+	return r.db.ApplyRetention(ctx, r.policy, time.Now())
+}
+
+// Start применяет политику сразу и затем каждые interval, пока не отменен ctx.
+func (r Retention) Start(ctx context.Context, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.Apply(ctx); err != nil && ctx.Err() == nil {
+			logger.Log.Error("error while applying retention policy", zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			logger.Log.Info("Stopped retention")
+			return
+		case <-ticker.C:
+		}
+	}
+}
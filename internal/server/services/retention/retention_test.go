@@ -0,0 +1,46 @@
+package retention
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/mocks"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApply(t *testing.T) {
+	policy, err := storage.ParseRetention("raw:1h,1m:1d")
+	require.NoError(t, err)
+
+	db := mocks.NewMockStorage(t)
+	db.EXPECT().ApplyRetention(mock.Anything, policy, mock.Anything).Return(nil).Once()
+
+	r := New(db, policy, config.ServerConfig{RetentionInterval: 60})
+	assert.NoError(t, r.Apply(context.Background()))
+}
+
+func TestStart(t *testing.T) {
+	policy, err := storage.ParseRetention("raw:1h")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	db := mocks.NewMockStorage(t)
+	db.EXPECT().ApplyRetention(mock.Anything, policy, mock.Anything).
+		Run(func(context.Context, storage.RetentionPolicy, time.Time) {
+			cancel()
+		}).
+		Return(nil)
+
+	r := New(db, policy, config.ServerConfig{RetentionInterval: 60})
+
+	wg := &sync.WaitGroup{}
+	wg.Add(1)
+	go r.Start(ctx, wg)
+	wg.Wait()
+}
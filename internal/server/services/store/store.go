@@ -13,6 +13,12 @@ import (
 	"github.com/LekcRg/metrics/internal/server/storage"
 )
 
+// loader — хранилище, которое загружает значения без записи в историю
+// (см. memstorage.MemStorage.Load).
+type loader interface {
+	Load(ctx context.Context, list storage.Database)
+}
+
 type Store struct {
 	db  storage.Storage
 	cfg config.ServerConfig
@@ -74,7 +80,11 @@ func (s Store) StartSaving(ctx context.Context, wg *sync.WaitGroup) {
 
 func (s Store) Restore(ctx context.Context) error {
 	if s.cfg.DatabaseDSN != "" {
-		return fmt.Errorf("postgres doesn't support restore from file")
+		return fmt.Errorf("database storage doesn't support restore from file")
+	}
+	if s.cfg.WALDir != "" {
+		// журнал уже восстановлен, файл применился бы поверх него второй раз
+		return fmt.Errorf("write-ahead log storage doesn't support restore from file")
 	}
 	file, err := os.ReadFile(s.cfg.FileStoragePath)
 	if err != nil {
@@ -87,8 +97,11 @@ func (s Store) Restore(ctx context.Context) error {
 		return err
 	}
 
-	err = s.db.UpdateMany(ctx, storage)
-	if err != nil {
+	// восстановленные значения не попадают в историю: иначе накопленные
+	// счетчики попали бы в нее одним приростом в момент запуска
+	if l, ok := s.db.(loader); ok {
+		l.Load(ctx, storage)
+	} else if err = s.db.UpdateMany(ctx, storage); err != nil {
 		logger.Log.Error("Error while restoring db")
 		return err
 	}
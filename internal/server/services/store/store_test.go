@@ -5,11 +5,14 @@ import (
 	"encoding/json"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/config"
 	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/mocks"
 	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -101,6 +104,7 @@ func TestRestore(t *testing.T) {
 		name        string
 		fileContent []byte
 		// chmod       os.FileMode
+		walDir  string
 		dbErr   bool
 		wantErr bool
 		// changeChmod bool
@@ -137,6 +141,11 @@ func TestRestore(t *testing.T) {
 		// 	wantErr:     false,
 		// 	chmod:       0o444,
 		// },
+		{
+			name:    "Write-ahead log storage",
+			walDir:  "wal",
+			wantErr: true,
+		},
 		{
 			name:        "Invalid JSON",
 			fileContent: []byte("invalid}}"),
@@ -172,6 +181,7 @@ func TestRestore(t *testing.T) {
 			s := Store{
 				cfg: config.ServerConfig{
 					FileStoragePath: file.Name(),
+					WALDir:          tt.walDir,
 				},
 				db: st,
 			}
@@ -189,3 +199,31 @@ func TestRestore(t *testing.T) {
 		})
 	}
 }
+
+func TestRestoreWithoutHistory(t *testing.T) {
+	ctx := context.Background()
+	file, err := os.CreateTemp("", "test_restore")
+	require.NoError(t, err)
+	defer os.Remove(file.Name())
+
+	content, err := json.Marshal(storage.Database{
+		Counter: storage.CounterCollection{"PollCount": 100},
+	})
+	require.NoError(t, err)
+	_, err = file.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, file.Close())
+
+	db, err := memstorage.New()
+	require.NoError(t, err)
+	s := NewStore(db, config.ServerConfig{FileStoragePath: file.Name()})
+	require.NoError(t, s.Restore(ctx))
+
+	val, err := db.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(100), val)
+
+	history, err := db.GetCounterHistory(ctx, "PollCount", time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
@@ -0,0 +1,130 @@
+package statsd
+
+import (
+	"context"
+	"math"
+	"sync"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+type gauge struct {
+	value float64
+	set   bool // было абсолютное значение, иначе value — прирост к сохраненному
+}
+
+type timer struct {
+	values []float64
+	count  float64 // количество с учетом sample rate
+}
+
+// aggregator накапливает значения за окно между сбросами.
+// Значения хранятся по ключу серии (см. storage.SeriesKey).
+type aggregator struct {
+	series   map[string]models.Metrics
+	counters map[string]float64
+	gauges   map[string]gauge
+	timers   map[string]*timer
+	mu       sync.Mutex
+}
+
+func newAggregator() *aggregator {
+	a := &aggregator{}
+	a.reset()
+
+	return a
+}
+
+func (a *aggregator) reset() {
+	a.series = map[string]models.Metrics{}
+	a.counters = map[string]float64{}
+	a.gauges = map[string]gauge{}
+	a.timers = map[string]*timer{}
+}
+
+func (a *aggregator) add(p packet) {
+	series := models.Metrics{ID: p.name, Labels: p.labels}
+	key := series.Key()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.series[key] = series
+
+	switch p.typ {
+	case "c":
+		a.counters[key] += p.value / p.rate
+	case "g":
+		g := a.gauges[key]
+		if p.relative {
+			g.value += p.value
+		} else {
+			g = gauge{value: p.value, set: true}
+		}
+		a.gauges[key] = g
+	case "ms", "h":
+		t, ok := a.timers[key]
+		if !ok {
+			t = &timer{}
+			a.timers[key] = t
+		}
+		t.values = append(t.values, p.value)
+		t.count += 1 / p.rate
+	}
+}
+
+// flush забирает накопленные значения и переводит их в метрики.
+// Для относительных gauge без абсолютного значения в окне base возвращает
+// текущее значение из хранилища.
+// Timer превращается в gauge name.mean, name.lower, name.upper и counter name.count.
+func (a *aggregator) flush(ctx context.Context, base func(ctx context.Context, series models.Metrics) float64) []models.Metrics {
+	a.mu.Lock()
+	series, counters, gauges, timers := a.series, a.counters, a.gauges, a.timers
+	a.reset()
+	a.mu.Unlock()
+
+	list := make([]models.Metrics, 0, len(counters)+len(gauges)+len(timers)*4)
+	addGauge := func(m models.Metrics, v float64) {
+		val := storage.Gauge(v)
+		m.MType, m.Value = "gauge", &val
+		list = append(list, m)
+	}
+	addCounter := func(m models.Metrics, v float64) {
+		val := storage.Counter(math.Round(v))
+		m.MType, m.Delta = "counter", &val
+		list = append(list, m)
+	}
+	suffixed := func(m models.Metrics, suffix string) models.Metrics {
+		m.ID += suffix
+		return m
+	}
+
+	for key, v := range counters {
+		addCounter(series[key], v)
+	}
+
+	for key, g := range gauges {
+		if !g.set {
+			g.value += base(ctx, series[key])
+		}
+		addGauge(series[key], g.value)
+	}
+
+	for key, t := range timers {
+		lower, upper, sum := t.values[0], t.values[0], 0.0
+		for _, v := range t.values {
+			lower = min(lower, v)
+			upper = max(upper, v)
+			sum += v
+		}
+
+		m := series[key]
+		addGauge(suffixed(m, ".mean"), sum/float64(len(t.values)))
+		addGauge(suffixed(m, ".lower"), lower)
+		addGauge(suffixed(m, ".upper"), upper)
+		addCounter(suffixed(m, ".count"), t.count)
+	}
+
+	return list
+}
@@ -0,0 +1,84 @@
+package statsd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAggregatorFlush(t *testing.T) {
+	gauge := func(id string, v storage.Gauge) models.Metrics {
+		return models.Metrics{ID: id, MType: "gauge", Value: &v}
+	}
+	counter := func(id string, v storage.Counter) models.Metrics {
+		return models.Metrics{ID: id, MType: "counter", Delta: &v}
+	}
+	labeled := func(m models.Metrics, k, v string) models.Metrics {
+		m.Labels = storage.Labels{k: v}
+		return m
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  []models.Metrics
+	}{
+		{
+			name:  "Counters honor sample rate",
+			lines: []string{"requests:1|c", "requests:1|c|@0.5"},
+			want:  []models.Metrics{counter("requests", 3)},
+		},
+		{
+			name:  "Absolute gauge overrides relative",
+			lines: []string{"queue:+3|g", "queue:10|g", "queue:-4|g"},
+			want:  []models.Metrics{gauge("queue", 6)},
+		},
+		{
+			name:  "Relative gauge uses stored value",
+			lines: []string{"stored:+3|g", "stored:-1|g"},
+			want:  []models.Metrics{gauge("stored", 102)},
+		},
+		{
+			name:  "Timer",
+			lines: []string{"latency:100|ms", "latency:300|ms|@0.5"},
+			want: []models.Metrics{
+				gauge("latency.mean", 200),
+				gauge("latency.lower", 100),
+				gauge("latency.upper", 300),
+				counter("latency.count", 3),
+			},
+		},
+		{
+			name:  "Tags are labels",
+			lines: []string{"requests:1|c|#env:prod", "requests:2|c|#env:dev", "requests:4|c|#env:prod"},
+			want: []models.Metrics{
+				labeled(counter("requests", 2), "env", "dev"),
+				labeled(counter("requests", 5), "env", "prod"),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newAggregator()
+			for _, line := range tt.lines {
+				list, err := parseLine(line)
+				assert.NoError(t, err)
+				for _, p := range list {
+					a.add(p)
+				}
+			}
+
+			got := a.flush(context.Background(), func(_ context.Context, series models.Metrics) float64 {
+				if series.ID == "stored" {
+					return 100
+				}
+				return 0
+			})
+			assert.ElementsMatch(t, tt.want, got)
+			assert.Empty(t, a.flush(context.Background(), nil))
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package statsd
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+)
+
+var (
+	errIncorrectLine = errors.New("incorrect statsd line")
+	errUnknownType   = errors.New("unknown statsd type")
+)
+
+// packet — одно значение из строки StatsD.
+type packet struct {
+	labels   storage.Labels
+	name     string
+	typ      string
+	value    float64
+	rate     float64
+	relative bool // для gauge: значение со знаком + или - прибавляется к текущему
+}
+
+// parseLine разбирает строку вида name:value|type[|@rate][|#tags].
+// В одной строке может быть несколько значений: name:1|c:2|c.
+// Имя не может содержать tenant.Separator: метрики StatsD пишутся
+// в тенанта по умолчанию.
+func parseLine(line string) ([]packet, error) {
+	name, rest, ok := strings.Cut(line, ":")
+	if !ok || name == "" || rest == "" {
+		return nil, errIncorrectLine
+	}
+	if strings.Contains(name, tenant.Separator) {
+		return nil, fmt.Errorf("%w: name must not contain %q", errIncorrectLine, tenant.Separator)
+	}
+
+	// теги DogStatsD (|#k:v) могут содержать двоеточия, поэтому отрезаются сразу
+	var labels storage.Labels
+	if i := strings.Index(rest, "|#"); i >= 0 {
+		labels = parseTags(rest[i+2:])
+		rest = rest[:i]
+	}
+
+	var list []packet
+	for _, part := range strings.Split(rest, ":") {
+		p, err := parseValue(name, part)
+		if err != nil {
+			return nil, err
+		}
+		p.labels = labels
+		list = append(list, p)
+	}
+
+	return list, nil
+}
+
+// parseTags разбирает теги DogStatsD вида k1:v1,k2:v2.
+// Теги без значения пропускаются.
+func parseTags(s string) storage.Labels {
+	// после тегов могут идти другие расширения: |c:container_id
+	s, _, _ = strings.Cut(s, "|")
+
+	var labels storage.Labels
+	for _, tag := range strings.Split(s, ",") {
+		k, v, ok := strings.Cut(tag, ":")
+		if !ok || k == "" || v == "" {
+			continue
+		}
+		if labels == nil {
+			labels = storage.Labels{}
+		}
+		labels[k] = v
+	}
+
+	return labels
+}
+
+func parseValue(name, part string) (packet, error) {
+	fields := strings.Split(part, "|")
+	if len(fields) < 2 || fields[0] == "" {
+		return packet{}, errIncorrectLine
+	}
+
+	p := packet{
+		name: name,
+		typ:  fields[1],
+		rate: 1,
+	}
+
+	switch p.typ {
+	case "c", "g", "ms", "h":
+	default:
+		return packet{}, fmt.Errorf("%w: %s", errUnknownType, p.typ)
+	}
+
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return packet{}, fmt.Errorf("%w: %w", errIncorrectLine, err)
+	}
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return packet{}, fmt.Errorf("%w: value must be finite", errIncorrectLine)
+	}
+	p.value = value
+	p.relative = p.typ == "g" && (fields[0][0] == '+' || fields[0][0] == '-')
+
+	for _, f := range fields[2:] {
+		rate, ok := strings.CutPrefix(f, "@")
+		if !ok {
+			// прочие расширения игнорируются
+			continue
+		}
+
+		p.rate, err = strconv.ParseFloat(rate, 64)
+		if err != nil || !(p.rate > 0 && p.rate <= 1) {
+			return packet{}, fmt.Errorf("%w: incorrect sample rate %s", errIncorrectLine, rate)
+		}
+	}
+
+	return p, nil
+}
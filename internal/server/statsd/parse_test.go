@@ -0,0 +1,107 @@
+package statsd
+
+import (
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	tests := []struct {
+		wantErr error
+		name    string
+		line    string
+		want    []packet
+	}{
+		{
+			name: "Counter",
+			line: "requests:1|c",
+			want: []packet{{name: "requests", typ: "c", value: 1, rate: 1}},
+		},
+		{
+			name: "Counter with sample rate and tags",
+			line: "requests:2|c|@0.1|#env:prod,host:web1:8080,canary",
+			want: []packet{{
+				name: "requests", typ: "c", value: 2, rate: 0.1,
+				labels: storage.Labels{"env": "prod", "host": "web1:8080"},
+			}},
+		},
+		{
+			name: "Gauge",
+			line: "temperature:3.2|g",
+			want: []packet{{name: "temperature", typ: "g", value: 3.2, rate: 1}},
+		},
+		{
+			name: "Relative gauges",
+			line: "queue:+5|g:-2|g",
+			want: []packet{
+				{name: "queue", typ: "g", value: 5, rate: 1, relative: true},
+				{name: "queue", typ: "g", value: -2, rate: 1, relative: true},
+			},
+		},
+		{
+			name: "Timer",
+			line: "latency:120|ms",
+			want: []packet{{name: "latency", typ: "ms", value: 120, rate: 1}},
+		},
+		{
+			name:    "Tenant prefix",
+			line:    "team-a/requests:1|c",
+			wantErr: errIncorrectLine,
+		},
+		{
+			name:    "Without value",
+			line:    "requests",
+			wantErr: errIncorrectLine,
+		},
+		{
+			name:    "Without type",
+			line:    "requests:1",
+			wantErr: errIncorrectLine,
+		},
+		{
+			name:    "Incorrect value",
+			line:    "requests:one|c",
+			wantErr: errIncorrectLine,
+		},
+		{
+			name:    "NaN value",
+			line:    "temperature:NaN|g",
+			wantErr: errIncorrectLine,
+		},
+		{
+			name:    "Infinite value",
+			line:    "requests:+Inf|c",
+			wantErr: errIncorrectLine,
+		},
+		{
+			name:    "Incorrect sample rate",
+			line:    "requests:1|c|@2",
+			wantErr: errIncorrectLine,
+		},
+		{
+			name:    "NaN sample rate",
+			line:    "requests:1|c|@NaN",
+			wantErr: errIncorrectLine,
+		},
+		{
+			name:    "Unknown type",
+			line:    "users:42|s",
+			wantErr: errUnknownType,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseLine(tt.line)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
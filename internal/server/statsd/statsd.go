@@ -0,0 +1,259 @@
+// Package statsd принимает метрики по протоколу StatsD через UDP и TCP.
+//
+// Теги DogStatsD (|#k:v) становятся метками метрик. Значения накапливаются
+// в течение окна и записываются пачкой через UpdateMany.
+package statsd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/tenant"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxPacketSize — максимальный размер UDP-пакета.
+	maxPacketSize   = 65535
+	defaultInterval = 10 * time.Second
+)
+
+// ErrServerClosed возвращается из ListenAndServe после вызова Stop.
+var ErrServerClosed = errors.New("statsd: server closed")
+
+// MetricService — интерфейс сервиса метрик, который нужен для работы сервера.
+type MetricService interface {
+	GetMetricJSON(ctx context.Context, json models.Metrics) (models.Metrics, error)
+	UpdateMany(ctx context.Context, list []models.Metrics) error
+}
+
+type Server struct {
+	service  MetricService
+	udp      net.PacketConn
+	tcp      net.Listener
+	agg      *aggregator
+	conns    map[net.Conn]struct{}
+	done     chan struct{}
+	addr     string
+	wg       sync.WaitGroup
+	interval time.Duration
+	mu       sync.Mutex
+	closed   bool
+}
+
+func New(s MetricService, addr string, interval time.Duration) *Server {
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+
+	return &Server{
+		service:  s,
+		addr:     addr,
+		interval: interval,
+		agg:      newAggregator(),
+		conns:    map[net.Conn]struct{}{},
+		done:     make(chan struct{}),
+	}
+}
+
+// ListenAndServe слушает UDP и TCP на одном адресе и блокируется до вызова Stop.
+func (s *Server) ListenAndServe() error {
+	udp, err := net.ListenPacket("udp", s.addr)
+	if err != nil {
+		return err
+	}
+
+	tcp, err := net.Listen("tcp", s.addr)
+	if err != nil {
+		udp.Close()
+		return err
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		udp.Close()
+		tcp.Close()
+		return ErrServerClosed
+	}
+	s.udp, s.tcp = udp, tcp
+	s.wg.Add(3)
+	s.mu.Unlock()
+
+	go s.serveUDP()
+	go s.serveTCP()
+	go s.flushLoop()
+
+	s.wg.Wait()
+
+	return ErrServerClosed
+}
+
+// Addr возвращает адрес UDP-сокета после запуска.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.udp == nil {
+		return nil
+	}
+
+	return s.udp.LocalAddr()
+}
+
+func (s *Server) serveUDP() {
+	defer s.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := s.udp.ReadFrom(buf)
+		if err != nil {
+			if !s.isClosed() {
+				logger.Log.Error("statsd: udp read error", zap.Error(err))
+			}
+			return
+		}
+
+		for _, line := range bytes.Split(buf[:n], []byte("\n")) {
+			s.handleLine(string(line))
+		}
+	}
+}
+
+func (s *Server) serveTCP() {
+	defer s.wg.Done()
+
+	for {
+		conn, err := s.tcp.Accept()
+		if err != nil {
+			if !s.isClosed() {
+				logger.Log.Error("statsd: tcp accept error", zap.Error(err))
+			}
+			return
+		}
+
+		s.mu.Lock()
+		if s.closed {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go s.serveConn(conn)
+	}
+}
+
+func (s *Server) serveConn(conn net.Conn) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		s.handleLine(scanner.Text())
+	}
+}
+
+func (s *Server) handleLine(line string) {
+	if len(line) > 0 && line[len(line)-1] == '\r' {
+		line = line[:len(line)-1]
+	}
+	if line == "" {
+		return
+	}
+
+	list, err := parseLine(line)
+	if err != nil {
+		logger.Log.Debug("statsd: skip line", zap.String("line", line), zap.Error(err))
+		return
+	}
+
+	for _, p := range list {
+		s.agg.add(p)
+	}
+}
+
+func (s *Server) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(context.Background())
+		case <-s.done:
+			return
+		}
+	}
+}
+
+// storedGauge возвращает текущее значение gauge или 0, если его еще нет.
+func (s *Server) storedGauge(ctx context.Context, series models.Metrics) float64 {
+	series.MType = "gauge"
+	m, err := s.service.GetMetricJSON(ctx, series)
+	if err != nil || m.Value == nil {
+		return 0
+	}
+
+	return float64(*m.Value)
+}
+
+// flush записывает накопленные значения. У StatsD нет токенов,
+// поэтому метрики пишутся в тенанта по умолчанию.
+func (s *Server) flush(ctx context.Context) {
+	ctx = tenant.WithTenant(ctx, tenant.Default)
+	list := s.agg.flush(ctx, s.storedGauge)
+	if len(list) == 0 {
+		return
+	}
+
+	if err := s.service.UpdateMany(ctx, list); err != nil {
+		logger.Log.Error("statsd: error while updating metrics", zap.Error(err))
+	}
+}
+
+func (s *Server) isClosed() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.closed
+}
+
+// Stop закрывает сокеты, дожидается обработки принятых строк
+// и записывает накопленные значения.
+func (s *Server) Stop(ctx context.Context) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		return
+	}
+	s.closed = true
+	close(s.done)
+	if s.udp != nil {
+		s.udp.Close()
+		s.tcp.Close()
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+	s.flush(ctx)
+}
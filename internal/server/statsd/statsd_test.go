@@ -0,0 +1,95 @@
+package statsd
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/models"
+	"github.com/LekcRg/metrics/internal/server/services/metric"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/server/storage/tenantstorage"
+	"github.com/LekcRg/metrics/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServer(t *testing.T) {
+	s := NewMockMetricService(t)
+	s.EXPECT().GetMetricJSON(mock.Anything, models.Metrics{ID: "queue", MType: "gauge"}).
+		Return(models.Metrics{}, merrors.ErrNotFoundMetric).Once()
+
+	updated := make(chan []models.Metrics, 1)
+	s.EXPECT().UpdateMany(mock.Anything, mock.Anything).
+		Run(func(ctx context.Context, list []models.Metrics) {
+			id, ok := tenant.FromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, tenant.Default, id)
+			updated <- list
+		}).Return(nil).Once()
+
+	srv := New(s, "127.0.0.1:0", time.Hour)
+	served := make(chan error, 1)
+	go func() {
+		served <- srv.ListenAndServe()
+	}()
+
+	require.Eventually(t, func() bool {
+		return srv.Addr() != nil
+	}, time.Second, 10*time.Millisecond)
+
+	udp, err := net.Dial("udp", srv.Addr().String())
+	require.NoError(t, err)
+	defer udp.Close()
+	_, err = udp.Write([]byte("requests:1|c\nrequests:2|c|@0.5\nbroken\n"))
+	require.NoError(t, err)
+
+	srv.mu.Lock()
+	tcpAddr := srv.tcp.Addr().String()
+	srv.mu.Unlock()
+	tcp, err := net.Dial("tcp", tcpAddr)
+	require.NoError(t, err)
+	_, err = tcp.Write([]byte("queue:+5|g\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, tcp.Close())
+
+	require.Eventually(t, func() bool {
+		srv.agg.mu.Lock()
+		defer srv.agg.mu.Unlock()
+		return srv.agg.counters["requests"] == 5 && srv.agg.gauges["queue"].value == 5
+	}, time.Second, 10*time.Millisecond)
+
+	srv.Stop(context.Background())
+	assert.ErrorIs(t, <-served, ErrServerClosed)
+
+	requests := storage.Counter(5)
+	queue := storage.Gauge(5)
+	assert.ElementsMatch(t, []models.Metrics{
+		{ID: "requests", MType: "counter", Delta: &requests},
+		{ID: "queue", MType: "gauge", Value: &queue},
+	}, <-updated)
+}
+
+func TestTenantIsolation(t *testing.T) {
+	db, err := memstorage.New()
+	require.NoError(t, err)
+	service := metric.NewMetricsService(tenantstorage.New(db, 0), config.ServerConfig{}, nil)
+	srv := New(service, "127.0.0.1:0", time.Hour)
+
+	srv.handleLine("team-a/requests:1|c")
+	srv.handleLine("requests:2|c")
+	srv.flush(context.Background())
+
+	// имя с префиксом тенанта, минуя разбор строки, отклоняет хранилище
+	srv.agg.add(packet{name: "team-a/requests", typ: "c", value: 3, rate: 1})
+	srv.flush(context.Background())
+
+	all, err := db.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, storage.CounterCollection{"requests": 2}, all.Counter)
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+)
+
+// BatchTTL — сколько хранилище помнит ключи примененных пачек.
+//
+// Пачка UpdateMany с непустым Database.BatchID записывается один раз:
+// если пачка с тем же ключом уже применялась за последние BatchTTL,
+// UpdateMany ничего не меняет и возвращает merrors.ErrDuplicateBatch.
+// Так повтор запроса после таймаута не увеличивает счетчики дважды.
+const BatchTTL = time.Hour
+
+// MaxBatchIDLen — наибольшая длина ключа пачки.
+const MaxBatchIDLen = 128
+
+// ValidateBatchID проверяет ключ пачки. Пустой ключ означает пачку
+// без идемпотентности.
+func ValidateBatchID(id string) error {
+	if len(id) > MaxBatchIDLen {
+		return merrors.ErrIncorrectBatchID
+	}
+
+	return nil
+}
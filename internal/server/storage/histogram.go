@@ -0,0 +1,155 @@
+package storage
+
+import (
+	"math"
+	"slices"
+	"sort"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+)
+
+// DefaultBuckets — границы бакетов гистограммы по умолчанию, как в Prometheus.
+var DefaultBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Histogram — значение метрики типа histogram.
+// Buckets — верхние границы бакетов по возрастанию. Counts[i] — количество
+// наблюдений в (Buckets[i-1], Buckets[i]], последний элемент Counts —
+// наблюдения больше последней границы (бакет +Inf).
+type Histogram struct {
+	Buckets []float64 `json:"buckets"`
+	Counts  []uint64  `json:"counts"`
+	Sum     float64   `json:"sum"`
+	Count   uint64    `json:"count"`
+}
+
+// HistogramCollection — набор гистограмм, сгруппированных по ключу серии (см. SeriesKey).
+type HistogramCollection map[string]Histogram
+
+// NewHistogram создает пустую гистограмму с копией границ buckets.
+func NewHistogram(buckets []float64) Histogram {
+	return Histogram{
+		Buckets: slices.Clone(buckets),
+		Counts:  make([]uint64, len(buckets)+1),
+	}
+}
+
+// ValidateBuckets проверяет, что границы конечные и строго возрастают.
+func ValidateBuckets(buckets []float64) error {
+	for i, b := range buckets {
+		if math.IsNaN(b) || math.IsInf(b, 0) || (i > 0 && b <= buckets[i-1]) {
+			return merrors.ErrIncorrectHistogram
+		}
+	}
+
+	return nil
+}
+
+// ValidateObservation проверяет, что наблюдение конечное: NaN и ±Inf
+// в сумме гистограммы не сериализуются в JSON.
+func ValidateObservation(v float64) error {
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return merrors.ErrIncorrectHistogramValue
+	}
+
+	return nil
+}
+
+// Validate проверяет согласованность границ, бакетов и количества наблюдений
+// и что сумма конечная.
+func (h Histogram) Validate() error {
+	if err := ValidateBuckets(h.Buckets); err != nil {
+		return err
+	}
+	if len(h.Counts) != len(h.Buckets)+1 {
+		return merrors.ErrIncorrectHistogram
+	}
+	if err := ValidateObservation(h.Sum); err != nil {
+		return err
+	}
+
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total != h.Count {
+		return merrors.ErrIncorrectHistogram
+	}
+
+	return nil
+}
+
+// Copy возвращает копию гистограммы.
+func (h Histogram) Copy() Histogram {
+	h.Buckets = slices.Clone(h.Buckets)
+	h.Counts = slices.Clone(h.Counts)
+
+	return h
+}
+
+// Observe добавляет одно наблюдение.
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.Buckets, v)
+	h.Counts[i]++
+	h.Sum += v
+	h.Count++
+}
+
+// Merge прибавляет к гистограмме значения o. Пустая гистограмма без границ
+// принимает границы o, в остальных случаях границы должны совпадать.
+func (h *Histogram) Merge(o Histogram) error {
+	if h.Counts == nil {
+		*h = o.Copy()
+		return nil
+	}
+	if !slices.Equal(h.Buckets, o.Buckets) || len(h.Counts) != len(o.Counts) {
+		return merrors.ErrIncorrectHistogramBuckets
+	}
+
+	for i, c := range o.Counts {
+		h.Counts[i] += c
+	}
+	h.Sum += o.Sum
+	h.Count += o.Count
+
+	return nil
+}
+
+// Quantile оценивает квантиль q линейной интерполяцией внутри бакета,
+// как histogram_quantile в Prometheus. Нижняя граница первого бакета
+// считается равной 0, если его верхняя граница положительна. Если квантиль
+// попадает в бакет +Inf, возвращается последняя конечная граница.
+// Для пустой гистограммы или q вне [0, 1] возвращает NaN.
+func (h Histogram) Quantile(q float64) float64 {
+	if h.Count == 0 || q < 0 || q > 1 || math.IsNaN(q) {
+		return math.NaN()
+	}
+	if len(h.Buckets) == 0 {
+		return math.Inf(1)
+	}
+
+	rank := q * float64(h.Count)
+	var cumulative uint64
+	for i, c := range h.Counts {
+		prev := cumulative
+		cumulative += c
+		if float64(cumulative) < rank || c == 0 {
+			continue
+		}
+
+		if i == len(h.Buckets) {
+			return h.Buckets[len(h.Buckets)-1]
+		}
+
+		lower, upper := 0.0, h.Buckets[i]
+		switch {
+		case i > 0:
+			lower = h.Buckets[i-1]
+		case upper <= 0:
+			return upper
+		}
+
+		return lower + (upper-lower)*(rank-float64(prev))/float64(c)
+	}
+
+	return h.Buckets[len(h.Buckets)-1]
+}
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"math"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHistogramObserve(t *testing.T) {
+	h := NewHistogram([]float64{0.1, 1})
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		h.Observe(v)
+	}
+
+	assert.Equal(t, []uint64{2, 1, 1}, h.Counts)
+	assert.Equal(t, uint64(4), h.Count)
+	assert.InDelta(t, 3.65, h.Sum, 1e-9)
+	require.NoError(t, h.Validate())
+}
+
+func TestHistogramMerge(t *testing.T) {
+	var h Histogram
+	src := Histogram{Buckets: []float64{1}, Counts: []uint64{1, 2}, Sum: 5, Count: 3}
+
+	require.NoError(t, h.Merge(src))
+	require.NoError(t, h.Merge(src))
+	assert.Equal(t, Histogram{Buckets: []float64{1}, Counts: []uint64{2, 4}, Sum: 10, Count: 6}, h)
+	assert.Equal(t, []uint64{1, 2}, src.Counts, "source must not change")
+
+	err := h.Merge(Histogram{Buckets: []float64{2}, Counts: []uint64{1, 0}, Count: 1})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectHistogramBuckets)
+}
+
+func TestHistogramValidate(t *testing.T) {
+	tests := []struct {
+		name string
+		h    Histogram
+		ok   bool
+	}{
+		{
+			name: "Valid",
+			h:    Histogram{Buckets: []float64{1, 2}, Counts: []uint64{1, 0, 2}, Count: 3},
+			ok:   true,
+		},
+		{
+			name: "Without buckets",
+			h:    Histogram{Counts: []uint64{2}, Count: 2},
+			ok:   true,
+		},
+		{
+			name: "Buckets not increasing",
+			h:    Histogram{Buckets: []float64{2, 1}, Counts: []uint64{0, 0, 0}},
+		},
+		{
+			name: "Infinite bucket",
+			h:    Histogram{Buckets: []float64{math.Inf(1)}, Counts: []uint64{0, 0}},
+		},
+		{
+			name: "Counts length",
+			h:    Histogram{Buckets: []float64{1}, Counts: []uint64{1}, Count: 1},
+		},
+		{
+			name: "Count mismatch",
+			h:    Histogram{Buckets: []float64{1}, Counts: []uint64{1, 1}, Count: 3},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.h.Validate()
+			if tt.ok {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, merrors.ErrIncorrectHistogram)
+		})
+	}
+}
+
+func TestHistogramNonFinite(t *testing.T) {
+	for _, v := range []float64{math.NaN(), math.Inf(1), math.Inf(-1)} {
+		assert.ErrorIs(t, ValidateObservation(v), merrors.ErrIncorrectHistogramValue)
+
+		h := Histogram{Buckets: []float64{1}, Counts: []uint64{0, 1}, Sum: v, Count: 1}
+		assert.ErrorIs(t, h.Validate(), merrors.ErrIncorrectHistogramValue)
+	}
+	assert.NoError(t, ValidateObservation(-1.5))
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	// 10 наблюдений в (0, 1], 10 в (1, 2], 5 выше 2
+	h := Histogram{Buckets: []float64{1, 2}, Counts: []uint64{10, 10, 5}, Count: 25}
+
+	tests := []struct {
+		name string
+		q    float64
+		want float64
+	}{
+		{name: "Inside first bucket", q: 0.2, want: 0.5},
+		{name: "Bucket boundary", q: 0.4, want: 1},
+		{name: "Inside second bucket", q: 0.6, want: 1.5},
+		{name: "Inf bucket returns last bound", q: 0.99, want: 2},
+		{name: "Zero", q: 0, want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, h.Quantile(tt.q), 1e-9)
+		})
+	}
+
+	assert.True(t, math.IsNaN(h.Quantile(1.5)))
+	assert.True(t, math.IsNaN(NewHistogram([]float64{1}).Quantile(0.5)))
+}
@@ -0,0 +1,299 @@
+package storage
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+)
+
+// labelSpecial — символы, которые экранируются в именах меток внутри ключа серии.
+const labelSpecial = `=!~{}," `
+
+// Labels — набор меток серии. Метки с пустым значением считаются отсутствующими.
+type Labels map[string]string
+
+// Series — серия, найденная по меткам.
+type Series struct {
+	Labels Labels
+	Name   string
+	Key    string  // ключ серии в хранилище, см. SeriesKey
+	Value  float64 // для counter — целое значение
+}
+
+// escapeName экранирует символы, которые нельзя использовать в имени
+// или метке внутри ключа серии без обратной косой черты.
+func escapeName(b *strings.Builder, s, special string) {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' || strings.IndexByte(special, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+}
+
+func escapeValue(b *strings.Builder, s string) {
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '\\', '"':
+			b.WriteByte('\\')
+			b.WriteByte(s[i])
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+}
+
+// Names возвращает отсортированные имена непустых меток.
+func (l Labels) Names() []string {
+	names := make([]string, 0, len(l))
+	for k, v := range l {
+		if k != "" && v != "" {
+			names = append(names, k)
+		}
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Copy возвращает копию меток без пустых значений.
+func (l Labels) Copy() Labels {
+	res := make(Labels, len(l))
+	for k, v := range l {
+		if k != "" && v != "" {
+			res[k] = v
+		}
+	}
+
+	return res
+}
+
+// SeriesKey возвращает ключ серии: name{k1="v1",k2="v2"} с метками,
+// отсортированными по имени. Без меток ключ совпадает с именем,
+// поэтому метрики, записанные до появления меток, остаются доступны.
+func SeriesKey(name string, labels Labels) string {
+	names := labels.Names()
+
+	var b strings.Builder
+	escapeName(&b, name, "{")
+	if len(names) == 0 {
+		return b.String()
+	}
+
+	b.WriteByte('{')
+	for i, k := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		escapeName(&b, k, labelSpecial)
+		b.WriteString(`="`)
+		escapeValue(&b, labels[k])
+		b.WriteByte('"')
+	}
+	b.WriteByte('}')
+
+	return b.String()
+}
+
+// unescapeUntil читает s до первого неэкранированного символа из stop
+// и возвращает прочитанное без экранирования и индекс символа-ограничителя.
+func unescapeUntil(s, stop string) (string, int) {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			if s[i] == 'n' && strings.Contains(stop, `"`) {
+				b.WriteByte('\n')
+			} else {
+				b.WriteByte(s[i])
+			}
+		case strings.IndexByte(stop, s[i]) >= 0:
+			return b.String(), i
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return b.String(), -1
+}
+
+// parseLabels разбирает k1="v1",k2="v2"} до закрывающей скобки
+// и возвращает метки и остаток строки после скобки.
+func parseLabels(s string, fn func(name, op, value string) error) (string, error) {
+	for {
+		s = strings.TrimLeft(s, " ")
+		if strings.HasPrefix(s, "}") {
+			return s[1:], nil
+		}
+
+		name, i := unescapeUntil(s, labelSpecial)
+		if i < 0 || name == "" {
+			return "", merrors.ErrIncorrectSeriesKey
+		}
+		s = s[i:]
+
+		op := ""
+		for _, o := range []string{"=~", "!=", "!~", "="} {
+			if strings.HasPrefix(s, o) {
+				op = o
+				break
+			}
+		}
+		if op == "" || len(s) <= len(op) || s[len(op)] != '"' {
+			return "", merrors.ErrIncorrectSeriesKey
+		}
+		s = s[len(op)+1:]
+
+		value, i := unescapeUntil(s, `"`)
+		if i < 0 {
+			return "", merrors.ErrIncorrectSeriesKey
+		}
+		s = s[i+1:]
+
+		if err := fn(name, op, value); err != nil {
+			return "", err
+		}
+
+		s = strings.TrimLeft(s, " ")
+		if strings.HasPrefix(s, ",") {
+			s = s[1:]
+		} else if !strings.HasPrefix(s, "}") {
+			return "", merrors.ErrIncorrectSeriesKey
+		}
+	}
+}
+
+// ParseSeriesKey разбирает ключ серии, созданный SeriesKey.
+func ParseSeriesKey(key string) (string, Labels, error) {
+	name, i := unescapeUntil(key, "{")
+	labels := Labels{}
+	if i < 0 {
+		return name, labels, nil
+	}
+
+	rest, err := parseLabels(key[i+1:], func(k, op, v string) error {
+		if op != "=" {
+			return merrors.ErrIncorrectSeriesKey
+		}
+		labels[k] = v
+		return nil
+	})
+	if err != nil {
+		return "", nil, err
+	}
+	if rest != "" {
+		return "", nil, merrors.ErrIncorrectSeriesKey
+	}
+
+	return name, labels, nil
+}
+
+// MatchType — тип сравнения метки.
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// Matcher — условие на значение метки. Отсутствующая метка
+// сравнивается как пустая строка.
+type Matcher struct {
+	re    *regexp.Regexp
+	Name  string
+	Value string
+	Type  MatchType
+}
+
+func NewMatcher(t MatchType, name, value string) (Matcher, error) {
+	m := Matcher{Type: t, Name: name, Value: value}
+
+	switch t {
+	case MatchEqual, MatchNotEqual:
+	case MatchRegexp, MatchNotRegexp:
+		re, err := regexp.Compile("^(?:" + value + ")$")
+		if err != nil {
+			return Matcher{}, fmt.Errorf("incorrect regexp for label %s: %w", name, err)
+		}
+		m.re = re
+	default:
+		return Matcher{}, fmt.Errorf("unknown match type %q", t)
+	}
+
+	return m, nil
+}
+
+func (m Matcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+
+	return false
+}
+
+// Match проверяет, что метки подходят под все условия.
+func (l Labels) Match(matchers []Matcher) bool {
+	for _, m := range matchers {
+		if !m.Matches(l[m.Name]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ParseMatchers разбирает селектор вида {host="a",region=~"eu-.*"}.
+// Фигурные скобки можно не указывать.
+func ParseMatchers(s string) ([]Matcher, error) {
+	matchers, err := parseMatchers(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", merrors.ErrIncorrectMatchers, err)
+	}
+
+	return matchers, nil
+}
+
+func parseMatchers(s string) ([]Matcher, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "{}" {
+		return nil, nil
+	}
+
+	s = strings.TrimPrefix(s, "{")
+	if !strings.HasSuffix(s, "}") {
+		s += "}"
+	}
+
+	var matchers []Matcher
+	rest, err := parseLabels(s, func(k, op, v string) error {
+		m, err := NewMatcher(MatchType(op), k, v)
+		if err != nil {
+			return err
+		}
+		matchers = append(matchers, m)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if rest != "" {
+		return nil, merrors.ErrIncorrectSeriesKey
+	}
+
+	return matchers, nil
+}
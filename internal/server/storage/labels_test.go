@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesKey(t *testing.T) {
+	tests := []struct {
+		labels Labels
+		name   string
+		metric string
+		want   string
+	}{
+		{
+			name:   "Without labels",
+			metric: "HeapAlloc",
+			want:   "HeapAlloc",
+		},
+		{
+			name:   "Sorted labels, empty values are dropped",
+			metric: "cpu",
+			labels: Labels{"region": "eu", "host": "web1", "zone": ""},
+			want:   `cpu{host="web1",region="eu"}`,
+		},
+		{
+			name:   "Escaping",
+			metric: `odd{name`,
+			labels: Labels{`a=b`: "quote \" slash \\ newline \n"},
+			want:   `odd\{name{a\=b="quote \" slash \\ newline \n"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key := SeriesKey(tt.metric, tt.labels)
+			assert.Equal(t, tt.want, key)
+
+			name, labels, err := ParseSeriesKey(key)
+			require.NoError(t, err)
+			assert.Equal(t, tt.metric, name)
+			assert.Equal(t, tt.labels.Copy(), labels)
+		})
+	}
+}
+
+func TestParseSeriesKey_Incorrect(t *testing.T) {
+	for _, key := range []string{
+		`cpu{host}`,
+		`cpu{host="a"`,
+		`cpu{host=~"a"}`,
+		`cpu{host="a"}tail`,
+		`cpu{host="a" region="b"}`,
+	} {
+		_, _, err := ParseSeriesKey(key)
+		assert.ErrorIs(t, err, merrors.ErrIncorrectSeriesKey, key)
+	}
+}
+
+func TestParseMatchers(t *testing.T) {
+	labels := Labels{"host": "web1", "region": "eu-west"}
+
+	tests := []struct {
+		name      string
+		selector  string
+		wantLen   int
+		wantMatch bool
+		wantErr   bool
+	}{
+		{
+			name:      "Empty selector",
+			selector:  "",
+			wantMatch: true,
+		},
+		{
+			name:      "Equal and regexp",
+			selector:  `{host="web1", region=~"eu-.*"}`,
+			wantLen:   2,
+			wantMatch: true,
+		},
+		{
+			name:      "Without braces",
+			selector:  `host!="web2",region!~"us-.*"`,
+			wantLen:   2,
+			wantMatch: true,
+		},
+		{
+			name:      "Missing label is empty",
+			selector:  `{zone=""}`,
+			wantLen:   1,
+			wantMatch: true,
+		},
+		{
+			name:     "Regexp is anchored",
+			selector: `{region=~"eu"}`,
+			wantLen:  1,
+		},
+		{
+			name:     "Incorrect regexp",
+			selector: `{host=~"("}`,
+			wantErr:  true,
+		},
+		{
+			name:     "Incorrect operator",
+			selector: `{host=="a"}`,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matchers, err := ParseMatchers(tt.selector)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, merrors.ErrIncorrectMatchers)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Len(t, matchers, tt.wantLen)
+			assert.Equal(t, tt.wantMatch, labels.Match(matchers))
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package memstorage
+
+import (
+	"maps"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// batchSweepInterval — как часто забываются ключи пачек старше storage.BatchTTL.
+const batchSweepInterval = time.Minute
+
+// Batches возвращает копию ключей примененных пачек со временем применения.
+func (s *MemStorage) Batches() map[string]time.Time {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+
+	return maps.Clone(s.batches)
+}
+
+// LoadBatches добавляет ключи примененных пачек, например из снимка.
+func (s *MemStorage) LoadBatches(batches map[string]time.Time) {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+
+	maps.Copy(s.batches, batches)
+}
+
+// seenBatch сообщает, что пачка с ключом id уже применялась.
+func (s *MemStorage) seenBatch(id string) bool {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+
+	applied, ok := s.batches[id]
+	return ok && !applied.Before(time.Now().Add(-storage.BatchTTL))
+}
+
+// claimBatch запоминает ключ пачки id, примененной в момент t,
+// и сообщает, что пачка с этим ключом еще не применялась.
+func (s *MemStorage) claimBatch(id string, t time.Time) bool {
+	s.batchMu.Lock()
+	defer s.batchMu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.batchesSweptAt) >= batchSweepInterval {
+		for key, applied := range s.batches {
+			if applied.Before(now.Add(-storage.BatchTTL)) {
+				delete(s.batches, key)
+			}
+		}
+		s.batchesSweptAt = now
+	}
+
+	if applied, ok := s.batches[id]; ok && !applied.Before(now.Add(-storage.BatchTTL)) {
+		return false
+	}
+	s.batches[id] = t
+
+	return true
+}
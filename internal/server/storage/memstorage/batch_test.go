@@ -0,0 +1,54 @@
+package memstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUpdateManyBatch(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	batch := storage.Database{Counter: storage.CounterCollection{"PollCount": 1}, BatchID: "batch-1"}
+	// проверка не запоминает ключ пачки
+	require.NoError(t, s.Check(batch))
+	require.NoError(t, s.UpdateMany(ctx, batch))
+	assert.ErrorIs(t, s.Check(batch), merrors.ErrDuplicateBatch)
+	assert.ErrorIs(t, s.UpdateMany(ctx, batch), merrors.ErrDuplicateBatch)
+
+	batch.BatchID = "batch-2"
+	require.NoError(t, s.UpdateMany(ctx, batch))
+
+	// пачки без ключа применяются всегда
+	batch.BatchID = ""
+	require.NoError(t, s.UpdateMany(ctx, batch))
+	require.NoError(t, s.UpdateMany(ctx, batch))
+
+	val, err := s.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(4), val)
+}
+
+func TestClaimBatchExpire(t *testing.T) {
+	s, err := New()
+	require.NoError(t, err)
+
+	old := time.Now().Add(-storage.BatchTTL - time.Minute)
+	assert.True(t, s.claimBatch("old", old))
+	assert.True(t, s.claimBatch("old", time.Now()))
+	assert.False(t, s.claimBatch("old", time.Now()))
+
+	// ключи старше BatchTTL удаляются при очередной проверке
+	assert.True(t, s.claimBatch("stale", old))
+	s.batchesSweptAt = time.Time{}
+	assert.True(t, s.claimBatch("new", time.Now()))
+	assert.NotContains(t, s.batches, "stale")
+	assert.Contains(t, s.batches, "new")
+}
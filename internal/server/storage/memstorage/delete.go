@@ -0,0 +1,152 @@
+package memstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// remove удаляет значение метрики и сообщает, было ли оно.
+func (sh *shard) remove(ref storage.MetricRef) bool {
+	var ok bool
+	switch ref.MType {
+	case "gauge":
+		_, ok = sh.db.Gauge[ref.Name]
+		delete(sh.db.Gauge, ref.Name)
+	case "counter":
+		_, ok = sh.db.Counter[ref.Name]
+		delete(sh.db.Counter, ref.Name)
+	case "histogram":
+		_, ok = sh.db.Histogram[ref.Name]
+		delete(sh.db.Histogram, ref.Name)
+	case "summary":
+		_, ok = sh.db.Summary[ref.Name]
+		delete(sh.db.Summary, ref.Name)
+	case "set":
+		_, ok = sh.db.Set[ref.Name]
+		delete(sh.db.Set, ref.Name)
+	}
+	delete(sh.updated, ref)
+
+	return ok
+}
+
+// has сообщает, есть ли значение метрики.
+func (sh *shard) has(ref storage.MetricRef) bool {
+	var ok bool
+	switch ref.MType {
+	case "gauge":
+		_, ok = sh.db.Gauge[ref.Name]
+	case "counter":
+		_, ok = sh.db.Counter[ref.Name]
+	case "histogram":
+		_, ok = sh.db.Histogram[ref.Name]
+	case "summary":
+		_, ok = sh.db.Summary[ref.Name]
+	case "set":
+		_, ok = sh.db.Set[ref.Name]
+	}
+
+	return ok
+}
+
+func validDeleteType(mType string) error {
+	switch mType {
+	case "gauge", "counter", "histogram", "summary", "set":
+		return nil
+	}
+
+	return merrors.ErrIncorrectMetricType
+}
+
+// CheckDelete сообщает, удалит ли DeleteMetric метрику: верен ли тип
+// и есть ли значение. Хранилище при этом не меняется.
+func (s *MemStorage) CheckDelete(mType string, name string) error {
+	if err := validDeleteType(mType); err != nil {
+		return err
+	}
+
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if !sh.has(storage.MetricRef{MType: mType, Name: name}) {
+		return merrors.ErrNotFoundMetric
+	}
+
+	return nil
+}
+
+func (s *MemStorage) DeleteMetric(_ context.Context, mType string, name string) error {
+	if err := validDeleteType(mType); err != nil {
+		return err
+	}
+
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	if !sh.remove(storage.MetricRef{MType: mType, Name: name}) {
+		return merrors.ErrNotFoundMetric
+	}
+
+	switch mType {
+	case "gauge":
+		delete(sh.gaugeHistory, name)
+		for _, r := range sh.gaugeRollups {
+			delete(r, name)
+		}
+	case "counter":
+		delete(sh.counterHistory, name)
+		for _, r := range sh.counterRollups {
+			delete(r, name)
+		}
+	}
+
+	return nil
+}
+
+func (s *MemStorage) DeleteStale(_ context.Context, before time.Time) ([]storage.MetricRef, error) {
+	list := make([]storage.MetricRef, 0)
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for ref, t := range sh.updated {
+			if t.Before(before) {
+				sh.remove(ref)
+				list = append(list, ref)
+			}
+		}
+		sh.mu.Unlock()
+	}
+
+	return list, nil
+}
+
+// Stale возвращает метрики, которые удалил бы DeleteStale, не удаляя их.
+func (s *MemStorage) Stale(before time.Time) []storage.MetricRef {
+	list := make([]storage.MetricRef, 0)
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+		for ref, t := range sh.updated {
+			if t.Before(before) {
+				list = append(list, ref)
+			}
+		}
+		sh.mu.RUnlock()
+	}
+
+	return list
+}
+
+// Expire удаляет значения метрик refs, не трогая историю, как DeleteStale.
+// Нужен для восстановления из журнала.
+func (s *MemStorage) Expire(refs []storage.MetricRef) {
+	for _, ref := range refs {
+		sh := s.shard(ref.Name)
+		sh.mu.Lock()
+		sh.remove(ref)
+		sh.mu.Unlock()
+	}
+}
@@ -0,0 +1,107 @@
+package memstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteMetric(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = s.UpdateGauge(ctx, "gauge1", 1.5)
+	require.NoError(t, err)
+	_, err = s.UpdateCounter(ctx, "counter1", 2)
+	require.NoError(t, err)
+
+	tests := []struct {
+		wantErr error
+		name    string
+		mType   string
+		key     string
+	}{
+		{
+			name:  "Delete gauge",
+			mType: "gauge",
+			key:   "gauge1",
+		},
+		{
+			name:    "Delete again",
+			mType:   "gauge",
+			key:     "gauge1",
+			wantErr: merrors.ErrNotFoundMetric,
+		},
+		{
+			name:    "Wrong type",
+			mType:   "gauge",
+			key:     "counter1",
+			wantErr: merrors.ErrNotFoundMetric,
+		},
+		{
+			name:    "Incorrect type",
+			mType:   "unknown",
+			key:     "counter1",
+			wantErr: merrors.ErrIncorrectMetricType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.DeleteMetric(ctx, tt.mType, tt.key)
+			assert.ErrorIs(t, err, tt.wantErr)
+		})
+	}
+
+	_, err = s.GetGaugeByName(ctx, "gauge1")
+	assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+	history, err := s.GetGaugeHistory(ctx, "gauge1", start, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	_, err = s.GetCounterByName(ctx, "counter1")
+	assert.NoError(t, err)
+}
+
+func TestDeleteStale(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = s.UpdateGauge(ctx, "old", 1)
+	require.NoError(t, err)
+	_, err = s.UpdateSet(ctx, "users", storage.NewSet())
+	require.NoError(t, err)
+	before := time.Now()
+	_, err = s.UpdateGauge(ctx, "fresh", 2)
+	require.NoError(t, err)
+
+	list, err := s.DeleteStale(ctx, before)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []storage.MetricRef{
+		{MType: "gauge", Name: "old"},
+		{MType: "set", Name: "users"},
+	}, list)
+
+	_, err = s.GetGaugeByName(ctx, "old")
+	assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+	_, err = s.GetGaugeByName(ctx, "fresh")
+	assert.NoError(t, err)
+
+	// история просроченной метрики остается
+	history, err := s.GetGaugeHistory(ctx, "old", start, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, history, 1)
+
+	list, err = s.DeleteStale(ctx, before)
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
@@ -0,0 +1,107 @@
+package memstorage
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// History — история gauge и counter метрик: сырые семплы, свертки по уровням
+// и границы, до которых история уже свернута (см. ApplyRetention).
+type History struct {
+	Gauge          map[string][]storage.Sample                   `json:"gauge,omitempty"`
+	Counter        map[string][]storage.Sample                   `json:"counter,omitempty"`
+	GaugeRollups   map[time.Duration]map[string][]storage.Rollup `json:"gauge_rollups,omitempty"`
+	CounterRollups map[time.Duration]map[string][]storage.Rollup `json:"counter_rollups,omitempty"`
+	RolledUntil    map[time.Duration]time.Time                   `json:"rolled_until,omitempty"`
+}
+
+// copyHistory добавляет в dst копии семплов src.
+func copyHistory(dst, src map[string][]storage.Sample) {
+	for name, list := range src {
+		dst[name] = slices.Clone(list)
+	}
+}
+
+// copyRollups добавляет в dst копии сверток src по уровням.
+func copyRollups(dst map[time.Duration]map[string][]storage.Rollup, src map[time.Duration]rollups) {
+	for res, r := range src {
+		if _, ok := dst[res]; !ok {
+			dst[res] = make(map[string][]storage.Rollup)
+		}
+		for name, list := range r {
+			dst[res][name] = slices.Clone(list)
+		}
+	}
+}
+
+// setRollups заменяет свертки серии name уровня res копией list.
+func setRollups(levels map[time.Duration]rollups, res time.Duration, name string, list []storage.Rollup) {
+	if _, ok := levels[res]; !ok {
+		levels[res] = make(rollups)
+	}
+	levels[res][name] = slices.Clone(list)
+}
+
+// History возвращает копию истории всех метрик, например для снимка.
+func (s *MemStorage) History() History {
+	s.retentionMu.Lock()
+	defer s.retentionMu.Unlock()
+	defer s.rlockAll()()
+
+	h := History{
+		Gauge:          make(map[string][]storage.Sample),
+		Counter:        make(map[string][]storage.Sample),
+		GaugeRollups:   make(map[time.Duration]map[string][]storage.Rollup),
+		CounterRollups: make(map[time.Duration]map[string][]storage.Rollup),
+		RolledUntil:    maps.Clone(s.rolledUntil),
+	}
+	for _, sh := range s.shards {
+		copyHistory(h.Gauge, sh.gaugeHistory)
+		copyHistory(h.Counter, sh.counterHistory)
+		copyRollups(h.GaugeRollups, sh.gaugeRollups)
+		copyRollups(h.CounterRollups, sh.counterRollups)
+	}
+
+	return h
+}
+
+// LoadHistory заменяет историю метрик из h историей, например из снимка.
+func (s *MemStorage) LoadHistory(h History) {
+	s.retentionMu.Lock()
+	defer s.retentionMu.Unlock()
+
+	for name, list := range h.Gauge {
+		sh := s.shard(name)
+		sh.mu.Lock()
+		sh.gaugeHistory[name] = slices.Clone(list)
+		sh.mu.Unlock()
+	}
+	for name, list := range h.Counter {
+		sh := s.shard(name)
+		sh.mu.Lock()
+		sh.counterHistory[name] = slices.Clone(list)
+		sh.mu.Unlock()
+	}
+
+	for res, r := range h.GaugeRollups {
+		for name, list := range r {
+			sh := s.shard(name)
+			sh.mu.Lock()
+			setRollups(sh.gaugeRollups, res, name, list)
+			sh.mu.Unlock()
+		}
+	}
+	for res, r := range h.CounterRollups {
+		for name, list := range r {
+			sh := s.shard(name)
+			sh.mu.Lock()
+			setRollups(sh.counterRollups, res, name, list)
+			sh.mu.Unlock()
+		}
+	}
+
+	maps.Copy(s.rolledUntil, h.RolledUntil)
+}
@@ -1,61 +1,440 @@
+// Package memstorage — хранилище метрик в памяти.
+//
+// Серии распределены по шардам по хешу ключа, у каждого шарда своя
+// блокировка, поэтому обновления разных серий не ждут друг друга.
+// Операции над несколькими шардами (UpdateMany, GetAll) блокируют шарды
+// в порядке номеров, чтобы не возникало взаимоблокировок.
 package memstorage
 
 import (
 	"context"
+	"slices"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/server/storage"
 )
 
+const (
+	// shardCount — число шардов, степень двойки.
+	shardCount = 32
+	// MaxHistorySamples — наибольшее число семплов в истории одной серии.
+	// Без политики хранения (см. storage.RetentionPolicy) история не чистится,
+	// поэтому при переполнении удаляются самые старые семплы.
+	MaxHistorySamples = 10000
+	// historyTrim — сколько старых семплов удаляется при переполнении истории,
+	// чтобы не сдвигать список на каждом обновлении.
+	historyTrim = MaxHistorySamples / 10
+)
+
+type history map[string][]storage.Sample
+
+type shard struct {
+	db             storage.Database
+	gaugeHistory   history
+	counterHistory history
+	gaugeRollups   map[time.Duration]rollups
+	counterRollups map[time.Duration]rollups
+	updated        map[storage.MetricRef]time.Time
+	mu             sync.RWMutex
+}
+
 type MemStorage struct {
-	db *storage.Database
+	batchesSweptAt time.Time
+	rolledUntil    map[time.Duration]time.Time
+	batches        map[string]time.Time // ключи примененных пачек, см. storage.BatchTTL
+	shards         [shardCount]*shard
+	retentionMu    sync.Mutex
+	batchMu        sync.Mutex
 }
 
 func New() (*MemStorage, error) {
-	return &MemStorage{
-		db: &storage.Database{
-			Gauge:   make(storage.GaugeCollection),
-			Counter: make(storage.CounterCollection),
-		},
-	}, nil
+	s := &MemStorage{
+		rolledUntil: make(map[time.Duration]time.Time),
+		batches:     make(map[string]time.Time),
+	}
+	for i := range s.shards {
+		s.shards[i] = &shard{
+			db: storage.Database{
+				Gauge:     make(storage.GaugeCollection),
+				Counter:   make(storage.CounterCollection),
+				Histogram: make(storage.HistogramCollection),
+				Summary:   make(storage.SummaryCollection),
+				Set:       make(storage.SetCollection),
+				Metadata:  make(storage.MetadataCollection),
+			},
+			gaugeHistory:   make(history),
+			counterHistory: make(history),
+			gaugeRollups:   make(map[time.Duration]rollups),
+			counterRollups: make(map[time.Duration]rollups),
+			updated:        make(map[storage.MetricRef]time.Time),
+		}
+	}
+
+	return s, nil
+}
+
+// add добавляет семпл, сохраняя порядок по времени: семплы с прошлым временем
+// приходят при восстановлении из журнала (см. UpdateManyAt). Семплы
+// с одинаковым временем остаются в порядке добавления.
+func (h history) add(name string, t time.Time, value float64) {
+	list := h[name]
+	sample := storage.Sample{Time: t, Value: value}
+	if n := len(list); n == 0 || !list[n-1].Time.After(t) {
+		list = append(list, sample)
+	} else {
+		idx := sort.Search(n, func(i int) bool {
+			return list[i].Time.After(t)
+		})
+		list = slices.Insert(list, idx, sample)
+	}
+
+	if len(list) > MaxHistorySamples {
+		list = slices.Delete(list, 0, len(list)-MaxHistorySamples+historyTrim)
+	}
+	h[name] = list
+}
+
+// between возвращает копию семплов из диапазона [from, to].
+// Список семплов отсортирован по времени (см. add).
+func (h history) between(name string, from, to time.Time) []storage.Sample {
+	list := h[name]
+	start := sort.Search(len(list), func(i int) bool {
+		return !list[i].Time.Before(from)
+	})
+	end := sort.Search(len(list), func(i int) bool {
+		return list[i].Time.After(to)
+	})
+
+	res := make([]storage.Sample, 0, max(end-start, 0))
+	if start < end {
+		res = append(res, list[start:end]...)
+	}
+
+	return res
+}
+
+// shardIndex возвращает номер шарда для ключа серии (FNV-1a).
+func shardIndex(key string) int {
+	h := uint32(2166136261)
+	for i := 0; i < len(key); i++ {
+		h ^= uint32(key[i])
+		h *= 16777619
+	}
+
+	return int(h & (shardCount - 1))
+}
+
+// touch запоминает время последнего обновления метрики.
+func (sh *shard) touch(mType string, name string, t time.Time) {
+	sh.updated[storage.MetricRef{MType: mType, Name: name}] = t
+}
+
+func (s *MemStorage) shard(key string) *shard {
+	return s.shards[shardIndex(key)]
+}
+
+// rlockAll блокирует все шарды на чтение и возвращает функцию разблокировки.
+func (s *MemStorage) rlockAll() func() {
+	for _, sh := range s.shards {
+		sh.mu.RLock()
+	}
+
+	return func() {
+		for _, sh := range s.shards {
+			sh.mu.RUnlock()
+		}
+	}
+}
+
+// lockKeys блокирует на запись шарды всех ключей list в порядке номеров
+// и возвращает функцию разблокировки.
+func (s *MemStorage) lockKeys(list storage.Database) func() {
+	var used [shardCount]bool
+	mark := func(key string) {
+		used[shardIndex(key)] = true
+	}
+	for key := range list.Gauge {
+		mark(key)
+	}
+	for key := range list.Counter {
+		mark(key)
+	}
+	for key := range list.Histogram {
+		mark(key)
+	}
+	for key := range list.Summary {
+		mark(key)
+	}
+	for key := range list.Set {
+		mark(key)
+	}
+	for name := range list.Metadata {
+		mark(name)
+	}
+
+	for i, sh := range s.shards {
+		if used[i] {
+			sh.mu.Lock()
+		}
+	}
+
+	return func() {
+		for i, sh := range s.shards {
+			if used[i] {
+				sh.mu.Unlock()
+			}
+		}
+	}
 }
 
 func (s *MemStorage) UpdateCounter(_ context.Context, name string, value storage.Counter) (storage.Counter, error) {
-	s.db.Counter[name] += value
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := time.Now()
+	sh.db.Counter[name] += value
+	sh.counterHistory.add(name, now, float64(value))
+	sh.touch("counter", name, now)
 
-	return s.db.Counter[name], nil
+	return sh.db.Counter[name], nil
 }
 
 func (s *MemStorage) UpdateGauge(_ context.Context, name string, value storage.Gauge) (storage.Gauge, error) {
-	s.db.Gauge[name] = value
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	now := time.Now()
+	sh.db.Gauge[name] = value
+	sh.gaugeHistory.add(name, now, float64(value))
+	sh.touch("gauge", name, now)
+
+	return sh.db.Gauge[name], nil
+}
+
+func (s *MemStorage) UpdateHistogram(
+	_ context.Context, name string, value storage.Histogram,
+) (storage.Histogram, error) {
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	h := sh.db.Histogram[name]
+	if err := h.Merge(value); err != nil {
+		return storage.Histogram{}, err
+	}
+	sh.db.Histogram[name] = h
+	sh.touch("histogram", name, time.Now())
+
+	return h.Copy(), nil
+}
+
+func (s *MemStorage) UpdateSummary(
+	_ context.Context, name string, value storage.Summary,
+) (storage.Summary, error) {
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	sm := sh.db.Summary[name]
+	sm.Merge(value)
+	sh.db.Summary[name] = sm
+	sh.touch("summary", name, time.Now())
+
+	return sm.Copy(), nil
+}
+
+func (s *MemStorage) UpdateSet(
+	_ context.Context, name string, value storage.Set,
+) (storage.Set, error) {
+	sh := s.shard(name)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	st := sh.db.Set[name]
+	if err := st.Merge(value); err != nil {
+		return storage.Set{}, err
+	}
+	sh.db.Set[name] = st
+	sh.touch("set", name, time.Now())
+
+	return st.Copy(), nil
+}
+
+func (s *MemStorage) UpdateMany(ctx context.Context, list storage.Database) error {
+	return s.UpdateManyAt(ctx, list, time.Now())
+}
+
+// Check сообщает, применится ли пачка list: совпадают ли границы гистограмм
+// и размеры скетчей с записанными и не применялась ли уже пачка с ее ключом.
+// Хранилище при этом не меняется.
+func (s *MemStorage) Check(list storage.Database) error {
+	defer s.lockKeys(list)()
+
+	if err := s.check(list); err != nil {
+		return err
+	}
+	if list.BatchID != "" && s.seenBatch(list.BatchID) {
+		return merrors.ErrDuplicateBatch
+	}
+
+	return nil
+}
 
-	return s.db.Gauge[name], nil
+// check проверяет гистограммы и скетчи пачки list, вызывается
+// под блокировкой ее шардов.
+func (s *MemStorage) check(list storage.Database) error {
+	for key, item := range list.Histogram {
+		h, ok := s.shard(key).db.Histogram[key]
+		if ok && (!slices.Equal(h.Buckets, item.Buckets) || len(h.Counts) != len(item.Counts)) {
+			return merrors.ErrIncorrectHistogramBuckets
+		}
+	}
+	for key, item := range list.Set {
+		st, ok := s.shard(key).db.Set[key]
+		if ok && item.Registers != nil && len(st.Registers) != len(item.Registers) {
+			return merrors.ErrIncorrectSet
+		}
+	}
+
+	return nil
 }
 
-func (s *MemStorage) UpdateMany(_ context.Context, list storage.Database) error {
+// UpdateManyAt работает как UpdateMany, но записывает значения в историю
+// с временем t. Нужен для восстановления из журнала.
+// Шарды всех серий пачки блокируются на время обновления, поэтому
+// GetAll видит пачку либо целиком, либо не видит совсем.
+func (s *MemStorage) UpdateManyAt(_ context.Context, list storage.Database, t time.Time) error {
+	defer s.lockKeys(list)()
+
+	// гистограммы и скетчи проверяются заранее, чтобы не записать пачку частично
+	if err := s.check(list); err != nil {
+		return err
+	}
+
+	if list.BatchID != "" && !s.claimBatch(list.BatchID, t) {
+		return merrors.ErrDuplicateBatch
+	}
+
 	for key, item := range list.Gauge {
-		s.db.Gauge[key] = item
+		sh := s.shard(key)
+		sh.db.Gauge[key] = item
+		sh.gaugeHistory.add(key, t, float64(item))
+		sh.touch("gauge", key, t)
 	}
 
 	for key, item := range list.Counter {
-		s.db.Counter[key] += item
+		sh := s.shard(key)
+		sh.db.Counter[key] += item
+		sh.counterHistory.add(key, t, float64(item))
+		sh.touch("counter", key, t)
+	}
+
+	for key, item := range list.Histogram {
+		sh := s.shard(key)
+		h := sh.db.Histogram[key]
+		h.Merge(item)
+		sh.db.Histogram[key] = h
+		sh.touch("histogram", key, t)
+	}
+
+	for key, item := range list.Summary {
+		sh := s.shard(key)
+		sm := sh.db.Summary[key]
+		sm.Merge(item)
+		sh.db.Summary[key] = sm
+		sh.touch("summary", key, t)
+	}
+
+	for key, item := range list.Set {
+		sh := s.shard(key)
+		st := sh.db.Set[key]
+		st.Merge(item)
+		sh.db.Set[key] = st
+		sh.touch("set", key, t)
+	}
+
+	for name, item := range list.Metadata {
+		s.shard(name).db.Metadata[name] = item.Copy()
 	}
 
 	return nil
 }
 
+// Load записывает значения list без истории: так восстанавливается
+// снимок последних значений. Метрики считаются обновленными в момент загрузки.
+func (s *MemStorage) Load(_ context.Context, list storage.Database) {
+	defer s.lockKeys(list)()
+
+	now := time.Now()
+	for key, item := range list.Gauge {
+		sh := s.shard(key)
+		sh.db.Gauge[key] = item
+		sh.touch("gauge", key, now)
+	}
+	for key, item := range list.Counter {
+		sh := s.shard(key)
+		sh.db.Counter[key] = item
+		sh.touch("counter", key, now)
+	}
+	for key, item := range list.Histogram {
+		sh := s.shard(key)
+		sh.db.Histogram[key] = item.Copy()
+		sh.touch("histogram", key, now)
+	}
+	for key, item := range list.Summary {
+		sh := s.shard(key)
+		sh.db.Summary[key] = item.Copy()
+		sh.touch("summary", key, now)
+	}
+	for key, item := range list.Set {
+		sh := s.shard(key)
+		sh.db.Set[key] = item.Copy()
+		sh.touch("set", key, now)
+	}
+	for name, item := range list.Metadata {
+		s.shard(name).db.Metadata[name] = item.Copy()
+	}
+}
+
+// GetAllCounter возвращает копию всех counter-метрик.
 func (s *MemStorage) GetAllCounter(_ context.Context) (storage.CounterCollection, error) {
-	collection := s.db.Counter
+	defer s.rlockAll()()
+
+	collection := make(storage.CounterCollection)
+	for _, sh := range s.shards {
+		for key, val := range sh.db.Counter {
+			collection[key] = val
+		}
+	}
+
 	return collection, nil
 }
 
+// GetAllGauge возвращает копию всех gauge-метрик.
 func (s *MemStorage) GetAllGauge(_ context.Context) (storage.GaugeCollection, error) {
-	collection := s.db.Gauge
+	defer s.rlockAll()()
+
+	collection := make(storage.GaugeCollection)
+	for _, sh := range s.shards {
+		for key, val := range sh.db.Gauge {
+			collection[key] = val
+		}
+	}
+
 	return collection, nil
 }
 
 func (s *MemStorage) GetGaugeByName(_ context.Context, name string) (storage.Gauge, error) {
-	if val, ok := s.db.Gauge[name]; ok {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if val, ok := sh.db.Gauge[name]; ok {
 		return val, nil
 	}
 
@@ -63,21 +442,151 @@ func (s *MemStorage) GetGaugeByName(_ context.Context, name string) (storage.Gau
 }
 
 func (s *MemStorage) GetCounterByName(_ context.Context, name string) (storage.Counter, error) {
-	if val, ok := s.db.Counter[name]; ok {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if val, ok := sh.db.Counter[name]; ok {
 		return val, nil
 	}
 
 	return 0, merrors.ErrNotFoundMetric
 }
 
+func (s *MemStorage) GetHistogramByName(_ context.Context, name string) (storage.Histogram, error) {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if val, ok := sh.db.Histogram[name]; ok {
+		return val.Copy(), nil
+	}
+
+	return storage.Histogram{}, merrors.ErrNotFoundMetric
+}
+
+func (s *MemStorage) GetSummaryByName(_ context.Context, name string) (storage.Summary, error) {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if val, ok := sh.db.Summary[name]; ok {
+		return val.Copy(), nil
+	}
+
+	return storage.Summary{}, merrors.ErrNotFoundMetric
+}
+
+func (s *MemStorage) GetSetByName(_ context.Context, name string) (storage.Set, error) {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	if val, ok := sh.db.Set[name]; ok {
+		return val.Copy(), nil
+	}
+
+	return storage.Set{}, merrors.ErrNotFoundMetric
+}
+
+func (s *MemStorage) GetGaugeHistory(
+	_ context.Context, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.gaugeHistory.between(name, from, to), nil
+}
+
+func (s *MemStorage) GetCounterHistory(
+	_ context.Context, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.counterHistory.between(name, from, to), nil
+}
+
+func (s *MemStorage) FindSeries(
+	_ context.Context, mType string, name string, matchers []storage.Matcher,
+) ([]storage.Series, error) {
+	if mType != "gauge" && mType != "counter" {
+		return nil, merrors.ErrIncorrectMetricType
+	}
+
+	var list []storage.Series
+	add := func(key string, value float64) {
+		seriesName, labels, err := storage.ParseSeriesKey(key)
+		if err != nil || seriesName != name || !labels.Match(matchers) {
+			return
+		}
+
+		list = append(list, storage.Series{Key: key, Name: seriesName, Labels: labels, Value: value})
+	}
+
+	defer s.rlockAll()()
+	for _, sh := range s.shards {
+		if mType == "gauge" {
+			for key, val := range sh.db.Gauge {
+				add(key, float64(val))
+			}
+		} else {
+			for key, val := range sh.db.Counter {
+				add(key, float64(val))
+			}
+		}
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+
+	return list, nil
+}
+
+// GetAll возвращает согласованный снимок всех значений: на время копирования
+// блокируются все шарды. Снимок не связан с хранилищем и его можно менять.
 func (s *MemStorage) GetAll(_ context.Context) (storage.Database, error) {
-	return *s.db, nil
+	defer s.rlockAll()()
+
+	all := storage.Database{
+		Gauge:     make(storage.GaugeCollection),
+		Counter:   make(storage.CounterCollection),
+		Histogram: make(storage.HistogramCollection),
+		Summary:   make(storage.SummaryCollection),
+		Set:       make(storage.SetCollection),
+		Metadata:  make(storage.MetadataCollection),
+	}
+	for _, sh := range s.shards {
+		for key, val := range sh.db.Gauge {
+			all.Gauge[key] = val
+		}
+		for key, val := range sh.db.Counter {
+			all.Counter[key] = val
+		}
+		for key, val := range sh.db.Histogram {
+			all.Histogram[key] = val.Copy()
+		}
+		for key, val := range sh.db.Summary {
+			all.Summary[key] = val.Copy()
+		}
+		for key, val := range sh.db.Set {
+			all.Set[key] = val.Copy()
+		}
+		for name, val := range sh.db.Metadata {
+			all.Metadata[name] = val.Copy()
+		}
+	}
+
+	return all, nil
 }
 
 func (s *MemStorage) Ping(_ context.Context) error {
 	return nil
 }
 
-func (s MemStorage) Close() {
+func (s *MemStorage) Close() {
 	//
 }
@@ -2,8 +2,12 @@ package memstorage
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
+	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/server/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -138,9 +142,16 @@ func TestUpdateMany(t *testing.T) {
 		"gauge1": 42.42,
 		"gauge2": 0,
 	}
+	precision := 1
 	db := storage.Database{
-		Counter: counters,
-		Gauge:   gauges,
+		Counter:   counters,
+		Gauge:     gauges,
+		Histogram: storage.HistogramCollection{},
+		Summary:   storage.SummaryCollection{},
+		Set:       storage.SetCollection{},
+		Metadata: storage.MetadataCollection{
+			"gauge1": {Unit: "bytes", Help: "help", Type: "gauge", Precision: &precision},
+		},
 	}
 
 	tests := []struct {
@@ -176,3 +187,483 @@ func TestUpdateMany(t *testing.T) {
 		})
 	}
 }
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, err = s.UpdateGauge(ctx, "gauge1", 1.5)
+	require.NoError(t, err)
+	_, err = s.UpdateCounter(ctx, "counter1", 2)
+	require.NoError(t, err)
+	err = s.UpdateMany(ctx, storage.Database{
+		Gauge:   storage.GaugeCollection{"gauge1": 2.5},
+		Counter: storage.CounterCollection{"counter1": 3},
+	})
+	require.NoError(t, err)
+	end := time.Now()
+
+	tests := []struct {
+		from  time.Time
+		to    time.Time
+		get   func(ctx context.Context, name string, from, to time.Time) ([]storage.Sample, error)
+		name  string
+		key   string
+		wants []float64
+	}{
+		{
+			name:  "Gauge history",
+			key:   "gauge1",
+			get:   s.GetGaugeHistory,
+			from:  start,
+			to:    end,
+			wants: []float64{1.5, 2.5},
+		},
+		{
+			name:  "Counter history contains deltas",
+			key:   "counter1",
+			get:   s.GetCounterHistory,
+			from:  start,
+			to:    end,
+			wants: []float64{2, 3},
+		},
+		{
+			name:  "Range before updates",
+			key:   "gauge1",
+			get:   s.GetGaugeHistory,
+			from:  start.Add(-time.Hour),
+			to:    start.Add(-time.Minute),
+			wants: []float64{},
+		},
+		{
+			name:  "Unknown metric",
+			key:   "missing",
+			get:   s.GetCounterHistory,
+			from:  start,
+			to:    end,
+			wants: []float64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.get(ctx, tt.key, tt.from, tt.to)
+			require.NoError(t, err)
+
+			values := make([]float64, 0, len(got))
+			for _, sample := range got {
+				assert.False(t, sample.Time.Before(tt.from))
+				assert.False(t, sample.Time.After(tt.to))
+				values = append(values, sample.Value)
+			}
+			assert.Equal(t, tt.wants, values)
+		})
+	}
+}
+
+func TestHistoryOutOfOrder(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	base := time.Unix(1_700_000_000, 0)
+	for _, sec := range []int{10, 30, 20, 0, 30} {
+		err = s.UpdateManyAt(ctx, storage.Database{
+			Gauge: storage.GaugeCollection{"gauge1": storage.Gauge(sec)},
+		}, base.Add(time.Duration(sec)*time.Second))
+		require.NoError(t, err)
+	}
+
+	got, err := s.GetGaugeHistory(ctx, "gauge1", base.Add(5*time.Second), base.Add(25*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Sample{
+		{Time: base.Add(10 * time.Second), Value: 10},
+		{Time: base.Add(20 * time.Second), Value: 20},
+	}, got)
+
+	got, err = s.GetGaugeHistory(ctx, "gauge1", base, base.Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, got, 5)
+	assert.Equal(t, float64(0), got[0].Value)
+	assert.Equal(t, float64(30), got[4].Value)
+}
+
+func TestHistoryLimit(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	base := time.Unix(1_700_000_000, 0)
+	total := MaxHistorySamples + 1
+	for i := range total {
+		err = s.UpdateManyAt(ctx, storage.Database{
+			Counter: storage.CounterCollection{"counter1": storage.Counter(i)},
+		}, base.Add(time.Duration(i)*time.Second))
+		require.NoError(t, err)
+	}
+
+	got, err := s.GetCounterHistory(ctx, "counter1", base, base.Add(time.Duration(total)*time.Second))
+	require.NoError(t, err)
+	require.LessOrEqual(t, len(got), MaxHistorySamples)
+	// удаляются самые старые семплы
+	assert.Equal(t, float64(total-1), got[len(got)-1].Value)
+	assert.Equal(t, float64(total-len(got)), got[0].Value)
+}
+
+func TestFindSeries(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	err = s.UpdateMany(ctx, storage.Database{
+		Gauge: storage.GaugeCollection{
+			"cpu":                          1,
+			`cpu{host="web1",region="eu"}`: 2,
+			`cpu{host="web2",region="us"}`: 3,
+			`cpu_total{host="web1"}`:       4,
+		},
+		Counter: storage.CounterCollection{`cpu{host="web1"}`: 5},
+	})
+	require.NoError(t, err)
+
+	matcher := func(typ storage.MatchType, name, value string) storage.Matcher {
+		m, err := storage.NewMatcher(typ, name, value)
+		require.NoError(t, err)
+		return m
+	}
+
+	tests := []struct {
+		wantErr  error
+		name     string
+		mType    string
+		matchers []storage.Matcher
+		want     []string
+	}{
+		{
+			name:  "All series of metric",
+			mType: "gauge",
+			want:  []string{"cpu", `cpu{host="web1",region="eu"}`, `cpu{host="web2",region="us"}`},
+		},
+		{
+			name:     "Equality matcher",
+			mType:    "gauge",
+			matchers: []storage.Matcher{matcher(storage.MatchEqual, "host", "web2")},
+			want:     []string{`cpu{host="web2",region="us"}`},
+		},
+		{
+			name:     "Empty value matches series without label",
+			mType:    "gauge",
+			matchers: []storage.Matcher{matcher(storage.MatchEqual, "host", "")},
+			want:     []string{"cpu"},
+		},
+		{
+			name:     "Regexp matcher",
+			mType:    "gauge",
+			matchers: []storage.Matcher{matcher(storage.MatchNotRegexp, "region", "u.")},
+			want:     []string{"cpu", `cpu{host="web1",region="eu"}`},
+		},
+		{
+			name:  "Counter",
+			mType: "counter",
+			want:  []string{`cpu{host="web1"}`},
+		},
+		{
+			name:    "Incorrect type",
+			mType:   "test",
+			wantErr: merrors.ErrIncorrectMetricType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.FindSeries(ctx, tt.mType, "cpu", tt.matchers)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			keys := make([]string, 0, len(got))
+			for _, series := range got {
+				assert.Equal(t, "cpu", series.Name)
+				keys = append(keys, series.Key)
+			}
+			assert.Equal(t, tt.want, keys)
+		})
+	}
+}
+
+func TestUpdateHistogram(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	_, err = s.GetHistogramByName(ctx, "latency")
+	require.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+
+	value := storage.Histogram{Buckets: []float64{1}, Counts: []uint64{1, 1}, Sum: 3, Count: 2}
+	got, err := s.UpdateHistogram(ctx, "latency", value)
+	require.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	got, err = s.UpdateHistogram(ctx, "latency", value)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 2}, got.Counts)
+	assert.Equal(t, uint64(4), got.Count)
+
+	// возвращается копия, хранилище не меняется
+	got.Counts[0] = 100
+	stored, err := s.GetHistogramByName(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 2}, stored.Counts)
+
+	_, err = s.UpdateHistogram(ctx, "latency", storage.Histogram{
+		Buckets: []float64{5}, Counts: []uint64{1, 0}, Count: 1,
+	})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectHistogramBuckets)
+}
+
+func TestUpdateSummary(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	_, err = s.GetSummaryByName(ctx, "rpc")
+	require.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+
+	value := storage.NewSummary(time.Minute)
+	value.Observe(2, time.Now())
+
+	got, err := s.UpdateSummary(ctx, "rpc", value)
+	require.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	err = s.UpdateMany(ctx, storage.Database{
+		Summary: storage.SummaryCollection{"rpc": value},
+	})
+	require.NoError(t, err)
+
+	got, err = s.GetSummaryByName(ctx, "rpc")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.Count)
+	assert.Len(t, got.Observations, 2)
+}
+
+func TestUpdateSet(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	_, err = s.GetSetByName(ctx, "users")
+	require.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+
+	first, second := storage.NewSet(), storage.NewSet()
+	first.Add("alice")
+	first.Add("bob")
+	second.Add("bob")
+	second.Add("carol")
+
+	got, err := s.UpdateSet(ctx, "users", first)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.Count())
+
+	err = s.UpdateMany(ctx, storage.Database{
+		Set: storage.SetCollection{"users": second},
+	})
+	require.NoError(t, err)
+
+	got, err = s.GetSetByName(ctx, "users")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(3), got.Count())
+
+	_, err = s.UpdateSet(ctx, "users", storage.Set{Registers: make([]uint8, 16)})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectSet)
+}
+
+func TestConcurrentUpdates(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	const (
+		workers = 16
+		updates = 200
+	)
+
+	var wg sync.WaitGroup
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range updates {
+				_, err := s.UpdateCounter(ctx, "requests", 1)
+				assert.NoError(t, err)
+				_, err = s.UpdateGauge(ctx, fmt.Sprintf("gauge%d", w), storage.Gauge(i))
+				assert.NoError(t, err)
+				err = s.UpdateMany(ctx, storage.Database{
+					Counter: storage.CounterCollection{"requests": 1, "errors": 1},
+				})
+				assert.NoError(t, err)
+
+				_, err = s.GetCounterByName(ctx, "requests")
+				assert.NoError(t, err)
+				_, err = s.GetCounterHistory(ctx, "requests", time.Time{}, time.Now())
+				assert.NoError(t, err)
+				_, err = s.FindSeries(ctx, "gauge", "gauge0", nil)
+				assert.NoError(t, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	requests, err := s.GetCounterByName(ctx, "requests")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(2*workers*updates), requests)
+
+	errs, err := s.GetCounterByName(ctx, "errors")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(workers*updates), errs)
+
+	history, err := s.GetCounterHistory(ctx, "requests", time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, history, 2*workers*updates)
+
+	all, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all.Gauge, workers)
+}
+
+// Пачка обновляет серии в разных шардах, снимок должен видеть ее целиком.
+func TestGetAllSnapshot(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+	require.NotEqual(t, shardIndex("first"), shardIndex("second"))
+
+	batch := storage.Database{
+		Counter: storage.CounterCollection{"first": 1, "second": 1},
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+					assert.NoError(t, s.UpdateMany(ctx, batch))
+				}
+			}
+		}()
+	}
+
+	for range 500 {
+		all, err := s.GetAll(ctx)
+		require.NoError(t, err)
+		require.Equal(t, all.Counter["first"], all.Counter["second"])
+	}
+	close(done)
+	wg.Wait()
+}
+
+func TestGetAllIsCopy(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	_, err = s.UpdateGauge(ctx, "gauge", 1)
+	require.NoError(t, err)
+	_, err = s.UpdateHistogram(ctx, "latency", storage.Histogram{
+		Buckets: []float64{1}, Counts: []uint64{1, 0}, Count: 1,
+	})
+	require.NoError(t, err)
+
+	all, err := s.GetAll(ctx)
+	require.NoError(t, err)
+	all.Gauge["gauge"] = 2
+	all.Histogram["latency"].Counts[0] = 10
+
+	gauge, err := s.GetGaugeByName(ctx, "gauge")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Gauge(1), gauge)
+
+	h, err := s.GetHistogramByName(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{1, 0}, h.Counts)
+}
+
+func BenchmarkUpdateCounterParallel(b *testing.B) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(b, err)
+
+	keys := make([]string, 100)
+	for i := range keys {
+		keys[i] = fmt.Sprintf("counter%d", i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			if _, err := s.UpdateCounter(ctx, keys[i%len(keys)], 1); err != nil {
+				b.Error(err)
+			}
+			i++
+		}
+	})
+}
+
+func BenchmarkUpdateManyParallel(b *testing.B) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(b, err)
+
+	batch := storage.Database{
+		Gauge:   make(storage.GaugeCollection),
+		Counter: storage.CounterCollection{"PollCount": 1},
+	}
+	for i := range 30 {
+		batch.Gauge[fmt.Sprintf("gauge%d", i)] = storage.Gauge(i)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if err := s.UpdateMany(ctx, batch); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+func BenchmarkGetAllWithUpdates(b *testing.B) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(b, err)
+
+	for i := range 100 {
+		_, err = s.UpdateGauge(ctx, fmt.Sprintf("gauge%d", i), storage.Gauge(i))
+		require.NoError(b, err)
+	}
+
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			var err error
+			if i%10 == 0 {
+				_, err = s.GetAll(ctx)
+			} else {
+				_, err = s.UpdateGauge(ctx, fmt.Sprintf("gauge%d", i%100), 1)
+			}
+			if err != nil {
+				b.Error(err)
+			}
+			i++
+		}
+	})
+}
@@ -0,0 +1,163 @@
+package memstorage
+
+import (
+	"context"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// rollups — свертки серий одного уровня, отсортированные по времени.
+type rollups map[string][]storage.Rollup
+
+// between возвращает копию сверток, начавшихся в диапазоне [from, to].
+func (r rollups) between(name string, from, to time.Time) []storage.Rollup {
+	list := r[name]
+	start := sort.Search(len(list), func(i int) bool {
+		return !list[i].Time.Before(from)
+	})
+	end := sort.Search(len(list), func(i int) bool {
+		return list[i].Time.After(to)
+	})
+
+	res := make([]storage.Rollup, 0, max(end-start, 0))
+	if start < end {
+		res = append(res, list[start:end]...)
+	}
+
+	return res
+}
+
+// add дописывает свертки в конец серии. Если первая из них относится
+// к тому же интервалу, что и последняя сохраненная, они объединяются.
+func (r rollups) add(name string, list []storage.Rollup) {
+	if len(list) == 0 {
+		return
+	}
+
+	old := r[name]
+	if n := len(old); n > 0 && old[n-1].Time.Equal(list[0].Time) {
+		old[n-1].Merge(list[0])
+		list = list[1:]
+	}
+	r[name] = append(old, list...)
+}
+
+// expire удаляет свертки, начавшиеся раньше before.
+func (r rollups) expire(before time.Time) {
+	for name, list := range r {
+		idx := sort.Search(len(list), func(i int) bool {
+			return !list[i].Time.Before(before)
+		})
+		if idx == len(list) {
+			delete(r, name)
+			continue
+		}
+		r[name] = slices.Delete(list, 0, idx)
+	}
+}
+
+// expire удаляет семплы старше before.
+func (h history) expire(before time.Time) {
+	for name, list := range h {
+		idx := sort.Search(len(list), func(i int) bool {
+			return !list[i].Time.Before(before)
+		})
+		if idx == len(list) {
+			delete(h, name)
+			continue
+		}
+		h[name] = slices.Delete(list, 0, idx)
+	}
+}
+
+// rollup строит свертки уровня levels[i] за интервал [start, end):
+// первый уровень — из сырых семплов raw, остальные — из предыдущего уровня.
+func rollup(raw history, levels map[time.Duration]rollups, policy []storage.RollupLevel, i int, start, end time.Time) {
+	res := policy[i].Resolution
+	dst, ok := levels[res]
+	if !ok {
+		dst = make(rollups)
+		levels[res] = dst
+	}
+
+	last := end.Add(-time.Nanosecond)
+	if i == 0 {
+		for name := range raw {
+			dst.add(name, storage.RollupSamples(raw.between(name, start, last), res))
+		}
+		return
+	}
+
+	for name := range levels[policy[i-1].Resolution] {
+		src := levels[policy[i-1].Resolution].between(name, start, last)
+		dst.add(name, storage.MergeRollups(src, res))
+	}
+}
+
+// ApplyRetention досворачивает историю до текущих границ интервалов
+// и удаляет семплы и свертки старше сроков хранения policy.
+// Шарды обрабатываются по одному, чтобы не останавливать запись целиком.
+func (s *MemStorage) ApplyRetention(_ context.Context, policy storage.RetentionPolicy, now time.Time) error {
+	if !policy.Enabled() {
+		return nil
+	}
+
+	s.retentionMu.Lock()
+	defer s.retentionMu.Unlock()
+
+	for _, sh := range s.shards {
+		sh.mu.Lock()
+		for i, level := range policy.Levels {
+			start, end := s.rolledUntil[level.Resolution], storage.BucketStart(now, level.Resolution)
+			if !start.Before(end) {
+				continue
+			}
+			rollup(sh.gaugeHistory, sh.gaugeRollups, policy.Levels, i, start, end)
+			rollup(sh.counterHistory, sh.counterRollups, policy.Levels, i, start, end)
+		}
+
+		sh.gaugeHistory.expire(now.Add(-policy.Raw))
+		sh.counterHistory.expire(now.Add(-policy.Raw))
+		for _, level := range policy.Levels {
+			if r, ok := sh.gaugeRollups[level.Resolution]; ok {
+				r.expire(now.Add(-level.Retention))
+			}
+			if r, ok := sh.counterRollups[level.Resolution]; ok {
+				r.expire(now.Add(-level.Retention))
+			}
+		}
+		sh.mu.Unlock()
+	}
+
+	for _, level := range policy.Levels {
+		end := storage.BucketStart(now, level.Resolution)
+		if s.rolledUntil[level.Resolution].Before(end) {
+			s.rolledUntil[level.Resolution] = end
+		}
+	}
+
+	return nil
+}
+
+func (s *MemStorage) GetGaugeRollups(
+	_ context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.gaugeRollups[resolution].between(name, storage.BucketStart(from, resolution), to), nil
+}
+
+func (s *MemStorage) GetCounterRollups(
+	_ context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	sh := s.shard(name)
+	sh.mu.RLock()
+	defer sh.mu.RUnlock()
+
+	return sh.counterRollups[resolution].between(name, storage.BucketStart(from, resolution), to), nil
+}
@@ -0,0 +1,88 @@
+package memstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRetention(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	base := storage.BucketStart(time.Unix(1_700_000_000, 0), time.Hour)
+	at := func(d time.Duration) time.Time {
+		return base.Add(d)
+	}
+	write := func(d time.Duration, gauge storage.Gauge, counter storage.Counter) {
+		err := s.UpdateManyAt(ctx, storage.Database{
+			Gauge:   storage.GaugeCollection{"gauge": gauge},
+			Counter: storage.CounterCollection{"counter": counter},
+		}, at(d))
+		require.NoError(t, err)
+	}
+	write(10*time.Second, 1, 2)
+	write(20*time.Second, 5, 0)
+	write(70*time.Second, 3, 3)
+
+	policy, err := storage.ParseRetention("raw:2m,1m:1h,1h:24h")
+	require.NoError(t, err)
+
+	now := at(150 * time.Second)
+	require.NoError(t, s.ApplyRetention(ctx, policy, now))
+	// повторный запуск не должен дублировать свертки
+	require.NoError(t, s.ApplyRetention(ctx, policy, now))
+
+	gauges, err := s.GetGaugeRollups(ctx, "gauge", time.Minute, base, now)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Rollup{
+		{Time: base, First: 1, Last: 5, Min: 1, Max: 5, Sum: 6, Count: 2},
+		{Time: at(time.Minute), First: 3, Last: 3, Min: 3, Max: 3, Sum: 3, Count: 1},
+	}, gauges)
+
+	counters, err := s.GetCounterRollups(ctx, "counter", time.Minute, base, now)
+	require.NoError(t, err)
+	require.Len(t, counters, 2)
+	assert.Equal(t, 2.0, counters[0].Sum)
+	assert.Equal(t, 3.0, counters[1].Sum)
+
+	raw, err := s.GetGaugeHistory(ctx, "gauge", base, now)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Sample{{Time: at(70 * time.Second), Value: 3}}, raw)
+
+	later := at(3 * time.Hour)
+	require.NoError(t, s.ApplyRetention(ctx, policy, later))
+
+	hours, err := s.GetGaugeRollups(ctx, "gauge", time.Hour, base, later)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Rollup{
+		{Time: base, First: 1, Last: 3, Min: 1, Max: 5, Sum: 9, Count: 3},
+	}, hours)
+
+	minutes, err := s.GetGaugeRollups(ctx, "gauge", time.Minute, base, later)
+	require.NoError(t, err)
+	assert.Empty(t, minutes)
+
+	raw, err = s.GetCounterHistory(ctx, "counter", base, later)
+	require.NoError(t, err)
+	assert.Empty(t, raw)
+}
+
+func TestApplyRetentionDisabled(t *testing.T) {
+	ctx := context.Background()
+	s, err := New()
+	require.NoError(t, err)
+
+	_, err = s.UpdateGauge(ctx, "gauge", 1)
+	require.NoError(t, err)
+	require.NoError(t, s.ApplyRetention(ctx, storage.RetentionPolicy{}, time.Now().Add(time.Hour)))
+
+	raw, err := s.GetGaugeHistory(ctx, "gauge", time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Len(t, raw, 1)
+}
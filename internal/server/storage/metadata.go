@@ -0,0 +1,80 @@
+package storage
+
+import (
+	"strconv"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+)
+
+const (
+	// DefaultPrecision — знаков после запятой у gauge, для которых точность не задана.
+	DefaultPrecision = 3
+	// MaxPrecision — наибольшая точность, которую имеет смысл задавать для float64.
+	MaxPrecision = 17
+)
+
+// Metadata — описание метрики: единица измерения, справка, ожидаемый тип
+// и точность вывода. Описание общее для всех серий метрики.
+type Metadata struct {
+	Precision *int   `json:"precision,omitempty"` // знаков после запятой при выводе, nil — DefaultPrecision
+	Unit      string `json:"unit,omitempty"`      // единица измерения, например bytes или ns
+	Help      string `json:"help,omitempty"`      // описание метрики
+	Type      string `json:"type,omitempty"`      // ожидаемый тип метрики
+}
+
+// MetadataCollection — описания метрик, сгруппированные по имени метрики без меток.
+type MetadataCollection map[string]Metadata
+
+// Validate проверяет тип и точность описания.
+func (m Metadata) Validate() error {
+	switch m.Type {
+	case "", "gauge", "counter", "histogram", "summary", "set":
+	default:
+		return merrors.ErrIncorrectMetadata
+	}
+
+	if m.Precision != nil && (*m.Precision < 0 || *m.Precision > MaxPrecision) {
+		return merrors.ErrIncorrectMetadata
+	}
+
+	return nil
+}
+
+// Copy возвращает копию описания, не связанную с исходным.
+func (m Metadata) Copy() Metadata {
+	if m.Precision != nil {
+		p := *m.Precision
+		m.Precision = &p
+	}
+
+	return m
+}
+
+// FormatFloat форматирует значение с точностью описания.
+func (m Metadata) FormatFloat(v float64) string {
+	precision := DefaultPrecision
+	if m.Precision != nil {
+		precision = *m.Precision
+	}
+
+	return strconv.FormatFloat(v, 'f', precision, 64)
+}
+
+// WithUnit добавляет к отформатированному значению единицу измерения.
+func (m Metadata) WithUnit(value string) string {
+	if m.Unit == "" {
+		return value
+	}
+
+	return value + " " + m.Unit
+}
+
+// Lookup возвращает описание метрики серии key (см. SeriesKey).
+func (c MetadataCollection) Lookup(key string) Metadata {
+	name, _, err := ParseSeriesKey(key)
+	if err != nil {
+		name = key
+	}
+
+	return c[name]
+}
@@ -0,0 +1,99 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetadataValidate(t *testing.T) {
+	negative, zero, tooBig := -1, 0, MaxPrecision+1
+
+	tests := []struct {
+		wantErr error
+		name    string
+		meta    Metadata
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Full",
+			meta: Metadata{Unit: "bytes", Help: "heap", Type: "gauge", Precision: &zero},
+		},
+		{
+			name:    "Unknown type",
+			meta:    Metadata{Type: "meter"},
+			wantErr: merrors.ErrIncorrectMetadata,
+		},
+		{
+			name:    "Negative precision",
+			meta:    Metadata{Precision: &negative},
+			wantErr: merrors.ErrIncorrectMetadata,
+		},
+		{
+			name:    "Too big precision",
+			meta:    Metadata{Precision: &tooBig},
+			wantErr: merrors.ErrIncorrectMetadata,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.meta.Validate(), tt.wantErr)
+		})
+	}
+}
+
+func TestMetadataFormat(t *testing.T) {
+	zero, six := 0, 6
+
+	tests := []struct {
+		name  string
+		want  string
+		meta  Metadata
+		value float64
+	}{
+		{
+			name:  "Default precision",
+			value: 1.23456,
+			want:  "1.235",
+		},
+		{
+			name:  "Zero precision with unit",
+			meta:  Metadata{Unit: "bytes", Precision: &zero},
+			value: 2048.4,
+			want:  "2048 bytes",
+		},
+		{
+			name:  "High precision",
+			meta:  Metadata{Precision: &six},
+			value: 0.123456789,
+			want:  "0.123457",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.meta.WithUnit(tt.meta.FormatFloat(tt.value)))
+		})
+	}
+}
+
+func TestMetadataLookup(t *testing.T) {
+	list := MetadataCollection{"temp": {Unit: "celsius"}}
+
+	assert.Equal(t, "celsius", list.Lookup("temp").Unit)
+	assert.Equal(t, "celsius", list.Lookup(`temp{room="hall"}`).Unit)
+	assert.Equal(t, Metadata{}, list.Lookup("missing"))
+	assert.Equal(t, Metadata{}, MetadataCollection(nil).Lookup("temp"))
+}
+
+func TestMetadataCopy(t *testing.T) {
+	precision := 2
+	meta := Metadata{Precision: &precision}
+
+	cp := meta.Copy()
+	*cp.Precision = 5
+
+	assert.Equal(t, 2, *meta.Precision)
+}
@@ -0,0 +1,34 @@
+package postgres
+
+import (
+	"context"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// claimBatch запоминает ключ пачки id в транзакции tx. Если пачка
+// с этим ключом уже применена, возвращает merrors.ErrDuplicateBatch.
+// Параллельная вставка того же ключа ждет завершения транзакции,
+// поэтому пачка применяется ровно один раз.
+func claimBatch(ctx context.Context, tx pgx.Tx, id string) error {
+	tag, err := tx.Exec(ctx, `INSERT INTO applied_batches (id) VALUES ($1)
+	ON CONFLICT (id) DO NOTHING`, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return merrors.ErrDuplicateBatch
+	}
+
+	return nil
+}
+
+// expireBatches забывает ключи пачек старше storage.BatchTTL.
+func (p Postgres) expireBatches(ctx context.Context) error {
+	_, err := p.db.Exec(ctx, `DELETE FROM applied_batches WHERE applied_at < $1`,
+		time.Now().Add(-storage.BatchTTL))
+	return err
+}
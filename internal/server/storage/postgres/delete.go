@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/retry"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// metricTables — таблицы текущих значений по типам метрик.
+var metricTables = []struct {
+	mType string
+	table string
+}{
+	{mType: "gauge", table: "gauge"},
+	{mType: "counter", table: "counter"},
+	{mType: "histogram", table: "histogram"},
+	{mType: "summary", table: "summary"},
+	{mType: "set", table: "sets"},
+}
+
+func metricTable(mType string) (string, bool) {
+	for _, t := range metricTables {
+		if t.mType == mType {
+			return t.table, true
+		}
+	}
+
+	return "", false
+}
+
+func (p Postgres) DeleteMetric(ctx context.Context, mType string, name string) error {
+	table, ok := metricTable(mType)
+	if !ok {
+		return merrors.ErrIncorrectMetricType
+	}
+
+	return retry.Retry(ctx, func() error {
+		tx, err := p.db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		tag, err := tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, table), name)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() == 0 {
+			return merrors.ErrNotFoundMetric
+		}
+
+		if mType == "gauge" || mType == "counter" {
+			for _, t := range []string{mType + "_history", mType + "_rollup"} {
+				if _, err = tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE name = $1`, t), name); err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Commit(ctx)
+	})
+}
+
+func (p Postgres) DeleteStale(ctx context.Context, before time.Time) ([]storage.MetricRef, error) {
+	var list []storage.MetricRef
+	err := retry.Retry(ctx, func() error {
+		tx, err := p.db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		list = make([]storage.MetricRef, 0)
+		for _, t := range metricTables {
+			rows, err := tx.Query(ctx, fmt.Sprintf(`DELETE FROM %s WHERE updated_at < $1 RETURNING name`, t.table),
+				before)
+			if err != nil {
+				return err
+			}
+
+			names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+			if err != nil {
+				return err
+			}
+			for _, name := range names {
+				list = append(list, storage.MetricRef{MType: t.mType, Name: name})
+			}
+		}
+
+		return tx.Commit(ctx)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
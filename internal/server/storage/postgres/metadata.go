@@ -0,0 +1,63 @@
+package postgres
+
+import (
+	"context"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/retry"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// updateMetadata заменяет описания метрик из list.
+func updateMetadata(ctx context.Context, tx pgx.Tx, list storage.MetadataCollection) error {
+	req := `INSERT INTO metadata (name, unit, help, type, precision)
+	VALUES ($1, $2, $3, $4, $5)
+	ON CONFLICT (name) DO UPDATE
+	SET unit = EXCLUDED.unit, help = EXCLUDED.help, type = EXCLUDED.type,
+	precision = EXCLUDED.precision, updated_at = now()`
+
+	for name, meta := range list {
+		if _, err := tx.Exec(ctx, req, name, meta.Unit, meta.Help, meta.Type, meta.Precision); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (p Postgres) GetAllMetadata(ctx context.Context) (storage.MetadataCollection, error) {
+	req := `SELECT name, unit, help, type, precision FROM metadata`
+
+	var list storage.MetadataCollection
+	err := retry.Retry(ctx, func() error {
+		rows, err := p.db.Query(ctx, req)
+		if err != nil {
+			logger.Log.Error("error while sending request to db")
+			return err
+		}
+		defer rows.Close()
+
+		list = make(storage.MetadataCollection, 0)
+		for rows.Next() {
+			var (
+				name string
+				meta storage.Metadata
+			)
+			if err := rows.Scan(&name, &meta.Unit, &meta.Help, &meta.Type, &meta.Precision); err != nil {
+				logger.Log.Error(err.Error())
+				return err
+			}
+
+			list[name] = meta
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
@@ -0,0 +1,249 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"go.uber.org/zap"
+)
+
+// Миграции лежат в migrations/ парами NNNN_name.up.sql и NNNN_name.down.sql,
+// номера идут подряд с 1. Первые миграции повторяют таблицы, которые раньше
+// создавались при старте, и написаны через if not exists, поэтому
+// на существующей базе они только записывают версию.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockID — ключ advisory lock, под которым применяются миграции.
+// Реплики, стартующие одновременно, ждут друг друга.
+const migrationLockID int64 = 0x6d657472696373
+
+var migrationFileRe = regexp.MustCompile(`^(\d+)_(\w+)\.(up|down)\.sql$`)
+
+type migration struct {
+	name    string
+	up      string
+	down    string
+	version int
+}
+
+// loadMigrations читает миграции из fsys и проверяет, что у каждой есть
+// up и down, а номера идут подряд с 1.
+func loadMigrations(fsys fs.FS) ([]migration, error) {
+	files, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, file := range files {
+		match := migrationFileRe.FindStringSubmatch(file.Name())
+		if match == nil {
+			return nil, fmt.Errorf("incorrect migration file name %q", file.Name())
+		}
+
+		version, err := strconv.Atoi(match[1])
+		if err != nil {
+			return nil, err
+		}
+		body, err := fs.ReadFile(fsys, file.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &migration{version: version, name: match[2]}
+			byVersion[version] = m
+		}
+		if m.name != match[2] {
+			return nil, fmt.Errorf("migration %d has different names %q and %q", version, m.name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.up = string(body)
+		} else {
+			m.down = string(body)
+		}
+	}
+
+	list := make([]migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.up == "" || m.down == "" {
+			return nil, fmt.Errorf("migration %d must have up and down files", m.version)
+		}
+		list = append(list, *m)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].version < list[j].version
+	})
+
+	for i, m := range list {
+		if m.version != i+1 {
+			return nil, fmt.Errorf("migration %d is missing", i+1)
+		}
+	}
+
+	return list, nil
+}
+
+func migrations() ([]migration, error) {
+	sub, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return loadMigrations(sub)
+}
+
+// withMigrationLock выполняет fn на отдельном соединении под advisory lock.
+// Таблица schema_version создается уже под блокировкой.
+func withMigrationLock(ctx context.Context, db *pgxpool.Pool, fn func(conn *pgxpool.Conn) error) error {
+	conn, err := db.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+
+	if _, err = conn.Exec(ctx, `SELECT pg_advisory_lock($1)`, migrationLockID); err != nil {
+		return err
+	}
+	defer func() {
+		// блокировка держится до конца сессии, поэтому снимается даже
+		// при отмененном ctx, а если не снялась — соединение закрывается
+		_, err := conn.Exec(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockID)
+		if err != nil {
+			logger.Log.Error("error while releasing migration lock", zap.Error(err))
+			conn.Conn().Close(context.Background())
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `create table if not exists schema_version(
+	version integer not null PRIMARY KEY,
+	name text not null,
+	applied_at timestamp with time zone not null default now()
+	);`)
+	if err != nil {
+		return err
+	}
+
+	return fn(conn)
+}
+
+func currentVersion(ctx context.Context, conn *pgxpool.Conn) (int, error) {
+	var version int
+	err := conn.QueryRow(ctx, `SELECT coalesce(max(version), 0) FROM schema_version`).Scan(&version)
+
+	return version, err
+}
+
+// applyMigration выполняет sql и меняет версию схемы в одной транзакции.
+func applyMigration(ctx context.Context, conn *pgxpool.Conn, sql string, version func(tx pgx.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, pgx.TxOptions{})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err = tx.Exec(ctx, sql); err != nil {
+		return err
+	}
+	if err = version(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit(ctx)
+}
+
+// MigrateUp применяет все миграции, которых еще нет в schema_version.
+// Если база новее известных миграций, возвращает ошибку.
+func MigrateUp(ctx context.Context, db *pgxpool.Pool) error {
+	list, err := migrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(ctx, db, func(conn *pgxpool.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if current > len(list) {
+			return fmt.Errorf("database schema version %d is newer than latest migration %d", current, len(list))
+		}
+
+		for _, m := range list[current:] {
+			err = applyMigration(ctx, conn, m.up, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, `INSERT INTO schema_version (version, name) VALUES ($1, $2)`,
+					m.version, m.name)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.version, m.name, err)
+			}
+
+			logger.Log.Info("applied migration", zap.Int("version", m.version), zap.String("name", m.name))
+		}
+
+		return nil
+	})
+}
+
+// MigrateDown откатывает steps последних примененных миграций.
+func MigrateDown(ctx context.Context, db *pgxpool.Pool, steps int) error {
+	if steps <= 0 {
+		return errors.New("steps must be positive")
+	}
+
+	list, err := migrations()
+	if err != nil {
+		return err
+	}
+
+	return withMigrationLock(ctx, db, func(conn *pgxpool.Conn) error {
+		current, err := currentVersion(ctx, conn)
+		if err != nil {
+			return err
+		}
+		if current > len(list) {
+			return fmt.Errorf("database schema version %d is newer than latest migration %d", current, len(list))
+		}
+
+		for i := current - 1; i >= max(current-steps, 0); i-- {
+			m := list[i]
+			err = applyMigration(ctx, conn, m.down, func(tx pgx.Tx) error {
+				_, err := tx.Exec(ctx, `DELETE FROM schema_version WHERE version = $1`, m.version)
+				return err
+			})
+			if err != nil {
+				return fmt.Errorf("migration %d_%s: %w", m.version, m.name, err)
+			}
+
+			logger.Log.Info("reverted migration", zap.Int("version", m.version), zap.String("name", m.name))
+		}
+
+		return nil
+	})
+}
+
+// SchemaVersion возвращает номер последней примененной миграции, 0 для пустой базы.
+func SchemaVersion(ctx context.Context, db *pgxpool.Pool) (int, error) {
+	var version int
+	err := withMigrationLock(ctx, db, func(conn *pgxpool.Conn) error {
+		var err error
+		version, err = currentVersion(ctx, conn)
+		return err
+	})
+
+	return version, err
+}
@@ -0,0 +1,150 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadMigrations(t *testing.T) {
+	file := func(body string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(body)}
+	}
+
+	tests := []struct {
+		fsys     fstest.MapFS
+		name     string
+		versions []int
+		wantErr  bool
+	}{
+		{
+			name: "Sorted by version",
+			fsys: fstest.MapFS{
+				"0002_second.up.sql":   file("create table b();"),
+				"0002_second.down.sql": file("drop table b;"),
+				"0001_first.up.sql":    file("create table a();"),
+				"0001_first.down.sql":  file("drop table a;"),
+			},
+			versions: []int{1, 2},
+		},
+		{
+			name: "Missing down",
+			fsys: fstest.MapFS{
+				"0001_first.up.sql": file("create table a();"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Gap in versions",
+			fsys: fstest.MapFS{
+				"0001_first.up.sql":   file("create table a();"),
+				"0001_first.down.sql": file("drop table a;"),
+				"0003_third.up.sql":   file("create table c();"),
+				"0003_third.down.sql": file("drop table c;"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Different names",
+			fsys: fstest.MapFS{
+				"0001_first.up.sql":   file("create table a();"),
+				"0001_other.down.sql": file("drop table a;"),
+			},
+			wantErr: true,
+		},
+		{
+			name: "Incorrect file name",
+			fsys: fstest.MapFS{
+				"first.sql": file("create table a();"),
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := loadMigrations(tt.fsys)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			versions := make([]int, 0, len(list))
+			for _, m := range list {
+				versions = append(versions, m.version)
+				assert.NotEmpty(t, m.up)
+				assert.NotEmpty(t, m.down)
+			}
+			assert.Equal(t, tt.versions, versions)
+		})
+	}
+}
+
+func TestEmbeddedMigrations(t *testing.T) {
+	list, err := migrations()
+	require.NoError(t, err)
+	assert.NotEmpty(t, list)
+}
+
+func TestMigrateUpDown(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	list, err := migrations()
+	require.NoError(t, err)
+
+	version, err := SchemaVersion(ctx, pg.db)
+	require.NoError(t, err)
+	assert.Equal(t, len(list), version)
+
+	// повторный запуск ничего не меняет
+	require.NoError(t, MigrateUp(ctx, pg.db))
+
+	require.NoError(t, MigrateDown(ctx, pg.db, 1))
+	version, err = SchemaVersion(ctx, pg.db)
+	require.NoError(t, err)
+	assert.Equal(t, len(list)-1, version)
+
+	require.NoError(t, MigrateDown(ctx, pg.db, len(list)+1))
+	version, err = SchemaVersion(ctx, pg.db)
+	require.NoError(t, err)
+	assert.Zero(t, version)
+
+	var exists bool
+	err = pg.db.QueryRow(ctx, `SELECT to_regclass('gauge') IS NOT NULL`).Scan(&exists)
+	require.NoError(t, err)
+	assert.False(t, exists)
+
+	require.NoError(t, MigrateUp(ctx, pg.db))
+	_, err = pg.UpdateGauge(ctx, `cpu{host="a"}`, 1)
+	require.NoError(t, err)
+}
+
+func TestMigrateParallel(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	require.NoError(t, MigrateDown(ctx, pg.db, 100))
+
+	errs := make(chan error, 4)
+	for range cap(errs) {
+		go func() {
+			errs <- MigrateUp(ctx, pg.db)
+		}()
+	}
+	for range cap(errs) {
+		assert.NoError(t, <-errs)
+	}
+
+	list, err := migrations()
+	require.NoError(t, err)
+	version, err := SchemaVersion(ctx, pg.db)
+	require.NoError(t, err)
+	assert.Equal(t, len(list), version)
+}
@@ -0,0 +1,2 @@
+drop table if exists counter;
+drop table if exists gauge;
@@ -0,0 +1,11 @@
+create table if not exists gauge(
+	name text not null unique PRIMARY KEY,
+	value double precision not null,
+	created_at timestamp with time zone not null default now()
+);
+
+create table if not exists counter(
+	name text not null unique PRIMARY KEY,
+	value bigint not null,
+	created_at timestamp with time zone not null default now()
+);
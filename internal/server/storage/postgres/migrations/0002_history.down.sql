@@ -0,0 +1,2 @@
+drop table if exists counter_history;
+drop table if exists gauge_history;
@@ -0,0 +1,15 @@
+create table if not exists gauge_history(
+	name text not null,
+	value double precision not null,
+	created_at timestamp with time zone not null default now()
+);
+create index if not exists gauge_history_name_created_at_idx
+	on gauge_history (name, created_at);
+
+create table if not exists counter_history(
+	name text not null,
+	value bigint not null,
+	created_at timestamp with time zone not null default now()
+);
+create index if not exists counter_history_name_created_at_idx
+	on counter_history (name, created_at);
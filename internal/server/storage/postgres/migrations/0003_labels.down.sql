@@ -0,0 +1,7 @@
+drop index if exists counter_metric_idx;
+alter table counter drop column if exists labels;
+alter table counter drop column if exists metric;
+
+drop index if exists gauge_metric_idx;
+alter table gauge drop column if exists labels;
+alter table gauge drop column if exists metric;
@@ -0,0 +1,10 @@
+-- метки серии хранятся отдельно от ключа, чтобы искать серии по имени и меткам
+alter table gauge add column if not exists metric text;
+alter table gauge add column if not exists labels jsonb not null default '{}'::jsonb;
+update gauge set metric = name where metric is null;
+create index if not exists gauge_metric_idx on gauge (metric);
+
+alter table counter add column if not exists metric text;
+alter table counter add column if not exists labels jsonb not null default '{}'::jsonb;
+update counter set metric = name where metric is null;
+create index if not exists counter_metric_idx on counter (metric);
@@ -0,0 +1,11 @@
+create table if not exists histogram(
+	name text not null unique PRIMARY KEY,
+	metric text,
+	labels jsonb not null default '{}'::jsonb,
+	buckets double precision[] not null,
+	counts bigint[] not null,
+	sum double precision not null,
+	count bigint not null,
+	created_at timestamp with time zone not null default now()
+);
+create index if not exists histogram_metric_idx on histogram (metric);
@@ -0,0 +1,13 @@
+create table if not exists summary(
+	name text not null unique PRIMARY KEY,
+	metric text,
+	labels jsonb not null default '{}'::jsonb,
+	window_ns bigint not null,
+	observations jsonb not null default '[]'::jsonb,
+	sum double precision not null,
+	min double precision not null,
+	max double precision not null,
+	count bigint not null,
+	created_at timestamp with time zone not null default now()
+);
+create index if not exists summary_metric_idx on summary (metric);
@@ -0,0 +1,9 @@
+-- скетч HyperLogLog хранится как массив регистров
+create table if not exists sets(
+	name text not null unique PRIMARY KEY,
+	metric text,
+	labels jsonb not null default '{}'::jsonb,
+	registers bytea not null,
+	created_at timestamp with time zone not null default now()
+);
+create index if not exists sets_metric_idx on sets (metric);
@@ -0,0 +1,3 @@
+drop table if exists rollup_watermark;
+drop table if exists counter_rollup;
+drop table if exists gauge_rollup;
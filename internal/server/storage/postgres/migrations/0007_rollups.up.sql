@@ -0,0 +1,32 @@
+-- свертки истории: resolution — шаг в секундах, bucket — начало интервала
+create table if not exists gauge_rollup(
+	name text not null,
+	resolution bigint not null,
+	bucket timestamp with time zone not null,
+	first double precision not null,
+	last double precision not null,
+	min double precision not null,
+	max double precision not null,
+	sum double precision not null,
+	count bigint not null,
+	PRIMARY KEY (name, resolution, bucket)
+);
+
+create table if not exists counter_rollup(
+	name text not null,
+	resolution bigint not null,
+	bucket timestamp with time zone not null,
+	first double precision not null,
+	last double precision not null,
+	min double precision not null,
+	max double precision not null,
+	sum double precision not null,
+	count bigint not null,
+	PRIMARY KEY (name, resolution, bucket)
+);
+
+-- до какого момента история уже свернута для каждого шага
+create table if not exists rollup_watermark(
+	resolution bigint not null PRIMARY KEY,
+	rolled_until timestamp with time zone not null
+);
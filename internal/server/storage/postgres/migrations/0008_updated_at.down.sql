@@ -0,0 +1,11 @@
+drop index if exists sets_updated_at_idx;
+drop index if exists summary_updated_at_idx;
+drop index if exists histogram_updated_at_idx;
+drop index if exists counter_updated_at_idx;
+drop index if exists gauge_updated_at_idx;
+
+alter table sets drop column if exists updated_at;
+alter table summary drop column if exists updated_at;
+alter table histogram drop column if exists updated_at;
+alter table counter drop column if exists updated_at;
+alter table gauge drop column if exists updated_at;
@@ -0,0 +1,12 @@
+-- время последнего обновления метрики, по нему удаляются устаревшие метрики
+alter table gauge add column if not exists updated_at timestamp with time zone not null default now();
+alter table counter add column if not exists updated_at timestamp with time zone not null default now();
+alter table histogram add column if not exists updated_at timestamp with time zone not null default now();
+alter table summary add column if not exists updated_at timestamp with time zone not null default now();
+alter table sets add column if not exists updated_at timestamp with time zone not null default now();
+
+create index if not exists gauge_updated_at_idx on gauge (updated_at);
+create index if not exists counter_updated_at_idx on counter (updated_at);
+create index if not exists histogram_updated_at_idx on histogram (updated_at);
+create index if not exists summary_updated_at_idx on summary (updated_at);
+create index if not exists sets_updated_at_idx on sets (updated_at);
@@ -0,0 +1,9 @@
+-- описания метрик по имени метрики без меток
+create table if not exists metadata(
+	name text not null unique PRIMARY KEY,
+	unit text not null default '',
+	help text not null default '',
+	type text not null default '',
+	precision integer,
+	updated_at timestamp with time zone not null default now()
+);
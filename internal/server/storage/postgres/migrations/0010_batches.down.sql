@@ -0,0 +1 @@
+drop table if exists applied_batches;
@@ -0,0 +1,6 @@
+-- ключи идемпотентности примененных пачек UpdateMany
+create table if not exists applied_batches(
+	id text not null PRIMARY KEY,
+	applied_at timestamp with time zone not null default now()
+);
+create index if not exists applied_batches_applied_at_idx on applied_batches (applied_at);
@@ -3,10 +3,14 @@ package postgres
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/config"
 	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/retry"
 	"github.com/LekcRg/metrics/internal/server/storage"
 	"github.com/jackc/pgx/v5"
@@ -17,8 +21,9 @@ type Postgres struct {
 	db *pgxpool.Pool
 }
 
-func NewPostgres(ctx context.Context, config config.ServerConfig) (*Postgres, error) {
-	conn, err := pgxpool.New(ctx, config.DatabaseDSN)
+// Connect подключается к базе dsn и ждет, пока она начнет отвечать.
+func Connect(ctx context.Context, dsn string) (*pgxpool.Pool, error) {
+	conn, err := pgxpool.New(ctx, dsn)
 	if err != nil {
 		return nil, err
 	}
@@ -33,34 +38,26 @@ func NewPostgres(ctx context.Context, config config.ServerConfig) (*Postgres, er
 	})
 
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 
-	err = retry.Retry(ctx, func() error {
-		_, err = conn.Exec(ctx, `create table if not exists gauge(
-		name text not null unique PRIMARY KEY,
-		value double precision not null,
-		created_at timestamp with time zone not null default now()
-		);`)
-
-		return err
-	})
+	return conn, nil
+}
 
+// NewPostgres подключается к базе и применяет недостающие миграции схемы.
+func NewPostgres(ctx context.Context, config config.ServerConfig) (*Postgres, error) {
+	conn, err := Connect(ctx, config.DatabaseDSN)
 	if err != nil {
 		return nil, err
 	}
 
 	err = retry.Retry(ctx, func() error {
-		_, err = conn.Exec(ctx, `create table if not exists counter(
-		name text not null unique PRIMARY KEY,
-		value bigint not null,
-		created_at timestamp with time zone not null default now()
-		);`)
-
-		return err
+		return MigrateUp(ctx, conn)
 	})
 
 	if err != nil {
+		conn.Close()
 		return nil, err
 	}
 
@@ -69,17 +66,39 @@ func NewPostgres(ctx context.Context, config config.ServerConfig) (*Postgres, er
 	}, nil
 }
 
+// seriesColumns возвращает имя метрики и метки в JSON для ключа серии.
+func seriesColumns(key string) (string, string) {
+	name, labels, err := storage.ParseSeriesKey(key)
+	if err != nil {
+		return key, "{}"
+	}
+
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return key, "{}"
+	}
+
+	return name, string(b)
+}
+
 func (p Postgres) UpdateCounter(ctx context.Context, name string, value storage.Counter) (storage.Counter, error) {
-	req := `INSERT INTO counter (name, value)
-	VALUES ($1, $2)
-	ON CONFLICT (name) DO UPDATE
-	SET value = counter.value + $2
-	RETURNING value;
+	req := `WITH upd AS (
+		INSERT INTO counter (name, value, metric, labels)
+		VALUES ($1, $2, $3, $4::jsonb)
+		ON CONFLICT (name) DO UPDATE
+		SET value = counter.value + $2, updated_at = now()
+		RETURNING value
+	), hist AS (
+		INSERT INTO counter_history (name, value)
+		VALUES ($1, $2)
+	)
+	SELECT value FROM upd;
 	`
 	var result storage.Counter
+	metric, labels := seriesColumns(name)
 
 	err := retry.Retry(ctx, func() error {
-		row := p.db.QueryRow(ctx, req, name, value)
+		row := p.db.QueryRow(ctx, req, name, value, metric, labels)
 
 		var val sql.NullInt64
 		err := row.Scan(&val)
@@ -104,16 +123,23 @@ func (p Postgres) UpdateCounter(ctx context.Context, name string, value storage.
 }
 
 func (p Postgres) UpdateGauge(ctx context.Context, name string, value storage.Gauge) (storage.Gauge, error) {
-	req := `INSERT INTO gauge (name, value)
-	VALUES ($1, $2)
-	ON CONFLICT (name) DO UPDATE
-	SET value = EXCLUDED.value
-	RETURNING value;
+	req := `WITH upd AS (
+		INSERT INTO gauge (name, value, metric, labels)
+		VALUES ($1, $2, $3, $4::jsonb)
+		ON CONFLICT (name) DO UPDATE
+		SET value = EXCLUDED.value, updated_at = now()
+		RETURNING value
+	), hist AS (
+		INSERT INTO gauge_history (name, value)
+		VALUES ($1, $2)
+	)
+	SELECT value FROM upd;
 	`
 	var result storage.Gauge
+	metric, labels := seriesColumns(name)
 
 	err := retry.Retry(ctx, func() error {
-		row := p.db.QueryRow(ctx, req, name, value)
+		row := p.db.QueryRow(ctx, req, name, value, metric, labels)
 
 		var val sql.NullFloat64
 		err := row.Scan(&val)
@@ -137,28 +163,276 @@ func (p Postgres) UpdateGauge(ctx context.Context, name string, value storage.Ga
 	return result, nil
 }
 
+// scanHistogram читает колонки buckets, counts, sum, count после колонок dest.
+// Количества в postgres хранятся как bigint.
+func scanHistogram(scan func(dest ...any) error, dest ...any) (storage.Histogram, error) {
+	var (
+		h      storage.Histogram
+		counts []int64
+		count  int64
+	)
+	err := scan(append(dest, &h.Buckets, &counts, &h.Sum, &count)...)
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	h.Counts = make([]uint64, len(counts))
+	for i, c := range counts {
+		h.Counts[i] = uint64(c)
+	}
+	h.Count = uint64(count)
+
+	return h, nil
+}
+
+// updateHistogram прибавляет value к гистограмме внутри транзакции.
+// Строка блокируется до конца транзакции, поэтому параллельные обновления
+// одной гистограммы не теряются.
+func updateHistogram(
+	ctx context.Context, tx pgx.Tx, name string, value storage.Histogram,
+) (storage.Histogram, error) {
+	metric, labels := seriesColumns(name)
+	_, err := tx.Exec(ctx, `INSERT INTO histogram (name, metric, labels, buckets, counts, sum, count)
+	VALUES ($1, $2, $3::jsonb, $4, $5, 0, 0)
+	ON CONFLICT (name) DO NOTHING`,
+		name, metric, labels, value.Buckets, make([]int64, len(value.Counts)))
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	h, err := scanHistogram(tx.QueryRow(ctx, `SELECT buckets, counts, sum, count FROM histogram
+	WHERE name = $1 FOR UPDATE`, name).Scan)
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	if err = h.Merge(value); err != nil {
+		return storage.Histogram{}, err
+	}
+
+	counts := make([]int64, len(h.Counts))
+	for i, c := range h.Counts {
+		counts[i] = int64(c)
+	}
+	_, err = tx.Exec(ctx, `UPDATE histogram SET counts = $2, sum = $3, count = $4, updated_at = now()
+	WHERE name = $1`,
+		name, counts, h.Sum, int64(h.Count))
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	return h, nil
+}
+
+func (p Postgres) UpdateHistogram(
+	ctx context.Context, name string, value storage.Histogram,
+) (storage.Histogram, error) {
+	var result storage.Histogram
+
+	err := retry.Retry(ctx, func() error {
+		tx, err := p.db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		result, err = updateHistogram(ctx, tx, name, value)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	return result, nil
+}
+
+// scanSummary читает колонки window_ns, observations, sum, min, max, count
+// после колонок dest.
+func scanSummary(scan func(dest ...any) error, dest ...any) (storage.Summary, error) {
+	var (
+		sm           storage.Summary
+		window       int64
+		observations []byte
+		count        int64
+	)
+	err := scan(append(dest, &window, &observations, &sm.Sum, &sm.Min, &sm.Max, &count)...)
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	if err = json.Unmarshal(observations, &sm.Observations); err != nil {
+		return storage.Summary{}, err
+	}
+	sm.Window = time.Duration(window)
+	sm.Count = uint64(count)
+
+	return sm, nil
+}
+
+// updateSummary добавляет value к summary внутри транзакции.
+// Строка блокируется до конца транзакции, как в updateHistogram.
+func updateSummary(
+	ctx context.Context, tx pgx.Tx, name string, value storage.Summary,
+) (storage.Summary, error) {
+	metric, labels := seriesColumns(name)
+	_, err := tx.Exec(ctx, `INSERT INTO summary (name, metric, labels, window_ns, sum, min, max, count)
+	VALUES ($1, $2, $3::jsonb, $4, 0, 0, 0, 0)
+	ON CONFLICT (name) DO NOTHING`,
+		name, metric, labels, int64(value.Window))
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	sm, err := scanSummary(tx.QueryRow(ctx, `SELECT window_ns, observations, sum, min, max, count
+	FROM summary WHERE name = $1 FOR UPDATE`, name).Scan)
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	sm.Merge(value)
+
+	observations, err := json.Marshal(sm.Observations)
+	if err != nil {
+		return storage.Summary{}, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE summary
+	SET window_ns = $2, observations = $3::jsonb, sum = $4, min = $5, max = $6, count = $7,
+	updated_at = now()
+	WHERE name = $1`,
+		name, int64(sm.Window), string(observations), sm.Sum, sm.Min, sm.Max, int64(sm.Count))
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	return sm, nil
+}
+
+func (p Postgres) UpdateSummary(
+	ctx context.Context, name string, value storage.Summary,
+) (storage.Summary, error) {
+	var result storage.Summary
+
+	err := retry.Retry(ctx, func() error {
+		tx, err := p.db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		result, err = updateSummary(ctx, tx, name, value)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	return result, nil
+}
+
+// updateSet объединяет value со скетчем внутри транзакции.
+// Строка блокируется до конца транзакции, как в updateHistogram.
+func updateSet(
+	ctx context.Context, tx pgx.Tx, name string, value storage.Set,
+) (storage.Set, error) {
+	metric, labels := seriesColumns(name)
+	_, err := tx.Exec(ctx, `INSERT INTO sets (name, metric, labels, registers)
+	VALUES ($1, $2, $3::jsonb, $4)
+	ON CONFLICT (name) DO NOTHING`,
+		name, metric, labels, storage.NewSet().Registers)
+	if err != nil {
+		return storage.Set{}, err
+	}
+
+	var st storage.Set
+	err = tx.QueryRow(ctx, `SELECT registers FROM sets WHERE name = $1 FOR UPDATE`, name).
+		Scan(&st.Registers)
+	if err != nil {
+		return storage.Set{}, err
+	}
+
+	if err = st.Merge(value); err != nil {
+		return storage.Set{}, err
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE sets SET registers = $2, updated_at = now() WHERE name = $1`, name, st.Registers)
+	if err != nil {
+		return storage.Set{}, err
+	}
+
+	return st, nil
+}
+
+func (p Postgres) UpdateSet(
+	ctx context.Context, name string, value storage.Set,
+) (storage.Set, error) {
+	var result storage.Set
+
+	err := retry.Retry(ctx, func() error {
+		tx, err := p.db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		result, err = updateSet(ctx, tx, name, value)
+		if err != nil {
+			return err
+		}
+
+		return tx.Commit(ctx)
+	})
+
+	if err != nil {
+		return storage.Set{}, err
+	}
+
+	return result, nil
+}
+
 func (p Postgres) UpdateMany(ctx context.Context, list storage.Database) error {
-	reqCounter := `INSERT INTO counter (name, value)
-	VALUES ($1, $2)
+	reqCounter := `INSERT INTO counter (name, value, metric, labels)
+	VALUES ($1, $2, $3, $4::jsonb)
 	ON CONFLICT (name) DO UPDATE
-	SET value = counter.value + $2
+	SET value = counter.value + $2, updated_at = now()
 	RETURNING value;
 	`
-	reqGauge := `INSERT INTO gauge (name, value)
-	VALUES ($1, $2)
+	reqGauge := `INSERT INTO gauge (name, value, metric, labels)
+	VALUES ($1, $2, $3, $4::jsonb)
 	ON CONFLICT (name) DO UPDATE
-	SET value = EXCLUDED.value
+	SET value = EXCLUDED.value, updated_at = now()
 	RETURNING value;
 	`
+	reqCounterHistory := `INSERT INTO counter_history (name, value) VALUES ($1, $2);`
+	reqGaugeHistory := `INSERT INTO gauge_history (name, value) VALUES ($1, $2);`
 
 	batch := &pgx.Batch{}
 
 	for key, value := range list.Counter {
-		batch.Queue(reqCounter, key, value)
+		metric, labels := seriesColumns(key)
+		batch.Queue(reqCounter, key, value, metric, labels)
+		batch.Queue(reqCounterHistory, key, value)
 	}
 
 	for key, value := range list.Gauge {
-		batch.Queue(reqGauge, key, value)
+		metric, labels := seriesColumns(key)
+		batch.Queue(reqGauge, key, value, metric, labels)
+		batch.Queue(reqGaugeHistory, key, value)
+	}
+
+	if list.BatchID != "" {
+		if err := p.expireBatches(ctx); err != nil {
+			return err
+		}
 	}
 
 	return retry.Retry(ctx, func() error {
@@ -168,6 +442,12 @@ func (p Postgres) UpdateMany(ctx context.Context, list storage.Database) error {
 		}
 		defer tx.Rollback(ctx)
 
+		if list.BatchID != "" {
+			if err = claimBatch(ctx, tx, list.BatchID); err != nil {
+				return err
+			}
+		}
+
 		br := tx.SendBatch(ctx, batch)
 		defer br.Close()
 
@@ -181,6 +461,28 @@ func (p Postgres) UpdateMany(ctx context.Context, list storage.Database) error {
 			return err
 		}
 
+		for key, value := range list.Histogram {
+			if _, err = updateHistogram(ctx, tx, key, value); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range list.Summary {
+			if _, err = updateSummary(ctx, tx, key, value); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range list.Set {
+			if _, err = updateSet(ctx, tx, key, value); err != nil {
+				return err
+			}
+		}
+
+		if err = updateMetadata(ctx, tx, list.Metadata); err != nil {
+			return err
+		}
+
 		err = tx.Commit(ctx)
 		return err
 	})
@@ -317,6 +619,278 @@ func (p Postgres) GetCounterByName(ctx context.Context, name string) (storage.Co
 	return storage.Counter(val.Int64), nil
 }
 
+func (p Postgres) GetHistogramByName(ctx context.Context, name string) (storage.Histogram, error) {
+	req := `SELECT buckets, counts, sum, count FROM histogram WHERE name=$1 LIMIT 1`
+
+	var h storage.Histogram
+	err := retry.Retry(ctx, func() error {
+		var err error
+		h, err = scanHistogram(p.db.QueryRow(ctx, req, name).Scan)
+		return err
+	})
+
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	return h, nil
+}
+
+func (p Postgres) GetAllHistogram(ctx context.Context) (storage.HistogramCollection, error) {
+	req := `SELECT name, buckets, counts, sum, count FROM histogram`
+
+	var list storage.HistogramCollection
+	err := retry.Retry(ctx, func() error {
+		rows, err := p.db.Query(ctx, req)
+		if err != nil {
+			logger.Log.Error("error while sending request to db")
+			return err
+		}
+		defer rows.Close()
+
+		list = make(storage.HistogramCollection, 0)
+		for rows.Next() {
+			var name string
+			h, err := scanHistogram(rows.Scan, &name)
+			if err != nil {
+				logger.Log.Error(err.Error())
+				return err
+			}
+
+			list[name] = h
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (p Postgres) getHistory(
+	ctx context.Context, req string, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	var list []storage.Sample
+	err := retry.Retry(ctx, func() error {
+		rows, err := p.db.Query(ctx, req, name, from, to)
+		if err != nil {
+			logger.Log.Error("error while sending request to db")
+			return err
+		}
+		defer rows.Close()
+
+		list = make([]storage.Sample, 0)
+		for rows.Next() {
+			var sample storage.Sample
+			err = rows.Scan(&sample.Time, &sample.Value)
+			if err != nil {
+				logger.Log.Error(err.Error())
+				return err
+			}
+
+			list = append(list, sample)
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (p Postgres) GetGaugeHistory(
+	ctx context.Context, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	req := `SELECT created_at, value FROM gauge_history
+	WHERE name = $1 AND created_at BETWEEN $2 AND $3
+	ORDER BY created_at`
+
+	return p.getHistory(ctx, req, name, from, to)
+}
+
+func (p Postgres) GetCounterHistory(
+	ctx context.Context, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	req := `SELECT created_at, value::double precision FROM counter_history
+	WHERE name = $1 AND created_at BETWEEN $2 AND $3
+	ORDER BY created_at`
+
+	return p.getHistory(ctx, req, name, from, to)
+}
+
+func (p Postgres) FindSeries(
+	ctx context.Context, mType string, name string, matchers []storage.Matcher,
+) ([]storage.Series, error) {
+	var req string
+	switch mType {
+	case "gauge":
+		req = `SELECT name, value FROM gauge WHERE metric = $1 AND labels @> $2::jsonb`
+	case "counter":
+		req = `SELECT name, value::double precision FROM counter WHERE metric = $1 AND labels @> $2::jsonb`
+	default:
+		return nil, merrors.ErrIncorrectMetricType
+	}
+
+	// равенства с непустым значением проверяются в базе, остальные условия — после
+	equal := storage.Labels{}
+	for _, m := range matchers {
+		if m.Type == storage.MatchEqual && m.Value != "" {
+			equal[m.Name] = m.Value
+		}
+	}
+	equalJSON, err := json.Marshal(equal)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []storage.Series
+	err = retry.Retry(ctx, func() error {
+		rows, err := p.db.Query(ctx, req, name, string(equalJSON))
+		if err != nil {
+			logger.Log.Error("error while sending request to db")
+			return err
+		}
+		defer rows.Close()
+
+		list = make([]storage.Series, 0)
+		for rows.Next() {
+			var series storage.Series
+			err = rows.Scan(&series.Key, &series.Value)
+			if err != nil {
+				logger.Log.Error(err.Error())
+				return err
+			}
+
+			series.Name, series.Labels, err = storage.ParseSeriesKey(series.Key)
+			if err != nil || !series.Labels.Match(matchers) {
+				continue
+			}
+
+			list = append(list, series)
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+
+	return list, nil
+}
+
+func (p Postgres) GetSummaryByName(ctx context.Context, name string) (storage.Summary, error) {
+	req := `SELECT window_ns, observations, sum, min, max, count FROM summary WHERE name=$1 LIMIT 1`
+
+	var sm storage.Summary
+	err := retry.Retry(ctx, func() error {
+		var err error
+		sm, err = scanSummary(p.db.QueryRow(ctx, req, name).Scan)
+		return err
+	})
+
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	return sm, nil
+}
+
+func (p Postgres) GetAllSummary(ctx context.Context) (storage.SummaryCollection, error) {
+	req := `SELECT name, window_ns, observations, sum, min, max, count FROM summary`
+
+	var list storage.SummaryCollection
+	err := retry.Retry(ctx, func() error {
+		rows, err := p.db.Query(ctx, req)
+		if err != nil {
+			logger.Log.Error("error while sending request to db")
+			return err
+		}
+		defer rows.Close()
+
+		list = make(storage.SummaryCollection, 0)
+		for rows.Next() {
+			var name string
+			sm, err := scanSummary(rows.Scan, &name)
+			if err != nil {
+				logger.Log.Error(err.Error())
+				return err
+			}
+
+			list[name] = sm
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (p Postgres) GetSetByName(ctx context.Context, name string) (storage.Set, error) {
+	req := `SELECT registers FROM sets WHERE name=$1 LIMIT 1`
+
+	var st storage.Set
+	err := retry.Retry(ctx, func() error {
+		return p.db.QueryRow(ctx, req, name).Scan(&st.Registers)
+	})
+
+	if err != nil {
+		return storage.Set{}, err
+	}
+
+	return st, nil
+}
+
+func (p Postgres) GetAllSet(ctx context.Context) (storage.SetCollection, error) {
+	req := `SELECT name, registers FROM sets`
+
+	var list storage.SetCollection
+	err := retry.Retry(ctx, func() error {
+		rows, err := p.db.Query(ctx, req)
+		if err != nil {
+			logger.Log.Error("error while sending request to db")
+			return err
+		}
+		defer rows.Close()
+
+		list = make(storage.SetCollection, 0)
+		for rows.Next() {
+			var (
+				name string
+				st   storage.Set
+			)
+			if err := rows.Scan(&name, &st.Registers); err != nil {
+				logger.Log.Error(err.Error())
+				return err
+			}
+
+			list[name] = st
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
 func (p Postgres) GetAll(ctx context.Context) (storage.Database, error) {
 	gaugeList, err := p.GetAllGauge(ctx)
 	if err != nil {
@@ -330,9 +904,37 @@ func (p Postgres) GetAll(ctx context.Context) (storage.Database, error) {
 		return storage.Database{}, err
 	}
 
+	histogramList, err := p.GetAllHistogram(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return storage.Database{}, err
+	}
+
+	summaryList, err := p.GetAllSummary(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return storage.Database{}, err
+	}
+
+	setList, err := p.GetAllSet(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return storage.Database{}, err
+	}
+
+	metadataList, err := p.GetAllMetadata(ctx)
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return storage.Database{}, err
+	}
+
 	return storage.Database{
-		Gauge:   gaugeList,
-		Counter: counterList,
+		Gauge:     gaugeList,
+		Counter:   counterList,
+		Histogram: histogramList,
+		Summary:   summaryList,
+		Set:       setList,
+		Metadata:  metadataList,
 	}, nil
 }
 
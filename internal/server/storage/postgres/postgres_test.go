@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
 	"github.com/LekcRg/metrics/internal/server/storage"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -199,9 +201,16 @@ func TestUpdateMany(t *testing.T) {
 		"gauge1": 42.42,
 		"gauge2": 0,
 	}
+	precision := 1
 	db := storage.Database{
-		Counter: counters,
-		Gauge:   gauges,
+		Counter:   counters,
+		Gauge:     gauges,
+		Histogram: storage.HistogramCollection{},
+		Summary:   storage.SummaryCollection{},
+		Set:       storage.SetCollection{},
+		Metadata: storage.MetadataCollection{
+			"gauge1": {Unit: "bytes", Help: "help", Type: "gauge", Precision: &precision},
+		},
 	}
 
 	tests := []struct {
@@ -237,3 +246,181 @@ func TestUpdateMany(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateManyBatch(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	batch := storage.Database{Counter: storage.CounterCollection{"PollCount": 1}, BatchID: "batch-1"}
+	require.NoError(t, pg.UpdateMany(ctx, batch))
+	assert.ErrorIs(t, pg.UpdateMany(ctx, batch), merrors.ErrDuplicateBatch)
+
+	batch.BatchID = "batch-2"
+	require.NoError(t, pg.UpdateMany(ctx, batch))
+
+	val, err := pg.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(2), val)
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	// now() считается на стороне postgres, поэтому берем диапазон с запасом.
+	from := time.Now().Add(-time.Minute)
+	_, err := pg.UpdateGauge(ctx, "gauge1", 1.5)
+	require.NoError(t, err)
+	_, err = pg.UpdateCounter(ctx, "counter1", 2)
+	require.NoError(t, err)
+	err = pg.UpdateMany(ctx, storage.Database{
+		Gauge:   storage.GaugeCollection{"gauge1": 2.5},
+		Counter: storage.CounterCollection{"counter1": 3},
+	})
+	require.NoError(t, err)
+	to := time.Now().Add(time.Minute)
+
+	gauges, err := pg.GetGaugeHistory(ctx, "gauge1", from, to)
+	require.NoError(t, err)
+	require.Len(t, gauges, 2)
+	assert.Equal(t, 1.5, gauges[0].Value)
+	assert.Equal(t, 2.5, gauges[1].Value)
+
+	counters, err := pg.GetCounterHistory(ctx, "counter1", from, to)
+	require.NoError(t, err)
+	require.Len(t, counters, 2)
+	assert.Equal(t, 2.0, counters[0].Value)
+	assert.Equal(t, 3.0, counters[1].Value)
+
+	missing, err := pg.GetGaugeHistory(ctx, "missing", from, to)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestFindSeries(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	err := pg.UpdateMany(ctx, storage.Database{
+		Gauge: storage.GaugeCollection{
+			"cpu":                          1,
+			`cpu{host="web1",region="eu"}`: 2,
+			`cpu{host="web2",region="us"}`: 3,
+		},
+		Counter: storage.CounterCollection{`cpu{host="web1"}`: 5},
+	})
+	require.NoError(t, err)
+
+	all, err := pg.FindSeries(ctx, "gauge", "cpu", nil)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "cpu", all[0].Key)
+
+	host, err := storage.NewMatcher(storage.MatchEqual, "host", "web1")
+	require.NoError(t, err)
+	region, err := storage.NewMatcher(storage.MatchNotRegexp, "region", "u.")
+	require.NoError(t, err)
+
+	found, err := pg.FindSeries(ctx, "gauge", "cpu", []storage.Matcher{host, region})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, storage.Labels{"host": "web1", "region": "eu"}, found[0].Labels)
+	assert.Equal(t, 2.0, found[0].Value)
+
+	counters, err := pg.FindSeries(ctx, "counter", "cpu", []storage.Matcher{host})
+	require.NoError(t, err)
+	require.Len(t, counters, 1)
+	assert.Equal(t, 5.0, counters[0].Value)
+}
+
+func TestHistogram(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	value := storage.Histogram{Buckets: []float64{1, 2}, Counts: []uint64{1, 0, 1}, Sum: 3.5, Count: 2}
+	got, err := pg.UpdateHistogram(ctx, `latency{route="/"}`, value)
+	require.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	err = pg.UpdateMany(ctx, storage.Database{
+		Histogram: storage.HistogramCollection{`latency{route="/"}`: value},
+	})
+	require.NoError(t, err)
+
+	got, err = pg.GetHistogramByName(ctx, `latency{route="/"}`)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 0, 2}, got.Counts)
+	assert.Equal(t, uint64(4), got.Count)
+
+	_, err = pg.UpdateHistogram(ctx, `latency{route="/"}`, storage.Histogram{
+		Buckets: []float64{5}, Counts: []uint64{1, 0}, Count: 1,
+	})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectHistogramBuckets)
+
+	all, err := pg.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all.Histogram, 1)
+}
+
+func TestSummary(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	value := storage.NewSummary(time.Minute)
+	value.Observe(2, time.Now())
+
+	got, err := pg.UpdateSummary(ctx, `rpc{method="get"}`, value)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), got.Count)
+	assert.Equal(t, time.Minute, got.Window)
+
+	err = pg.UpdateMany(ctx, storage.Database{
+		Summary: storage.SummaryCollection{`rpc{method="get"}`: value},
+	})
+	require.NoError(t, err)
+
+	got, err = pg.GetSummaryByName(ctx, `rpc{method="get"}`)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.Count)
+	assert.Len(t, got.Observations, 2)
+	assert.InDelta(t, 2.0, got.Quantile(0.5, time.Now()), 1e-9)
+
+	all, err := pg.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all.Summary, 1)
+}
+
+func TestSet(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	first, second := storage.NewSet(), storage.NewSet()
+	first.Add("alice")
+	second.Add("bob")
+
+	got, err := pg.UpdateSet(ctx, `users{region="eu"}`, first)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), got.Count())
+
+	err = pg.UpdateMany(ctx, storage.Database{
+		Set: storage.SetCollection{`users{region="eu"}`: second},
+	})
+	require.NoError(t, err)
+
+	got, err = pg.GetSetByName(ctx, `users{region="eu"}`)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.Count())
+
+	_, err = pg.UpdateSet(ctx, `users{region="eu"}`, storage.Set{Registers: make([]uint8, 16)})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectSet)
+
+	all, err := pg.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all.Set, 1)
+}
@@ -0,0 +1,188 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/retry"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/jackc/pgx/v5"
+)
+
+// retentionLockID — ключ advisory lock, под которым сворачивается история.
+// Если несколько реплик работают с одной базой, свертку выполняет одна.
+const retentionLockID int64 = 0x726f6c6c7570
+
+// rollupRawReq сворачивает сырые семплы из %[1]s в %[2]s.
+// $1 — шаг в секундах, [$2, $3) — сворачиваемый интервал.
+const rollupRawReq = `INSERT INTO %[2]s AS r (name, resolution, bucket, first, last, min, max, sum, count)
+	SELECT name, $1::bigint,
+		to_timestamp((floor(extract(epoch FROM created_at) / $1::bigint) * $1::bigint)::double precision) AS b,
+		(array_agg(value::double precision ORDER BY created_at))[1],
+		(array_agg(value::double precision ORDER BY created_at DESC))[1],
+		min(value), max(value), sum(value), count(*)
+	FROM %[1]s
+	WHERE created_at >= $2 AND created_at < $3
+	GROUP BY name, b
+	ON CONFLICT (name, resolution, bucket) DO UPDATE
+	SET last = EXCLUDED.last, min = least(r.min, EXCLUDED.min), max = greatest(r.max, EXCLUDED.max),
+		sum = r.sum + EXCLUDED.sum, count = r.count + EXCLUDED.count`
+
+// rollupMergeReq сворачивает свертки шага $4 в свертки шага $1.
+const rollupMergeReq = `INSERT INTO %[1]s AS r (name, resolution, bucket, first, last, min, max, sum, count)
+	SELECT name, $1::bigint,
+		to_timestamp((floor(extract(epoch FROM bucket) / $1::bigint) * $1::bigint)::double precision) AS b,
+		(array_agg(first ORDER BY bucket))[1],
+		(array_agg(last ORDER BY bucket DESC))[1],
+		min(min), max(max), sum(sum), sum(count)
+	FROM %[1]s
+	WHERE resolution = $4 AND bucket >= $2 AND bucket < $3
+	GROUP BY name, b
+	ON CONFLICT (name, resolution, bucket) DO UPDATE
+	SET last = EXCLUDED.last, min = least(r.min, EXCLUDED.min), max = greatest(r.max, EXCLUDED.max),
+		sum = r.sum + EXCLUDED.sum, count = r.count + EXCLUDED.count`
+
+var rollupTables = []struct {
+	history string
+	rollup  string
+}{
+	{history: "gauge_history", rollup: "gauge_rollup"},
+	{history: "counter_history", rollup: "counter_rollup"},
+}
+
+func seconds(d time.Duration) int64 {
+	return int64(d / time.Second)
+}
+
+// rollupLevel сворачивает историю для уровня policy.Levels[i]
+// от сохраненной отметки до начала текущего интервала.
+func rollupLevel(ctx context.Context, tx pgx.Tx, policy storage.RetentionPolicy, i int, now time.Time) error {
+	level := policy.Levels[i]
+	res := seconds(level.Resolution)
+
+	var start time.Time
+	err := tx.QueryRow(ctx, `SELECT rolled_until FROM rollup_watermark WHERE resolution = $1`, res).Scan(&start)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return err
+	}
+
+	end := storage.BucketStart(now, level.Resolution)
+	if !start.Before(end) {
+		return nil
+	}
+
+	for _, table := range rollupTables {
+		if i == 0 {
+			_, err = tx.Exec(ctx, fmt.Sprintf(rollupRawReq, table.history, table.rollup), res, start, end)
+		} else {
+			prev := seconds(policy.Levels[i-1].Resolution)
+			_, err = tx.Exec(ctx, fmt.Sprintf(rollupMergeReq, table.rollup), res, start, end, prev)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.Exec(ctx, `INSERT INTO rollup_watermark (resolution, rolled_until) VALUES ($1, $2)
+	ON CONFLICT (resolution) DO UPDATE SET rolled_until = EXCLUDED.rolled_until`, res, end)
+
+	return err
+}
+
+// ApplyRetention досворачивает историю до текущих границ интервалов
+// и удаляет семплы и свертки старше сроков хранения policy.
+func (p Postgres) ApplyRetention(ctx context.Context, policy storage.RetentionPolicy, now time.Time) error {
+	if !policy.Enabled() {
+		return nil
+	}
+
+	return retry.Retry(ctx, func() error {
+		tx, err := p.db.BeginTx(ctx, pgx.TxOptions{})
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback(ctx)
+
+		if _, err = tx.Exec(ctx, `SELECT pg_advisory_xact_lock($1)`, retentionLockID); err != nil {
+			return err
+		}
+
+		for i := range policy.Levels {
+			if err = rollupLevel(ctx, tx, policy, i, now); err != nil {
+				return err
+			}
+		}
+
+		for _, table := range rollupTables {
+			_, err = tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE created_at < $1`, table.history),
+				now.Add(-policy.Raw))
+			if err != nil {
+				return err
+			}
+
+			for _, level := range policy.Levels {
+				_, err = tx.Exec(ctx, fmt.Sprintf(`DELETE FROM %s WHERE resolution = $1 AND bucket < $2`, table.rollup),
+					seconds(level.Resolution), now.Add(-level.Retention))
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return tx.Commit(ctx)
+	})
+}
+
+func (p Postgres) getRollups(
+	ctx context.Context, table string, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	req := fmt.Sprintf(`SELECT bucket, first, last, min, max, sum, count FROM %s
+	WHERE name = $1 AND resolution = $2 AND bucket BETWEEN $3 AND $4
+	ORDER BY bucket`, table)
+
+	var list []storage.Rollup
+	err := retry.Retry(ctx, func() error {
+		rows, err := p.db.Query(ctx, req, name, seconds(resolution), storage.BucketStart(from, resolution), to)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		list = make([]storage.Rollup, 0)
+		for rows.Next() {
+			var (
+				r     storage.Rollup
+				count int64
+			)
+			err = rows.Scan(&r.Time, &r.First, &r.Last, &r.Min, &r.Max, &r.Sum, &count)
+			if err != nil {
+				return err
+			}
+			r.Count = uint64(count)
+
+			list = append(list, r)
+		}
+
+		return rows.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
+
+func (p Postgres) GetGaugeRollups(
+	ctx context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	return p.getRollups(ctx, "gauge_rollup", name, resolution, from, to)
+}
+
+func (p Postgres) GetCounterRollups(
+	ctx context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	return p.getRollups(ctx, "counter_rollup", name, resolution, from, to)
+}
@@ -0,0 +1,75 @@
+package postgres
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApplyRetention(t *testing.T) {
+	ctx := context.Background()
+	pg, container := getPostgres(t)
+	defer terminateContainer(t, container)
+
+	base := storage.BucketStart(time.Unix(1_700_000_000, 0), time.Hour)
+	at := func(d time.Duration) time.Time {
+		return base.Add(d)
+	}
+	write := func(d time.Duration, gauge float64, counter int64) {
+		_, err := pg.db.Exec(ctx, `INSERT INTO gauge_history (name, value, created_at) VALUES ('gauge', $1, $2)`,
+			gauge, at(d))
+		require.NoError(t, err)
+		_, err = pg.db.Exec(ctx, `INSERT INTO counter_history (name, value, created_at) VALUES ('counter', $1, $2)`,
+			counter, at(d))
+		require.NoError(t, err)
+	}
+	write(10*time.Second, 1, 2)
+	write(20*time.Second, 5, 0)
+	write(70*time.Second, 3, 3)
+
+	policy, err := storage.ParseRetention("raw:2m,1m:1h,1h:24h")
+	require.NoError(t, err)
+
+	now := at(150 * time.Second)
+	require.NoError(t, pg.ApplyRetention(ctx, policy, now))
+	require.NoError(t, pg.ApplyRetention(ctx, policy, now))
+
+	gauges, err := pg.GetGaugeRollups(ctx, "gauge", time.Minute, base, now)
+	require.NoError(t, err)
+	require.Len(t, gauges, 2)
+	assert.True(t, gauges[0].Time.Equal(base))
+	assert.Equal(t, 1.0, gauges[0].First)
+	assert.Equal(t, 5.0, gauges[0].Last)
+	assert.Equal(t, 6.0, gauges[0].Sum)
+	assert.Equal(t, uint64(2), gauges[0].Count)
+
+	counters, err := pg.GetCounterRollups(ctx, "counter", time.Minute, base, now)
+	require.NoError(t, err)
+	require.Len(t, counters, 2)
+	assert.Equal(t, 2.0, counters[0].Sum)
+	assert.Equal(t, 3.0, counters[1].Sum)
+
+	raw, err := pg.GetGaugeHistory(ctx, "gauge", base, now)
+	require.NoError(t, err)
+	require.Len(t, raw, 1)
+	assert.Equal(t, 3.0, raw[0].Value)
+
+	later := at(3 * time.Hour)
+	require.NoError(t, pg.ApplyRetention(ctx, policy, later))
+
+	hours, err := pg.GetGaugeRollups(ctx, "gauge", time.Hour, base, later)
+	require.NoError(t, err)
+	require.Len(t, hours, 1)
+	assert.Equal(t, 1.0, hours[0].First)
+	assert.Equal(t, 3.0, hours[0].Last)
+	assert.Equal(t, 5.0, hours[0].Max)
+	assert.Equal(t, uint64(3), hours[0].Count)
+
+	minutes, err := pg.GetGaugeRollups(ctx, "gauge", time.Minute, base, later)
+	require.NoError(t, err)
+	assert.Empty(t, minutes)
+}
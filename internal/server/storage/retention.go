@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+)
+
+// Rollup — свертка семплов серии за интервал [Time, Time+resolution).
+// Для gauge нужны First, Last, Min, Max и среднее Sum/Count,
+// для counter — сумма приростов Sum.
+type Rollup struct {
+	Time  time.Time `json:"time"`
+	First float64   `json:"first"`
+	Last  float64   `json:"last"`
+	Min   float64   `json:"min"`
+	Max   float64   `json:"max"`
+	Sum   float64   `json:"sum"`
+	Count uint64    `json:"count"`
+}
+
+// Avg возвращает среднее значение семплов свертки.
+func (r Rollup) Avg() float64 {
+	if r.Count == 0 {
+		return 0
+	}
+
+	return r.Sum / float64(r.Count)
+}
+
+// Merge добавляет к свертке более позднюю свертку o.
+func (r *Rollup) Merge(o Rollup) {
+	if o.Count == 0 {
+		return
+	}
+	if r.Count == 0 {
+		t := r.Time
+		*r = o
+		r.Time = t
+		return
+	}
+
+	r.Last = o.Last
+	r.Min = min(r.Min, o.Min)
+	r.Max = max(r.Max, o.Max)
+	r.Sum += o.Sum
+	r.Count += o.Count
+}
+
+// Add добавляет к свертке более поздний семпл со значением value.
+func (r *Rollup) Add(value float64) {
+	r.Merge(Rollup{First: value, Last: value, Min: value, Max: value, Sum: value, Count: 1})
+}
+
+// BucketStart возвращает начало интервала длиной resolution, в который
+// попадает t. Интервалы отсчитываются от начала эпохи unix.
+func BucketStart(t time.Time, resolution time.Duration) time.Time {
+	ns := t.UnixNano()
+	start := ns - ns%int64(resolution)
+	if ns < 0 && start != ns {
+		start -= int64(resolution)
+	}
+
+	return time.Unix(0, start)
+}
+
+// RollupSamples сворачивает отсортированные по времени семплы в интервалы
+// длиной resolution.
+func RollupSamples(samples []Sample, resolution time.Duration) []Rollup {
+	list := make([]Rollup, 0)
+	for _, sample := range samples {
+		start := BucketStart(sample.Time, resolution)
+		if len(list) == 0 || !list[len(list)-1].Time.Equal(start) {
+			list = append(list, Rollup{Time: start})
+		}
+		list[len(list)-1].Add(sample.Value)
+	}
+
+	return list
+}
+
+// MergeRollups сворачивает отсортированные по времени свертки в более
+// крупные интервалы длиной resolution.
+func MergeRollups(rollups []Rollup, resolution time.Duration) []Rollup {
+	list := make([]Rollup, 0)
+	for _, r := range rollups {
+		start := BucketStart(r.Time, resolution)
+		if len(list) == 0 || !list[len(list)-1].Time.Equal(start) {
+			list = append(list, Rollup{Time: start})
+		}
+		list[len(list)-1].Merge(r)
+	}
+
+	return list
+}
+
+// RollupLevel — уровень свертки: интервалы длиной Resolution хранятся Retention.
+type RollupLevel struct {
+	Resolution time.Duration
+	Retention  time.Duration
+}
+
+// RetentionPolicy — сколько хранить историю. Сырые семплы хранятся Raw,
+// после чего остаются только свертки Levels. Levels отсортированы
+// по возрастанию Resolution, каждый уровень строится из предыдущего,
+// первый — из сырых семплов. Нулевая политика хранит историю бессрочно.
+type RetentionPolicy struct {
+	Levels []RollupLevel
+	Raw    time.Duration
+}
+
+// Enabled сообщает, ограничено ли время хранения истории.
+func (p RetentionPolicy) Enabled() bool {
+	return p.Raw > 0
+}
+
+// Level возвращает самый подробный уровень свертки, который еще хранит
+// данные за момент from. Если ни один уровень не хранит from,
+// возвращается самый крупный.
+func (p RetentionPolicy) Level(from, now time.Time) (RollupLevel, bool) {
+	if len(p.Levels) == 0 {
+		return RollupLevel{}, false
+	}
+
+	for _, level := range p.Levels {
+		if !from.Before(now.Add(-level.Retention)) {
+			return level, true
+		}
+	}
+
+	return p.Levels[len(p.Levels)-1], true
+}
+
+// RetentionOff — значение политики, при котором история хранится бессрочно.
+const RetentionOff = "off"
+
+// parseRetentionDuration разбирает длительность в формате time.ParseDuration
+// и дополнительно в днях: 30d.
+func parseRetentionDuration(val string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(val, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+
+	return time.ParseDuration(val)
+}
+
+// ParseRetention разбирает политику вида raw:24h,1m:30d,1h:365d: сколько
+// хранить сырые семплы и для каждого шага свертки — сколько хранить свертки.
+// Пустая строка и RetentionOff означают бессрочное хранение.
+//
+// Шаги сверток должны быть целым числом секунд, возрастать и делиться
+// на предыдущий шаг. Каждый уровень должен храниться не меньше шага
+// следующего, а сырые семплы — не меньше первого шага, иначе данные
+// удалятся раньше, чем попадут в свертку.
+func ParseRetention(val string) (RetentionPolicy, error) {
+	var policy RetentionPolicy
+	if val == "" || val == RetentionOff {
+		return policy, nil
+	}
+
+	for i, part := range strings.Split(val, ",") {
+		step, keep, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok {
+			return RetentionPolicy{}, merrors.ErrIncorrectRetention
+		}
+
+		retention, err := parseRetentionDuration(keep)
+		if err != nil || retention <= 0 {
+			return RetentionPolicy{}, merrors.ErrIncorrectRetention
+		}
+
+		if i == 0 {
+			if step != "raw" {
+				return RetentionPolicy{}, merrors.ErrIncorrectRetention
+			}
+			policy.Raw = retention
+			continue
+		}
+
+		resolution, err := parseRetentionDuration(step)
+		if err != nil || resolution < time.Second || resolution%time.Second != 0 {
+			return RetentionPolicy{}, merrors.ErrIncorrectRetention
+		}
+		policy.Levels = append(policy.Levels, RollupLevel{Resolution: resolution, Retention: retention})
+	}
+
+	prevResolution, prevRetention := time.Duration(0), policy.Raw
+	for _, level := range policy.Levels {
+		if level.Resolution <= prevResolution || prevRetention < level.Resolution ||
+			(prevResolution > 0 && level.Resolution%prevResolution != 0) {
+			return RetentionPolicy{}, merrors.ErrIncorrectRetention
+		}
+		prevResolution, prevRetention = level.Resolution, level.Retention
+	}
+
+	return policy, nil
+}
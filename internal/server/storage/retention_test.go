@@ -0,0 +1,138 @@
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRetention(t *testing.T) {
+	day := 24 * time.Hour
+	tests := []struct {
+		name    string
+		val     string
+		want    RetentionPolicy
+		wantErr bool
+	}{
+		{
+			name: "Empty",
+			val:  "",
+			want: RetentionPolicy{},
+		},
+		{
+			name: "Off",
+			val:  "off",
+			want: RetentionPolicy{},
+		},
+		{
+			name: "Full policy",
+			val:  "raw:24h, 1m:30d, 1h:365d",
+			want: RetentionPolicy{
+				Raw: day,
+				Levels: []RollupLevel{
+					{Resolution: time.Minute, Retention: 30 * day},
+					{Resolution: time.Hour, Retention: 365 * day},
+				},
+			},
+		},
+		{
+			name: "Raw only",
+			val:  "raw:1h",
+			want: RetentionPolicy{Raw: time.Hour},
+		},
+		{
+			name:    "No raw",
+			val:     "1m:30d",
+			wantErr: true,
+		},
+		{
+			name:    "Decreasing resolution",
+			val:     "raw:24h,1h:30d,1m:365d",
+			wantErr: true,
+		},
+		{
+			name:    "Not divisible",
+			val:     "raw:24h,1m:30d,90s:365d",
+			wantErr: true,
+		},
+		{
+			name:    "Raw shorter than resolution",
+			val:     "raw:30s,1m:30d",
+			wantErr: true,
+		},
+		{
+			name:    "Fractional seconds",
+			val:     "raw:24h,1500ms:1d",
+			wantErr: true,
+		},
+		{
+			name:    "Bad duration",
+			val:     "raw:forever",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRetention(tt.val)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, merrors.ErrIncorrectRetention)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestRetentionLevel(t *testing.T) {
+	policy, err := ParseRetention("raw:24h,1m:30d,1h:365d")
+	require.NoError(t, err)
+
+	now := time.Now()
+	level, ok := policy.Level(now.Add(-48*time.Hour), now)
+	require.True(t, ok)
+	assert.Equal(t, time.Minute, level.Resolution)
+
+	level, ok = policy.Level(now.Add(-60*24*time.Hour), now)
+	require.True(t, ok)
+	assert.Equal(t, time.Hour, level.Resolution)
+
+	level, ok = policy.Level(now.Add(-1000*24*time.Hour), now)
+	require.True(t, ok)
+	assert.Equal(t, time.Hour, level.Resolution)
+
+	_, ok = RetentionPolicy{Raw: time.Hour}.Level(now, now)
+	assert.False(t, ok)
+}
+
+func TestRollupSamples(t *testing.T) {
+	start := time.Unix(600, 0)
+	samples := []Sample{
+		{Time: start, Value: 3},
+		{Time: start.Add(10 * time.Second), Value: 1},
+		{Time: start.Add(50 * time.Second), Value: 2},
+		{Time: start.Add(2 * time.Minute), Value: 8},
+	}
+
+	minutes := RollupSamples(samples, time.Minute)
+	assert.Equal(t, []Rollup{
+		{Time: start, First: 3, Last: 2, Min: 1, Max: 3, Sum: 6, Count: 3},
+		{Time: start.Add(2 * time.Minute), First: 8, Last: 8, Min: 8, Max: 8, Sum: 8, Count: 1},
+	}, minutes)
+	assert.Equal(t, 2.0, minutes[0].Avg())
+
+	hours := MergeRollups(minutes, time.Hour)
+	assert.Equal(t, []Rollup{
+		{Time: time.Unix(0, 0), First: 3, Last: 8, Min: 1, Max: 8, Sum: 14, Count: 4},
+	}, hours)
+}
+
+func TestBucketStart(t *testing.T) {
+	assert.Equal(t, time.Unix(60, 0), BucketStart(time.Unix(119, 999), time.Minute))
+	assert.Equal(t, time.Unix(120, 0), BucketStart(time.Unix(120, 0), time.Minute))
+	assert.Equal(t, time.Unix(-60, 0), BucketStart(time.Unix(-1, 0), time.Minute))
+}
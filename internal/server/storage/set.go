@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"hash/fnv"
+	"math"
+	"math/bits"
+	"slices"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+)
+
+// SetPrecision — количество бит хеша, которые выбирают регистр HyperLogLog.
+// Стандартная ошибка оценки около 1.04/sqrt(2^SetPrecision), то есть ~0.8%.
+const SetPrecision = 14
+
+const setRegisters = 1 << SetPrecision
+
+// Set — значение метрики типа set: скетч HyperLogLog для оценки количества
+// уникальных значений. Сами значения не хранятся, скетчи объединяются
+// без потерь, поэтому одно множество можно собирать с нескольких агентов.
+type Set struct {
+	Registers []uint8 `json:"registers"`
+}
+
+// SetCollection — набор скетчей, сгруппированных по ключу серии (см. SeriesKey).
+type SetCollection map[string]Set
+
+// NewSet создает пустой скетч.
+func NewSet() Set {
+	return Set{Registers: make([]uint8, setRegisters)}
+}
+
+// hashMember возвращает 64-битный хеш значения. Хеш не зависит от процесса,
+// чтобы скетчи с разных агентов и серверов можно было объединять.
+func hashMember(member string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(member))
+	x := h.Sum64()
+
+	// финализатор splitmix64 перемешивает биты FNV, у которого плохо
+	// распределены старшие биты на коротких строках
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
+
+// Add добавляет значение в скетч.
+func (s *Set) Add(member string) {
+	if s.Registers == nil {
+		*s = NewSet()
+	}
+
+	x := hashMember(member)
+	idx := x >> (64 - SetPrecision)
+	// единица в младших битах ограничивает длину серии нулей
+	rest := x<<SetPrecision | 1<<(SetPrecision-1)
+	rank := uint8(bits.LeadingZeros64(rest)) + 1
+
+	if rank > s.Registers[idx] {
+		s.Registers[idx] = rank
+	}
+}
+
+// Validate проверяет размер скетча и значения регистров.
+func (s Set) Validate() error {
+	if len(s.Registers) != setRegisters {
+		return merrors.ErrIncorrectSet
+	}
+	for _, r := range s.Registers {
+		if r > 64-SetPrecision+1 {
+			return merrors.ErrIncorrectSet
+		}
+	}
+
+	return nil
+}
+
+// Copy возвращает копию скетча.
+func (s Set) Copy() Set {
+	s.Registers = slices.Clone(s.Registers)
+
+	return s
+}
+
+// Merge объединяет скетч с o. Пустой скетч принимает копию o.
+func (s *Set) Merge(o Set) error {
+	if o.Registers == nil {
+		return nil
+	}
+	if s.Registers == nil {
+		*s = o.Copy()
+		return nil
+	}
+	if len(s.Registers) != len(o.Registers) {
+		return merrors.ErrIncorrectSet
+	}
+
+	for i, r := range o.Registers {
+		s.Registers[i] = max(s.Registers[i], r)
+	}
+
+	return nil
+}
+
+// Count оценивает количество уникальных значений. Для небольших множеств,
+// пока есть пустые регистры, используется linear counting.
+func (s Set) Count() uint64 {
+	if len(s.Registers) == 0 {
+		return 0
+	}
+
+	m := float64(len(s.Registers))
+	var (
+		sum   float64
+		zeros int
+	)
+	for _, r := range s.Registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+
+	return uint64(math.Round(estimate))
+}
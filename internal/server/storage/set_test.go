@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetCount(t *testing.T) {
+	tests := []struct {
+		name   string
+		unique int
+	}{
+		{name: "Empty", unique: 0},
+		{name: "Small", unique: 10},
+		{name: "Medium", unique: 1000},
+		{name: "Large", unique: 100000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSet()
+			for i := range tt.unique {
+				member := "user-" + strconv.Itoa(i)
+				// повторы не меняют оценку
+				s.Add(member)
+				s.Add(member)
+			}
+
+			assert.InEpsilon(t, float64(tt.unique)+1, float64(s.Count())+1, 0.03)
+		})
+	}
+}
+
+func TestSetMerge(t *testing.T) {
+	a, b, all := NewSet(), NewSet(), NewSet()
+	for i := range 5000 {
+		member := "host-" + strconv.Itoa(i)
+		all.Add(member)
+		if i < 3000 {
+			a.Add(member)
+		}
+		if i >= 2000 {
+			b.Add(member)
+		}
+	}
+
+	var merged Set
+	require.NoError(t, merged.Merge(a))
+	require.NoError(t, merged.Merge(b))
+	assert.Equal(t, all, merged, "merge must equal the sketch of the union")
+	assert.NotEqual(t, all, a, "source must not change")
+
+	err := merged.Merge(Set{Registers: make([]uint8, 16)})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectSet)
+}
+
+func TestSetValidate(t *testing.T) {
+	assert.NoError(t, NewSet().Validate())
+	assert.ErrorIs(t, Set{Registers: make([]uint8, 16)}.Validate(), merrors.ErrIncorrectSet)
+
+	s := NewSet()
+	s.Registers[0] = 64
+	assert.ErrorIs(t, s.Validate(), merrors.ErrIncorrectSet)
+}
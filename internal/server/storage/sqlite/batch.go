@@ -0,0 +1,37 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// claimBatch забывает ключи пачек старше storage.BatchTTL и запоминает
+// ключ пачки id, примененной в момент t. Если пачка с этим ключом
+// уже применена, возвращает merrors.ErrDuplicateBatch.
+func claimBatch(ctx context.Context, tx *sql.Tx, id string, t time.Time) error {
+	_, err := tx.ExecContext(ctx, `DELETE FROM applied_batches WHERE applied_at < ?`,
+		t.Add(-storage.BatchTTL).UnixNano())
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO applied_batches (id, applied_at) VALUES (?, ?)
+	ON CONFLICT (id) DO NOTHING`, id, t.UnixNano())
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return merrors.ErrDuplicateBatch
+	}
+
+	return nil
+}
@@ -0,0 +1,124 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// metricTables — таблицы текущих значений по типам метрик.
+var metricTables = []struct {
+	mType string
+	table string
+}{
+	{mType: "gauge", table: "gauge"},
+	{mType: "counter", table: "counter"},
+	{mType: "histogram", table: "histogram"},
+	{mType: "summary", table: "summary"},
+	{mType: "set", table: "sets"},
+}
+
+func metricTable(mType string) (string, bool) {
+	for _, t := range metricTables {
+		if t.mType == mType {
+			return t.table, true
+		}
+	}
+
+	return "", false
+}
+
+// addUpdatedAt добавляет время последнего обновления в таблицы значений,
+// созданные до его появления. Существующие метрики считаются обновленными
+// в момент добавления колонки.
+func addUpdatedAt(ctx context.Context, db *sql.DB) error {
+	for _, t := range metricTables {
+		var n int
+		err := db.QueryRowContext(ctx, `SELECT count(*) FROM pragma_table_info(?) WHERE name = 'updated_at'`,
+			t.table).Scan(&n)
+		if err != nil {
+			return err
+		}
+		if n > 0 {
+			continue
+		}
+
+		_, err = db.ExecContext(ctx, fmt.Sprintf(`ALTER TABLE %[1]s ADD COLUMN updated_at integer not null default 0;
+		UPDATE %[1]s SET updated_at = ?;
+		CREATE INDEX IF NOT EXISTS %[1]s_updated_at_idx ON %[1]s (updated_at);`, t.table), time.Now().UnixNano())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s SQLite) DeleteMetric(ctx context.Context, mType string, name string) error {
+	table, ok := metricTable(mType)
+	if !ok {
+		return merrors.ErrIncorrectMetricType
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		res, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE name = ?`, table), name)
+		if err != nil {
+			return err
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if n == 0 {
+			return merrors.ErrNotFoundMetric
+		}
+
+		if mType == "gauge" || mType == "counter" {
+			for _, t := range []string{mType + "_history", mType + "_rollup"} {
+				if _, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE name = ?`, t), name); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s SQLite) DeleteStale(ctx context.Context, before time.Time) ([]storage.MetricRef, error) {
+	list := make([]storage.MetricRef, 0)
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		for _, t := range metricTables {
+			rows, err := tx.QueryContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE updated_at < ? RETURNING name`, t.table),
+				before.UnixNano())
+			if err != nil {
+				return err
+			}
+
+			for rows.Next() {
+				var name string
+				if err = rows.Scan(&name); err != nil {
+					rows.Close()
+					return err
+				}
+				list = append(list, storage.MetricRef{MType: t.mType, Name: name})
+			}
+			rows.Close()
+			if err = rows.Err(); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return list, nil
+}
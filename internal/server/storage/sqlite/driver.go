@@ -0,0 +1,8 @@
+package sqlite
+
+// Драйвер регистрируется под именем driverName. Остальной код пакета
+// работает через database/sql и от драйвера не зависит. Драйвер написан
+// на Go и не требует cgo.
+import _ "modernc.org/sqlite"
+
+const driverName = "sqlite"
@@ -0,0 +1,47 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// updateMetadata заменяет описания метрик из list.
+func updateMetadata(ctx context.Context, tx *sql.Tx, list storage.MetadataCollection) error {
+	req := `INSERT INTO metadata (name, unit, help, type, precision)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (name) DO UPDATE
+	SET unit = excluded.unit, help = excluded.help, type = excluded.type, precision = excluded.precision`
+
+	for name, meta := range list {
+		var precision sql.NullInt64
+		if meta.Precision != nil {
+			precision = sql.NullInt64{Int64: int64(*meta.Precision), Valid: true}
+		}
+
+		if _, err := tx.ExecContext(ctx, req, name, meta.Unit, meta.Help, meta.Type, precision); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scanMetadata читает описание из строки unit, help, type, precision.
+func scanMetadata(scan func(dest ...any) error, dest ...any) (storage.Metadata, error) {
+	var (
+		meta      storage.Metadata
+		precision sql.NullInt64
+	)
+	if err := scan(append(dest, &meta.Unit, &meta.Help, &meta.Type, &precision)...); err != nil {
+		return storage.Metadata{}, err
+	}
+
+	if precision.Valid {
+		p := int(precision.Int64)
+		meta.Precision = &p
+	}
+
+	return meta, nil
+}
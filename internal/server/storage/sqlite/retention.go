@@ -0,0 +1,238 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+var rollupTables = []struct {
+	history string
+	rollup  string
+}{
+	{history: "gauge_history", rollup: "gauge_rollup"},
+	{history: "counter_history", rollup: "counter_rollup"},
+}
+
+// readSamples читает сырые семплы всех серий за [start, end).
+func readSamples(ctx context.Context, tx *sql.Tx, table string, start, end time.Time) (map[string][]storage.Sample, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT name, created_at, CAST(value AS REAL) FROM %s
+	WHERE created_at >= ? AND created_at < ?
+	ORDER BY name, created_at`, table), start.UnixNano(), end.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make(map[string][]storage.Sample)
+	for rows.Next() {
+		var (
+			name   string
+			ns     int64
+			sample storage.Sample
+		)
+		if err = rows.Scan(&name, &ns, &sample.Value); err != nil {
+			return nil, err
+		}
+
+		sample.Time = time.Unix(0, ns)
+		list[name] = append(list[name], sample)
+	}
+
+	return list, rows.Err()
+}
+
+// scanRollup читает свертку из строки bucket, first, last, min, max, sum, count.
+func scanRollup(scan func(dest ...any) error, dest ...any) (storage.Rollup, error) {
+	var (
+		r     storage.Rollup
+		ns    int64
+		count int64
+	)
+	err := scan(append(dest, &ns, &r.First, &r.Last, &r.Min, &r.Max, &r.Sum, &count)...)
+	if err != nil {
+		return storage.Rollup{}, err
+	}
+
+	r.Time = time.Unix(0, ns)
+	r.Count = uint64(count)
+
+	return r, nil
+}
+
+// readRollups читает свертки шага resolution всех серий за [start, end).
+func readRollups(
+	ctx context.Context, tx *sql.Tx, table string, resolution time.Duration, start, end time.Time,
+) (map[string][]storage.Rollup, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`SELECT name, bucket, first, last, min, max, sum, count FROM %s
+	WHERE resolution = ? AND bucket >= ? AND bucket < ?
+	ORDER BY name, bucket`, table), int64(resolution), start.UnixNano(), end.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make(map[string][]storage.Rollup)
+	for rows.Next() {
+		var name string
+		r, err := scanRollup(rows.Scan, &name)
+		if err != nil {
+			return nil, err
+		}
+
+		list[name] = append(list[name], r)
+	}
+
+	return list, rows.Err()
+}
+
+// writeRollups сохраняет свертки, объединяя их с уже сохраненными
+// за те же интервалы.
+func writeRollups(
+	ctx context.Context, tx *sql.Tx, table string, resolution time.Duration, list map[string][]storage.Rollup,
+) error {
+	req := fmt.Sprintf(`INSERT INTO %[1]s (name, resolution, bucket, first, last, min, max, sum, count)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (name, resolution, bucket) DO UPDATE
+	SET last = excluded.last, min = min(%[1]s.min, excluded.min), max = max(%[1]s.max, excluded.max),
+	sum = %[1]s.sum + excluded.sum, count = %[1]s.count + excluded.count`, table)
+
+	for name, rollups := range list {
+		for _, r := range rollups {
+			_, err := tx.ExecContext(ctx, req, name, int64(resolution), r.Time.UnixNano(),
+				r.First, r.Last, r.Min, r.Max, r.Sum, int64(r.Count))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// rollupLevel сворачивает историю для уровня policy.Levels[i]
+// от сохраненной отметки до начала текущего интервала.
+func rollupLevel(ctx context.Context, tx *sql.Tx, policy storage.RetentionPolicy, i int, now time.Time) error {
+	level := policy.Levels[i]
+
+	// без отметки история сворачивается с начала эпохи
+	var ns int64
+	err := tx.QueryRowContext(ctx, `SELECT rolled_until FROM rollup_watermark WHERE resolution = ?`,
+		int64(level.Resolution)).Scan(&ns)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+
+	start := time.Unix(0, ns)
+
+	end := storage.BucketStart(now, level.Resolution)
+	if !start.Before(end) {
+		return nil
+	}
+
+	for _, table := range rollupTables {
+		list := make(map[string][]storage.Rollup)
+		if i == 0 {
+			samples, err := readSamples(ctx, tx, table.history, start, end)
+			if err != nil {
+				return err
+			}
+			for name, s := range samples {
+				list[name] = storage.RollupSamples(s, level.Resolution)
+			}
+		} else {
+			src, err := readRollups(ctx, tx, table.rollup, policy.Levels[i-1].Resolution, start, end)
+			if err != nil {
+				return err
+			}
+			for name, r := range src {
+				list[name] = storage.MergeRollups(r, level.Resolution)
+			}
+		}
+
+		if err = writeRollups(ctx, tx, table.rollup, level.Resolution, list); err != nil {
+			return err
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO rollup_watermark (resolution, rolled_until) VALUES (?, ?)
+	ON CONFLICT (resolution) DO UPDATE SET rolled_until = excluded.rolled_until`,
+		int64(level.Resolution), end.UnixNano())
+
+	return err
+}
+
+// ApplyRetention досворачивает историю до текущих границ интервалов
+// и удаляет семплы и свертки старше сроков хранения policy.
+func (s SQLite) ApplyRetention(ctx context.Context, policy storage.RetentionPolicy, now time.Time) error {
+	if !policy.Enabled() {
+		return nil
+	}
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for i := range policy.Levels {
+			if err := rollupLevel(ctx, tx, policy, i, now); err != nil {
+				return err
+			}
+		}
+
+		for _, table := range rollupTables {
+			_, err := tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE created_at < ?`, table.history),
+				now.Add(-policy.Raw).UnixNano())
+			if err != nil {
+				return err
+			}
+
+			for _, level := range policy.Levels {
+				_, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %s WHERE resolution = ? AND bucket < ?`, table.rollup),
+					int64(level.Resolution), now.Add(-level.Retention).UnixNano())
+				if err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+func (s SQLite) getRollups(
+	ctx context.Context, table string, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	rows, err := s.db.QueryContext(ctx, fmt.Sprintf(`SELECT bucket, first, last, min, max, sum, count FROM %s
+	WHERE name = ? AND resolution = ? AND bucket BETWEEN ? AND ?
+	ORDER BY bucket`, table),
+		name, int64(resolution), storage.BucketStart(from, resolution).UnixNano(), to.UnixNano())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]storage.Rollup, 0)
+	for rows.Next() {
+		r, err := scanRollup(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+
+		list = append(list, r)
+	}
+
+	return list, rows.Err()
+}
+
+func (s SQLite) GetGaugeRollups(
+	ctx context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	return s.getRollups(ctx, "gauge_rollup", name, resolution, from, to)
+}
+
+func (s SQLite) GetCounterRollups(
+	ctx context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	return s.getRollups(ctx, "counter_rollup", name, resolution, from, to)
+}
@@ -0,0 +1,791 @@
+// Package sqlite — хранилище метрик в файле SQLite для небольших
+// установок без сервера postgres. Семантика обновлений та же, что
+// у postgres.Postgres.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+)
+
+// Scheme — префикс DSN, по которому выбирается это хранилище,
+// например sqlite://metrics.db.
+const Scheme = "sqlite://"
+
+// IsDSN сообщает, указывает ли dsn на файл SQLite.
+func IsDSN(dsn string) bool {
+	return strings.HasPrefix(dsn, Scheme)
+}
+
+type SQLite struct {
+	db *sql.DB
+}
+
+func NewSQLite(ctx context.Context, config config.ServerConfig) (*SQLite, error) {
+	db, err := sql.Open(driverName, strings.TrimPrefix(config.DatabaseDSN, Scheme))
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite допускает одного писателя; с одним соединением запросы
+	// ждут друг друга в пуле, а не получают SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	// время и шаги сверток хранятся в наносекундах, чтобы сравнивать числа
+	_, err = db.ExecContext(ctx, `PRAGMA journal_mode = WAL;
+	create table if not exists gauge(
+	name text not null primary key,
+	metric text not null,
+	labels text not null default '{}',
+	value real not null
+	);
+	create index if not exists gauge_metric_idx on gauge (metric);
+	create table if not exists counter(
+	name text not null primary key,
+	metric text not null,
+	labels text not null default '{}',
+	value integer not null
+	);
+	create index if not exists counter_metric_idx on counter (metric);
+	create table if not exists histogram(
+	name text not null primary key,
+	metric text not null,
+	labels text not null default '{}',
+	buckets text not null,
+	counts text not null,
+	sum real not null,
+	count integer not null
+	);
+	create table if not exists summary(
+	name text not null primary key,
+	metric text not null,
+	labels text not null default '{}',
+	window_ns integer not null,
+	observations text not null default '[]',
+	sum real not null,
+	min real not null,
+	max real not null,
+	count integer not null
+	);
+	create table if not exists sets(
+	name text not null primary key,
+	metric text not null,
+	labels text not null default '{}',
+	registers blob not null
+	);
+	create table if not exists gauge_history(
+	name text not null,
+	value real not null,
+	created_at integer not null
+	);
+	create index if not exists gauge_history_name_created_at_idx
+	on gauge_history (name, created_at);
+	create table if not exists counter_history(
+	name text not null,
+	value integer not null,
+	created_at integer not null
+	);
+	create index if not exists counter_history_name_created_at_idx
+	on counter_history (name, created_at);
+	create table if not exists gauge_rollup(
+	name text not null,
+	resolution integer not null,
+	bucket integer not null,
+	first real not null,
+	last real not null,
+	min real not null,
+	max real not null,
+	sum real not null,
+	count integer not null,
+	primary key (name, resolution, bucket)
+	);
+	create table if not exists counter_rollup(
+	name text not null,
+	resolution integer not null,
+	bucket integer not null,
+	first real not null,
+	last real not null,
+	min real not null,
+	max real not null,
+	sum real not null,
+	count integer not null,
+	primary key (name, resolution, bucket)
+	);
+	create table if not exists rollup_watermark(
+	resolution integer not null primary key,
+	rolled_until integer not null
+	);
+	create table if not exists metadata(
+	name text not null primary key,
+	unit text not null default '',
+	help text not null default '',
+	type text not null default '',
+	precision integer
+	);
+	create table if not exists applied_batches(
+	id text not null primary key,
+	applied_at integer not null
+	);
+	create index if not exists applied_batches_applied_at_idx on applied_batches (applied_at);`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	if err = addUpdatedAt(ctx, db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &SQLite{
+		db: db,
+	}, nil
+}
+
+// seriesColumns возвращает имя метрики и метки в JSON для ключа серии.
+func seriesColumns(key string) (string, string) {
+	name, labels, err := storage.ParseSeriesKey(key)
+	if err != nil {
+		return key, "{}"
+	}
+
+	b, err := json.Marshal(labels)
+	if err != nil {
+		return key, "{}"
+	}
+
+	return name, string(b)
+}
+
+// notFound заменяет sql.ErrNoRows на merrors.ErrNotFoundMetric.
+func notFound(err error) error {
+	if errors.Is(err, sql.ErrNoRows) {
+		return merrors.ErrNotFoundMetric
+	}
+
+	return err
+}
+
+// inTx выполняет fn в транзакции и фиксирует ее, если fn не вернула ошибку.
+func (s SQLite) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func updateCounter(
+	ctx context.Context, tx *sql.Tx, name string, value storage.Counter, t time.Time,
+) (storage.Counter, error) {
+	metric, labels := seriesColumns(name)
+
+	var result storage.Counter
+	err := tx.QueryRowContext(ctx, `INSERT INTO counter (name, metric, labels, value, updated_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (name) DO UPDATE
+	SET value = counter.value + excluded.value, updated_at = excluded.updated_at
+	RETURNING value`, name, metric, labels, value, t.UnixNano()).Scan(&result)
+	if err != nil {
+		logger.Log.Error("error while scan setted counter value")
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO counter_history (name, value, created_at)
+	VALUES (?, ?, ?)`, name, value, t.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+func updateGauge(
+	ctx context.Context, tx *sql.Tx, name string, value storage.Gauge, t time.Time,
+) (storage.Gauge, error) {
+	metric, labels := seriesColumns(name)
+
+	var result storage.Gauge
+	err := tx.QueryRowContext(ctx, `INSERT INTO gauge (name, metric, labels, value, updated_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (name) DO UPDATE
+	SET value = excluded.value, updated_at = excluded.updated_at
+	RETURNING value`, name, metric, labels, value, t.UnixNano()).Scan(&result)
+	if err != nil {
+		logger.Log.Error("error while scan setted gauge value")
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, `INSERT INTO gauge_history (name, value, created_at)
+	VALUES (?, ?, ?)`, name, value, t.UnixNano())
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+func (s SQLite) UpdateCounter(ctx context.Context, name string, value storage.Counter) (storage.Counter, error) {
+	var result storage.Counter
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = updateCounter(ctx, tx, name, value, time.Now())
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+func (s SQLite) UpdateGauge(ctx context.Context, name string, value storage.Gauge) (storage.Gauge, error) {
+	var result storage.Gauge
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = updateGauge(ctx, tx, name, value, time.Now())
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return result, nil
+}
+
+// scanHistogram читает колонки buckets, counts, sum, count после колонок dest.
+// Границы и количества хранятся в JSON.
+func scanHistogram(scan func(dest ...any) error, dest ...any) (storage.Histogram, error) {
+	var (
+		h               storage.Histogram
+		buckets, counts string
+	)
+	err := scan(append(dest, &buckets, &counts, &h.Sum, &h.Count)...)
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	if err = json.Unmarshal([]byte(buckets), &h.Buckets); err != nil {
+		return storage.Histogram{}, err
+	}
+	if err = json.Unmarshal([]byte(counts), &h.Counts); err != nil {
+		return storage.Histogram{}, err
+	}
+
+	return h, nil
+}
+
+// updateHistogram прибавляет value к гистограмме внутри транзакции.
+// Писатель в SQLite один, поэтому чтение и запись строки не пересекаются
+// с другими обновлениями.
+func updateHistogram(
+	ctx context.Context, tx *sql.Tx, name string, value storage.Histogram,
+) (storage.Histogram, error) {
+	h, err := scanHistogram(tx.QueryRowContext(ctx, `SELECT buckets, counts, sum, count
+	FROM histogram WHERE name = ?`, name).Scan)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return storage.Histogram{}, err
+	}
+
+	if err = h.Merge(value); err != nil {
+		return storage.Histogram{}, err
+	}
+
+	buckets, err := json.Marshal(h.Buckets)
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+	counts, err := json.Marshal(h.Counts)
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	metric, labels := seriesColumns(name)
+	_, err = tx.ExecContext(ctx, `INSERT INTO histogram
+	(name, metric, labels, buckets, counts, sum, count, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (name) DO UPDATE
+	SET counts = excluded.counts, sum = excluded.sum, count = excluded.count,
+	updated_at = excluded.updated_at`,
+		name, metric, labels, string(buckets), string(counts), h.Sum, h.Count, time.Now().UnixNano())
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	return h, nil
+}
+
+func (s SQLite) UpdateHistogram(
+	ctx context.Context, name string, value storage.Histogram,
+) (storage.Histogram, error) {
+	var result storage.Histogram
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = updateHistogram(ctx, tx, name, value)
+		return err
+	})
+
+	if err != nil {
+		return storage.Histogram{}, err
+	}
+
+	return result, nil
+}
+
+// scanSummary читает колонки window_ns, observations, sum, min, max, count
+// после колонок dest.
+func scanSummary(scan func(dest ...any) error, dest ...any) (storage.Summary, error) {
+	var (
+		sm           storage.Summary
+		window       int64
+		observations string
+	)
+	err := scan(append(dest, &window, &observations, &sm.Sum, &sm.Min, &sm.Max, &sm.Count)...)
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	if err = json.Unmarshal([]byte(observations), &sm.Observations); err != nil {
+		return storage.Summary{}, err
+	}
+	sm.Window = time.Duration(window)
+
+	return sm, nil
+}
+
+// updateSummary добавляет value к summary внутри транзакции, как updateHistogram.
+func updateSummary(
+	ctx context.Context, tx *sql.Tx, name string, value storage.Summary,
+) (storage.Summary, error) {
+	sm, err := scanSummary(tx.QueryRowContext(ctx, `SELECT window_ns, observations, sum, min, max, count
+	FROM summary WHERE name = ?`, name).Scan)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return storage.Summary{}, err
+	}
+
+	sm.Merge(value)
+
+	observations, err := json.Marshal(sm.Observations)
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	metric, labels := seriesColumns(name)
+	_, err = tx.ExecContext(ctx, `INSERT INTO summary
+	(name, metric, labels, window_ns, observations, sum, min, max, count, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	ON CONFLICT (name) DO UPDATE
+	SET window_ns = excluded.window_ns, observations = excluded.observations,
+	sum = excluded.sum, min = excluded.min, max = excluded.max, count = excluded.count,
+	updated_at = excluded.updated_at`,
+		name, metric, labels, int64(sm.Window), string(observations), sm.Sum, sm.Min, sm.Max, sm.Count,
+		time.Now().UnixNano())
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	return sm, nil
+}
+
+func (s SQLite) UpdateSummary(
+	ctx context.Context, name string, value storage.Summary,
+) (storage.Summary, error) {
+	var result storage.Summary
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = updateSummary(ctx, tx, name, value)
+		return err
+	})
+
+	if err != nil {
+		return storage.Summary{}, err
+	}
+
+	return result, nil
+}
+
+// updateSet объединяет value со скетчем внутри транзакции, как updateHistogram.
+func updateSet(
+	ctx context.Context, tx *sql.Tx, name string, value storage.Set,
+) (storage.Set, error) {
+	var st storage.Set
+	err := tx.QueryRowContext(ctx, `SELECT registers FROM sets WHERE name = ?`, name).
+		Scan(&st.Registers)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return storage.Set{}, err
+	}
+
+	if err = st.Merge(value); err != nil {
+		return storage.Set{}, err
+	}
+
+	metric, labels := seriesColumns(name)
+	_, err = tx.ExecContext(ctx, `INSERT INTO sets (name, metric, labels, registers, updated_at)
+	VALUES (?, ?, ?, ?, ?)
+	ON CONFLICT (name) DO UPDATE
+	SET registers = excluded.registers, updated_at = excluded.updated_at`,
+		name, metric, labels, st.Registers, time.Now().UnixNano())
+	if err != nil {
+		return storage.Set{}, err
+	}
+
+	return st, nil
+}
+
+func (s SQLite) UpdateSet(
+	ctx context.Context, name string, value storage.Set,
+) (storage.Set, error) {
+	var result storage.Set
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = updateSet(ctx, tx, name, value)
+		return err
+	})
+
+	if err != nil {
+		return storage.Set{}, err
+	}
+
+	return result, nil
+}
+
+// UpdateMany применяет все значения в одной транзакции: при ошибке
+// не сохраняется ни одно из них.
+func (s SQLite) UpdateMany(ctx context.Context, list storage.Database) error {
+	now := time.Now()
+
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		if list.BatchID != "" {
+			if err := claimBatch(ctx, tx, list.BatchID, now); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range list.Counter {
+			if _, err := updateCounter(ctx, tx, key, value, now); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range list.Gauge {
+			if _, err := updateGauge(ctx, tx, key, value, now); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range list.Histogram {
+			if _, err := updateHistogram(ctx, tx, key, value); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range list.Summary {
+			if _, err := updateSummary(ctx, tx, key, value); err != nil {
+				return err
+			}
+		}
+
+		for key, value := range list.Set {
+			if _, err := updateSet(ctx, tx, key, value); err != nil {
+				return err
+			}
+		}
+
+		return updateMetadata(ctx, tx, list.Metadata)
+	})
+}
+
+func (s SQLite) GetGaugeByName(ctx context.Context, name string) (storage.Gauge, error) {
+	var val storage.Gauge
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM gauge WHERE name = ?`, name).Scan(&val)
+	if err != nil {
+		return 0, notFound(err)
+	}
+
+	return val, nil
+}
+
+func (s SQLite) GetCounterByName(ctx context.Context, name string) (storage.Counter, error) {
+	var val storage.Counter
+	err := s.db.QueryRowContext(ctx, `SELECT value FROM counter WHERE name = ?`, name).Scan(&val)
+	if err != nil {
+		return 0, notFound(err)
+	}
+
+	return val, nil
+}
+
+func (s SQLite) GetHistogramByName(ctx context.Context, name string) (storage.Histogram, error) {
+	h, err := scanHistogram(s.db.QueryRowContext(ctx, `SELECT buckets, counts, sum, count
+	FROM histogram WHERE name = ?`, name).Scan)
+	if err != nil {
+		return storage.Histogram{}, notFound(err)
+	}
+
+	return h, nil
+}
+
+func (s SQLite) GetSummaryByName(ctx context.Context, name string) (storage.Summary, error) {
+	sm, err := scanSummary(s.db.QueryRowContext(ctx, `SELECT window_ns, observations, sum, min, max, count
+	FROM summary WHERE name = ?`, name).Scan)
+	if err != nil {
+		return storage.Summary{}, notFound(err)
+	}
+
+	return sm, nil
+}
+
+func (s SQLite) GetSetByName(ctx context.Context, name string) (storage.Set, error) {
+	var st storage.Set
+	err := s.db.QueryRowContext(ctx, `SELECT registers FROM sets WHERE name = ?`, name).
+		Scan(&st.Registers)
+	if err != nil {
+		return storage.Set{}, notFound(err)
+	}
+
+	return st, nil
+}
+
+func (s SQLite) getHistory(
+	ctx context.Context, req string, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	rows, err := s.db.QueryContext(ctx, req, name, from.UnixNano(), to.UnixNano())
+	if err != nil {
+		logger.Log.Error("error while sending request to db")
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]storage.Sample, 0)
+	for rows.Next() {
+		var (
+			sample storage.Sample
+			ns     int64
+		)
+		if err = rows.Scan(&ns, &sample.Value); err != nil {
+			return nil, err
+		}
+
+		sample.Time = time.Unix(0, ns)
+		list = append(list, sample)
+	}
+
+	return list, rows.Err()
+}
+
+func (s SQLite) GetGaugeHistory(
+	ctx context.Context, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	req := `SELECT created_at, value FROM gauge_history
+	WHERE name = ? AND created_at BETWEEN ? AND ?
+	ORDER BY created_at`
+
+	return s.getHistory(ctx, req, name, from, to)
+}
+
+func (s SQLite) GetCounterHistory(
+	ctx context.Context, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	req := `SELECT created_at, CAST(value AS REAL) FROM counter_history
+	WHERE name = ? AND created_at BETWEEN ? AND ?
+	ORDER BY created_at`
+
+	return s.getHistory(ctx, req, name, from, to)
+}
+
+// FindSeries выбирает серии по имени метрики, метки проверяются после чтения.
+func (s SQLite) FindSeries(
+	ctx context.Context, mType string, name string, matchers []storage.Matcher,
+) ([]storage.Series, error) {
+	var req string
+	switch mType {
+	case "gauge":
+		req = `SELECT name, value FROM gauge WHERE metric = ?`
+	case "counter":
+		req = `SELECT name, CAST(value AS REAL) FROM counter WHERE metric = ?`
+	default:
+		return nil, merrors.ErrIncorrectMetricType
+	}
+
+	rows, err := s.db.QueryContext(ctx, req, name)
+	if err != nil {
+		logger.Log.Error("error while sending request to db")
+		return nil, err
+	}
+	defer rows.Close()
+
+	list := make([]storage.Series, 0)
+	for rows.Next() {
+		var series storage.Series
+		if err = rows.Scan(&series.Key, &series.Value); err != nil {
+			return nil, err
+		}
+
+		series.Name, series.Labels, err = storage.ParseSeriesKey(series.Key)
+		if err != nil || !series.Labels.Match(matchers) {
+			continue
+		}
+
+		list = append(list, series)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Key < list[j].Key
+	})
+
+	return list, nil
+}
+
+func (s SQLite) GetAll(ctx context.Context) (storage.Database, error) {
+	all := storage.Database{
+		Gauge:     make(storage.GaugeCollection),
+		Counter:   make(storage.CounterCollection),
+		Histogram: make(storage.HistogramCollection),
+		Summary:   make(storage.SummaryCollection),
+		Set:       make(storage.SetCollection),
+		Metadata:  make(storage.MetadataCollection),
+	}
+
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		err := scanAll(ctx, tx, `SELECT name, value FROM gauge`, func(scan func(dest ...any) error) error {
+			var (
+				name  string
+				value storage.Gauge
+			)
+			if err := scan(&name, &value); err != nil {
+				return err
+			}
+			all.Gauge[name] = value
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = scanAll(ctx, tx, `SELECT name, value FROM counter`, func(scan func(dest ...any) error) error {
+			var (
+				name  string
+				value storage.Counter
+			)
+			if err := scan(&name, &value); err != nil {
+				return err
+			}
+			all.Counter[name] = value
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		err = scanAll(ctx, tx, `SELECT name, buckets, counts, sum, count FROM histogram`,
+			func(scan func(dest ...any) error) error {
+				var name string
+				h, err := scanHistogram(scan, &name)
+				if err != nil {
+					return err
+				}
+				all.Histogram[name] = h
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+
+		err = scanAll(ctx, tx, `SELECT name, window_ns, observations, sum, min, max, count FROM summary`,
+			func(scan func(dest ...any) error) error {
+				var name string
+				sm, err := scanSummary(scan, &name)
+				if err != nil {
+					return err
+				}
+				all.Summary[name] = sm
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+
+		err = scanAll(ctx, tx, `SELECT name, registers FROM sets`, func(scan func(dest ...any) error) error {
+			var (
+				name string
+				st   storage.Set
+			)
+			if err := scan(&name, &st.Registers); err != nil {
+				return err
+			}
+			all.Set[name] = st
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		return scanAll(ctx, tx, `SELECT name, unit, help, type, precision FROM metadata`,
+			func(scan func(dest ...any) error) error {
+				var name string
+				meta, err := scanMetadata(scan, &name)
+				if err != nil {
+					return err
+				}
+				all.Metadata[name] = meta
+				return nil
+			})
+	})
+
+	if err != nil {
+		logger.Log.Error(err.Error())
+		return storage.Database{}, err
+	}
+
+	return all, nil
+}
+
+// scanAll выполняет запрос и вызывает fn для каждой строки.
+func scanAll(ctx context.Context, tx *sql.Tx, req string, fn func(scan func(dest ...any) error) error) error {
+	rows, err := tx.QueryContext(ctx, req)
+	if err != nil {
+		logger.Log.Error("error while sending request to db")
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err = fn(rows.Scan); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+func (s SQLite) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+func (s SQLite) Close() {
+	if err := s.db.Close(); err != nil {
+		logger.Log.Error("error while closing sqlite")
+	}
+}
@@ -0,0 +1,513 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getSQLite(t *testing.T) *SQLite {
+	cfg := config.ServerConfig{
+		DatabaseDSN: Scheme + filepath.Join(t.TempDir(), "metrics.db"),
+	}
+
+	db, err := NewSQLite(context.Background(), cfg)
+	require.NoError(t, err)
+	t.Cleanup(db.Close)
+
+	return db
+}
+
+func TestIsDSN(t *testing.T) {
+	assert.True(t, IsDSN("sqlite:///var/lib/metrics.db"))
+	assert.True(t, IsDSN("sqlite://metrics.db"))
+	assert.False(t, IsDSN("postgresql://localhost/metrics"))
+	assert.False(t, IsDSN(""))
+}
+
+func TestReopen(t *testing.T) {
+	ctx := context.Background()
+	cfg := config.ServerConfig{
+		DatabaseDSN: Scheme + filepath.Join(t.TempDir(), "metrics.db"),
+	}
+
+	db, err := NewSQLite(ctx, cfg)
+	require.NoError(t, err)
+	_, err = db.UpdateCounter(ctx, "counter", 2)
+	require.NoError(t, err)
+	db.Close()
+
+	db, err = NewSQLite(ctx, cfg)
+	require.NoError(t, err)
+	defer db.Close()
+
+	got, err := db.GetCounterByName(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(2), got)
+}
+
+func TestGetUpdateGauge(t *testing.T) {
+	db := getSQLite(t)
+
+	tests := []struct {
+		name    string
+		key     string
+		value   storage.Gauge
+		wantErr bool
+	}{
+		{
+			name:  "Set 42.42",
+			key:   "gauge1",
+			value: 42.42,
+		},
+		{
+			name:  "Set 0.0",
+			key:   "gauge2",
+			value: 0.0,
+		},
+		{
+			name:  "Overwrite",
+			key:   "gauge1",
+			value: -1.5,
+		},
+		{
+			name:    "Read unknown key",
+			key:     "missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !tt.wantErr {
+				got, err := db.UpdateGauge(ctx, tt.key, tt.value)
+				require.NoError(t, err)
+				assert.Equal(t, tt.value, got)
+			}
+
+			got, err := db.GetGaugeByName(ctx, tt.key)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.value, got)
+			}
+		})
+	}
+}
+
+func TestUpdateCounter(t *testing.T) {
+	db := getSQLite(t)
+
+	tests := []struct {
+		name    string
+		key     string
+		value   storage.Counter
+		want    storage.Counter
+		wantErr bool
+	}{
+		{
+			name:  "Set 42",
+			key:   "counter1",
+			value: 42,
+			want:  42,
+		},
+		{
+			name:  "Add 1",
+			key:   "counter1",
+			value: 1,
+			want:  43,
+		},
+		{
+			name:  "Add negative",
+			key:   "counter1",
+			value: -3,
+			want:  40,
+		},
+		{
+			name:    "Read unknown key",
+			key:     "missing",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if !tt.wantErr {
+				got, err := db.UpdateCounter(ctx, tt.key, tt.value)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+
+			got, err := db.GetCounterByName(ctx, tt.key)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestUpdateMany(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	_, err := db.UpdateCounter(ctx, "counter1", 1)
+	require.NoError(t, err)
+
+	precision := 0
+	metadata := storage.MetadataCollection{
+		"counter2": {Help: "requests"},
+		"gauge1":   {Unit: "bytes", Type: "gauge", Precision: &precision},
+	}
+	err = db.UpdateMany(ctx, storage.Database{
+		Counter:  storage.CounterCollection{"counter1": 42, `counter2{host="a"}`: 0},
+		Gauge:    storage.GaugeCollection{"gauge1": 42.42},
+		Metadata: metadata,
+	})
+	require.NoError(t, err)
+
+	got, err := db.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Database{
+		Counter:   storage.CounterCollection{"counter1": 43, `counter2{host="a"}`: 0},
+		Gauge:     storage.GaugeCollection{"gauge1": 42.42},
+		Histogram: storage.HistogramCollection{},
+		Summary:   storage.SummaryCollection{},
+		Set:       storage.SetCollection{},
+		Metadata:  metadata,
+	}, got)
+}
+
+func TestUpdateManyBatch(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	batch := storage.Database{Counter: storage.CounterCollection{"PollCount": 1}, BatchID: "batch-1"}
+	require.NoError(t, db.UpdateMany(ctx, batch))
+	assert.ErrorIs(t, db.UpdateMany(ctx, batch), merrors.ErrDuplicateBatch)
+
+	batch.BatchID = "batch-2"
+	require.NoError(t, db.UpdateMany(ctx, batch))
+
+	val, err := db.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(2), val)
+}
+
+func TestUpdateManyRollback(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	_, err := db.UpdateHistogram(ctx, "latency", storage.Histogram{
+		Buckets: []float64{1}, Counts: []uint64{1, 0}, Count: 1,
+	})
+	require.NoError(t, err)
+
+	err = db.UpdateMany(ctx, storage.Database{
+		Counter: storage.CounterCollection{"counter": 1},
+		Histogram: storage.HistogramCollection{"latency": {
+			Buckets: []float64{2}, Counts: []uint64{1, 0}, Count: 1,
+		}},
+	})
+	require.ErrorIs(t, err, merrors.ErrIncorrectHistogramBuckets)
+
+	_, err = db.GetCounterByName(ctx, "counter")
+	assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+
+	history, err := db.GetCounterHistory(ctx, "counter", time.Time{}, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, history)
+}
+
+func TestHistory(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	from := time.Now()
+	_, err := db.UpdateGauge(ctx, "gauge1", 1.5)
+	require.NoError(t, err)
+	_, err = db.UpdateCounter(ctx, "counter1", 2)
+	require.NoError(t, err)
+	err = db.UpdateMany(ctx, storage.Database{
+		Gauge:   storage.GaugeCollection{"gauge1": 2.5},
+		Counter: storage.CounterCollection{"counter1": 3},
+	})
+	require.NoError(t, err)
+	to := time.Now()
+
+	gauges, err := db.GetGaugeHistory(ctx, "gauge1", from, to)
+	require.NoError(t, err)
+	require.Len(t, gauges, 2)
+	assert.Equal(t, 1.5, gauges[0].Value)
+	assert.Equal(t, 2.5, gauges[1].Value)
+
+	counters, err := db.GetCounterHistory(ctx, "counter1", from, to)
+	require.NoError(t, err)
+	require.Len(t, counters, 2)
+	assert.Equal(t, 2.0, counters[0].Value)
+	assert.Equal(t, 3.0, counters[1].Value)
+
+	before, err := db.GetGaugeHistory(ctx, "gauge1", from.Add(-time.Hour), from.Add(-time.Minute))
+	require.NoError(t, err)
+	assert.Empty(t, before)
+
+	missing, err := db.GetGaugeHistory(ctx, "missing", from, to)
+	require.NoError(t, err)
+	assert.Empty(t, missing)
+}
+
+func TestFindSeries(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	err := db.UpdateMany(ctx, storage.Database{
+		Gauge: storage.GaugeCollection{
+			"cpu":                          1,
+			`cpu{host="web1",region="eu"}`: 2,
+			`cpu{host="web2",region="us"}`: 3,
+		},
+		Counter: storage.CounterCollection{`cpu{host="web1"}`: 5},
+	})
+	require.NoError(t, err)
+
+	all, err := db.FindSeries(ctx, "gauge", "cpu", nil)
+	require.NoError(t, err)
+	require.Len(t, all, 3)
+	assert.Equal(t, "cpu", all[0].Key)
+
+	host, err := storage.NewMatcher(storage.MatchEqual, "host", "web1")
+	require.NoError(t, err)
+	region, err := storage.NewMatcher(storage.MatchNotRegexp, "region", "u.")
+	require.NoError(t, err)
+
+	found, err := db.FindSeries(ctx, "gauge", "cpu", []storage.Matcher{host, region})
+	require.NoError(t, err)
+	require.Len(t, found, 1)
+	assert.Equal(t, storage.Labels{"host": "web1", "region": "eu"}, found[0].Labels)
+	assert.Equal(t, 2.0, found[0].Value)
+
+	counters, err := db.FindSeries(ctx, "counter", "cpu", []storage.Matcher{host})
+	require.NoError(t, err)
+	require.Len(t, counters, 1)
+	assert.Equal(t, 5.0, counters[0].Value)
+
+	_, err = db.FindSeries(ctx, "histogram", "cpu", nil)
+	assert.ErrorIs(t, err, merrors.ErrIncorrectMetricType)
+}
+
+func TestHistogram(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	value := storage.Histogram{Buckets: []float64{1, 2}, Counts: []uint64{1, 0, 1}, Sum: 3.5, Count: 2}
+	got, err := db.UpdateHistogram(ctx, `latency{route="/"}`, value)
+	require.NoError(t, err)
+	assert.Equal(t, value, got)
+
+	err = db.UpdateMany(ctx, storage.Database{
+		Histogram: storage.HistogramCollection{`latency{route="/"}`: value},
+	})
+	require.NoError(t, err)
+
+	got, err = db.GetHistogramByName(ctx, `latency{route="/"}`)
+	require.NoError(t, err)
+	assert.Equal(t, []uint64{2, 0, 2}, got.Counts)
+	assert.Equal(t, uint64(4), got.Count)
+
+	_, err = db.UpdateHistogram(ctx, `latency{route="/"}`, storage.Histogram{
+		Buckets: []float64{5}, Counts: []uint64{1, 0}, Count: 1,
+	})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectHistogramBuckets)
+
+	all, err := db.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all.Histogram, 1)
+}
+
+func TestSummary(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	value := storage.NewSummary(time.Minute)
+	value.Observe(2, time.Now())
+
+	got, err := db.UpdateSummary(ctx, `rpc{method="get"}`, value)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), got.Count)
+	assert.Equal(t, time.Minute, got.Window)
+
+	err = db.UpdateMany(ctx, storage.Database{
+		Summary: storage.SummaryCollection{`rpc{method="get"}`: value},
+	})
+	require.NoError(t, err)
+
+	got, err = db.GetSummaryByName(ctx, `rpc{method="get"}`)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.Count)
+	assert.Len(t, got.Observations, 2)
+	assert.InDelta(t, 2.0, got.Quantile(0.5, time.Now()), 1e-9)
+
+	all, err := db.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all.Summary, 1)
+}
+
+func TestSet(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	first, second := storage.NewSet(), storage.NewSet()
+	first.Add("alice")
+	second.Add("bob")
+
+	got, err := db.UpdateSet(ctx, `users{region="eu"}`, first)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), got.Count())
+
+	err = db.UpdateMany(ctx, storage.Database{
+		Set: storage.SetCollection{`users{region="eu"}`: second},
+	})
+	require.NoError(t, err)
+
+	got, err = db.GetSetByName(ctx, `users{region="eu"}`)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(2), got.Count())
+
+	_, err = db.UpdateSet(ctx, `users{region="eu"}`, storage.Set{Registers: make([]uint8, 16)})
+	assert.ErrorIs(t, err, merrors.ErrIncorrectSet)
+
+	all, err := db.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Len(t, all.Set, 1)
+}
+
+func TestPing(t *testing.T) {
+	db := getSQLite(t)
+
+	assert.NoError(t, db.Ping(context.Background()))
+}
+
+func TestApplyRetention(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	base := storage.BucketStart(time.Unix(1_700_000_000, 0), time.Hour)
+	at := func(d time.Duration) time.Time {
+		return base.Add(d)
+	}
+	write := func(d time.Duration, gauge storage.Gauge, counter storage.Counter) {
+		err := db.inTx(ctx, func(tx *sql.Tx) error {
+			if _, err := updateGauge(ctx, tx, "gauge", gauge, at(d)); err != nil {
+				return err
+			}
+			_, err := updateCounter(ctx, tx, "counter", counter, at(d))
+			return err
+		})
+		require.NoError(t, err)
+	}
+	write(10*time.Second, 1, 2)
+	write(20*time.Second, 5, 0)
+	write(70*time.Second, 3, 3)
+
+	policy, err := storage.ParseRetention("raw:2m,1m:1h,1h:24h")
+	require.NoError(t, err)
+
+	now := at(150 * time.Second)
+	require.NoError(t, db.ApplyRetention(ctx, policy, now))
+	require.NoError(t, db.ApplyRetention(ctx, policy, now))
+
+	gauges, err := db.GetGaugeRollups(ctx, "gauge", time.Minute, base, now)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Rollup{
+		{Time: base, First: 1, Last: 5, Min: 1, Max: 5, Sum: 6, Count: 2},
+		{Time: at(time.Minute), First: 3, Last: 3, Min: 3, Max: 3, Sum: 3, Count: 1},
+	}, gauges)
+
+	counters, err := db.GetCounterRollups(ctx, "counter", time.Minute, base, now)
+	require.NoError(t, err)
+	require.Len(t, counters, 2)
+	assert.Equal(t, 2.0, counters[0].Sum)
+	assert.Equal(t, 3.0, counters[1].Sum)
+
+	raw, err := db.GetGaugeHistory(ctx, "gauge", base, now)
+	require.NoError(t, err)
+	require.Len(t, raw, 1)
+	assert.Equal(t, 3.0, raw[0].Value)
+
+	later := at(3 * time.Hour)
+	require.NoError(t, db.ApplyRetention(ctx, policy, later))
+
+	hours, err := db.GetGaugeRollups(ctx, "gauge", time.Hour, base, later)
+	require.NoError(t, err)
+	assert.Equal(t, []storage.Rollup{
+		{Time: base, First: 1, Last: 3, Min: 1, Max: 5, Sum: 9, Count: 3},
+	}, hours)
+
+	minutes, err := db.GetGaugeRollups(ctx, "gauge", time.Minute, base, later)
+	require.NoError(t, err)
+	assert.Empty(t, minutes)
+}
+
+func TestDeleteMetric(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	from := time.Now()
+	_, err := db.UpdateGauge(ctx, "gauge1", 1.5)
+	require.NoError(t, err)
+	_, err = db.UpdateCounter(ctx, "counter1", 2)
+	require.NoError(t, err)
+
+	require.NoError(t, db.DeleteMetric(ctx, "gauge", "gauge1"))
+	assert.ErrorIs(t, db.DeleteMetric(ctx, "gauge", "gauge1"), merrors.ErrNotFoundMetric)
+	assert.ErrorIs(t, db.DeleteMetric(ctx, "unknown", "counter1"), merrors.ErrIncorrectMetricType)
+
+	_, err = db.GetGaugeByName(ctx, "gauge1")
+	assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+	history, err := db.GetGaugeHistory(ctx, "gauge1", from, time.Now())
+	require.NoError(t, err)
+	assert.Empty(t, history)
+
+	_, err = db.GetCounterByName(ctx, "counter1")
+	assert.NoError(t, err)
+}
+
+func TestDeleteStale(t *testing.T) {
+	ctx := context.Background()
+	db := getSQLite(t)
+
+	_, err := db.UpdateGauge(ctx, "old", 1)
+	require.NoError(t, err)
+	_, err = db.UpdateCounter(ctx, "old", 1)
+	require.NoError(t, err)
+	before := time.Now()
+	_, err = db.UpdateGauge(ctx, "fresh", 2)
+	require.NoError(t, err)
+
+	list, err := db.DeleteStale(ctx, before)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []storage.MetricRef{
+		{MType: "gauge", Name: "old"},
+		{MType: "counter", Name: "old"},
+	}, list)
+
+	_, err = db.GetGaugeByName(ctx, "old")
+	assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+	_, err = db.GetGaugeByName(ctx, "fresh")
+	assert.NoError(t, err)
+}
@@ -1,6 +1,9 @@
 package storage
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Gauge — значение метрики типа gauge.
 type Gauge float64
@@ -8,27 +11,77 @@ type Gauge float64
 // Counter — значение метрики типа counter.
 type Counter int64
 
-// GaugeCollection — набор gauge-метрик, сгруппированных по имени.
+// GaugeCollection — набор gauge-метрик, сгруппированных по ключу серии (см. SeriesKey).
 type GaugeCollection map[string]Gauge
 
-// CounterCollection — набор counter-метрик, сгруппированных по имени.
+// CounterCollection — набор counter-метрик, сгруппированных по ключу серии (см. SeriesKey).
 type CounterCollection map[string]Counter
 
-// Database — структура, содержащая метрики типов gauge и counter.
+// Database — структура, содержащая метрики типов gauge, counter, histogram, summary и set
+// и их описания.
 type Database struct {
-	Gauge   GaugeCollection
-	Counter CounterCollection
+	Gauge     GaugeCollection
+	Counter   CounterCollection
+	Histogram HistogramCollection
+	Summary   SummaryCollection
+	Set       SetCollection
+	Metadata  MetadataCollection `json:",omitempty"`
+	BatchID   string             `json:",omitempty"` // ключ идемпотентности пачки, см. BatchTTL
+}
+
+// Sample — значение метрики в момент времени.
+// Для gauge хранит установленное значение, для counter — прирост.
+type Sample struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// MetricRef — ссылка на метрику: тип и ключ серии.
+type MetricRef struct {
+	MType string `json:"type"`
+	Name  string `json:"name"`
 }
 
 // Storage — интерфейс для работы с хранилищем метрик.
 // Позволяет обновлять, читать.
+// Параметр name во всех методах, кроме FindSeries, — ключ серии (см. SeriesKey).
 type Storage interface {
 	UpdateCounter(ctx context.Context, name string, value Counter) (Counter, error)
 	UpdateGauge(ctx context.Context, name string, value Gauge) (Gauge, error)
+	// UpdateHistogram прибавляет value к сохраненной гистограмме и возвращает результат.
+	// Если границы бакетов не совпадают, возвращает merrors.ErrIncorrectHistogramBuckets.
+	UpdateHistogram(ctx context.Context, name string, value Histogram) (Histogram, error)
+	// UpdateSummary добавляет к сохраненному summary наблюдения и статистику value
+	// и возвращает результат.
+	UpdateSummary(ctx context.Context, name string, value Summary) (Summary, error)
+	// UpdateSet объединяет value с сохраненным скетчем и возвращает результат.
+	// Если размеры скетчей не совпадают, возвращает merrors.ErrIncorrectSet.
+	UpdateSet(ctx context.Context, name string, value Set) (Set, error)
 	UpdateMany(ctx context.Context, list Database) error
 	GetGaugeByName(ctx context.Context, name string) (Gauge, error)
 	GetCounterByName(ctx context.Context, name string) (Counter, error)
+	GetHistogramByName(ctx context.Context, name string) (Histogram, error)
+	GetSummaryByName(ctx context.Context, name string) (Summary, error)
+	GetSetByName(ctx context.Context, name string) (Set, error)
+	GetGaugeHistory(ctx context.Context, name string, from, to time.Time) ([]Sample, error)
+	GetCounterHistory(ctx context.Context, name string, from, to time.Time) ([]Sample, error)
+	// GetGaugeRollups возвращает свертки gauge с шагом resolution, интервалы
+	// которых пересекаются с [from, to], по возрастанию времени.
+	GetGaugeRollups(ctx context.Context, name string, resolution time.Duration, from, to time.Time) ([]Rollup, error)
+	GetCounterRollups(ctx context.Context, name string, resolution time.Duration, from, to time.Time) ([]Rollup, error)
+	// ApplyRetention сворачивает историю по policy в интервалы, закончившиеся
+	// к моменту now, и удаляет семплы и свертки старше сроков хранения.
+	ApplyRetention(ctx context.Context, policy RetentionPolicy, now time.Time) error
+	// DeleteMetric удаляет метрику типа mType вместе с ее историей.
+	// Если метрики нет, возвращает merrors.ErrNotFoundMetric.
+	DeleteMetric(ctx context.Context, mType string, name string) error
+	// DeleteStale удаляет метрики, которые не обновлялись с момента before,
+	// и возвращает их список. История метрик остается до ApplyRetention.
+	DeleteStale(ctx context.Context, before time.Time) ([]MetricRef, error)
 	GetAll(ctx context.Context) (Database, error)
+	// FindSeries возвращает серии типа mType с именем name, метки которых подходят
+	// под все matchers. Серии отсортированы по ключу.
+	FindSeries(ctx context.Context, mType string, name string, matchers []Matcher) ([]Series, error)
 	Ping(ctx context.Context) error
 	Close()
 }
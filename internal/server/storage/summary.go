@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"math"
+	"slices"
+	"time"
+)
+
+// DefaultSummaryWindow — окно, за которое summary считает квантили, по умолчанию.
+const DefaultSummaryWindow = 10 * time.Minute
+
+// MaxSummaryObservations — максимальное количество наблюдений в окне summary.
+// При переполнении отбрасываются самые старые, поэтому при частых наблюдениях
+// квантили считаются за время короче Window.
+const MaxSummaryObservations = 1000
+
+// SummaryObservation — одно наблюдение summary.
+type SummaryObservation struct {
+	Time  time.Time `json:"time"`
+	Value float64   `json:"value"`
+}
+
+// Summary — значение метрики типа summary.
+//
+// Окна у статистики разные, как у summary в Prometheus: Count, Sum, Min и Max
+// накапливаются за все время, чтобы по Count и Sum считать скорость и среднее
+// за любой период, а квантили — по скользящему окну из наблюдений за последние
+// Window, но не больше MaxSummaryObservations последних. Поэтому память серии
+// ограничена, но среднее Sum/Count и квантили относятся к разным периодам.
+type Summary struct {
+	Observations []SummaryObservation `json:"observations,omitempty"`
+	Window       time.Duration        `json:"window"`
+	Sum          float64              `json:"sum"`
+	Min          float64              `json:"min"`
+	Max          float64              `json:"max"`
+	Count        uint64               `json:"count"`
+}
+
+// SummaryCollection — набор summary, сгруппированных по ключу серии (см. SeriesKey).
+type SummaryCollection map[string]Summary
+
+// NewSummary создает пустой summary с окном window.
+func NewSummary(window time.Duration) Summary {
+	return Summary{Window: window}
+}
+
+// Copy возвращает копию summary.
+func (s Summary) Copy() Summary {
+	s.Observations = slices.Clone(s.Observations)
+
+	return s
+}
+
+// Observe добавляет наблюдение v в момент t.
+func (s *Summary) Observe(v float64, t time.Time) {
+	s.Merge(Summary{
+		Observations: []SummaryObservation{{Time: t, Value: v}},
+		Sum:          v,
+		Min:          v,
+		Max:          v,
+		Count:        1,
+	})
+}
+
+// Merge добавляет к summary наблюдения и статистику o. Окно берется
+// из summary, если оно не задано — из o.
+func (s *Summary) Merge(o Summary) {
+	if o.Count == 0 {
+		return
+	}
+	if s.Window == 0 {
+		s.Window = o.Window
+	}
+
+	if s.Count == 0 {
+		s.Min, s.Max = o.Min, o.Max
+	} else {
+		s.Min = min(s.Min, o.Min)
+		s.Max = max(s.Max, o.Max)
+	}
+	s.Sum += o.Sum
+	s.Count += o.Count
+
+	s.Observations = append(slices.Clone(s.Observations), o.Observations...)
+	slices.SortStableFunc(s.Observations, func(a, b SummaryObservation) int {
+		return a.Time.Compare(b.Time)
+	})
+	s.trim()
+}
+
+// trim отбрасывает наблюдения старше окна относительно последнего
+// наблюдения и оставляет не больше MaxSummaryObservations.
+func (s *Summary) trim() {
+	if len(s.Observations) == 0 {
+		return
+	}
+
+	start := 0
+	if s.Window > 0 {
+		from := s.Observations[len(s.Observations)-1].Time.Add(-s.Window)
+		for start < len(s.Observations) && s.Observations[start].Time.Before(from) {
+			start++
+		}
+	}
+	start = max(start, len(s.Observations)-MaxSummaryObservations)
+
+	s.Observations = s.Observations[start:]
+}
+
+// Quantile возвращает квантиль q наблюдений в окне (now-Window, now]
+// с линейной интерполяцией между соседними значениями.
+// Если наблюдений в окне нет или q вне [0, 1], возвращает NaN.
+func (s Summary) Quantile(q float64, now time.Time) float64 {
+	if q < 0 || q > 1 || math.IsNaN(q) {
+		return math.NaN()
+	}
+
+	values := make([]float64, 0, len(s.Observations))
+	for _, o := range s.Observations {
+		if s.Window > 0 && !o.Time.After(now.Add(-s.Window)) {
+			continue
+		}
+		values = append(values, o.Value)
+	}
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	slices.Sort(values)
+
+	rank := q * float64(len(values)-1)
+	lower := int(math.Floor(rank))
+	upper := int(math.Ceil(rank))
+
+	return values[lower] + (values[upper]-values[lower])*(rank-float64(lower))
+}
@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSummaryObserve(t *testing.T) {
+	now := time.Now()
+	s := NewSummary(time.Minute)
+	s.Observe(3, now.Add(-2*time.Minute))
+	s.Observe(1, now.Add(-time.Second))
+	s.Observe(2, now)
+
+	assert.Equal(t, uint64(3), s.Count)
+	assert.InDelta(t, 6.0, s.Sum, 1e-9)
+	assert.InDelta(t, 1.0, s.Min, 1e-9)
+	assert.InDelta(t, 3.0, s.Max, 1e-9)
+	assert.Len(t, s.Observations, 2, "observations outside window must be dropped")
+}
+
+func TestSummaryMerge(t *testing.T) {
+	now := time.Now()
+	a := NewSummary(time.Minute)
+	a.Observe(5, now)
+
+	var b Summary
+	b.Merge(a)
+	b.Merge(a)
+	assert.Equal(t, time.Minute, b.Window)
+	assert.Equal(t, uint64(2), b.Count)
+	assert.Len(t, b.Observations, 2)
+	assert.Len(t, a.Observations, 1, "source must not change")
+
+	for i := range MaxSummaryObservations + 10 {
+		b.Observe(float64(i), now)
+	}
+	assert.Len(t, b.Observations, MaxSummaryObservations)
+}
+
+func TestSummaryQuantile(t *testing.T) {
+	now := time.Now()
+	s := NewSummary(time.Minute)
+	s.Observe(100, now.Add(-2*time.Minute))
+	for _, v := range []float64{4, 1, 3, 2, 5} {
+		s.Observe(v, now)
+	}
+
+	tests := []struct {
+		name string
+		q    float64
+		want float64
+	}{
+		{name: "Min", q: 0, want: 1},
+		{name: "Median", q: 0.5, want: 3},
+		{name: "Interpolated", q: 0.9, want: 4.6},
+		{name: "Max", q: 1, want: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.InDelta(t, tt.want, s.Quantile(tt.q, now), 1e-9)
+		})
+	}
+
+	assert.True(t, math.IsNaN(s.Quantile(2, now)))
+	assert.True(t, math.IsNaN(s.Quantile(0.5, now.Add(time.Hour))), "window is empty")
+}
@@ -0,0 +1,162 @@
+package tenantstorage
+
+import (
+	"context"
+	"sync"
+
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+	"go.uber.org/zap"
+)
+
+// quota ограничивает число серий тенанта. Серии всех тенантов читаются
+// из хранилища при первой записи, дальше список ведется по записям
+// и удалениям этого сервера.
+//
+// Новая серия занимает место в квоте до записи. Для серии хранится число
+// незавершенных записей, которые ее добавляют, 0 — серия уже в хранилище.
+// При ошибке записи серия убирается, только если ее не записал никто.
+type quota struct {
+	db     storage.Storage
+	series map[string]map[storage.MetricRef]int // серии по тенантам, ключи без префикса тенанта
+	max    int
+	mu     sync.Mutex
+}
+
+func newQuota(db storage.Storage, maxSeries int) *quota {
+	return &quota{
+		db:  db,
+		max: maxSeries,
+	}
+}
+
+// load читает серии всех тенантов из хранилища.
+func (q *quota) load(ctx context.Context) error {
+	all, err := q.db.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	q.series = make(map[string]map[storage.MetricRef]int)
+	add := func(mType string, key string) {
+		id, k := tenant.Split(key)
+		q.list(id)[storage.MetricRef{MType: mType, Name: k}] = 0
+	}
+	for k := range all.Gauge {
+		add("gauge", k)
+	}
+	for k := range all.Counter {
+		add("counter", k)
+	}
+	for k := range all.Histogram {
+		add("histogram", k)
+	}
+	for k := range all.Summary {
+		add("summary", k)
+	}
+	for k := range all.Set {
+		add("set", k)
+	}
+
+	return nil
+}
+
+// list возвращает серии тенанта id, вызывается под mu.
+func (q *quota) list(id string) map[storage.MetricRef]int {
+	list, ok := q.series[id]
+	if !ok {
+		list = make(map[storage.MetricRef]int)
+		q.series[id] = list
+	}
+
+	return list
+}
+
+// remove убирает серии refs с ключами из хранилища.
+func (q *quota) remove(refs []storage.MetricRef) {
+	if q.max == 0 {
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.series == nil {
+		return
+	}
+
+	for _, ref := range refs {
+		id, k := tenant.Split(ref.Name)
+		delete(q.series[id], storage.MetricRef{MType: ref.MType, Name: k})
+	}
+}
+
+// reserve занимает места новых серий refs тенанта из ctx, если после этого
+// их будет не больше max, и возвращает функцию, которую нужно вызвать
+// после записи с ее результатом.
+// Без тенанта в контексте проверяется квота тенанта по умолчанию.
+func (q *quota) reserve(ctx context.Context, refs []storage.MetricRef) (func(ok bool), error) {
+	id, _ := tenant.FromContext(ctx)
+	if q.max == 0 {
+		return func(bool) {}, nil
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.series == nil {
+		if err := q.load(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	// серии, которые эта запись добавляет или добавляет вместе с другими
+	pending := make([]storage.MetricRef, 0)
+	for _, ref := range refs {
+		rid, k := tenant.Split(ref.Name)
+		list := q.list(rid)
+		r := storage.MetricRef{MType: ref.MType, Name: k}
+		if n, ok := list[r]; ok && n == 0 {
+			continue
+		}
+		list[r]++
+		pending = append(pending, r)
+	}
+
+	if len(q.series[id]) > q.max {
+		q.finish(id, pending, false)
+		logger.Log.Warn("tenant series quota exceeded", zap.String("tenant", id), zap.Int("max", q.max))
+		return nil, merrors.ErrSeriesQuotaExceeded
+	}
+
+	return func(ok bool) {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		q.finish(id, pending, ok)
+	}, nil
+}
+
+// finish завершает запись незавершенных серий refs тенанта id, вызывается под mu.
+// После успешной записи серии остаются в квоте, после ошибки — только если
+// их добавляет еще одна незавершенная запись.
+func (q *quota) finish(id string, refs []storage.MetricRef, ok bool) {
+	list := q.series[id]
+	for _, ref := range refs {
+		n, found := list[ref]
+		if !found || n == 0 {
+			// серию удалили или уже записала другая запись
+			continue
+		}
+
+		switch {
+		case ok:
+			list[ref] = 0
+		case n == 1:
+			delete(list, ref)
+		default:
+			list[ref] = n - 1
+		}
+	}
+}
@@ -0,0 +1,340 @@
+// Package tenantstorage разделяет хранилище между тенантами.
+//
+// Ключи серий и имена метрик в описаниях хранятся с префиксом тенанта
+// (см. tenant.Key), тенант берется из контекста. Без тенанта в контексте
+// GetAll, DeleteStale и ApplyRetention работают с исходными ключами всех
+// тенантов: так хранилище используют фоновые задачи — свертка истории
+// и удаление устаревших метрик. DeleteStale с тенантом в контексте
+// не выполняется. Запись и чтение отдельных серий без тенанта
+// в контексте идут от имени тенанта по умолчанию, поэтому имя с префиксом
+// другого тенанта отклоняется.
+package tenantstorage
+
+import (
+	"context"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/tenant"
+)
+
+type Storage struct {
+	db    storage.Storage
+	quota *quota
+}
+
+// New оборачивает db. maxSeries — наибольшее число серий одного тенанта,
+// 0 — без ограничения.
+func New(db storage.Storage, maxSeries int) *Storage {
+	return &Storage{
+		db:    db,
+		quota: newQuota(db, maxSeries),
+	}
+}
+
+// key возвращает ключ серии name в хранилище для тенанта из ctx.
+// Без тенанта в контексте ключ строится для тенанта по умолчанию.
+func key(ctx context.Context, name string) (string, error) {
+	id, _ := tenant.FromContext(ctx)
+	return tenant.Key(id, name)
+}
+
+// update записывает значение новой или существующей серии name,
+// проверяя квоту тенанта.
+func update[T any](
+	ctx context.Context, s *Storage, mType string, name string, value T,
+	fn func(ctx context.Context, name string, value T) (T, error),
+) (T, error) {
+	var zero T
+	k, err := key(ctx, name)
+	if err != nil {
+		return zero, err
+	}
+
+	done, err := s.quota.reserve(ctx, []storage.MetricRef{{MType: mType, Name: k}})
+	if err != nil {
+		return zero, err
+	}
+
+	res, err := fn(ctx, k, value)
+	done(err == nil)
+
+	return res, err
+}
+
+func (s *Storage) UpdateCounter(ctx context.Context, name string, value storage.Counter) (storage.Counter, error) {
+	return update(ctx, s, "counter", name, value, s.db.UpdateCounter)
+}
+
+func (s *Storage) UpdateGauge(ctx context.Context, name string, value storage.Gauge) (storage.Gauge, error) {
+	return update(ctx, s, "gauge", name, value, s.db.UpdateGauge)
+}
+
+func (s *Storage) UpdateHistogram(
+	ctx context.Context, name string, value storage.Histogram,
+) (storage.Histogram, error) {
+	return update(ctx, s, "histogram", name, value, s.db.UpdateHistogram)
+}
+
+func (s *Storage) UpdateSummary(ctx context.Context, name string, value storage.Summary) (storage.Summary, error) {
+	return update(ctx, s, "summary", name, value, s.db.UpdateSummary)
+}
+
+func (s *Storage) UpdateSet(ctx context.Context, name string, value storage.Set) (storage.Set, error) {
+	return update(ctx, s, "set", name, value, s.db.UpdateSet)
+}
+
+// withKeys возвращает копию коллекции с ключами тенанта из ctx
+// и добавляет ссылки на ее серии в refs.
+func withKeys[T any](
+	ctx context.Context, list map[string]T, mType string, refs *[]storage.MetricRef,
+) (map[string]T, error) {
+	if list == nil {
+		return nil, nil
+	}
+
+	res := make(map[string]T, len(list))
+	for name, val := range list {
+		k, err := key(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		res[k] = val
+		if refs != nil {
+			*refs = append(*refs, storage.MetricRef{MType: mType, Name: k})
+		}
+	}
+
+	return res, nil
+}
+
+// batchID возвращает ключ пачки id тенанта из ctx, чтобы одинаковые
+// ключи разных тенантов не считались повтором.
+func batchID(ctx context.Context, id string) string {
+	t, ok := tenant.FromContext(ctx)
+	if id == "" || !ok || t == tenant.Default {
+		return id
+	}
+
+	return t + tenant.Separator + id
+}
+
+func (s *Storage) UpdateMany(ctx context.Context, list storage.Database) error {
+	var (
+		res  storage.Database
+		refs []storage.MetricRef
+		err  error
+	)
+	if res.Gauge, err = withKeys(ctx, list.Gauge, "gauge", &refs); err != nil {
+		return err
+	}
+	if res.Counter, err = withKeys(ctx, list.Counter, "counter", &refs); err != nil {
+		return err
+	}
+	if res.Histogram, err = withKeys(ctx, list.Histogram, "histogram", &refs); err != nil {
+		return err
+	}
+	if res.Summary, err = withKeys(ctx, list.Summary, "summary", &refs); err != nil {
+		return err
+	}
+	if res.Set, err = withKeys(ctx, list.Set, "set", &refs); err != nil {
+		return err
+	}
+	if res.Metadata, err = withKeys(ctx, list.Metadata, "", nil); err != nil {
+		return err
+	}
+	res.BatchID = batchID(ctx, list.BatchID)
+
+	done, err := s.quota.reserve(ctx, refs)
+	if err != nil {
+		return err
+	}
+
+	err = s.db.UpdateMany(ctx, res)
+	done(err == nil)
+
+	return err
+}
+
+// get читает значение серии name тенанта из ctx.
+func get[T any](
+	ctx context.Context, name string, fn func(ctx context.Context, name string) (T, error),
+) (T, error) {
+	k, err := key(ctx, name)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return fn(ctx, k)
+}
+
+func (s *Storage) GetGaugeByName(ctx context.Context, name string) (storage.Gauge, error) {
+	return get(ctx, name, s.db.GetGaugeByName)
+}
+
+func (s *Storage) GetCounterByName(ctx context.Context, name string) (storage.Counter, error) {
+	return get(ctx, name, s.db.GetCounterByName)
+}
+
+func (s *Storage) GetHistogramByName(ctx context.Context, name string) (storage.Histogram, error) {
+	return get(ctx, name, s.db.GetHistogramByName)
+}
+
+func (s *Storage) GetSummaryByName(ctx context.Context, name string) (storage.Summary, error) {
+	return get(ctx, name, s.db.GetSummaryByName)
+}
+
+func (s *Storage) GetSetByName(ctx context.Context, name string) (storage.Set, error) {
+	return get(ctx, name, s.db.GetSetByName)
+}
+
+func (s *Storage) GetGaugeHistory(ctx context.Context, name string, from, to time.Time) ([]storage.Sample, error) {
+	k, err := key(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.GetGaugeHistory(ctx, k, from, to)
+}
+
+func (s *Storage) GetCounterHistory(ctx context.Context, name string, from, to time.Time) ([]storage.Sample, error) {
+	k, err := key(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.GetCounterHistory(ctx, k, from, to)
+}
+
+func (s *Storage) GetGaugeRollups(
+	ctx context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	k, err := key(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.GetGaugeRollups(ctx, k, resolution, from, to)
+}
+
+func (s *Storage) GetCounterRollups(
+	ctx context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	k, err := key(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.db.GetCounterRollups(ctx, k, resolution, from, to)
+}
+
+// ApplyRetention сворачивает историю всех тенантов.
+func (s *Storage) ApplyRetention(ctx context.Context, policy storage.RetentionPolicy, now time.Time) error {
+	return s.db.ApplyRetention(ctx, policy, now)
+}
+
+func (s *Storage) DeleteMetric(ctx context.Context, mType string, name string) error {
+	k, err := key(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if err = s.db.DeleteMetric(ctx, mType, k); err != nil {
+		return err
+	}
+	s.quota.remove([]storage.MetricRef{{MType: mType, Name: k}})
+
+	return nil
+}
+
+// DeleteStale удаляет устаревшие метрики всех тенантов и возвращает
+// их исходные ключи. Хранилище не умеет удалять устаревшие метрики
+// одного тенанта, поэтому с тенантом в контексте возвращается
+// merrors.ErrTenantNotAllowed и ничего не удаляется.
+func (s *Storage) DeleteStale(ctx context.Context, before time.Time) ([]storage.MetricRef, error) {
+	if _, ok := tenant.FromContext(ctx); ok {
+		return nil, merrors.ErrTenantNotAllowed
+	}
+
+	list, err := s.db.DeleteStale(ctx, before)
+	if err != nil {
+		return nil, err
+	}
+	s.quota.remove(list)
+
+	return list, nil
+}
+
+// own оставляет в коллекции серии тенанта id и убирает из ключей префикс.
+func own[T any](list map[string]T, id string) map[string]T {
+	if list == nil {
+		return nil
+	}
+
+	res := make(map[string]T)
+	for k, val := range list {
+		if t, rest := tenant.Split(k); t == id {
+			res[rest] = val
+		}
+	}
+
+	return res
+}
+
+// GetAll возвращает метрики тенанта из ctx, а без тенанта — метрики всех
+// тенантов с префиксами в ключах.
+func (s *Storage) GetAll(ctx context.Context) (storage.Database, error) {
+	all, err := s.db.GetAll(ctx)
+	if err != nil {
+		return storage.Database{}, err
+	}
+
+	id, ok := tenant.FromContext(ctx)
+	if !ok {
+		return all, nil
+	}
+
+	return storage.Database{
+		Gauge:     own(all.Gauge, id),
+		Counter:   own(all.Counter, id),
+		Histogram: own(all.Histogram, id),
+		Summary:   own(all.Summary, id),
+		Set:       own(all.Set, id),
+		Metadata:  own(all.Metadata, id),
+	}, nil
+}
+
+func (s *Storage) FindSeries(
+	ctx context.Context, mType string, name string, matchers []storage.Matcher,
+) ([]storage.Series, error) {
+	k, err := key(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	list, err := s.db.FindSeries(ctx, mType, k, matchers)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := tenant.FromContext(ctx); !ok {
+		return list, nil
+	}
+
+	for i := range list {
+		_, list[i].Key = tenant.Split(list[i].Key)
+		list[i].Name = name
+	}
+
+	return list, nil
+}
+
+func (s *Storage) Ping(ctx context.Context) error {
+	return s.db.Ping(ctx)
+}
+
+func (s *Storage) Close() {
+	s.db.Close()
+}
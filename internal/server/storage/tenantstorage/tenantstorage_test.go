@@ -0,0 +1,251 @@
+package tenantstorage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"github.com/LekcRg/metrics/internal/tenant"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newStorage(t *testing.T, maxSeries int) (*Storage, *memstorage.MemStorage) {
+	t.Helper()
+
+	db, err := memstorage.New()
+	require.NoError(t, err)
+
+	return New(db, maxSeries), db
+}
+
+func TestIsolation(t *testing.T) {
+	s, db := newStorage(t, 0)
+	ctxA := tenant.WithTenant(context.Background(), "team-a")
+	ctxB := tenant.WithTenant(context.Background(), "team-b")
+	ctxDefault := tenant.WithTenant(context.Background(), tenant.Default)
+
+	_, err := s.UpdateGauge(ctxA, "RandomValue", 1)
+	require.NoError(t, err)
+	_, err = s.UpdateGauge(ctxB, "RandomValue", 2)
+	require.NoError(t, err)
+	_, err = s.UpdateGauge(ctxDefault, "RandomValue", 3)
+	require.NoError(t, err)
+	_, err = s.UpdateCounter(ctxA, "PollCount", 5)
+	require.NoError(t, err)
+
+	val, err := s.GetGaugeByName(ctxA, "RandomValue")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Gauge(1), val)
+
+	val, err = s.GetGaugeByName(ctxB, "RandomValue")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Gauge(2), val)
+
+	val, err = s.GetGaugeByName(ctxDefault, "RandomValue")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Gauge(3), val)
+
+	_, err = s.GetCounterByName(ctxB, "PollCount")
+	assert.Error(t, err)
+
+	_, err = s.UpdateGauge(ctxDefault, "team-a/RandomValue", 4)
+	assert.ErrorIs(t, err, merrors.ErrIncorrectTenantKey)
+
+	all, err := s.GetAll(ctxA)
+	require.NoError(t, err)
+	assert.Equal(t, storage.GaugeCollection{"RandomValue": 1}, all.Gauge)
+	assert.Equal(t, storage.CounterCollection{"PollCount": 5}, all.Counter)
+
+	all, err = s.GetAll(ctxDefault)
+	require.NoError(t, err)
+	assert.Equal(t, storage.GaugeCollection{"RandomValue": 3}, all.Gauge)
+	assert.Empty(t, all.Counter)
+
+	// без тенанта в контексте видны ключи всех тенантов
+	all, err = s.GetAll(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, storage.GaugeCollection{
+		"RandomValue":        3,
+		"team-a/RandomValue": 1,
+		"team-b/RandomValue": 2,
+	}, all.Gauge)
+
+	raw, err := db.GetGaugeByName(context.Background(), "team-b/RandomValue")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Gauge(2), raw)
+}
+
+func TestUpdateMany(t *testing.T) {
+	s, _ := newStorage(t, 0)
+	ctxA := tenant.WithTenant(context.Background(), "team-a")
+	ctxB := tenant.WithTenant(context.Background(), "team-b")
+	unit := storage.Metadata{Unit: "bytes"}
+
+	err := s.UpdateMany(ctxA, storage.Database{
+		Gauge:    storage.GaugeCollection{"Alloc": 10},
+		Counter:  storage.CounterCollection{"PollCount": 1},
+		Metadata: storage.MetadataCollection{"Alloc": unit},
+	})
+	require.NoError(t, err)
+
+	all, err := s.GetAll(ctxA)
+	require.NoError(t, err)
+	assert.Equal(t, storage.GaugeCollection{"Alloc": 10}, all.Gauge)
+	assert.Equal(t, storage.CounterCollection{"PollCount": 1}, all.Counter)
+	assert.Equal(t, storage.MetadataCollection{"Alloc": unit}, all.Metadata)
+
+	all, err = s.GetAll(ctxB)
+	require.NoError(t, err)
+	assert.Empty(t, all.Gauge)
+	assert.Empty(t, all.Metadata)
+}
+
+func TestUpdateManyBatch(t *testing.T) {
+	s, _ := newStorage(t, 0)
+	ctxA := tenant.WithTenant(context.Background(), "team-a")
+	ctxB := tenant.WithTenant(context.Background(), "team-b")
+	batch := storage.Database{Counter: storage.CounterCollection{"PollCount": 1}, BatchID: "batch-1"}
+
+	require.NoError(t, s.UpdateMany(ctxA, batch))
+	assert.ErrorIs(t, s.UpdateMany(ctxA, batch), merrors.ErrDuplicateBatch)
+
+	// одинаковые ключи разных тенантов не считаются повтором
+	require.NoError(t, s.UpdateMany(ctxB, batch))
+
+	val, err := s.GetCounterByName(ctxA, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(1), val)
+}
+
+func TestFindSeries(t *testing.T) {
+	s, _ := newStorage(t, 0)
+	ctxA := tenant.WithTenant(context.Background(), "team-a")
+	ctxB := tenant.WithTenant(context.Background(), "team-b")
+
+	_, err := s.UpdateGauge(ctxA, `cpu{host="a"}`, 1)
+	require.NoError(t, err)
+	_, err = s.UpdateGauge(ctxB, `cpu{host="b"}`, 2)
+	require.NoError(t, err)
+
+	list, err := s.FindSeries(ctxA, "gauge", "cpu", nil)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, `cpu{host="a"}`, list[0].Key)
+	assert.Equal(t, "cpu", list[0].Name)
+	assert.Equal(t, float64(1), list[0].Value)
+}
+
+func TestDelete(t *testing.T) {
+	s, _ := newStorage(t, 1)
+	ctxA := tenant.WithTenant(context.Background(), "team-a")
+	ctxB := tenant.WithTenant(context.Background(), "team-b")
+
+	_, err := s.UpdateGauge(ctxA, "RandomValue", 1)
+	require.NoError(t, err)
+	_, err = s.UpdateGauge(ctxB, "RandomValue", 2)
+	require.NoError(t, err)
+
+	require.NoError(t, s.DeleteMetric(ctxA, "gauge", "RandomValue"))
+	_, err = s.GetGaugeByName(ctxA, "RandomValue")
+	assert.Error(t, err)
+	_, err = s.GetGaugeByName(ctxB, "RandomValue")
+	assert.NoError(t, err)
+
+	// удаленная серия освобождает место в квоте
+	_, err = s.UpdateGauge(ctxA, "Alloc", 1)
+	require.NoError(t, err)
+
+	// тенант не может удалить устаревшие метрики других тенантов
+	_, err = s.DeleteStale(ctxB, time.Now().Add(time.Hour))
+	require.ErrorIs(t, err, merrors.ErrTenantNotAllowed)
+	_, err = s.GetGaugeByName(ctxA, "Alloc")
+	require.NoError(t, err)
+
+	list, err := s.DeleteStale(context.Background(), time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []storage.MetricRef{
+		{MType: "gauge", Name: "team-a/Alloc"},
+		{MType: "gauge", Name: "team-b/RandomValue"},
+	}, list)
+
+	_, err = s.GetGaugeByName(ctxA, "Alloc")
+	assert.Error(t, err)
+
+	_, err = s.UpdateGauge(ctxA, "HeapAlloc", 1)
+	assert.NoError(t, err)
+}
+
+func TestQuota(t *testing.T) {
+	db, err := memstorage.New()
+	require.NoError(t, err)
+	_, err = db.UpdateGauge(context.Background(), "team-a/Alloc", 1)
+	require.NoError(t, err)
+
+	s := New(db, 2)
+	ctxA := tenant.WithTenant(context.Background(), "team-a")
+	ctxB := tenant.WithTenant(context.Background(), "team-b")
+
+	// серии, сохраненные до запуска, учитываются в квоте
+	_, err = s.UpdateGauge(ctxA, "HeapAlloc", 1)
+	require.NoError(t, err)
+	_, err = s.UpdateGauge(ctxA, "Frees", 1)
+	assert.ErrorIs(t, err, merrors.ErrSeriesQuotaExceeded)
+
+	// существующие серии обновляются
+	_, err = s.UpdateGauge(ctxA, "Alloc", 2)
+	require.NoError(t, err)
+
+	// пачка, превышающая квоту, не записывается целиком
+	err = s.UpdateMany(ctxB, storage.Database{
+		Gauge: storage.GaugeCollection{"Alloc": 1, "HeapAlloc": 1, "Frees": 1},
+	})
+	assert.ErrorIs(t, err, merrors.ErrSeriesQuotaExceeded)
+	all, err := s.GetAll(ctxB)
+	require.NoError(t, err)
+	assert.Empty(t, all.Gauge)
+
+	err = s.UpdateMany(ctxB, storage.Database{
+		Gauge:    storage.GaugeCollection{"Alloc": 1},
+		Counter:  storage.CounterCollection{"Alloc": 1},
+		Metadata: storage.MetadataCollection{"Alloc": {Unit: "bytes"}},
+	})
+	assert.NoError(t, err)
+
+	// без тенанта в контексте нельзя писать в серии тенанта
+	_, err = s.UpdateGauge(context.Background(), "team-a/Frees", 1)
+	assert.ErrorIs(t, err, merrors.ErrIncorrectTenantKey)
+}
+
+func TestQuotaFailedWrite(t *testing.T) {
+	db, err := memstorage.New()
+	require.NoError(t, err)
+	q := newQuota(db, 1)
+	ctx := tenant.WithTenant(context.Background(), "team-a")
+	alloc := []storage.MetricRef{{MType: "gauge", Name: "team-a/Alloc"}}
+	frees := []storage.MetricRef{{MType: "gauge", Name: "team-a/Frees"}}
+
+	// ошибка единственной записи освобождает место
+	done, err := q.reserve(ctx, alloc)
+	require.NoError(t, err)
+	done(false)
+
+	// ошибка одной из двух записей серии не убирает ее из квоты
+	doneA, err := q.reserve(ctx, alloc)
+	require.NoError(t, err)
+	doneB, err := q.reserve(ctx, alloc)
+	require.NoError(t, err)
+	doneB(true)
+	doneA(false)
+
+	_, err = q.reserve(ctx, frees)
+	assert.ErrorIs(t, err, merrors.ErrSeriesQuotaExceeded)
+
+	q.remove(alloc)
+	done, err = q.reserve(ctx, frees)
+	require.NoError(t, err)
+	done(true)
+}
@@ -0,0 +1,535 @@
+// Package wal — хранилище метрик на локальном диске. Значения хранятся
+// в памяти (memstorage), каждое изменение до применения в памяти дописывается
+// в журнал (write-ahead log) и сбрасывается на диск. Журнал периодически
+// сворачивается в снимок, после чего очищается.
+//
+// Снимок хранит последние значения, историю значений со свертками
+// (см. memstorage.History) и ключи примененных пачек (см. storage.BatchTTL).
+// ApplyRetention в журнал не пишется: свертки и удаление старых семплов
+// после последнего снимка повторяются при следующем применении политики.
+// Время последнего обновления метрик в снимок тоже не входит: метрики
+// из снимка считаются обновленными в момент запуска.
+package wal
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/logger"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/LekcRg/metrics/internal/server/storage/memstorage"
+	"go.uber.org/zap"
+)
+
+const (
+	logFile      = "wal.log"
+	snapshotFile = "snapshot.json"
+)
+
+// record — одна запись журнала. Data применяется как storage.Storage.UpdateMany,
+// затем удаляются метрики Deleted (как DeleteMetric) и Expired (как DeleteStale).
+type record struct {
+	Time    time.Time           `json:"time"`
+	Data    storage.Database    `json:"data"`
+	Deleted []storage.MetricRef `json:"deleted,omitempty"`
+	Expired []storage.MetricRef `json:"expired,omitempty"`
+	Seq     uint64              `json:"seq"`
+}
+
+// snapshot — снимок всех значений и истории. Seq — номер последней записи
+// журнала, которая в него вошла.
+type snapshot struct {
+	Batches map[string]time.Time `json:"batches,omitempty"` // ключи примененных пачек
+	History memstorage.History   `json:"history"`
+	Data    storage.Database     `json:"data"`
+	Seq     uint64               `json:"seq"`
+}
+
+type WAL struct {
+	mem  *memstorage.MemStorage
+	log  *os.File
+	stop chan struct{}
+	done chan struct{}
+	dir  string
+	seq  uint64
+	mu   sync.RWMutex
+}
+
+// New открывает хранилище в каталоге cfg.WALDir: читает снимок, применяет
+// записи журнала после него и запускает периодическое сворачивание журнала.
+func New(ctx context.Context, cfg config.ServerConfig) (*WAL, error) {
+	if err := os.MkdirAll(cfg.WALDir, 0o755); err != nil {
+		return nil, err
+	}
+
+	mem, err := memstorage.New()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &WAL{
+		mem:  mem,
+		dir:  cfg.WALDir,
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	if err = w.loadSnapshot(ctx); err != nil {
+		return nil, err
+	}
+	if err = w.replay(ctx); err != nil {
+		return nil, err
+	}
+
+	w.log, err = os.OpenFile(w.path(logFile), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	interval := time.Duration(cfg.WALSnapshotInterval) * time.Second
+	go w.compactLoop(ctx, interval)
+
+	return w, nil
+}
+
+func (w *WAL) path(name string) string {
+	return filepath.Join(w.dir, name)
+}
+
+func (w *WAL) loadSnapshot(ctx context.Context) error {
+	b, err := os.ReadFile(w.path(snapshotFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var snap snapshot
+	if err = json.Unmarshal(b, &snap); err != nil {
+		return err
+	}
+
+	// значения снимка не пишутся в историю: иначе накопленные счетчики
+	// попали бы в нее приростом на момент запуска, история берется из снимка
+	w.seq = snap.Seq
+	w.mem.Load(ctx, snap.Data)
+	w.mem.LoadHistory(snap.History)
+	w.mem.LoadBatches(snap.Batches)
+
+	return nil
+}
+
+// replay применяет записи журнала, которых нет в снимке. Недописанная
+// последняя запись (сбой во время записи) отрезается.
+func (w *WAL) replay(ctx context.Context) error {
+	f, err := os.OpenFile(w.path(logFile), os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := bufio.NewReader(f)
+	var offset int64
+	for {
+		line, err := r.ReadBytes('\n')
+		if errors.Is(err, io.EOF) && len(line) == 0 {
+			return nil
+		}
+
+		var rec record
+		if err != nil || json.Unmarshal(bytes.TrimSpace(line), &rec) != nil {
+			logger.Log.Warn("truncate broken write-ahead log tail", zap.Int64("offset", offset))
+			return f.Truncate(offset)
+		}
+		offset += int64(len(line))
+
+		if rec.Seq <= w.seq {
+			continue
+		}
+		w.seq = rec.Seq
+
+		if err := w.mem.UpdateManyAt(ctx, rec.Data, rec.Time); err != nil {
+			// запись уже не применилась при работе, результат будет тем же
+			logger.Log.Warn("skip write-ahead log record", zap.Uint64("seq", rec.Seq), zap.Error(err))
+		}
+		for _, ref := range rec.Deleted {
+			// метрики могло не быть в снимке, если она удалена до него
+			_ = w.mem.DeleteMetric(ctx, ref.MType, ref.Name)
+		}
+		w.mem.Expire(rec.Expired)
+	}
+}
+
+// apply проверяет, что изменение data применится к значениям в памяти,
+// и дописывает его в журнал. Значения в памяти меняются только после
+// записи в журнал: если она не удалась, повтор запроса не применит
+// изменение дважды. Вызывается под w.mu.
+func (w *WAL) apply(data storage.Database, t time.Time) error {
+	if err := w.mem.Check(data); err != nil {
+		return err
+	}
+
+	return w.writeRecord(record{Time: t, Data: data})
+}
+
+// writeRecord присваивает записи следующий номер, дописывает ее в журнал
+// и сбрасывает его на диск. Вызывается под w.mu.
+func (w *WAL) writeRecord(rec record) error {
+	rec.Seq = w.seq + 1
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+
+	info, err := w.log.Stat()
+	if err != nil {
+		return err
+	}
+
+	_, err = w.log.Write(append(b, '\n'))
+	if err == nil {
+		err = w.log.Sync()
+	}
+	if err != nil {
+		// недописанная или не сброшенная на диск запись не должна
+		// примениться при восстановлении
+		if terr := w.log.Truncate(info.Size()); terr != nil {
+			logger.Log.Error("error while truncating write-ahead log", zap.Error(terr))
+		}
+		return err
+	}
+
+	w.seq = rec.Seq
+	return nil
+}
+
+// Snapshot записывает все значения и историю в снимок и очищает журнал.
+// Снимок пишется во временный файл и переименовывается, поэтому при сбое
+// остается либо старый, либо новый снимок.
+func (w *WAL) Snapshot(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data, err := w.mem.GetAll(ctx)
+	if err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(snapshot{
+		Data:    data,
+		History: w.mem.History(),
+		Batches: w.mem.Batches(),
+		Seq:     w.seq,
+	})
+	if err != nil {
+		return err
+	}
+
+	tmp := w.path(snapshotFile + ".tmp")
+	if err = writeFileSync(tmp, b); err != nil {
+		return err
+	}
+	if err = os.Rename(tmp, w.path(snapshotFile)); err != nil {
+		return err
+	}
+	if err = syncDir(w.dir); err != nil {
+		return err
+	}
+
+	// записи до w.seq уже в снимке; если очистка не дойдет до диска,
+	// при чтении они будут пропущены по номеру
+	if err = w.log.Truncate(0); err != nil {
+		return err
+	}
+
+	return w.log.Sync()
+}
+
+func writeFileSync(name string, b []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+
+	if _, err = f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	if err = f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+
+	return f.Close()
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
+
+func (w *WAL) compactLoop(ctx context.Context, interval time.Duration) {
+	defer close(w.done)
+
+	if interval <= 0 {
+		<-w.stop
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			if err := w.Snapshot(ctx); err != nil {
+				logger.Log.Error("error while compacting write-ahead log", zap.Error(err))
+			}
+		}
+	}
+}
+
+func (w *WAL) UpdateCounter(ctx context.Context, name string, value storage.Counter) (storage.Counter, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.apply(storage.Database{Counter: storage.CounterCollection{name: value}}, time.Now()); err != nil {
+		return 0, err
+	}
+
+	return w.mem.UpdateCounter(ctx, name, value)
+}
+
+func (w *WAL) UpdateGauge(ctx context.Context, name string, value storage.Gauge) (storage.Gauge, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.apply(storage.Database{Gauge: storage.GaugeCollection{name: value}}, time.Now()); err != nil {
+		return 0, err
+	}
+
+	return w.mem.UpdateGauge(ctx, name, value)
+}
+
+func (w *WAL) UpdateHistogram(
+	ctx context.Context, name string, value storage.Histogram,
+) (storage.Histogram, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.apply(storage.Database{Histogram: storage.HistogramCollection{name: value}}, time.Now()); err != nil {
+		return storage.Histogram{}, err
+	}
+
+	return w.mem.UpdateHistogram(ctx, name, value)
+}
+
+func (w *WAL) UpdateSummary(ctx context.Context, name string, value storage.Summary) (storage.Summary, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.apply(storage.Database{Summary: storage.SummaryCollection{name: value}}, time.Now()); err != nil {
+		return storage.Summary{}, err
+	}
+
+	return w.mem.UpdateSummary(ctx, name, value)
+}
+
+func (w *WAL) UpdateSet(ctx context.Context, name string, value storage.Set) (storage.Set, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.apply(storage.Database{Set: storage.SetCollection{name: value}}, time.Now()); err != nil {
+		return storage.Set{}, err
+	}
+
+	return w.mem.UpdateSet(ctx, name, value)
+}
+
+func (w *WAL) UpdateMany(ctx context.Context, list storage.Database) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	now := time.Now()
+	if err := w.apply(list, now); err != nil {
+		return err
+	}
+
+	return w.mem.UpdateManyAt(ctx, list, now)
+}
+
+func (w *WAL) GetGaugeByName(ctx context.Context, name string) (storage.Gauge, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetGaugeByName(ctx, name)
+}
+
+func (w *WAL) GetCounterByName(ctx context.Context, name string) (storage.Counter, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetCounterByName(ctx, name)
+}
+
+func (w *WAL) GetHistogramByName(ctx context.Context, name string) (storage.Histogram, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetHistogramByName(ctx, name)
+}
+
+func (w *WAL) GetSummaryByName(ctx context.Context, name string) (storage.Summary, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetSummaryByName(ctx, name)
+}
+
+func (w *WAL) GetSetByName(ctx context.Context, name string) (storage.Set, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetSetByName(ctx, name)
+}
+
+func (w *WAL) GetGaugeHistory(
+	ctx context.Context, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetGaugeHistory(ctx, name, from, to)
+}
+
+func (w *WAL) GetCounterHistory(
+	ctx context.Context, name string, from, to time.Time,
+) ([]storage.Sample, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetCounterHistory(ctx, name, from, to)
+}
+
+func (w *WAL) DeleteMetric(ctx context.Context, mType string, name string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.mem.CheckDelete(mType, name); err != nil {
+		return err
+	}
+
+	err := w.writeRecord(record{
+		Time:    time.Now(),
+		Deleted: []storage.MetricRef{{MType: mType, Name: name}},
+	})
+	if err != nil {
+		return err
+	}
+
+	return w.mem.DeleteMetric(ctx, mType, name)
+}
+
+// DeleteStale удаляет ровно те метрики, что записаны в журнал: под w.mu
+// значения не меняются между поиском устаревших метрик и удалением.
+func (w *WAL) DeleteStale(_ context.Context, before time.Time) ([]storage.MetricRef, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	list := w.mem.Stale(before)
+	if len(list) == 0 {
+		return list, nil
+	}
+
+	if err := w.writeRecord(record{Time: time.Now(), Expired: list}); err != nil {
+		return nil, err
+	}
+	w.mem.Expire(list)
+
+	return list, nil
+}
+
+func (w *WAL) GetGaugeRollups(
+	ctx context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetGaugeRollups(ctx, name, resolution, from, to)
+}
+
+func (w *WAL) GetCounterRollups(
+	ctx context.Context, name string, resolution time.Duration, from, to time.Time,
+) ([]storage.Rollup, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetCounterRollups(ctx, name, resolution, from, to)
+}
+
+// ApplyRetention не меняет значения метрик и не пишется в журнал,
+// поэтому выполняется под блокировкой на чтение.
+func (w *WAL) ApplyRetention(ctx context.Context, policy storage.RetentionPolicy, now time.Time) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.ApplyRetention(ctx, policy, now)
+}
+
+func (w *WAL) GetAll(ctx context.Context) (storage.Database, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.GetAll(ctx)
+}
+
+func (w *WAL) FindSeries(
+	ctx context.Context, mType string, name string, matchers []storage.Matcher,
+) ([]storage.Series, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.mem.FindSeries(ctx, mType, name, matchers)
+}
+
+func (w *WAL) Ping(_ context.Context) error {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	_, err := w.log.Stat()
+	return err
+}
+
+// Close останавливает сворачивание, делает последний снимок и закрывает журнал.
+func (w *WAL) Close() {
+	close(w.stop)
+	<-w.done
+
+	if err := w.Snapshot(context.Background()); err != nil {
+		logger.Log.Error("error while saving write-ahead log snapshot", zap.Error(err))
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.log.Close()
+}
@@ -0,0 +1,365 @@
+package wal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LekcRg/metrics/internal/config"
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/LekcRg/metrics/internal/server/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func open(t *testing.T, dir string) *WAL {
+	w, err := New(context.Background(), config.ServerConfig{WALDir: dir})
+	require.NoError(t, err)
+
+	return w
+}
+
+// crash останавливает хранилище без снимка, как при падении процесса.
+func crash(w *WAL) {
+	close(w.stop)
+	<-w.done
+	w.log.Close()
+}
+
+func fill(t *testing.T, w *WAL) {
+	ctx := context.Background()
+
+	_, err := w.UpdateGauge(ctx, "gauge", 1.5)
+	require.NoError(t, err)
+	_, err = w.UpdateCounter(ctx, "counter", 2)
+	require.NoError(t, err)
+	_, err = w.UpdateHistogram(ctx, "latency", storage.Histogram{
+		Buckets: []float64{1}, Counts: []uint64{1, 0}, Sum: 0.5, Count: 1,
+	})
+	require.NoError(t, err)
+
+	sm := storage.NewSummary(time.Minute)
+	sm.Observe(3, time.Now())
+	_, err = w.UpdateSummary(ctx, "rpc", sm)
+	require.NoError(t, err)
+
+	st := storage.NewSet()
+	st.Add("alice")
+	_, err = w.UpdateSet(ctx, "users", st)
+	require.NoError(t, err)
+
+	err = w.UpdateMany(ctx, storage.Database{
+		Gauge:    storage.GaugeCollection{`gauge{host="a"}`: 3},
+		Counter:  storage.CounterCollection{"counter": 3},
+		Metadata: storage.MetadataCollection{"gauge": {Unit: "bytes"}},
+	})
+	require.NoError(t, err)
+}
+
+func check(t *testing.T, w *WAL) {
+	ctx := context.Background()
+
+	gauge, err := w.GetGaugeByName(ctx, "gauge")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Gauge(1.5), gauge)
+
+	gauge, err = w.GetGaugeByName(ctx, `gauge{host="a"}`)
+	require.NoError(t, err)
+	assert.Equal(t, storage.Gauge(3), gauge)
+
+	counter, err := w.GetCounterByName(ctx, "counter")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(5), counter)
+
+	h, err := w.GetHistogramByName(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), h.Count)
+
+	sm, err := w.GetSummaryByName(ctx, "rpc")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), sm.Count)
+
+	st, err := w.GetSetByName(ctx, "users")
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), st.Count())
+
+	all, err := w.GetAll(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, storage.MetadataCollection{"gauge": {Unit: "bytes"}}, all.Metadata)
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		prepare func(t *testing.T, w *WAL, dir string)
+		name    string
+	}{
+		{
+			name: "Only log",
+		},
+		{
+			name: "Snapshot and empty log",
+			prepare: func(t *testing.T, w *WAL, _ string) {
+				require.NoError(t, w.Snapshot(context.Background()))
+			},
+		},
+		{
+			name: "Log is not truncated after snapshot",
+			prepare: func(t *testing.T, w *WAL, dir string) {
+				log, err := os.ReadFile(filepath.Join(dir, logFile))
+				require.NoError(t, err)
+				require.NoError(t, w.Snapshot(context.Background()))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, logFile), log, 0o644))
+			},
+		},
+		{
+			name: "Broken tail",
+			prepare: func(t *testing.T, _ *WAL, dir string) {
+				f, err := os.OpenFile(filepath.Join(dir, logFile), os.O_WRONLY|os.O_APPEND, 0)
+				require.NoError(t, err)
+				_, err = f.WriteString(`{"seq":100,"data":{"Counter":{"coun`)
+				require.NoError(t, err)
+				require.NoError(t, f.Close())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			w := open(t, dir)
+			fill(t, w)
+			if tt.prepare != nil {
+				tt.prepare(t, w, dir)
+			}
+			crash(w)
+
+			restored := open(t, dir)
+			defer restored.Close()
+			check(t, restored)
+
+			// после восстановления журнал продолжает писаться
+			_, err := restored.UpdateCounter(context.Background(), "counter", 1)
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestClose(t *testing.T) {
+	dir := t.TempDir()
+	w := open(t, dir)
+	fill(t, w)
+	w.Close()
+
+	info, err := os.Stat(filepath.Join(dir, logFile))
+	require.NoError(t, err)
+	assert.Zero(t, info.Size(), "log must be compacted into snapshot")
+
+	restored := open(t, dir)
+	defer restored.Close()
+	check(t, restored)
+}
+
+func TestRejectedUpdateIsNotLogged(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := open(t, dir)
+
+	_, err := w.UpdateHistogram(ctx, "latency", storage.Histogram{
+		Buckets: []float64{1}, Counts: []uint64{1, 0}, Count: 1,
+	})
+	require.NoError(t, err)
+	_, err = w.UpdateHistogram(ctx, "latency", storage.Histogram{
+		Buckets: []float64{2}, Counts: []uint64{1, 0}, Count: 1,
+	})
+	require.ErrorIs(t, err, merrors.ErrIncorrectHistogramBuckets)
+	assert.Equal(t, uint64(1), w.seq)
+	crash(w)
+
+	restored := open(t, dir)
+	defer restored.Close()
+	h, err := restored.GetHistogramByName(ctx, "latency")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{1}, h.Buckets)
+}
+
+func TestRecoverDeleted(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := open(t, dir)
+	fill(t, w)
+
+	require.NoError(t, w.DeleteMetric(ctx, "gauge", "gauge"))
+	list, err := w.DeleteStale(ctx, time.Now())
+	require.NoError(t, err)
+	assert.NotEmpty(t, list)
+	crash(w)
+
+	restored := open(t, dir)
+	defer restored.Close()
+
+	_, err = restored.GetGaugeByName(ctx, "gauge")
+	assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+	_, err = restored.GetCounterByName(ctx, "counter")
+	assert.ErrorIs(t, err, merrors.ErrNotFoundMetric)
+}
+
+func TestRecoverBatch(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := open(t, dir)
+
+	batch := storage.Database{Counter: storage.CounterCollection{"PollCount": 1}, BatchID: "batch-1"}
+	require.NoError(t, w.UpdateMany(ctx, batch))
+	assert.ErrorIs(t, w.UpdateMany(ctx, batch), merrors.ErrDuplicateBatch)
+	crash(w)
+
+	// ключи пачек восстанавливаются из журнала
+	restored := open(t, dir)
+	defer restored.Close()
+
+	assert.ErrorIs(t, restored.UpdateMany(ctx, batch), merrors.ErrDuplicateBatch)
+	val, err := restored.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(1), val)
+}
+
+func TestFailedWriteIsNotApplied(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := open(t, dir)
+
+	_, err := w.UpdateCounter(ctx, "PollCount", 1)
+	require.NoError(t, err)
+
+	// журнал, открытый только на чтение, не принимает записи
+	log := w.log
+	w.log, err = os.Open(filepath.Join(dir, logFile))
+	require.NoError(t, err)
+
+	_, err = w.UpdateCounter(ctx, "PollCount", 2)
+	require.Error(t, err)
+	err = w.UpdateMany(ctx, storage.Database{Counter: storage.CounterCollection{"PollCount": 2}})
+	require.Error(t, err)
+
+	val, err := w.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(1), val)
+
+	// повтор после восстановления журнала применяет изменение один раз
+	require.NoError(t, w.log.Close())
+	w.log = log
+	_, err = w.UpdateCounter(ctx, "PollCount", 2)
+	require.NoError(t, err)
+	crash(w)
+
+	restored := open(t, dir)
+	defer restored.Close()
+	val, err = restored.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(3), val)
+}
+
+func TestFailedDeleteIsNotApplied(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := open(t, dir)
+	defer w.Close()
+	fill(t, w)
+
+	// журнал, открытый только на чтение, не принимает записи
+	log := w.log
+	var err error
+	w.log, err = os.Open(filepath.Join(dir, logFile))
+	require.NoError(t, err)
+
+	require.Error(t, w.DeleteMetric(ctx, "gauge", "gauge"))
+	_, err = w.DeleteStale(ctx, time.Now())
+	require.Error(t, err)
+
+	require.NoError(t, w.log.Close())
+	w.log = log
+	check(t, w)
+
+	assert.ErrorIs(t, w.DeleteMetric(ctx, "gauge", "unknown"), merrors.ErrNotFoundMetric)
+	assert.ErrorIs(t, w.DeleteMetric(ctx, "unknown", "gauge"), merrors.ErrIncorrectMetricType)
+}
+
+func TestRecoverSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := open(t, dir)
+
+	batch := storage.Database{Counter: storage.CounterCollection{"PollCount": 5}, BatchID: "batch-1"}
+	require.NoError(t, w.UpdateMany(ctx, batch))
+	require.NoError(t, w.Snapshot(ctx))
+	_, err := w.UpdateCounter(ctx, "PollCount", 2)
+	require.NoError(t, err)
+	crash(w)
+
+	restored := open(t, dir)
+	defer restored.Close()
+
+	val, err := restored.GetCounterByName(ctx, "PollCount")
+	require.NoError(t, err)
+	assert.Equal(t, storage.Counter(7), val)
+
+	// история из снимка дополняется записями журнала
+	samples, err := restored.GetCounterHistory(ctx, "PollCount", time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, samples, 2)
+	assert.Equal(t, float64(5), samples[0].Value)
+	assert.Equal(t, float64(2), samples[1].Value)
+
+	// ключи пачек восстанавливаются из снимка
+	assert.ErrorIs(t, restored.UpdateMany(ctx, batch), merrors.ErrDuplicateBatch)
+}
+
+func TestRecoverSnapshotHistory(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	w := open(t, dir)
+
+	start := time.Now()
+	for _, v := range []storage.Gauge{1, 5, 3} {
+		_, err := w.UpdateGauge(ctx, "gauge", v)
+		require.NoError(t, err)
+	}
+
+	policy, err := storage.ParseRetention("raw:1h,1m:24h")
+	require.NoError(t, err)
+	now := start.Add(2 * time.Minute)
+	require.NoError(t, w.ApplyRetention(ctx, policy, now))
+
+	wantHistory, err := w.GetGaugeHistory(ctx, "gauge", start, now)
+	require.NoError(t, err)
+	require.Len(t, wantHistory, 3)
+	wantRollups, err := w.GetGaugeRollups(ctx, "gauge", time.Minute, start, now)
+	require.NoError(t, err)
+	require.NotEmpty(t, wantRollups)
+
+	require.NoError(t, w.Snapshot(ctx))
+	crash(w)
+
+	restored := open(t, dir)
+	defer restored.Close()
+
+	history, err := restored.GetGaugeHistory(ctx, "gauge", start, now)
+	require.NoError(t, err)
+	require.Len(t, history, len(wantHistory))
+	for i := range wantHistory {
+		assert.True(t, wantHistory[i].Time.Equal(history[i].Time))
+		assert.Equal(t, wantHistory[i].Value, history[i].Value)
+	}
+
+	// свернутая история не сворачивается повторно
+	require.NoError(t, restored.ApplyRetention(ctx, policy, now))
+	rollups, err := restored.GetGaugeRollups(ctx, "gauge", time.Minute, start, now)
+	require.NoError(t, err)
+	require.Len(t, rollups, len(wantRollups))
+	for i := range wantRollups {
+		assert.True(t, wantRollups[i].Time.Equal(rollups[i].Time))
+		assert.Equal(t, wantRollups[i].Count, rollups[i].Count)
+		assert.Equal(t, wantRollups[i].Sum, rollups[i].Sum)
+	}
+}
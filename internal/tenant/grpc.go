@@ -0,0 +1,80 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// first возвращает первое значение ключа метаданных.
+func first(md metadata.MD, key string) string {
+	if vals := md.Get(key); len(vals) > 0 {
+		return vals[0]
+	}
+
+	return ""
+}
+
+// FromMetadata определяет тенанта по метаданным gRPC-запроса так же,
+// как Middleware по заголовкам, и возвращает контекст с тенантом.
+func (r *Resolver) FromMetadata(ctx context.Context) (context.Context, error) {
+	if !r.Enabled() {
+		return ctx, nil
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	var header string
+	if r.header != "" {
+		header = first(md, strings.ToLower(r.header))
+	}
+
+	id, err := r.Resolve(first(md, "authorization"), header)
+	if errors.Is(err, merrors.ErrUnknownTenantToken) || errors.Is(err, merrors.ErrTenantTokenRequired) {
+		return nil, status.Error(codes.Unauthenticated, "Unauthenticated")
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return WithTenant(ctx, id), nil
+}
+
+// Interceptor — аналог Middleware для gRPC.
+func (r *Resolver) Interceptor(
+	ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler,
+) (any, error) {
+	ctx, err := r.FromMetadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// serverStream — поток gRPC с контекстом тенанта.
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+// StreamInterceptor — Interceptor для потоковых методов.
+func (r *Resolver) StreamInterceptor(
+	srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler,
+) error {
+	ctx, err := r.FromMetadata(ss.Context())
+	if err != nil {
+		return err
+	}
+
+	return handler(srv, &serverStream{ServerStream: ss, ctx: ctx})
+}
@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestFromMetadata(t *testing.T) {
+	tokens := map[string]string{"tok1": "team-a"}
+
+	tests := []struct {
+		tokens   map[string]string
+		md       metadata.MD
+		name     string
+		want     string
+		wantCode codes.Code
+	}{
+		{
+			name:   "Token",
+			tokens: tokens,
+			md:     metadata.Pairs("authorization", "Bearer tok1"),
+			want:   "team-a",
+		},
+		{
+			name: "Header",
+			md:   metadata.Pairs("x-scope-orgid", "team-b"),
+			want: "team-b",
+		},
+		{
+			name:   "Default tenant",
+			tokens: tokens,
+			want:   Default,
+		},
+		{
+			name:     "Unknown token",
+			tokens:   tokens,
+			md:       metadata.Pairs("authorization", "Bearer tok2"),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Spoofed header with tokens",
+			tokens:   tokens,
+			md:       metadata.Pairs("x-scope-orgid", "team-a"),
+			wantCode: codes.Unauthenticated,
+		},
+		{
+			name:     "Incorrect header",
+			md:       metadata.Pairs("x-scope-orgid", "team/b"),
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResolver(tt.tokens, "X-Scope-OrgID")
+			ctx := metadata.NewIncomingContext(context.Background(), tt.md)
+			ctx, err := r.FromMetadata(ctx)
+			if tt.wantCode != codes.OK {
+				assert.Equal(t, tt.wantCode, status.Code(err))
+				return
+			}
+
+			require.NoError(t, err)
+			id, ok := FromContext(ctx)
+			assert.True(t, ok)
+			assert.Equal(t, tt.want, id)
+		})
+	}
+}
+
+func TestFromMetadataDisabled(t *testing.T) {
+	ctx, err := NewResolver(nil, "").FromMetadata(context.Background())
+	require.NoError(t, err)
+
+	_, ok := FromContext(ctx)
+	assert.False(t, ok)
+}
+
+// mockStream — поток gRPC с контекстом входящего запроса.
+type mockStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *mockStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestStreamInterceptor(t *testing.T) {
+	r := NewResolver(map[string]string{"tok1": "team-a"}, "")
+
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer tok1"))
+	var got string
+	err := r.StreamInterceptor(nil, &mockStream{ctx: ctx}, nil, func(_ any, ss grpc.ServerStream) error {
+		got, _ = FromContext(ss.Context())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "team-a", got)
+
+	ctx = metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer tok2"))
+	err = r.StreamInterceptor(nil, &mockStream{ctx: ctx}, nil, func(_ any, _ grpc.ServerStream) error {
+		t.Fatal("handler must not be called")
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
@@ -0,0 +1,31 @@
+package tenant
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+)
+
+// Middleware определяет тенанта запроса и кладет его в контекст.
+// Если тенанты не настроены, запросы проходят без тенанта.
+func (r *Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if !r.Enabled() {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		id, err := r.Resolve(req.Header.Get("Authorization"), req.Header.Get(r.header))
+		if errors.Is(err, merrors.ErrUnknownTenantToken) || errors.Is(err, merrors.ErrTenantTokenRequired) {
+			http.Error(w, "401 Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Bad request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		next.ServeHTTP(w, req.WithContext(WithTenant(req.Context(), id)))
+	})
+}
@@ -0,0 +1,91 @@
+package tenant
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name       string
+		resolver   *Resolver
+		headers    map[string]string
+		wantTenant string
+		wantCode   int
+		wantOK     bool
+	}{
+		{
+			name:     "Tenants disabled",
+			resolver: NewResolver(nil, ""),
+			headers:  map[string]string{"Authorization": "Bearer tok1"},
+			wantCode: http.StatusOK,
+		},
+		{
+			name:       "Token",
+			resolver:   NewResolver(map[string]string{"tok1": "team-a"}, ""),
+			headers:    map[string]string{"Authorization": "Bearer tok1"},
+			wantTenant: "team-a",
+			wantCode:   http.StatusOK,
+			wantOK:     true,
+		},
+		{
+			name:       "Default tenant",
+			resolver:   NewResolver(map[string]string{"tok1": "team-a"}, ""),
+			wantTenant: Default,
+			wantCode:   http.StatusOK,
+			wantOK:     true,
+		},
+		{
+			name:     "Unknown token",
+			resolver: NewResolver(map[string]string{"tok1": "team-a"}, ""),
+			headers:  map[string]string{"Authorization": "Bearer tok2"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:     "Spoofed header with tokens",
+			resolver: NewResolver(map[string]string{"tok1": "team-a"}, "X-Scope-OrgID"),
+			headers:  map[string]string{"X-Scope-OrgID": "team-a"},
+			wantCode: http.StatusUnauthorized,
+		},
+		{
+			name:       "Header",
+			resolver:   NewResolver(nil, "X-Scope-OrgID"),
+			headers:    map[string]string{"X-Scope-OrgID": "team-b"},
+			wantTenant: "team-b",
+			wantCode:   http.StatusOK,
+			wantOK:     true,
+		},
+		{
+			name:     "Incorrect header",
+			resolver: NewResolver(nil, "X-Scope-OrgID"),
+			headers:  map[string]string{"X-Scope-OrgID": "team b"},
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				gotTenant string
+				gotOK     bool
+			)
+			next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotTenant, gotOK = FromContext(r.Context())
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			tt.resolver.Middleware(next).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantCode, w.Code)
+			assert.Equal(t, tt.wantOK, gotOK)
+			assert.Equal(t, tt.wantTenant, gotTenant)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+// Package tenant разделяет метрики команд, которые пишут в один сервер.
+//
+// Тенант запроса определяется по токену из заголовка Authorization
+// или по доверенному заголовку и передается дальше в контексте.
+// Хранилище (см. tenantstorage) хранит серии тенанта с префиксом "<тенант>/",
+// у тенанта по умолчанию префикса нет, поэтому без тенантов ключи не меняются.
+package tenant
+
+import (
+	"context"
+	"strings"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+)
+
+// Default — тенант запросов без токена и заголовка тенанта.
+const Default = ""
+
+// Separator отделяет тенанта от ключа серии.
+const Separator = "/"
+
+// maxLen — наибольшая длина идентификатора тенанта.
+const maxLen = 64
+
+type ctxKey struct{}
+
+// WithTenant возвращает контекст запроса тенанта id.
+func WithTenant(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает тенанта запроса. ok == false, если контекст
+// не относится к запросу тенанта, например у фоновых задач.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxKey{}).(string)
+	return id, ok
+}
+
+// Validate проверяет идентификатор тенанта: 1-64 латинские буквы, цифры, _ или -.
+func Validate(id string) error {
+	if id == "" || len(id) > maxLen {
+		return merrors.ErrIncorrectTenant
+	}
+
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_', r == '-':
+		default:
+			return merrors.ErrIncorrectTenant
+		}
+	}
+
+	return nil
+}
+
+// metricName возвращает имя метрики из ключа серии (см. storage.SeriesKey).
+func metricName(key string) string {
+	name, _, _ := strings.Cut(key, "{")
+	return name
+}
+
+// Key возвращает ключ серии key тенанта id в хранилище.
+// Имя метрики тенанта по умолчанию не может содержать Separator,
+// иначе ключ совпадет с ключом другого тенанта.
+func Key(id string, key string) (string, error) {
+	if id == Default {
+		if strings.Contains(metricName(key), Separator) {
+			return "", merrors.ErrIncorrectTenantKey
+		}
+		return key, nil
+	}
+
+	return id + Separator + key, nil
+}
+
+// Split разделяет ключ из хранилища на тенанта и ключ серии.
+func Split(key string) (string, string) {
+	id, rest, ok := strings.Cut(key, Separator)
+	if !ok || strings.Contains(id, "{") {
+		return Default, key
+	}
+
+	return id, rest
+}
+
+// ParseTokens разбирает токены тенантов вида token1:tenant1,token2:tenant2.
+// Пустая строка означает, что токенов нет.
+func ParseTokens(val string) (map[string]string, error) {
+	if val == "" {
+		return nil, nil
+	}
+
+	tokens := make(map[string]string)
+
+	for _, part := range strings.Split(val, ",") {
+		token, id, ok := strings.Cut(strings.TrimSpace(part), ":")
+		if !ok || token == "" || Validate(id) != nil {
+			return nil, merrors.ErrIncorrectTenants
+		}
+		if _, ok := tokens[token]; ok {
+			return nil, merrors.ErrIncorrectTenants
+		}
+		tokens[token] = id
+	}
+
+	return tokens, nil
+}
+
+// Resolver определяет тенанта запроса.
+type Resolver struct {
+	tokens map[string]string
+	header string
+}
+
+// NewResolver создает Resolver. tokens — тенанты по токенам из заголовка
+// Authorization: Bearer <token>, header — доверенный заголовок с тенантом,
+// пустой, если тенант по заголовку не определяется. Заголовку можно доверять,
+// только если его ставит прокси перед сервером, поэтому с токенами
+// он не принимается.
+func NewResolver(tokens map[string]string, header string) *Resolver {
+	return &Resolver{
+		tokens: tokens,
+		header: header,
+	}
+}
+
+// Enabled сообщает, разделяются ли метрики по тенантам.
+func (r *Resolver) Enabled() bool {
+	return r != nil && (len(r.tokens) > 0 || r.header != "")
+}
+
+// Resolve возвращает тенанта по значению заголовка Authorization
+// и доверенного заголовка. Если настроены токены, заголовок без токена
+// отклоняется с merrors.ErrTenantTokenRequired: иначе любой клиент мог бы
+// выбрать чужого тенанта. Без токена и заголовка запрос относится
+// к тенанту по умолчанию.
+func (r *Resolver) Resolve(authorization string, header string) (string, error) {
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok && len(r.tokens) > 0 {
+		id, ok := r.tokens[strings.TrimSpace(token)]
+		if !ok {
+			return "", merrors.ErrUnknownTenantToken
+		}
+		return id, nil
+	}
+
+	if r.header == "" || header == "" {
+		return Default, nil
+	}
+	if len(r.tokens) > 0 {
+		return "", merrors.ErrTenantTokenRequired
+	}
+
+	if err := Validate(header); err != nil {
+		return "", err
+	}
+
+	return header, nil
+}
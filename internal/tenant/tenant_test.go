@@ -0,0 +1,199 @@
+package tenant
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LekcRg/metrics/internal/merrors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := FromContext(WithTenant(context.Background(), "team-a"))
+	assert.True(t, ok)
+	assert.Equal(t, "team-a", id)
+
+	id, ok = FromContext(WithTenant(context.Background(), Default))
+	assert.True(t, ok)
+	assert.Equal(t, Default, id)
+}
+
+func TestKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		key     string
+		want    string
+		wantErr error
+	}{
+		{
+			name: "Default tenant",
+			key:  "RandomValue",
+			want: "RandomValue",
+		},
+		{
+			name: "Default tenant with slash in label",
+			key:  `http_requests{path="/api"}`,
+			want: `http_requests{path="/api"}`,
+		},
+		{
+			name:    "Default tenant with slash in name",
+			key:     "team-a/RandomValue",
+			wantErr: merrors.ErrIncorrectTenantKey,
+		},
+		{
+			name: "Tenant",
+			id:   "team-a",
+			key:  `RandomValue{host="a"}`,
+			want: `team-a/RandomValue{host="a"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Key(tt.id, tt.key)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			id, key := Split(got)
+			assert.Equal(t, tt.id, id)
+			assert.Equal(t, tt.key, key)
+		})
+	}
+}
+
+func TestParseTokens(t *testing.T) {
+	tests := []struct {
+		name    string
+		val     string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "Empty",
+		},
+		{
+			name: "Several tokens",
+			val:  "tok1:team-a, tok2:team-b,tok3:team-a",
+			want: map[string]string{"tok1": "team-a", "tok2": "team-b", "tok3": "team-a"},
+		},
+		{
+			name:    "Without tenant",
+			val:     "tok1",
+			wantErr: true,
+		},
+		{
+			name:    "Incorrect tenant",
+			val:     "tok1:team/a",
+			wantErr: true,
+		},
+		{
+			name:    "Empty token",
+			val:     ":team-a",
+			wantErr: true,
+		},
+		{
+			name:    "Duplicate token",
+			val:     "tok1:team-a,tok1:team-b",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTokens(tt.val)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, merrors.ErrIncorrectTenants)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	tokens := map[string]string{"tok1": "team-a"}
+
+	tests := []struct {
+		wantErr       error
+		tokens        map[string]string
+		name          string
+		authorization string
+		header        string
+		want          string
+	}{
+		{
+			name:   "Default tenant",
+			tokens: tokens,
+			want:   Default,
+		},
+		{
+			name:          "Token",
+			tokens:        tokens,
+			authorization: "Bearer tok1",
+			want:          "team-a",
+		},
+		{
+			name:          "Token is preferred over header",
+			tokens:        tokens,
+			authorization: "Bearer tok1",
+			header:        "team-b",
+			want:          "team-a",
+		},
+		{
+			name:          "Unknown token",
+			tokens:        tokens,
+			authorization: "Bearer tok2",
+			wantErr:       merrors.ErrUnknownTenantToken,
+		},
+		{
+			name:    "Spoofed header without token",
+			tokens:  tokens,
+			header:  "team-a",
+			wantErr: merrors.ErrTenantTokenRequired,
+		},
+		{
+			name:   "Header",
+			header: "team-b",
+			want:   "team-b",
+		},
+		{
+			name:    "Incorrect header",
+			header:  "team/b",
+			wantErr: merrors.ErrIncorrectTenant,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewResolver(tt.tokens, "X-Scope-OrgID")
+			got, err := r.Resolve(tt.authorization, tt.header)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEnabled(t *testing.T) {
+	var r *Resolver
+	assert.False(t, r.Enabled())
+	assert.False(t, NewResolver(nil, "").Enabled())
+	assert.True(t, NewResolver(nil, "X-Scope-OrgID").Enabled())
+	assert.True(t, NewResolver(map[string]string{"tok1": "team-a"}, "").Enabled())
+}
@@ -24,8 +24,11 @@ const (
 type Metric_Type int32
 
 const (
-	Metric_COUNTER Metric_Type = 0
-	Metric_GAUGE   Metric_Type = 1
+	Metric_COUNTER   Metric_Type = 0
+	Metric_GAUGE     Metric_Type = 1
+	Metric_HISTOGRAM Metric_Type = 2
+	Metric_SUMMARY   Metric_Type = 3
+	Metric_SET       Metric_Type = 4
 )
 
 // Enum value maps for Metric_Type.
@@ -33,10 +36,16 @@ var (
 	Metric_Type_name = map[int32]string{
 		0: "COUNTER",
 		1: "GAUGE",
+		2: "HISTOGRAM",
+		3: "SUMMARY",
+		4: "SET",
 	}
 	Metric_Type_value = map[string]int32{
-		"COUNTER": 0,
-		"GAUGE":   1,
+		"COUNTER":   0,
+		"GAUGE":     1,
+		"HISTOGRAM": 2,
+		"SUMMARY":   3,
+		"SET":       4,
 	}
 )
 
@@ -64,7 +73,257 @@ func (x Metric_Type) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use Metric_Type.Descriptor instead.
 func (Metric_Type) EnumDescriptor() ([]byte, []int) {
-	return file_proto_metric_proto_rawDescGZIP(), []int{0, 0}
+	return file_proto_metric_proto_rawDescGZIP(), []int{3, 0}
+}
+
+type MetricEvent_Kind int32
+
+const (
+	MetricEvent_UPDATED MetricEvent_Kind = 0
+	MetricEvent_DELETED MetricEvent_Kind = 1
+)
+
+// Enum value maps for MetricEvent_Kind.
+var (
+	MetricEvent_Kind_name = map[int32]string{
+		0: "UPDATED",
+		1: "DELETED",
+	}
+	MetricEvent_Kind_value = map[string]int32{
+		"UPDATED": 0,
+		"DELETED": 1,
+	}
+)
+
+func (x MetricEvent_Kind) Enum() *MetricEvent_Kind {
+	p := new(MetricEvent_Kind)
+	*p = x
+	return p
+}
+
+func (x MetricEvent_Kind) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (MetricEvent_Kind) Descriptor() protoreflect.EnumDescriptor {
+	return file_proto_metric_proto_enumTypes[1].Descriptor()
+}
+
+func (MetricEvent_Kind) Type() protoreflect.EnumType {
+	return &file_proto_metric_proto_enumTypes[1]
+}
+
+func (x MetricEvent_Kind) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use MetricEvent_Kind.Descriptor instead.
+func (MetricEvent_Kind) EnumDescriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{16, 0}
+}
+
+type Histogram struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Buckets       []float64              `protobuf:"fixed64,1,rep,packed,name=buckets,proto3" json:"buckets,omitempty"`
+	Counts        []uint64               `protobuf:"varint,2,rep,packed,name=counts,proto3" json:"counts,omitempty"`
+	Sum           float64                `protobuf:"fixed64,3,opt,name=sum,proto3" json:"sum,omitempty"`
+	Count         uint64                 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Histogram) Reset() {
+	*x = Histogram{}
+	mi := &file_proto_metric_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Histogram) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Histogram) ProtoMessage() {}
+
+func (x *Histogram) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Histogram.ProtoReflect.Descriptor instead.
+func (*Histogram) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Histogram) GetBuckets() []float64 {
+	if x != nil {
+		return x.Buckets
+	}
+	return nil
+}
+
+func (x *Histogram) GetCounts() []uint64 {
+	if x != nil {
+		return x.Counts
+	}
+	return nil
+}
+
+func (x *Histogram) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *Histogram) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type Summary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Sum           float64                `protobuf:"fixed64,1,opt,name=sum,proto3" json:"sum,omitempty"`
+	Min           float64                `protobuf:"fixed64,2,opt,name=min,proto3" json:"min,omitempty"`
+	Max           float64                `protobuf:"fixed64,3,opt,name=max,proto3" json:"max,omitempty"`
+	Count         uint64                 `protobuf:"varint,4,opt,name=count,proto3" json:"count,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Summary) Reset() {
+	*x = Summary{}
+	mi := &file_proto_metric_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Summary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Summary) ProtoMessage() {}
+
+func (x *Summary) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Summary.ProtoReflect.Descriptor instead.
+func (*Summary) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Summary) GetSum() float64 {
+	if x != nil {
+		return x.Sum
+	}
+	return 0
+}
+
+func (x *Summary) GetMin() float64 {
+	if x != nil {
+		return x.Min
+	}
+	return 0
+}
+
+func (x *Summary) GetMax() float64 {
+	if x != nil {
+		return x.Max
+	}
+	return 0
+}
+
+func (x *Summary) GetCount() uint64 {
+	if x != nil {
+		return x.Count
+	}
+	return 0
+}
+
+type Metadata struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Unit          string                 `protobuf:"bytes,1,opt,name=unit,proto3" json:"unit,omitempty"`
+	Help          string                 `protobuf:"bytes,2,opt,name=help,proto3" json:"help,omitempty"`
+	Type          *Metric_Type           `protobuf:"varint,3,opt,name=type,proto3,enum=metric.Metric_Type,oneof" json:"type,omitempty"`
+	Precision     *int32                 `protobuf:"varint,4,opt,name=precision,proto3,oneof" json:"precision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Metadata) Reset() {
+	*x = Metadata{}
+	mi := &file_proto_metric_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Metadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Metadata) ProtoMessage() {}
+
+func (x *Metadata) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Metadata.ProtoReflect.Descriptor instead.
+func (*Metadata) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Metadata) GetUnit() string {
+	if x != nil {
+		return x.Unit
+	}
+	return ""
+}
+
+func (x *Metadata) GetHelp() string {
+	if x != nil {
+		return x.Help
+	}
+	return ""
+}
+
+func (x *Metadata) GetType() Metric_Type {
+	if x != nil && x.Type != nil {
+		return *x.Type
+	}
+	return Metric_COUNTER
+}
+
+func (x *Metadata) GetPrecision() int32 {
+	if x != nil && x.Precision != nil {
+		return *x.Precision
+	}
+	return 0
 }
 
 type Metric struct {
@@ -73,13 +332,21 @@ type Metric struct {
 	MType         Metric_Type            `protobuf:"varint,2,opt,name=m_type,json=mType,proto3,enum=metric.Metric_Type" json:"m_type,omitempty"`
 	Value         *float64               `protobuf:"fixed64,3,opt,name=value,proto3,oneof" json:"value,omitempty"`
 	Delta         *int64                 `protobuf:"zigzag64,4,opt,name=delta,proto3,oneof" json:"delta,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,5,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Histogram     *Histogram             `protobuf:"bytes,6,opt,name=histogram,proto3" json:"histogram,omitempty"`
+	Members       []string               `protobuf:"bytes,7,rep,name=members,proto3" json:"members,omitempty"`
+	Set           []byte                 `protobuf:"bytes,8,opt,name=set,proto3" json:"set,omitempty"`
+	Meta          *Metadata              `protobuf:"bytes,9,opt,name=meta,proto3" json:"meta,omitempty"`
+	Summary       *Summary               `protobuf:"bytes,10,opt,name=summary,proto3" json:"summary,omitempty"`
+	Quantiles     map[string]float64     `protobuf:"bytes,11,rep,name=quantiles,proto3" json:"quantiles,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"fixed64,2,opt,name=value"`
+	Unique        *uint64                `protobuf:"varint,12,opt,name=unique,proto3,oneof" json:"unique,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Metric) Reset() {
 	*x = Metric{}
-	mi := &file_proto_metric_proto_msgTypes[0]
+	mi := &file_proto_metric_proto_msgTypes[3]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -91,7 +358,7 @@ func (x *Metric) String() string {
 func (*Metric) ProtoMessage() {}
 
 func (x *Metric) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metric_proto_msgTypes[0]
+	mi := &file_proto_metric_proto_msgTypes[3]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -104,7 +371,7 @@ func (x *Metric) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Metric.ProtoReflect.Descriptor instead.
 func (*Metric) Descriptor() ([]byte, []int) {
-	return file_proto_metric_proto_rawDescGZIP(), []int{0}
+	return file_proto_metric_proto_rawDescGZIP(), []int{3}
 }
 
 func (x *Metric) GetId() string {
@@ -135,17 +402,75 @@ func (x *Metric) GetDelta() int64 {
 	return 0
 }
 
+func (x *Metric) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+func (x *Metric) GetHistogram() *Histogram {
+	if x != nil {
+		return x.Histogram
+	}
+	return nil
+}
+
+func (x *Metric) GetMembers() []string {
+	if x != nil {
+		return x.Members
+	}
+	return nil
+}
+
+func (x *Metric) GetSet() []byte {
+	if x != nil {
+		return x.Set
+	}
+	return nil
+}
+
+func (x *Metric) GetMeta() *Metadata {
+	if x != nil {
+		return x.Meta
+	}
+	return nil
+}
+
+func (x *Metric) GetSummary() *Summary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *Metric) GetQuantiles() map[string]float64 {
+	if x != nil {
+		return x.Quantiles
+	}
+	return nil
+}
+
+func (x *Metric) GetUnique() uint64 {
+	if x != nil && x.Unique != nil {
+		return *x.Unique
+	}
+	return 0
+}
+
 type UpdateMetricsRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Metrics       []*Metric              `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
 	Encrypted     []byte                 `protobuf:"bytes,2,opt,name=encrypted,proto3" json:"encrypted,omitempty"`
+	BatchId       string                 `protobuf:"bytes,3,opt,name=batch_id,json=batchId,proto3" json:"batch_id,omitempty"`
+	Strict        bool                   `protobuf:"varint,4,opt,name=strict,proto3" json:"strict,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *UpdateMetricsRequest) Reset() {
 	*x = UpdateMetricsRequest{}
-	mi := &file_proto_metric_proto_msgTypes[1]
+	mi := &file_proto_metric_proto_msgTypes[4]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -157,7 +482,7 @@ func (x *UpdateMetricsRequest) String() string {
 func (*UpdateMetricsRequest) ProtoMessage() {}
 
 func (x *UpdateMetricsRequest) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metric_proto_msgTypes[1]
+	mi := &file_proto_metric_proto_msgTypes[4]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -170,7 +495,7 @@ func (x *UpdateMetricsRequest) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UpdateMetricsRequest.ProtoReflect.Descriptor instead.
 func (*UpdateMetricsRequest) Descriptor() ([]byte, []int) {
-	return file_proto_metric_proto_rawDescGZIP(), []int{1}
+	return file_proto_metric_proto_rawDescGZIP(), []int{4}
 }
 
 func (x *UpdateMetricsRequest) GetMetrics() []*Metric {
@@ -187,27 +512,45 @@ func (x *UpdateMetricsRequest) GetEncrypted() []byte {
 	return nil
 }
 
-type UpdateMetricsResponse struct {
+func (x *UpdateMetricsRequest) GetBatchId() string {
+	if x != nil {
+		return x.BatchId
+	}
+	return ""
+}
+
+func (x *UpdateMetricsRequest) GetStrict() bool {
+	if x != nil {
+		return x.Strict
+	}
+	return false
+}
+
+type BatchItem struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Id            string                 `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	MType         Metric_Type            `protobuf:"varint,3,opt,name=m_type,json=mType,proto3,enum=metric.Metric_Type" json:"m_type,omitempty"`
+	Reason        string                 `protobuf:"bytes,4,opt,name=reason,proto3" json:"reason,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
-func (x *UpdateMetricsResponse) Reset() {
-	*x = UpdateMetricsResponse{}
-	mi := &file_proto_metric_proto_msgTypes[2]
+func (x *BatchItem) Reset() {
+	*x = BatchItem{}
+	mi := &file_proto_metric_proto_msgTypes[5]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
 
-func (x *UpdateMetricsResponse) String() string {
+func (x *BatchItem) String() string {
 	return protoimpl.X.MessageStringOf(x)
 }
 
-func (*UpdateMetricsResponse) ProtoMessage() {}
+func (*BatchItem) ProtoMessage() {}
 
-func (x *UpdateMetricsResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_metric_proto_msgTypes[2]
+func (x *BatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[5]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -218,32 +561,770 @@ func (x *UpdateMetricsResponse) ProtoReflect() protoreflect.Message {
 	return mi.MessageOf(x)
 }
 
-// Deprecated: Use UpdateMetricsResponse.ProtoReflect.Descriptor instead.
-func (*UpdateMetricsResponse) Descriptor() ([]byte, []int) {
-	return file_proto_metric_proto_rawDescGZIP(), []int{2}
+// Deprecated: Use BatchItem.ProtoReflect.Descriptor instead.
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{5}
 }
 
-var File_proto_metric_proto protoreflect.FileDescriptor
+func (x *BatchItem) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
 
-const file_proto_metric_proto_rawDesc = "" +
-	"\n" +
-	"\x12proto/metric.proto\x12\x06metric\"\xae\x01\n" +
-	"\x06Metric\x12\x0e\n" +
-	"\x02id\x18\x01 \x01(\tR\x02id\x12*\n" +
-	"\x06m_type\x18\x02 \x01(\x0e2\x13.metric.Metric.TypeR\x05mType\x12\x19\n" +
-	"\x05value\x18\x03 \x01(\x01H\x00R\x05value\x88\x01\x01\x12\x19\n" +
-	"\x05delta\x18\x04 \x01(\x12H\x01R\x05delta\x88\x01\x01\"\x1e\n" +
-	"\x04Type\x12\v\n" +
-	"\aCOUNTER\x10\x00\x12\t\n" +
-	"\x05GAUGE\x10\x01B\b\n" +
-	"\x06_valueB\b\n" +
-	"\x06_delta\"^\n" +
-	"\x14UpdateMetricsRequest\x12(\n" +
-	"\ametrics\x18\x01 \x03(\v2\x0e.metric.MetricR\ametrics\x12\x1c\n" +
-	"\tencrypted\x18\x02 \x01(\fR\tencrypted\"\x17\n" +
-	"\x15UpdateMetricsResponse2W\n" +
+func (x *BatchItem) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *BatchItem) GetMType() Metric_Type {
+	if x != nil {
+		return x.MType
+	}
+	return Metric_COUNTER
+}
+
+func (x *BatchItem) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type UpdateMetricsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Replayed      bool                   `protobuf:"varint,1,opt,name=replayed,proto3" json:"replayed,omitempty"`
+	Accepted      []*BatchItem           `protobuf:"bytes,2,rep,name=accepted,proto3" json:"accepted,omitempty"`
+	Rejected      []*BatchItem           `protobuf:"bytes,3,rep,name=rejected,proto3" json:"rejected,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateMetricsResponse) Reset() {
+	*x = UpdateMetricsResponse{}
+	mi := &file_proto_metric_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateMetricsResponse) ProtoMessage() {}
+
+func (x *UpdateMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateMetricsResponse.ProtoReflect.Descriptor instead.
+func (*UpdateMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *UpdateMetricsResponse) GetReplayed() bool {
+	if x != nil {
+		return x.Replayed
+	}
+	return false
+}
+
+func (x *UpdateMetricsResponse) GetAccepted() []*BatchItem {
+	if x != nil {
+		return x.Accepted
+	}
+	return nil
+}
+
+func (x *UpdateMetricsResponse) GetRejected() []*BatchItem {
+	if x != nil {
+		return x.Rejected
+	}
+	return nil
+}
+
+type MetricsFrame struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Request       *UpdateMetricsRequest  `protobuf:"bytes,2,opt,name=request,proto3" json:"request,omitempty"`
+	Hash          string                 `protobuf:"bytes,3,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricsFrame) Reset() {
+	*x = MetricsFrame{}
+	mi := &file_proto_metric_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricsFrame) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricsFrame) ProtoMessage() {}
+
+func (x *MetricsFrame) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricsFrame.ProtoReflect.Descriptor instead.
+func (*MetricsFrame) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *MetricsFrame) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *MetricsFrame) GetRequest() *UpdateMetricsRequest {
+	if x != nil {
+		return x.Request
+	}
+	return nil
+}
+
+func (x *MetricsFrame) GetHash() string {
+	if x != nil {
+		return x.Hash
+	}
+	return ""
+}
+
+type FrameAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           uint64                 `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	Response      *UpdateMetricsResponse `protobuf:"bytes,2,opt,name=response,proto3" json:"response,omitempty"`
+	Code          int32                  `protobuf:"varint,3,opt,name=code,proto3" json:"code,omitempty"`
+	Message       string                 `protobuf:"bytes,4,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FrameAck) Reset() {
+	*x = FrameAck{}
+	mi := &file_proto_metric_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FrameAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FrameAck) ProtoMessage() {}
+
+func (x *FrameAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FrameAck.ProtoReflect.Descriptor instead.
+func (*FrameAck) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *FrameAck) GetSeq() uint64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *FrameAck) GetResponse() *UpdateMetricsResponse {
+	if x != nil {
+		return x.Response
+	}
+	return nil
+}
+
+func (x *FrameAck) GetCode() int32 {
+	if x != nil {
+		return x.Code
+	}
+	return 0
+}
+
+func (x *FrameAck) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type DeleteMetricRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MType         Metric_Type            `protobuf:"varint,2,opt,name=m_type,json=mType,proto3,enum=metric.Metric_Type" json:"m_type,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMetricRequest) Reset() {
+	*x = DeleteMetricRequest{}
+	mi := &file_proto_metric_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMetricRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMetricRequest) ProtoMessage() {}
+
+func (x *DeleteMetricRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMetricRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMetricRequest) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteMetricRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *DeleteMetricRequest) GetMType() Metric_Type {
+	if x != nil {
+		return x.MType
+	}
+	return Metric_COUNTER
+}
+
+func (x *DeleteMetricRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type DeleteMetricResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMetricResponse) Reset() {
+	*x = DeleteMetricResponse{}
+	mi := &file_proto_metric_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMetricResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMetricResponse) ProtoMessage() {}
+
+func (x *DeleteMetricResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMetricResponse.ProtoReflect.Descriptor instead.
+func (*DeleteMetricResponse) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{10}
+}
+
+type GetMetricRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            string                 `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
+	MType         Metric_Type            `protobuf:"varint,2,opt,name=m_type,json=mType,proto3,enum=metric.Metric_Type" json:"m_type,omitempty"`
+	Labels        map[string]string      `protobuf:"bytes,3,rep,name=labels,proto3" json:"labels,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMetricRequest) Reset() {
+	*x = GetMetricRequest{}
+	mi := &file_proto_metric_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricRequest) ProtoMessage() {}
+
+func (x *GetMetricRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricRequest.ProtoReflect.Descriptor instead.
+func (*GetMetricRequest) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetMetricRequest) GetId() string {
+	if x != nil {
+		return x.Id
+	}
+	return ""
+}
+
+func (x *GetMetricRequest) GetMType() Metric_Type {
+	if x != nil {
+		return x.MType
+	}
+	return Metric_COUNTER
+}
+
+func (x *GetMetricRequest) GetLabels() map[string]string {
+	if x != nil {
+		return x.Labels
+	}
+	return nil
+}
+
+type GetMetricResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metric        *Metric                `protobuf:"bytes,1,opt,name=metric,proto3" json:"metric,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetMetricResponse) Reset() {
+	*x = GetMetricResponse{}
+	mi := &file_proto_metric_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetMetricResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetMetricResponse) ProtoMessage() {}
+
+func (x *GetMetricResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetMetricResponse.ProtoReflect.Descriptor instead.
+func (*GetMetricResponse) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *GetMetricResponse) GetMetric() *Metric {
+	if x != nil {
+		return x.Metric
+	}
+	return nil
+}
+
+type ListMetricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	MType         *Metric_Type           `protobuf:"varint,2,opt,name=m_type,json=mType,proto3,enum=metric.Metric_Type,oneof" json:"m_type,omitempty"`
+	PageSize      int32                  `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	PageToken     string                 `protobuf:"bytes,4,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMetricsRequest) Reset() {
+	*x = ListMetricsRequest{}
+	mi := &file_proto_metric_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMetricsRequest) ProtoMessage() {}
+
+func (x *ListMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMetricsRequest.ProtoReflect.Descriptor instead.
+func (*ListMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListMetricsRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *ListMetricsRequest) GetMType() Metric_Type {
+	if x != nil && x.MType != nil {
+		return *x.MType
+	}
+	return Metric_COUNTER
+}
+
+func (x *ListMetricsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+func (x *ListMetricsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+type ListMetricsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Metrics       []*Metric              `protobuf:"bytes,1,rep,name=metrics,proto3" json:"metrics,omitempty"`
+	NextPageToken string                 `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMetricsResponse) Reset() {
+	*x = ListMetricsResponse{}
+	mi := &file_proto_metric_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMetricsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMetricsResponse) ProtoMessage() {}
+
+func (x *ListMetricsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMetricsResponse.ProtoReflect.Descriptor instead.
+func (*ListMetricsResponse) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListMetricsResponse) GetMetrics() []*Metric {
+	if x != nil {
+		return x.Metrics
+	}
+	return nil
+}
+
+func (x *ListMetricsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+type WatchMetricsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Prefix        string                 `protobuf:"bytes,1,opt,name=prefix,proto3" json:"prefix,omitempty"`
+	MType         *Metric_Type           `protobuf:"varint,2,opt,name=m_type,json=mType,proto3,enum=metric.Metric_Type,oneof" json:"m_type,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchMetricsRequest) Reset() {
+	*x = WatchMetricsRequest{}
+	mi := &file_proto_metric_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchMetricsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchMetricsRequest) ProtoMessage() {}
+
+func (x *WatchMetricsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchMetricsRequest.ProtoReflect.Descriptor instead.
+func (*WatchMetricsRequest) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *WatchMetricsRequest) GetPrefix() string {
+	if x != nil {
+		return x.Prefix
+	}
+	return ""
+}
+
+func (x *WatchMetricsRequest) GetMType() Metric_Type {
+	if x != nil && x.MType != nil {
+		return *x.MType
+	}
+	return Metric_COUNTER
+}
+
+type MetricEvent struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Kind          MetricEvent_Kind       `protobuf:"varint,1,opt,name=kind,proto3,enum=metric.MetricEvent_Kind" json:"kind,omitempty"`
+	Metric        *Metric                `protobuf:"bytes,2,opt,name=metric,proto3" json:"metric,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MetricEvent) Reset() {
+	*x = MetricEvent{}
+	mi := &file_proto_metric_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MetricEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MetricEvent) ProtoMessage() {}
+
+func (x *MetricEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_metric_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MetricEvent.ProtoReflect.Descriptor instead.
+func (*MetricEvent) Descriptor() ([]byte, []int) {
+	return file_proto_metric_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *MetricEvent) GetKind() MetricEvent_Kind {
+	if x != nil {
+		return x.Kind
+	}
+	return MetricEvent_UPDATED
+}
+
+func (x *MetricEvent) GetMetric() *Metric {
+	if x != nil {
+		return x.Metric
+	}
+	return nil
+}
+
+var File_proto_metric_proto protoreflect.FileDescriptor
+
+const file_proto_metric_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/metric.proto\x12\x06metric\"e\n" +
+	"\tHistogram\x12\x18\n" +
+	"\abuckets\x18\x01 \x03(\x01R\abuckets\x12\x16\n" +
+	"\x06counts\x18\x02 \x03(\x04R\x06counts\x12\x10\n" +
+	"\x03sum\x18\x03 \x01(\x01R\x03sum\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x04R\x05count\"U\n" +
+	"\aSummary\x12\x10\n" +
+	"\x03sum\x18\x01 \x01(\x01R\x03sum\x12\x10\n" +
+	"\x03min\x18\x02 \x01(\x01R\x03min\x12\x10\n" +
+	"\x03max\x18\x03 \x01(\x01R\x03max\x12\x14\n" +
+	"\x05count\x18\x04 \x01(\x04R\x05count\"\x9a\x01\n" +
+	"\bMetadata\x12\x12\n" +
+	"\x04unit\x18\x01 \x01(\tR\x04unit\x12\x12\n" +
+	"\x04help\x18\x02 \x01(\tR\x04help\x12,\n" +
+	"\x04type\x18\x03 \x01(\x0e2\x13.metric.Metric.TypeH\x00R\x04type\x88\x01\x01\x12!\n" +
+	"\tprecision\x18\x04 \x01(\x05H\x01R\tprecision\x88\x01\x01B\a\n" +
+	"\x05_typeB\f\n" +
+	"\n" +
+	"_precision\"\x93\x05\n" +
+	"\x06Metric\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12*\n" +
+	"\x06m_type\x18\x02 \x01(\x0e2\x13.metric.Metric.TypeR\x05mType\x12\x19\n" +
+	"\x05value\x18\x03 \x01(\x01H\x00R\x05value\x88\x01\x01\x12\x19\n" +
+	"\x05delta\x18\x04 \x01(\x12H\x01R\x05delta\x88\x01\x01\x122\n" +
+	"\x06labels\x18\x05 \x03(\v2\x1a.metric.Metric.LabelsEntryR\x06labels\x12/\n" +
+	"\thistogram\x18\x06 \x01(\v2\x11.metric.HistogramR\thistogram\x12\x18\n" +
+	"\amembers\x18\a \x03(\tR\amembers\x12\x10\n" +
+	"\x03set\x18\b \x01(\fR\x03set\x12$\n" +
+	"\x04meta\x18\t \x01(\v2\x10.metric.MetadataR\x04meta\x12)\n" +
+	"\asummary\x18\n" +
+	" \x01(\v2\x0f.metric.SummaryR\asummary\x12;\n" +
+	"\tquantiles\x18\v \x03(\v2\x1d.metric.Metric.QuantilesEntryR\tquantiles\x12\x1b\n" +
+	"\x06unique\x18\f \x01(\x04H\x02R\x06unique\x88\x01\x01\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a<\n" +
+	"\x0eQuantilesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\x01R\x05value:\x028\x01\"C\n" +
+	"\x04Type\x12\v\n" +
+	"\aCOUNTER\x10\x00\x12\t\n" +
+	"\x05GAUGE\x10\x01\x12\r\n" +
+	"\tHISTOGRAM\x10\x02\x12\v\n" +
+	"\aSUMMARY\x10\x03\x12\a\n" +
+	"\x03SET\x10\x04B\b\n" +
+	"\x06_valueB\b\n" +
+	"\x06_deltaB\t\n" +
+	"\a_unique\"\x91\x01\n" +
+	"\x14UpdateMetricsRequest\x12(\n" +
+	"\ametrics\x18\x01 \x03(\v2\x0e.metric.MetricR\ametrics\x12\x1c\n" +
+	"\tencrypted\x18\x02 \x01(\fR\tencrypted\x12\x19\n" +
+	"\bbatch_id\x18\x03 \x01(\tR\abatchId\x12\x16\n" +
+	"\x06strict\x18\x04 \x01(\bR\x06strict\"u\n" +
+	"\tBatchItem\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\tR\x02id\x12*\n" +
+	"\x06m_type\x18\x03 \x01(\x0e2\x13.metric.Metric.TypeR\x05mType\x12\x16\n" +
+	"\x06reason\x18\x04 \x01(\tR\x06reason\"\x91\x01\n" +
+	"\x15UpdateMetricsResponse\x12\x1a\n" +
+	"\breplayed\x18\x01 \x01(\bR\breplayed\x12-\n" +
+	"\baccepted\x18\x02 \x03(\v2\x11.metric.BatchItemR\baccepted\x12-\n" +
+	"\brejected\x18\x03 \x03(\v2\x11.metric.BatchItemR\brejected\"l\n" +
+	"\fMetricsFrame\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x126\n" +
+	"\arequest\x18\x02 \x01(\v2\x1c.metric.UpdateMetricsRequestR\arequest\x12\x12\n" +
+	"\x04hash\x18\x03 \x01(\tR\x04hash\"\x85\x01\n" +
+	"\bFrameAck\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x04R\x03seq\x129\n" +
+	"\bresponse\x18\x02 \x01(\v2\x1d.metric.UpdateMetricsResponseR\bresponse\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\x05R\x04code\x12\x18\n" +
+	"\amessage\x18\x04 \x01(\tR\amessage\"\xcd\x01\n" +
+	"\x13DeleteMetricRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12*\n" +
+	"\x06m_type\x18\x02 \x01(\x0e2\x13.metric.Metric.TypeR\x05mType\x12?\n" +
+	"\x06labels\x18\x03 \x03(\v2'.metric.DeleteMetricRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x16\n" +
+	"\x14DeleteMetricResponse\"\xc7\x01\n" +
+	"\x10GetMetricRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\tR\x02id\x12*\n" +
+	"\x06m_type\x18\x02 \x01(\x0e2\x13.metric.Metric.TypeR\x05mType\x12<\n" +
+	"\x06labels\x18\x03 \x03(\v2$.metric.GetMetricRequest.LabelsEntryR\x06labels\x1a9\n" +
+	"\vLabelsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\";\n" +
+	"\x11GetMetricResponse\x12&\n" +
+	"\x06metric\x18\x01 \x01(\v2\x0e.metric.MetricR\x06metric\"\xa4\x01\n" +
+	"\x12ListMetricsRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12/\n" +
+	"\x06m_type\x18\x02 \x01(\x0e2\x13.metric.Metric.TypeH\x00R\x05mType\x88\x01\x01\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x04 \x01(\tR\tpageTokenB\t\n" +
+	"\a_m_type\"g\n" +
+	"\x13ListMetricsResponse\x12(\n" +
+	"\ametrics\x18\x01 \x03(\v2\x0e.metric.MetricR\ametrics\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"i\n" +
+	"\x13WatchMetricsRequest\x12\x16\n" +
+	"\x06prefix\x18\x01 \x01(\tR\x06prefix\x12/\n" +
+	"\x06m_type\x18\x02 \x01(\x0e2\x13.metric.Metric.TypeH\x00R\x05mType\x88\x01\x01B\t\n" +
+	"\a_m_type\"\x85\x01\n" +
+	"\vMetricEvent\x12,\n" +
+	"\x04kind\x18\x01 \x01(\x0e2\x18.metric.MetricEvent.KindR\x04kind\x12&\n" +
+	"\x06metric\x18\x02 \x01(\v2\x0e.metric.MetricR\x06metric\" \n" +
+	"\x04Kind\x12\v\n" +
+	"\aUPDATED\x10\x00\x12\v\n" +
+	"\aDELETED\x10\x012\xad\x03\n" +
 	"\aMetrics\x12L\n" +
-	"\rUpdateMetrics\x12\x1c.metric.UpdateMetricsRequest\x1a\x1d.metric.UpdateMetricsResponseB!Z\x1fgithub.com/LekcRg/metrics/protob\x06proto3"
+	"\rUpdateMetrics\x12\x1c.metric.UpdateMetricsRequest\x1a\x1d.metric.UpdateMetricsResponse\x12;\n" +
+	"\rStreamMetrics\x12\x14.metric.MetricsFrame\x1a\x10.metric.FrameAck(\x010\x01\x12I\n" +
+	"\fDeleteMetric\x12\x1b.metric.DeleteMetricRequest\x1a\x1c.metric.DeleteMetricResponse\x12@\n" +
+	"\tGetMetric\x12\x18.metric.GetMetricRequest\x1a\x19.metric.GetMetricResponse\x12F\n" +
+	"\vListMetrics\x12\x1a.metric.ListMetricsRequest\x1a\x1b.metric.ListMetricsResponse\x12B\n" +
+	"\fWatchMetrics\x12\x1b.metric.WatchMetricsRequest\x1a\x13.metric.MetricEvent0\x01B!Z\x1fgithub.com/LekcRg/metrics/protob\x06proto3"
 
 var (
 	file_proto_metric_proto_rawDescOnce sync.Once
@@ -257,24 +1338,74 @@ func file_proto_metric_proto_rawDescGZIP() []byte {
 	return file_proto_metric_proto_rawDescData
 }
 
-var file_proto_metric_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
-var file_proto_metric_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_proto_metric_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_proto_metric_proto_msgTypes = make([]protoimpl.MessageInfo, 21)
 var file_proto_metric_proto_goTypes = []any{
 	(Metric_Type)(0),              // 0: metric.Metric.Type
-	(*Metric)(nil),                // 1: metric.Metric
-	(*UpdateMetricsRequest)(nil),  // 2: metric.UpdateMetricsRequest
-	(*UpdateMetricsResponse)(nil), // 3: metric.UpdateMetricsResponse
+	(MetricEvent_Kind)(0),         // 1: metric.MetricEvent.Kind
+	(*Histogram)(nil),             // 2: metric.Histogram
+	(*Summary)(nil),               // 3: metric.Summary
+	(*Metadata)(nil),              // 4: metric.Metadata
+	(*Metric)(nil),                // 5: metric.Metric
+	(*UpdateMetricsRequest)(nil),  // 6: metric.UpdateMetricsRequest
+	(*BatchItem)(nil),             // 7: metric.BatchItem
+	(*UpdateMetricsResponse)(nil), // 8: metric.UpdateMetricsResponse
+	(*MetricsFrame)(nil),          // 9: metric.MetricsFrame
+	(*FrameAck)(nil),              // 10: metric.FrameAck
+	(*DeleteMetricRequest)(nil),   // 11: metric.DeleteMetricRequest
+	(*DeleteMetricResponse)(nil),  // 12: metric.DeleteMetricResponse
+	(*GetMetricRequest)(nil),      // 13: metric.GetMetricRequest
+	(*GetMetricResponse)(nil),     // 14: metric.GetMetricResponse
+	(*ListMetricsRequest)(nil),    // 15: metric.ListMetricsRequest
+	(*ListMetricsResponse)(nil),   // 16: metric.ListMetricsResponse
+	(*WatchMetricsRequest)(nil),   // 17: metric.WatchMetricsRequest
+	(*MetricEvent)(nil),           // 18: metric.MetricEvent
+	nil,                           // 19: metric.Metric.LabelsEntry
+	nil,                           // 20: metric.Metric.QuantilesEntry
+	nil,                           // 21: metric.DeleteMetricRequest.LabelsEntry
+	nil,                           // 22: metric.GetMetricRequest.LabelsEntry
 }
 var file_proto_metric_proto_depIdxs = []int32{
-	0, // 0: metric.Metric.m_type:type_name -> metric.Metric.Type
-	1, // 1: metric.UpdateMetricsRequest.metrics:type_name -> metric.Metric
-	2, // 2: metric.Metrics.UpdateMetrics:input_type -> metric.UpdateMetricsRequest
-	3, // 3: metric.Metrics.UpdateMetrics:output_type -> metric.UpdateMetricsResponse
-	3, // [3:4] is the sub-list for method output_type
-	2, // [2:3] is the sub-list for method input_type
-	2, // [2:2] is the sub-list for extension type_name
-	2, // [2:2] is the sub-list for extension extendee
-	0, // [0:2] is the sub-list for field type_name
+	0,  // 0: metric.Metadata.type:type_name -> metric.Metric.Type
+	0,  // 1: metric.Metric.m_type:type_name -> metric.Metric.Type
+	19, // 2: metric.Metric.labels:type_name -> metric.Metric.LabelsEntry
+	2,  // 3: metric.Metric.histogram:type_name -> metric.Histogram
+	4,  // 4: metric.Metric.meta:type_name -> metric.Metadata
+	3,  // 5: metric.Metric.summary:type_name -> metric.Summary
+	20, // 6: metric.Metric.quantiles:type_name -> metric.Metric.QuantilesEntry
+	5,  // 7: metric.UpdateMetricsRequest.metrics:type_name -> metric.Metric
+	0,  // 8: metric.BatchItem.m_type:type_name -> metric.Metric.Type
+	7,  // 9: metric.UpdateMetricsResponse.accepted:type_name -> metric.BatchItem
+	7,  // 10: metric.UpdateMetricsResponse.rejected:type_name -> metric.BatchItem
+	6,  // 11: metric.MetricsFrame.request:type_name -> metric.UpdateMetricsRequest
+	8,  // 12: metric.FrameAck.response:type_name -> metric.UpdateMetricsResponse
+	0,  // 13: metric.DeleteMetricRequest.m_type:type_name -> metric.Metric.Type
+	21, // 14: metric.DeleteMetricRequest.labels:type_name -> metric.DeleteMetricRequest.LabelsEntry
+	0,  // 15: metric.GetMetricRequest.m_type:type_name -> metric.Metric.Type
+	22, // 16: metric.GetMetricRequest.labels:type_name -> metric.GetMetricRequest.LabelsEntry
+	5,  // 17: metric.GetMetricResponse.metric:type_name -> metric.Metric
+	0,  // 18: metric.ListMetricsRequest.m_type:type_name -> metric.Metric.Type
+	5,  // 19: metric.ListMetricsResponse.metrics:type_name -> metric.Metric
+	0,  // 20: metric.WatchMetricsRequest.m_type:type_name -> metric.Metric.Type
+	1,  // 21: metric.MetricEvent.kind:type_name -> metric.MetricEvent.Kind
+	5,  // 22: metric.MetricEvent.metric:type_name -> metric.Metric
+	6,  // 23: metric.Metrics.UpdateMetrics:input_type -> metric.UpdateMetricsRequest
+	9,  // 24: metric.Metrics.StreamMetrics:input_type -> metric.MetricsFrame
+	11, // 25: metric.Metrics.DeleteMetric:input_type -> metric.DeleteMetricRequest
+	13, // 26: metric.Metrics.GetMetric:input_type -> metric.GetMetricRequest
+	15, // 27: metric.Metrics.ListMetrics:input_type -> metric.ListMetricsRequest
+	17, // 28: metric.Metrics.WatchMetrics:input_type -> metric.WatchMetricsRequest
+	8,  // 29: metric.Metrics.UpdateMetrics:output_type -> metric.UpdateMetricsResponse
+	10, // 30: metric.Metrics.StreamMetrics:output_type -> metric.FrameAck
+	12, // 31: metric.Metrics.DeleteMetric:output_type -> metric.DeleteMetricResponse
+	14, // 32: metric.Metrics.GetMetric:output_type -> metric.GetMetricResponse
+	16, // 33: metric.Metrics.ListMetrics:output_type -> metric.ListMetricsResponse
+	18, // 34: metric.Metrics.WatchMetrics:output_type -> metric.MetricEvent
+	29, // [29:35] is the sub-list for method output_type
+	23, // [23:29] is the sub-list for method input_type
+	23, // [23:23] is the sub-list for extension type_name
+	23, // [23:23] is the sub-list for extension extendee
+	0,  // [0:23] is the sub-list for field type_name
 }
 
 func init() { file_proto_metric_proto_init() }
@@ -282,14 +1413,17 @@ func file_proto_metric_proto_init() {
 	if File_proto_metric_proto != nil {
 		return
 	}
-	file_proto_metric_proto_msgTypes[0].OneofWrappers = []any{}
+	file_proto_metric_proto_msgTypes[2].OneofWrappers = []any{}
+	file_proto_metric_proto_msgTypes[3].OneofWrappers = []any{}
+	file_proto_metric_proto_msgTypes[13].OneofWrappers = []any{}
+	file_proto_metric_proto_msgTypes[15].OneofWrappers = []any{}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_metric_proto_rawDesc), len(file_proto_metric_proto_rawDesc)),
-			NumEnums:      1,
-			NumMessages:   3,
+			NumEnums:      2,
+			NumMessages:   21,
 			NumExtensions: 0,
 			NumServices:   1,
 		},